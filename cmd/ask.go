@@ -3,22 +3,56 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/davealtena/trix/internal/agent"
+	"github.com/davealtena/trix/internal/config"
 	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/session"
+	"github.com/davealtena/trix/internal/tools/kubectl"
 	"github.com/spf13/cobra"
 )
 
 var (
-	llmModel    string
-	llmProvider string
-	ollamaURL   string
-	interactive bool
-	renderer    *glamour.TermRenderer
+	llmModel       string
+	llmProvider    string
+	ollamaURL      string
+	llmBaseURL     string
+	llmFixture     string
+	llmRecord      string
+	llmLog         string
+	apiKeyFile     string
+	apiKeySecret   string
+	maxAttempts    int
+	proxyURL       string
+	caFile         string
+	insecureTLS    bool
+	noCache        bool
+	cacheTTL       time.Duration
+	temperature    float64
+	topP           float64
+	maxTokens      int
+	maxLLMTokens   int
+	stopSeqs       []string
+	seed           int
+	deterministic  bool
+	freqPenalty    float64
+	presPenalty    float64
+	maxLLMCost     float64
+	timeout        time.Duration
+	connectTimeout time.Duration
+	thinking       int
+	showThink      bool
+	interactive    bool
+	resumeID       string
+	imagePaths     []string
+	renderer       *glamour.TermRenderer
+	exporter       *llm.OTLPExporter // Set when an OTLP endpoint is configured
 )
 
 var askCmd = &cobra.Command{
@@ -35,145 +69,582 @@ Examples:
 Providers:
   anthropic  - Requires ANTHROPIC_API_KEY
   openai     - Requires OPENAI_API_KEY
+  azure      - Requires AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_API_KEY and a deployment
+               (--model or AZURE_OPENAI_DEPLOYMENT)
   mistral    - Requires MISTRAL_API_KEY (EU-based)
-  ollama     - Local/remote Ollama (set OLLAMA_HOST or use --ollama-url)`,
-	Args: cobra.MinimumNArgs(1),
+  gemini     - Requires GEMINI_API_KEY
+  vertex     - Google Vertex AI via application default credentials / workload identity
+               (GOOGLE_CLOUD_PROJECT, GOOGLE_CLOUD_LOCATION; must be selected with --provider)
+  groq       - Requires GROQ_API_KEY (fast inference)
+  openrouter - Requires OPENROUTER_API_KEY (--model accepts a comma-separated fallback list)
+  cohere     - Requires COHERE_API_KEY (Command R+)
+  deepseek   - Requires DEEPSEEK_API_KEY (low cost, good for bulk triage)
+  grok       - Requires XAI_API_KEY (model via --model or XAI_MODEL)
+  huggingface - Hugging Face Inference Endpoint (set HF_ENDPOINT_URL or --base-url, plus HF_TOKEN)
+  together   - Requires TOGETHER_API_KEY (open-weight models such as Llama 3.1 405B)
+  llamacpp   - llama.cpp server (set LLAMACPP_HOST or use --base-url; grammar-constrained tool calls)
+  ollama     - Local/remote Ollama (set OLLAMA_HOST or use --ollama-url)
+  compatible - Any OpenAI-compatible endpoint such as vLLM, LM Studio or LiteLLM
+               (set OPENAI_COMPATIBLE_BASE_URL or use --base-url, plus --model)
+  mock       - Replays responses from a fixture file (--fixture or TRIX_LLM_FIXTURE)
+
+Pass a comma-separated list to --provider (e.g. anthropic,mistral,ollama) to fail over
+to the next provider on errors. Each provider in a chain uses its default model.
+
+Use --record <file> with any provider to save its responses as a fixture for the mock provider.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if resumeID != "" {
+			return nil // The question is optional when resuming
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		question := strings.Join(args, " ")
 
 		// Initialize markdown renderer
 		var err error
 		renderer, err = glamour.NewTermRenderer(
-			glamour.WithAutoStyle(),
+			markdownStyle(),
 			glamour.WithWordWrap(100),
 		)
 		if err != nil {
 			renderer = nil // Fall back to plain text
 		}
 
-		// Create LLM client based on provider flag or auto-detect
-		client, err := createLLMClient()
-		if err != nil {
+		if err := applyConfig(cmd); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		// Create agent and ask
-		a := agent.New(client)
-		ctx := context.Background()
+		llm.MaxAttempts = maxAttempts
+		if err := llm.ConfigureHTTP(llm.HTTPConfig{
+			ProxyURL:           proxyURL,
+			CAFile:             caFile,
+			InsecureSkipVerify: insecureTLS,
+			ConnectTimeout:     connectTimeout,
+		}); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
-		if interactive {
-			// Interactive mode with follow-ups
-			conv := a.NewConversation()
-			scanner := bufio.NewScanner(os.Stdin)
+		// Trace LLM calls to an OpenTelemetry collector if one is configured
+		if exporter, err = llm.NewOTLPExporter("trix ask"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if exporter != nil {
+			defer func() {
+				if err := exporter.Close(); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
+			}()
+		}
 
-			// First question from args
-			fmt.Println("Investigating...")
-			response, err := conv.Ask(ctx, question)
+		// Create LLM client based on provider flag or auto-detect
+		client, err := createLLMClient(generationOptions(cmd))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if maxLLMTokens > 0 || maxLLMCost > 0 {
+			client = llm.NewBudgetClient(client, llm.Budget{MaxTokens: maxLLMTokens, MaxCost: maxLLMCost})
+		}
+		if llmRecord != "" {
+			client = llm.NewRecordingClient(client, llmRecord)
+		}
+		if llmLog == "" {
+			llmLog = os.Getenv("TRIX_LLM_LOG")
+		}
+		if llmLog != "" {
+			logging, err := llm.NewLoggingClient(client, llmLog)
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
 				return
 			}
-			fmt.Println()
-			printResponse(response)
-
-			// Follow-up loop
-			for {
-				fmt.Print("\n> ")
-				if !scanner.Scan() {
-					break
-				}
-				input := strings.TrimSpace(scanner.Text())
-				if input == "" || input == "exit" || input == "quit" {
-					break
-				}
-				if input == "clear" {
-					conv = a.NewConversation()
-					fmt.Println("Context cleared.")
-					continue
-				}
+			defer func() { _ = logging.Close() }()
+			client = logging
+		}
 
-				fmt.Println("Investigating...")
-				response, err := conv.Ask(ctx, input)
-				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					continue
-				}
-				fmt.Println()
-				printResponse(response)
+		var images []llm.Image
+		for _, path := range imagePaths {
+			var img llm.Image
+			if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+				img = llm.ImageFromURL(path)
+			} else if img, err = llm.LoadImage(path); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
 			}
+			images = append(images, img)
+		}
+
+		// Create agent and ask
+		a := agent.New(client)
+		a.SetShowThinking(showThink)
+
+		// Keep long investigations within the model's context window,
+		// summarizing older turns rather than dropping them
+		contextManager := llm.NewContextManager(llmModel)
+		contextManager.Summarizer = client
+		a.SetContextManager(contextManager)
+		ctx := context.Background()
+
+		if interactive || resumeID != "" {
+			runInteractive(ctx, a, cmd.CommandPath(), question, images)
 		} else {
 			// Single question mode
 			fmt.Println("Investigating...")
-			response, err := a.Ask(ctx, question)
+			response, err := a.Ask(ctx, question, images...)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				printLLMError(err)
+				fmt.Printf("%s\n", a.Usage())
 				return
 			}
 			fmt.Println()
 			printResponse(response)
+			fmt.Printf("%s\n", a.Usage())
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(askCmd)
-	askCmd.Flags().StringVar(&llmModel, "model", "", "LLM model to use")
-	askCmd.Flags().StringVar(&llmProvider, "provider", "", "LLM provider: anthropic, openai, ollama (auto-detects if not set)")
-	askCmd.Flags().StringVar(&ollamaURL, "ollama-url", "", "Ollama server URL (default: http://localhost:11434)")
+
+	// The mock provider replays the fixture given on the command line
+	llm.Register("mock", func(opts ...llm.Option) (llm.Client, error) {
+		return llm.NewMockClient(llmFixture)
+	})
+
+	addLLMFlags(askCmd)
+	askCmd.Flags().StringVar(&llmRecord, "record", "", "Record LLM responses to a fixture file for later replay")
+	askCmd.Flags().StringVar(&llmLog, "llm-log", "", "Append all LLM requests and responses to a JSONL file, with secrets redacted (or set TRIX_LLM_LOG)")
+	askCmd.Flags().Float64Var(&topP, "top-p", 0, "Nucleus sampling top_p (provider default if not set)")
+	askCmd.Flags().StringSliceVar(&stopSeqs, "stop", nil, "Stop generating at this sequence (repeatable)")
+	askCmd.Flags().IntVar(&seed, "seed", 0, "Sampling seed for reproducible responses (not supported by Anthropic)")
+	askCmd.Flags().Float64Var(&freqPenalty, "frequency-penalty", 0, "Penalize repeated tokens, e.g. 0.5 (provider default if not set; not supported by Anthropic)")
+	askCmd.Flags().Float64Var(&presPenalty, "presence-penalty", 0, "Penalize tokens that already appeared, e.g. 0.5 (provider default if not set; not supported by Anthropic)")
+	askCmd.Flags().IntVar(&maxLLMTokens, "max-llm-tokens", 0, "Abort once the LLM requests of this run have used this many tokens in total (no limit if not set)")
+	askCmd.Flags().Float64Var(&maxLLMCost, "max-llm-cost", 0, "Abort once the LLM requests of this run have cost this many USD, e.g. 0.50 (no limit if not set; needs known model prices)")
+	askCmd.Flags().IntVar(&thinking, "thinking", 0, "Enable Claude's extended thinking with this token budget (e.g. 8000; minimum 1024)")
+	askCmd.Flags().BoolVar(&showThink, "show-thinking", false, "Print the model's full reasoning instead of a one-line summary")
 	askCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode for follow-up questions")
+	askCmd.Flags().StringSliceVar(&imagePaths, "image", nil, "Attach an image file or URL to the question, e.g. a dashboard screenshot (repeatable; Anthropic, OpenAI and Gemini)")
+	askCmd.Flags().StringVar(&resumeID, "resume", "", "Resume a saved interactive session by ID ('last' for the most recent; implies -i)")
+}
+
+// addLLMFlags registers the flags that pick and configure the LLM client,
+// for every command that calls one
+func addLLMFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&llmModel, "model", "", "LLM model to use")
+	_ = cmd.RegisterFlagCompletionFunc("model", completeModels)
+	cmd.Flags().StringVar(&llmProvider, "provider", "", "LLM provider: anthropic, openai, azure, mistral, gemini, vertex, groq, openrouter, cohere, deepseek, grok, huggingface, together, llamacpp, ollama, compatible, mock (auto-detects if not set)")
+	cmd.Flags().StringVar(&ollamaURL, "ollama-url", "", "Ollama server URL (default: http://localhost:11434)")
+	cmd.Flags().StringVar(&llmBaseURL, "base-url", "", "Base URL of an OpenAI-compatible, Hugging Face or llama.cpp endpoint (e.g. http://localhost:8000/v1)")
+	cmd.Flags().StringVar(&llmFixture, "fixture", "", "Fixture file for the mock provider")
+	cmd.Flags().StringVar(&apiKeyFile, "api-key-file", "", "Read the provider's API key from a file instead of its environment variable (requires --provider)")
+	cmd.Flags().StringVar(&apiKeySecret, "api-key-secret", "", "Read the provider's API key from a Kubernetes Secret: [namespace/]name[:key] (key defaults to api-key; requires --provider)")
+	cmd.Flags().Float64Var(&temperature, "temperature", 0, "Sampling temperature (provider default if not set)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Maximum tokens per response (provider default if not set)")
+	cmd.Flags().BoolVar(&deterministic, "deterministic", false, fmt.Sprintf("Use temperature 0 and seed %d for reproducible output, e.g. in CI", llm.DeterministicSeed))
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Timeout per LLM request, including retries (default 2m, 10m for local models)")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Don't reuse cached LLM responses")
+	cmd.Flags().DurationVar(&cacheTTL, "cache-ttl", llm.DefaultCacheTTL, "How long cached LLM responses are reused")
+	cmd.Flags().IntVar(&maxAttempts, "max-attempts", llm.MaxAttempts, "Maximum attempts per LLM request on rate limits and transient errors")
+	cmd.Flags().StringVar(&proxyURL, "proxy", "", "Proxy for LLM requests (default: HTTPS_PROXY/NO_PROXY)")
+	cmd.Flags().StringVar(&caFile, "ca-file", "", "PEM CA bundle to trust for LLM endpoints, e.g. a corporate proxy CA (or set TRIX_CA_FILE)")
+	cmd.Flags().DurationVar(&connectTimeout, "connect-timeout", llm.DefaultConnectTimeout, "Timeout for connecting to an LLM endpoint, for the dial and the TLS handshake each")
+	cmd.Flags().BoolVar(&insecureTLS, "insecure-skip-verify", false, "Skip TLS certificate verification for LLM endpoints (insecure)")
+}
+
+// runInteractive asks question, if any, and then follow-up questions read
+// from stdin, saving the session after every answer. command is the command
+// that resumes the session, e.g. "trix ask".
+func runInteractive(ctx context.Context, a *agent.Agent, command, question string, images []llm.Image) {
+	sess := session.New(llmProvider, llmModel)
+	conv := a.NewConversation()
+	if resumeID != "" {
+		var err error
+		sess, err = session.Load(resumeID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		conv = a.ResumeConversation(sess.Messages, sess.Usage)
+		fmt.Printf("Resumed session %s: %s\n", sess.ID, sess.Title())
+	}
+	save := func() {
+		sess.Messages = conv.Messages()
+		sess.Usage = conv.Usage()
+		if err := sess.Save(); err != nil {
+			fmt.Printf("Warning: failed to save session: %v\n", err)
+		}
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+
+	// First question from args
+	if question != "" {
+		fmt.Println("Investigating...")
+		response, err := conv.Ask(ctx, question, images...)
+		if err != nil {
+			printLLMError(err)
+			return
+		}
+		printAnswer(a, response)
+		save()
+	}
+
+	// Follow-up loop
+	for {
+		fmt.Print("\n> ")
+		if !scanner.Scan() {
+			break
+		}
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" || input == "exit" || input == "quit" {
+			break
+		}
+		if input == "clear" {
+			conv = a.NewConversation()
+			sess = session.New(llmProvider, llmModel)
+			fmt.Println("Context cleared.")
+			continue
+		}
+
+		fmt.Println("Investigating...")
+		response, err := conv.Ask(ctx, input)
+		if err != nil {
+			printLLMError(err)
+			continue
+		}
+		printAnswer(a, response)
+		save()
+	}
+	fmt.Printf("\n%s\n", a.Usage())
+	if len(sess.Messages) > 0 {
+		fmt.Printf("Session saved: resume with '%s --resume %s'\n", command, sess.ID)
+	}
+}
+
+// printAnswer prints an answer unless it was already streamed
+func printAnswer(a *agent.Agent, response string) {
+	if a.Streaming() {
+		return
+	}
+	fmt.Println()
+	printResponse(response)
+}
+
+// applyConfig fills in flags the user didn't set from the config file.
+// Precedence is flag, then environment variable, then config file.
+func applyConfig(cmd *cobra.Command) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	setString := func(flag string, value *string, fromConfig string) {
+		if !cmd.Flags().Changed(flag) && *value == "" {
+			*value = fromConfig
+		}
+	}
+	setString("provider", &llmProvider, cfg.LLM.Provider)
+	setString("model", &llmModel, cfg.LLM.Model)
+	setString("base-url", &llmBaseURL, cfg.LLM.BaseURL)
+	setString("ollama-url", &ollamaURL, cfg.LLM.OllamaURL)
+	setString("proxy", &proxyURL, cfg.LLM.Proxy)
+	setString("ca-file", &caFile, os.Getenv("TRIX_CA_FILE"))
+	setString("ca-file", &caFile, cfg.LLM.CAFile)
+	if !cmd.Flags().Changed("max-attempts") && cfg.LLM.MaxAttempts > 0 {
+		maxAttempts = cfg.LLM.MaxAttempts
+	}
+	return nil
+}
+
+// generationOptions returns the generation parameters set on the command line.
+// Only flags the user actually set are sent, so providers keep their defaults.
+func generationOptions(cmd *cobra.Command) []llm.Option {
+	var opts []llm.Option
+	// First, so explicit --temperature and --seed take precedence
+	if deterministic {
+		opts = append(opts, llm.WithDeterministic())
+	}
+	if cmd.Flags().Changed("temperature") {
+		opts = append(opts, llm.WithTemperature(temperature))
+	}
+	if cmd.Flags().Changed("top-p") {
+		opts = append(opts, llm.WithTopP(topP))
+	}
+	if maxTokens > 0 {
+		opts = append(opts, llm.WithMaxTokens(maxTokens))
+	}
+	if len(stopSeqs) > 0 {
+		opts = append(opts, llm.WithStop(stopSeqs...))
+	}
+	if cmd.Flags().Changed("seed") {
+		opts = append(opts, llm.WithSeed(seed))
+	}
+	if cmd.Flags().Changed("frequency-penalty") {
+		opts = append(opts, llm.WithFrequencyPenalty(freqPenalty))
+	}
+	if cmd.Flags().Changed("presence-penalty") {
+		opts = append(opts, llm.WithPresencePenalty(presPenalty))
+	}
+	if timeout > 0 {
+		opts = append(opts, llm.WithTimeout(timeout))
+	}
+	if thinking > 0 {
+		opts = append(opts, llm.WithThinking(thinking))
+	}
+	return opts
 }
 
 // createLLMClient creates an LLM client based on --provider flag or auto-detects from env vars
-func createLLMClient() (llm.Client, error) {
+func createLLMClient(opts []llm.Option) (llm.Client, error) {
 	provider := llmProvider
 
+	// A key read from a file or Secret belongs to a single, named provider
+	apiKey, err := readAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		if provider == "" || strings.Contains(provider, ",") {
+			return nil, fmt.Errorf("--api-key-file and --api-key-secret require --provider with a single provider")
+		}
+		opts = append(opts, llm.WithAPIKey(apiKey))
+	}
+
 	// Auto-detect provider if not specified
 	if provider == "" {
-		hasAnthropic := os.Getenv("ANTHROPIC_API_KEY") != ""
-		hasOpenAI := os.Getenv("OPENAI_API_KEY") != ""
-		hasMistral := os.Getenv("MISTRAL_API_KEY") != ""
-		hasOllama := os.Getenv("OLLAMA_HOST") != "" || ollamaURL != ""
+		if provider, err = detectProvider(); err != nil {
+			return nil, err
+		}
+	}
 
-		// Count how many providers are available
-		count := 0
-		if hasAnthropic {
-			count++
-			provider = "anthropic"
+	// A comma-separated list builds a fallback chain, e.g. anthropic,mistral,ollama
+	if strings.Contains(provider, ",") {
+		var chain []llm.FallbackProvider
+		for _, name := range strings.Split(provider, ",") {
+			name = strings.TrimSpace(name)
+			// --model names a single provider's model, so each link uses its default
+			client, err := llm.New(name, providerOptions(name, "", opts)...)
+			if err != nil {
+				return nil, fmt.Errorf("provider %s: %w", name, err)
+			}
+			client = traced(client, name, "")
+			chain = append(chain, llm.FallbackProvider{Name: name, Client: client})
 		}
-		if hasOpenAI {
-			count++
-			provider = "openai"
+		fallback := llm.NewFallbackClient(chain...)
+		fallback.OnFailover = func(from, to string, err error) {
+			fmt.Printf("  [%s failed, falling back to %s: %v]\n", from, to, err)
 		}
-		if hasMistral {
-			count++
-			provider = "mistral"
+		return withCache(fallback, provider, opts), nil
+	}
+
+	// Catch typos early, but don't block models released after this version
+	if err := llm.ValidateModel(provider, llmModel); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	opts = providerOptions(provider, llmModel, opts)
+	client, err := llm.New(provider, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client = traced(client, provider, llmModel)
+	if provider == "mock" {
+		return client, nil
+	}
+	return withCache(client, provider+"/"+llmModel, opts), nil
+}
+
+// traced records a span per LLM call if OpenTelemetry export is configured.
+// It wraps the provider inside the cache, so cache hits don't show up as calls.
+func traced(client llm.Client, provider, model string) llm.Client {
+	if exporter == nil {
+		return client
+	}
+	return exporter.Middleware(provider, model)(client)
+}
+
+// detectProvider returns the only provider configured in the environment.
+func detectProvider() (string, error) {
+	provider := ""
+	hasAnthropic := hasKey("ANTHROPIC_API_KEY")
+	hasOpenAI := hasKey("OPENAI_API_KEY")
+	hasAzure := hasKey("AZURE_OPENAI_API_KEY")
+	hasMistral := hasKey("MISTRAL_API_KEY")
+	hasGemini := hasKey("GEMINI_API_KEY")
+	hasGroq := hasKey("GROQ_API_KEY")
+	hasOpenRouter := hasKey("OPENROUTER_API_KEY")
+	hasCohere := hasKey("COHERE_API_KEY") || hasKey("CO_API_KEY")
+	hasDeepseek := hasKey("DEEPSEEK_API_KEY")
+	hasGrok := hasKey("XAI_API_KEY")
+	hasHuggingface := os.Getenv("HF_ENDPOINT_URL") != ""
+	hasTogether := hasKey("TOGETHER_API_KEY")
+	hasLlamacpp := os.Getenv("LLAMACPP_HOST") != ""
+	hasOllama := os.Getenv("OLLAMA_HOST") != "" || ollamaURL != ""
+	hasCompatible := os.Getenv("OPENAI_COMPATIBLE_BASE_URL") != "" || llmBaseURL != ""
+
+	// Count how many providers are available
+	count := 0
+	if hasAnthropic {
+		count++
+		provider = "anthropic"
+	}
+	if hasOpenAI {
+		count++
+		provider = "openai"
+	}
+	if hasAzure {
+		count++
+		provider = "azure"
+	}
+	if hasMistral {
+		count++
+		provider = "mistral"
+	}
+	if hasGemini {
+		count++
+		provider = "gemini"
+	}
+	if hasGroq {
+		count++
+		provider = "groq"
+	}
+	if hasOpenRouter {
+		count++
+		provider = "openrouter"
+	}
+	if hasCohere {
+		count++
+		provider = "cohere"
+	}
+	if hasDeepseek {
+		count++
+		provider = "deepseek"
+	}
+	if hasGrok {
+		count++
+		provider = "grok"
+	}
+	if hasHuggingface {
+		count++
+		provider = "huggingface"
+	}
+	if hasTogether {
+		count++
+		provider = "together"
+	}
+	if hasLlamacpp {
+		count++
+		provider = "llamacpp"
+	}
+	if hasOllama {
+		count++
+		provider = "ollama"
+	}
+	if hasCompatible {
+		count++
+		provider = "compatible"
+	}
+
+	if count > 1 {
+		return "", fmt.Errorf("multiple providers available. Use --provider to choose (%s)", strings.Join(llm.Providers(), ", "))
+	}
+	if count == 0 {
+		return "", fmt.Errorf("no provider configured. Set ANTHROPIC_API_KEY, OPENAI_API_KEY, AZURE_OPENAI_API_KEY, MISTRAL_API_KEY, GEMINI_API_KEY, GROQ_API_KEY, OPENROUTER_API_KEY, COHERE_API_KEY, DEEPSEEK_API_KEY, XAI_API_KEY, HF_ENDPOINT_URL, TOGETHER_API_KEY, LLAMACPP_HOST, OLLAMA_HOST, or OPENAI_COMPATIBLE_BASE_URL")
+	}
+	return provider, nil
+}
+
+// readAPIKey returns the API key given with --api-key-file or --api-key-secret, if any.
+func readAPIKey() (string, error) {
+	if apiKeyFile != "" && apiKeySecret != "" {
+		return "", fmt.Errorf("use either --api-key-file or --api-key-secret, not both")
+	}
+
+	if apiKeyFile != "" {
+		data, err := os.ReadFile(apiKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
 		}
-		if hasOllama {
-			count++
-			provider = "ollama"
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", fmt.Errorf("API key file %s is empty", apiKeyFile)
 		}
+		return key, nil
+	}
 
-		if count > 1 {
-			return nil, fmt.Errorf("multiple providers available. Use --provider to choose (anthropic, openai, mistral, ollama)")
+	if apiKeySecret != "" {
+		// [namespace/]name[:key]
+		namespace, name := "", apiKeySecret
+		if i := strings.Index(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+		key := "api-key"
+		if i := strings.Index(name, ":"); i >= 0 {
+			name, key = name[:i], name[i+1:]
+		}
+		if name == "" || key == "" {
+			return "", fmt.Errorf("invalid --api-key-secret %q, expected [namespace/]name[:key]", apiKeySecret)
 		}
-		if count == 0 {
-			return nil, fmt.Errorf("no provider configured. Set ANTHROPIC_API_KEY, OPENAI_API_KEY, MISTRAL_API_KEY, or OLLAMA_HOST")
+
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			return "", err
 		}
+		return k8sClient.GetSecretValue(context.Background(), namespace, name, key)
 	}
 
-	switch provider {
-	case "anthropic":
-		return llm.NewAnthropicClient(llmModel)
-	case "openai":
-		return llm.NewOpenAIClient(llmModel)
-	case "mistral":
-		return llm.NewMistralClient(llmModel)
-	case "ollama":
-		return llm.NewOllamaClient(ollamaURL, llmModel)
-	default:
-		return nil, fmt.Errorf("unknown provider: %s (use 'anthropic', 'openai', 'mistral', or 'ollama')", provider)
+	return "", nil
+}
+
+// printLLMError prints err with a hint for the failures users can fix themselves.
+func printLLMError(err error) {
+	fmt.Printf("Error: %v\n", err)
+	switch {
+	case errors.Is(err, llm.ErrAuth):
+		fmt.Println("Hint: check the provider's API key and that it has access to the model.")
+	case errors.Is(err, llm.ErrRateLimited):
+		fmt.Println("Hint: the provider's rate limit was hit; retry later, raise --max-attempts or add more API keys.")
+	case errors.Is(err, llm.ErrOverloaded):
+		fmt.Println("Hint: the provider is overloaded; retry later or add a fallback with --provider, e.g. anthropic,openai.")
+	case errors.Is(err, llm.ErrContextTooLong):
+		fmt.Println("Hint: the conversation no longer fits the model's context window; ask a narrower question or start a new session.")
+	}
+}
+
+// hasKey reports whether an API key is set in the environment, directly or
+// as a <VAR>_FILE path.
+func hasKey(name string) bool {
+	return os.Getenv(name) != "" || os.Getenv(name+"_FILE") != ""
+}
+
+// withCache wraps client with the on-disk response cache unless --no-cache is
+// set. opts are the options the client was created with.
+func withCache(client llm.Client, namespace string, opts []llm.Option) llm.Client {
+	if noCache {
+		return client
+	}
+	dir, err := llm.DefaultCacheDir()
+	if err != nil {
+		return client
+	}
+	_ = llm.PruneCache(dir, cacheTTL)
+	return llm.NewCachingClient(client, namespace, dir, cacheTTL, opts...)
+}
+
+// providerOptions adds the model and endpoint flags for a provider to opts.
+func providerOptions(provider, model string, opts []llm.Option) []llm.Option {
+	opts = append(opts[:len(opts):len(opts)], llm.WithModel(model))
+	baseURL := llmBaseURL
+	if provider == "ollama" && ollamaURL != "" {
+		baseURL = ollamaURL
+	}
+	if baseURL != "" {
+		opts = append(opts, llm.WithBaseURL(baseURL))
 	}
+	return opts
 }
 
 // printResponse renders markdown response to terminal
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/davealtena/trix/internal/bundle"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fromBundle   string
+	openedBundle *bundle.Bundle
+)
+
+var exportBundleCmd = &cobra.Command{
+	Use:   "export-bundle [file]",
+	Short: "Export the Trivy reports to an archive for offline analysis",
+	Long: `Write every Trivy Operator report, plus the cluster metadata trix reads
+(namespaces, pod images and owners, the operator deployment and
+NetworkPolicies), to a compressed archive (default trix-bundle-<date>.tgz).
+
+Read commands analyze the archive instead of a cluster with --from-bundle,
+e.g. 'trix query summary -A --from-bundle bundle.tgz', so findings can be
+investigated outside a restricted environment. Pod specs and annotations are
+left out, as they may hold secrets.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := fmt.Sprintf("trix-bundle-%s.tgz", time.Now().Format("20060102-150405"))
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if !requireCluster("export-bundle") {
+			return
+		}
+
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			fmt.Printf("Error creating bundle: %v\n", err)
+			return
+		}
+		meta, err := bundle.Export(context.Background(), f, k8sClient, bundle.Options{Namespace: explicitNamespace(), TrixVersion: Version})
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			fmt.Printf("Error exporting bundle: %v\n", err)
+			return
+		}
+
+		total := 0
+		var resources []string
+		for resource, n := range meta.Reports {
+			total += n
+			if n > 0 {
+				resources = append(resources, fmt.Sprintf("%s: %d", resource, n))
+			}
+		}
+		sort.Strings(resources)
+		fmt.Printf("Exported %d reports from context %s to %s\n", total, meta.Context, path)
+		for _, r := range resources {
+			fmt.Printf("  %s\n", r)
+		}
+	},
+}
+
+// newK8sClient returns a client of the current kubeconfig context, or one
+// reading the bundle given with --from-bundle (or TRIX_BUNDLE, which passes
+// it on to the commands 'trix ask' runs). The context is printed the first
+// time, so every report shows which cluster it's of.
+func newK8sClient() (*kubectl.Client, error) {
+	path := os.Getenv("TRIX_BUNDLE")
+	if path == "" {
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		if !contextShown && !structuredOutput() {
+			contextShown = true
+			if currentCtx, err := k8sClient.GetCurrentContext(); err == nil && currentCtx != "" {
+				fmt.Fprintf(os.Stderr, "Using context: %s\n", currentCtx)
+			}
+		}
+		return k8sClient, nil
+	}
+	if openedBundle == nil {
+		b, err := bundle.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		openedBundle = b
+		fmt.Fprintf(os.Stderr, "Reading bundle %s (context %s, exported %s)\n", path, b.Metadata.Context, b.Metadata.CreatedAt.Local().Format(time.DateTime))
+	}
+	return openedBundle.Client(), nil
+}
+
+// requireCluster prints an error and returns false when a command that
+// changes or follows the cluster is run against a bundle
+func requireCluster(command string) bool {
+	if os.Getenv("TRIX_BUNDLE") == "" {
+		return true
+	}
+	fmt.Printf("Error: %s needs a live cluster and can't run from a bundle\n", command)
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(exportBundleCmd)
+	rootCmd.PersistentFlags().StringVar(&fromBundle, "from-bundle", "", "Read reports from a bundle made by 'trix export-bundle' instead of the cluster")
+
+	cobra.OnInitialize(func() {
+		if fromBundle == "" {
+			return
+		}
+		// Through the environment so the commands 'trix ask' runs read it too
+		if abs, err := filepath.Abs(fromBundle); err == nil {
+			fromBundle = abs
+		}
+		_ = os.Setenv("TRIX_BUNDLE", fromBundle)
+	})
+}
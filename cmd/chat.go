@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/davealtena/trix/internal/agent"
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+var chatCmd = &cobra.Command{
+	Use:   "chat [question]",
+	Short: "Chat with a security assistant that can query your cluster",
+	Long: `Start an interactive security assistant. The LLM calls the same tools as
+'trix ask' to query findings and cluster state, keeps the conversation
+history, and streams its answers as they are written. Token usage (and cost,
+for models with known prices) is shown after every answer.
+
+Type 'clear' to start over and 'exit' to quit. Sessions are saved and can be
+continued with --resume.
+
+Examples:
+  trix chat
+  trix chat "which internet-facing deployments have critical RCEs?"
+  trix chat --resume last`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := newLLMClient(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		a := agent.New(client)
+		a.SetShowThinking(showThink)
+		a.SetStream(func(delta string) { fmt.Print(delta) })
+		contextManager := llm.NewContextManager(llmModel)
+		contextManager.Summarizer = client
+		a.SetContextManager(contextManager)
+
+		if len(args) == 0 && resumeID == "" {
+			fmt.Println("Ask about your cluster's security. Type 'exit' to quit.")
+		}
+		runInteractive(context.Background(), a, cmd.CommandPath(), strings.Join(args, " "), nil)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(chatCmd)
+	addLLMFlags(chatCmd)
+	chatCmd.Flags().IntVar(&thinking, "thinking", 0, "Enable Claude's extended thinking with this token budget (e.g. 8000; minimum 1024)")
+	chatCmd.Flags().BoolVar(&showThink, "show-thinking", false, "Print the model's full reasoning instead of a one-line summary")
+	chatCmd.Flags().StringVar(&resumeID, "resume", "", "Resume a saved session by ID ('last' for the most recent)")
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciFailOn      string
+	ciBaseline    string
+	ciMaxCritical int
+	ciMaxHigh     int
+	ciMaxMedium   int
+	ciMaxLow      int
+	ciPartial     bool
+)
+
+// ciListLimit caps the issues listed under a failed policy
+const ciListLimit = 10
+
+// CIResult is the outcome of 'trix ci'
+type CIResult struct {
+	Passed     bool                   `json:"passed"`
+	Context    string                 `json:"context,omitempty"`
+	Namespace  string                 `json:"namespace,omitempty"`
+	Baseline   string                 `json:"baseline,omitempty"` // Only issues new since this snapshot count
+	Counts     map[trivy.Severity]int `json:"counts"`
+	Violations []string               `json:"violations"`
+	Issues     []snapshot.Issue       `json:"issues"` // The issues of the violated severities
+}
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Gate a pipeline on findings, with meaningful exit codes",
+	Long: `Scan, print a compact summary and exit non-zero when the findings break the
+policy, so trix can be dropped into a pipeline as a gate.
+
+The policy is --fail-on, which fails on any issue at or above a severity, and
+--max-critical, --max-high, --max-medium and --max-low, which fail when there
+are more issues of exactly that severity. Issues are counted once however many
+workloads they affect: a CVE in one package of one image is one issue.
+
+With --baseline, only what got worse since a snapshot saved with 'trix
+snapshot' counts: new issues, issues in new workloads and raised severities.
+The ignore file is honored as in every command, so accepted risks don't fail
+the pipeline.
+
+Exit status is 0 when the policy passes, 1 when it fails and 2 on errors, such
+as an unreadable baseline or a scanner that failed. Partial results, e.g. from
+a forbidden namespace or malformed reports, are errors too, unless
+--allow-partial gates on what could be read.
+
+Examples:
+  trix ci -A --fail-on critical --max-high 5
+  trix ci -n prod --baseline baseline.json --fail-on high
+  trix ci -A --fail-on critical -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var failOn trivy.Severity
+		if ciFailOn != "" {
+			sev, err := trivy.ParseSeverity(ciFailOn)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(2)
+			}
+			failOn = sev
+		}
+		baseline := &snapshot.Snapshot{}
+		if ciBaseline != "" {
+			var err error
+			baseline, err = snapshot.Load(ciBaseline)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(2)
+			}
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			os.Exit(2)
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+		}
+
+		ns := resolveNamespace()
+		ctx := context.Background()
+		var findings []trivy.Finding
+		for _, scanner := range scannersFor(trivyClient) {
+			found, err := scanner.Scan(ctx, ns)
+			// A gate that passes because a scanner failed would be worse than none
+			if err != nil && !(ciPartial && partialResults(err)) {
+				fmt.Printf("Error in %s: %v\n", scanner.Name(), err)
+				if trivy.IsPartial(err) {
+					fmt.Println("Hint: use --allow-partial to gate on the reports that could be read.")
+				}
+				os.Exit(2)
+			}
+			findings = append(findings, found...)
+		}
+		if len(findings) == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
+
+		diff := snapshot.Compare(baseline, snapshot.New(currentCtx, ns, findings))
+		result := evaluateCI(diff.Regressed(), failOn, map[trivy.Severity]int{
+			trivy.SeverityCritical: ciMaxCritical,
+			trivy.SeverityHigh:     ciMaxHigh,
+			trivy.SeverityMedium:   ciMaxMedium,
+			trivy.SeverityLow:      ciMaxLow,
+		})
+		result.Context = currentCtx
+		result.Namespace = ns
+		result.Baseline = ciBaseline
+
+		if structuredOutput() {
+			if !printStructured(result) {
+				os.Exit(2)
+			}
+		} else {
+			fmt.Println(formatCI(result))
+		}
+		if !result.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+// evaluateCI checks the issues against the policy: any issue at or above
+// failOn, or more issues of a severity than its maximum (negative for none)
+func evaluateCI(issues []snapshot.Issue, failOn trivy.Severity, maximum map[trivy.Severity]int) CIResult {
+	result := CIResult{Counts: make(map[trivy.Severity]int), Violations: []string{}, Issues: []snapshot.Issue{}}
+	for _, issue := range issues {
+		result.Counts[issue.Severity]++
+	}
+
+	violated := make(map[trivy.Severity]bool)
+	for _, sev := range []trivy.Severity{trivy.SeverityCritical, trivy.SeverityHigh, trivy.SeverityMedium, trivy.SeverityLow} {
+		count := result.Counts[sev]
+		if failOn != "" && count > 0 && sev.Rank() >= failOn.Rank() {
+			result.Violations = append(result.Violations, fmt.Sprintf("%d %s (--fail-on %s)", count, sev, strings.ToLower(string(failOn))))
+			violated[sev] = true
+			continue
+		}
+		if limit := maximum[sev]; limit >= 0 && count > limit {
+			result.Violations = append(result.Violations, fmt.Sprintf("%d %s, more than --max-%s %d", count, sev, strings.ToLower(string(sev)), limit))
+			violated[sev] = true
+		}
+	}
+	for _, issue := range issues {
+		if violated[issue.Severity] {
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+	result.Passed = len(result.Violations) == 0
+	return result
+}
+
+// formatCI renders the result as a few plain lines, readable in CI logs
+func formatCI(result CIResult) string {
+	var out strings.Builder
+	scope := result.Namespace
+	if scope == "" {
+		scope = "all namespaces"
+	}
+	if result.Context != "" {
+		scope = result.Context + ", " + scope
+	}
+	if result.Baseline != "" {
+		scope += ", new since " + result.Baseline
+	}
+	out.WriteString(fmt.Sprintf("trix ci: %s\n", scope))
+
+	var counts []string
+	for _, sev := range []trivy.Severity{trivy.SeverityCritical, trivy.SeverityHigh, trivy.SeverityMedium, trivy.SeverityLow} {
+		counts = append(counts, fmt.Sprintf("%d %s", result.Counts[sev], sev))
+	}
+	out.WriteString("Issues: " + strings.Join(counts, ", ") + "\n")
+
+	if result.Passed {
+		out.WriteString("PASS")
+		return out.String()
+	}
+	for _, v := range result.Violations {
+		out.WriteString("  " + v + "\n")
+	}
+	for i, issue := range result.Issues {
+		if i == ciListLimit {
+			out.WriteString(fmt.Sprintf("  ... and %d more (-o json lists all)\n", len(result.Issues)-ciListLimit))
+			break
+		}
+		workloads := fmt.Sprintf("%d workloads", len(issue.Workloads))
+		if len(issue.Workloads) == 1 {
+			workloads = issue.Workloads[0]
+		}
+		out.WriteString(fmt.Sprintf("  %-8s %s %s (%s)\n", issue.Severity, issue.ID, issueLocation(issue), workloads))
+	}
+	out.WriteString("FAIL")
+	return out.String()
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+	ciCmd.Flags().StringVar(&ciFailOn, "fail-on", "", "Fail on any issue at or above this severity (e.g. critical)")
+	ciCmd.Flags().IntVar(&ciMaxCritical, "max-critical", -1, "Fail on more CRITICAL issues than this, -1 for no limit")
+	ciCmd.Flags().IntVar(&ciMaxHigh, "max-high", -1, "Fail on more HIGH issues than this, -1 for no limit")
+	ciCmd.Flags().IntVar(&ciMaxMedium, "max-medium", -1, "Fail on more MEDIUM issues than this, -1 for no limit")
+	ciCmd.Flags().IntVar(&ciMaxLow, "max-low", -1, "Fail on more LOW issues than this, -1 for no limit")
+	ciCmd.Flags().BoolVar(&ciPartial, "allow-partial", false, "Gate on partial results instead of exiting with status 2, e.g. when a namespace is forbidden")
+	ciCmd.Flags().StringVar(&ciBaseline, "baseline", "", "Only gate on what got worse since this snapshot, saved with 'trix snapshot'")
+	addScanFlags(ciCmd)
+	ciCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
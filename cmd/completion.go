@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completionTimeout bounds the cluster requests of one completion, so a slow
+// or unreachable cluster doesn't hang the shell
+const completionTimeout = 5 * time.Second
+
+// vulnIDCacheTTL is how long completions use the cached vulnerability IDs of
+// a context before reading its reports again
+const vulnIDCacheTTL = 10 * time.Minute
+
+// completionKinds are the workload kinds completed for kind/name arguments
+var completionKinds = []string{"deployment", "statefulset", "daemonset", "cronjob", "job"}
+
+// vulnIDCache is the on-disk list of the vulnerability IDs in a context's
+// reports
+type vulnIDCache struct {
+	CreatedAt time.Time `json:"createdAt"`
+	IDs       []string  `json:"ids"`
+}
+
+// registerCompletions completes the --namespace, --namespaces,
+// --exclude-namespaces and --cve flags of every command
+func registerCompletions(cmd *cobra.Command) {
+	register := func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "namespace":
+			_ = cmd.RegisterFlagCompletionFunc(flag.Name, completeNamespaces)
+		case "namespaces", "exclude-namespaces":
+			_ = cmd.RegisterFlagCompletionFunc(flag.Name, completeList(completeNamespaces))
+		case "cve":
+			_ = cmd.RegisterFlagCompletionFunc(flag.Name, completeList(completeVulnIDs))
+		}
+	}
+	cmd.LocalNonPersistentFlags().VisitAll(register)
+	cmd.PersistentFlags().VisitAll(register)
+	for _, sub := range cmd.Commands() {
+		registerCompletions(sub)
+	}
+}
+
+// completeNamespaces completes the namespaces of the cluster
+func completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	applyKubeFlags() // Completion parses the flags after OnInitialize
+	k8sClient, err := kubectl.NewClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	names, err := k8sClient.ListNamespaces(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeList wraps a completion for flags that take comma-separated values,
+// completing the value after the last comma
+func completeList(complete cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		i := strings.LastIndex(toComplete, ",")
+		values, directive := complete(cmd, args, toComplete[i+1:])
+		if i < 0 {
+			return values, directive
+		}
+		for j := range values {
+			values[j] = toComplete[:i+1] + values[j]
+		}
+		return values, directive
+	}
+}
+
+// completeWorkloads completes kind/name arguments: the kinds first, then the
+// workloads of that kind in the namespace of -n
+func completeWorkloads(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	kindName, _, ok := strings.Cut(toComplete, "/")
+	if !ok {
+		kinds := make([]string, len(completionKinds))
+		for i, kind := range completionKinds {
+			kinds[i] = kind + "/"
+		}
+		return kinds, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+	kind := workloadKinds[strings.ToLower(kindName)]
+	if kind == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	namespace := "default"
+	if flag := cmd.Flag("namespace"); flag != nil && flag.Value.String() != "" {
+		namespace = flag.Value.String()
+	}
+
+	applyKubeFlags() // Completion parses the flags after OnInitialize
+	k8sClient, err := kubectl.NewClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	names, err := k8sClient.ListWorkloads(ctx, namespace, kind)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	for i, name := range names {
+		names[i] = kindName + "/" + name
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeVulnIDs completes the vulnerability IDs in the cluster's reports,
+// from a cache refreshed every vulnIDCacheTTL
+func completeVulnIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	applyKubeFlags() // Completion parses the flags after OnInitialize
+	k8sClient, err := kubectl.NewClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	currentCtx, err := k8sClient.GetCurrentContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	path, err := vulnIDCachePath(currentCtx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if ids, ok := cachedVulnIDs(path); ok {
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	reports, err := trivy.NewClient(k8sClient).ListVulnerabilityReports(ctx, "")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, report := range reports {
+		for _, v := range report.Report.Vulnerabilities {
+			if !seen[v.VulnerabilityID] {
+				seen[v.VulnerabilityID] = true
+				ids = append(ids, v.VulnerabilityID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	saveVulnIDs(path, ids)
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeExplainArgs completes a vulnerability ID or a kind/name
+func completeExplainArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	workloads, directive := completeWorkloads(cmd, args, toComplete)
+	if strings.Contains(toComplete, "/") {
+		return workloads, directive
+	}
+	ids, idDirective := completeVulnIDs(cmd, args, toComplete)
+	if toComplete == "" {
+		return append(workloads, ids...), directive
+	}
+	// Kinds are lowercase and IDs uppercase, so the prefix tells them apart
+	for _, kind := range workloads {
+		if strings.HasPrefix(kind, toComplete) {
+			return workloads, directive
+		}
+	}
+	return ids, idDirective
+}
+
+// vulnIDCachePath returns the cache file of a context's vulnerability IDs
+// (~/.cache/trix/completion/<context>.json on Linux)
+func vulnIDCachePath(contextName string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trix", "completion", contextFileName(contextName)+".json"), nil
+}
+
+// contextFileName turns a context name, which may hold slashes and colons
+// (e.g. an EKS ARN), into a safe file name
+func contextFileName(contextName string) string {
+	if contextName == "" {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, contextName)
+}
+
+// cachedVulnIDs returns the cached IDs, if the cache isn't older than
+// vulnIDCacheTTL
+func cachedVulnIDs(path string) ([]string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var cache vulnIDCache
+	if err := json.Unmarshal(data, &cache); err != nil || time.Since(cache.CreatedAt) > vulnIDCacheTTL {
+		return nil, false
+	}
+	return cache.IDs, true
+}
+
+// saveVulnIDs writes the cache. Failing to is harmless: the next completion
+// reads the reports again.
+func saveVulnIDs(path string, ids []string) {
+	data, err := json.Marshal(vulnIDCache{CreatedAt: time.Now(), IDs: ids})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/kubebench"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	complianceFramework string
+	complianceDetails   string
+	complianceNodes     bool
+)
+
+// ComplianceResult is the outcome of one compliance framework, as shown by
+// 'trix compliance'
+type ComplianceResult struct {
+	Name     string                             `json:"name"`
+	Title    string                             `json:"title,omitempty"`
+	Score    float64                            `json:"score"` // Percentage of automated controls passing
+	Pass     int                                `json:"pass"`
+	Fail     int                                `json:"fail"`
+	Manual   int                                `json:"manual"`
+	Sections []trivy.BenchmarkSection           `json:"sections"`
+	Controls []trivy.BenchmarkControl           `json:"controls"`
+	Failures map[string][]trivy.ControlResource `json:"failures,omitempty"` // With --details
+}
+
+var complianceCmd = &cobra.Command{
+	Use:   "compliance",
+	Short: "Show CIS and NSA compliance results per control and section",
+	Long: `Show the results of Trivy Operator's ClusterComplianceReports: pass or fail
+for every control, and a score per section (e.g. CIS 1.2, API Server) and
+for the whole framework. The score is the share of automated controls that
+pass; manual controls are listed but not scored.
+
+--framework picks a framework (cis, nsa, pss-baseline, pss-restricted);
+--details lists the resources failing one control.
+
+--nodes adds the CIS node checks of the kube-bench jobs 'trix nodes benchmark'
+ran, for what the operator can't check from the API server.
+
+Examples:
+  trix compliance
+  trix compliance --framework cis
+  trix compliance --framework cis --details 5.2.2
+  trix compliance --framework cis --nodes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		reports, err := trivyClient.ListBenchmarkReports(ctx)
+		if err != nil {
+			fmt.Printf("Error listing compliance reports: %v\n", err)
+			return
+		}
+
+		var results []ComplianceResult
+		for _, report := range reports {
+			if complianceFramework != "" && !trivy.BenchmarkFramework(&report, complianceFramework) {
+				continue
+			}
+			controls := trivy.ConvertBenchmarkControls(&report)
+			result := ComplianceResult{
+				Name:     report.Name,
+				Title:    report.Spec.Compliance.Title,
+				Sections: trivy.BenchmarkSections(controls),
+				Controls: controls,
+			}
+			for _, c := range controls {
+				switch c.Status {
+				case trivy.ControlPass:
+					result.Pass++
+				case trivy.ControlFail:
+					result.Fail++
+				default:
+					result.Manual++
+				}
+			}
+			result.Score = trivy.BenchmarkScore(result.Pass, result.Fail)
+
+			if complianceDetails != "" {
+				result.Controls = nil
+				for _, c := range controls {
+					if c.ID == complianceDetails {
+						result.Controls = append(result.Controls, c)
+					}
+				}
+				if len(result.Controls) == 0 {
+					continue
+				}
+				if result.Failures, err = trivyClient.ControlFailures(ctx, result.Controls); err != nil {
+					fmt.Printf("Error finding failing resources: %v\n", err)
+					return
+				}
+			}
+			results = append(results, result)
+		}
+		if complianceNodes && (complianceFramework == "" || strings.EqualFold(complianceFramework, "cis")) {
+			if result, ok := nodeComplianceResult(ctx, k8sClient); ok {
+				results = append(results, result)
+			}
+		}
+
+		if structuredOutput() {
+			printStructured(results)
+			return
+		}
+
+		if len(results) == 0 {
+			switch {
+			case complianceDetails != "":
+				fmt.Printf("Control %s not found.\n", complianceDetails)
+			case complianceFramework != "":
+				fmt.Printf("No %s compliance report found. Is it enabled in Trivy Operator's compliance.specs?\n", complianceFramework)
+			default:
+				fmt.Println("No compliance reports found. Is compliance reporting enabled in Trivy Operator?")
+			}
+			return
+		}
+		for _, result := range results {
+			if complianceDetails != "" {
+				fmt.Println(formatControlDetails(result))
+			} else {
+				fmt.Println(formatComplianceResult(result))
+			}
+		}
+	},
+}
+
+// nodeComplianceResult returns the kube-bench results of the nodes as a
+// compliance result, narrowed down to the control of --details
+func nodeComplianceResult(ctx context.Context, k8sClient *kubectl.Client) (ComplianceResult, bool) {
+	if !requireCluster("compliance --nodes") {
+		return ComplianceResult{}, false
+	}
+	nodeResults, errs := kubebench.NewRunner(k8sClient.Clientset(), "", "", 0).Existing(ctx, "")
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if len(nodeResults) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: no kube-bench results found; run 'trix nodes benchmark' first")
+		return ComplianceResult{}, false
+	}
+	result := nodeCompliance(nodeResults)
+	if complianceDetails != "" {
+		return result, filterControl(&result, complianceDetails)
+	}
+	result.Failures = nil // As for the operator's reports, only with --details
+	return result, true
+}
+
+// complianceHeader returns the title line of a framework's results
+func complianceHeader(result ComplianceResult) string {
+	name := result.Name
+	if result.Title != "" {
+		name += " - " + result.Title
+	}
+	return fmt.Sprintf("%s: %.0f%% (%d pass, %d fail, %d manual)", name, result.Score, result.Pass, result.Fail, result.Manual)
+}
+
+// formatComplianceResult renders the section scores and controls of a framework
+func formatComplianceResult(result ComplianceResult) string {
+	var content strings.Builder
+	sections := ui.NewTable("Section", "Score", "Pass", "Fail", "Manual")
+	for _, s := range result.Sections {
+		score := fmt.Sprintf("%.0f%%", s.Score)
+		if s.Pass+s.Fail == 0 {
+			score = "-"
+		}
+		sections.AddRow(s.ID, score, fmt.Sprintf("%d", s.Pass), fmt.Sprintf("%d", s.Fail), fmt.Sprintf("%d", s.Manual))
+	}
+	content.WriteString(sections.Render() + "\n")
+
+	controls := ui.NewTable("ID", "Status", "Severity", "Failed", "Control")
+	for _, c := range result.Controls {
+		name := ui.Truncate(c.Name, 60)
+		failed := ""
+		if c.Status == trivy.ControlFail {
+			failed = fmt.Sprintf("%d", c.TotalFail)
+		}
+		controls.AddRow(c.ID, c.Status, c.Severity, failed, name)
+	}
+	content.WriteString(controls.Render())
+	if result.Fail > 0 {
+		content.WriteString("\n" + ui.Muted.Render("List the resources failing a control with --details <control-id>"))
+	}
+	return ui.Box(complianceHeader(result), content.String(), 110)
+}
+
+// formatControlDetails renders one control and the resources failing it
+func formatControlDetails(result ComplianceResult) string {
+	var out strings.Builder
+	out.WriteString(ui.Muted.Render(complianceHeader(result)) + "\n\n")
+	for _, c := range result.Controls {
+		out.WriteString(ui.Section(fmt.Sprintf("%s %s", c.ID, c.Name)) + "\n")
+		out.WriteString(fmt.Sprintf("Status: %s  Severity: %s  Checks: %s\n\n", c.Status, c.Severity, strings.Join(c.Checks, ", ")))
+		resources := result.Failures[c.ID]
+		switch {
+		case c.Status == trivy.ControlManual:
+			out.WriteString("This control has no automated checks; verify it by hand.\n")
+			continue
+		case len(resources) == 0:
+			out.WriteString("No failing resources found for this control.\n")
+			continue
+		}
+		table := ui.NewTable("Resource", "Check", "Title")
+		for _, r := range resources {
+			resource := r.Kind + "/" + r.Name
+			if r.Namespace != "" {
+				resource = r.Namespace + "/" + resource
+			}
+			table.AddRow(resource, r.CheckID, r.Title)
+		}
+		out.WriteString(table.Render())
+	}
+	return out.String()
+}
+
+func init() {
+	rootCmd.AddCommand(complianceCmd)
+	complianceCmd.Flags().StringVar(&complianceFramework, "framework", "", "Only show this framework: cis, nsa, pss-baseline or pss-restricted (default all)")
+	complianceCmd.Flags().StringVar(&complianceDetails, "details", "", "List the resources failing this control (e.g. 5.2.2)")
+	complianceCmd.Flags().BoolVar(&complianceNodes, "nodes", false, "Include the kube-bench node results of 'trix nodes benchmark'")
+	addScanFlags(complianceCmd)
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configAuditCategories []string
+	configAuditCheck      string
+)
+
+// ConfigAuditCheck is a misconfiguration check with the resources failing it
+type ConfigAuditCheck struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Severity    string   `json:"severity"`
+	Category    string   `json:"category"`
+	Remediation string   `json:"remediation,omitempty"`
+	Resources   []string `json:"resources"` // namespace/Kind/name
+}
+
+// ConfigAuditNamespace summarizes the failed checks of one namespace
+type ConfigAuditNamespace struct {
+	Namespace  string         `json:"namespace"`
+	Failures   int            `json:"failures"`
+	Checks     int            `json:"checks"`    // Distinct checks failing
+	Resources  int            `json:"resources"` // Resources failing a check
+	BySeverity map[string]int `json:"bySeverity"`
+}
+
+// ConfigAuditResult is the output of 'trix configaudit'
+type ConfigAuditResult struct {
+	Checks     []ConfigAuditCheck     `json:"checks"`
+	Namespaces []ConfigAuditNamespace `json:"namespaces"`
+}
+
+var configAuditCmd = &cobra.Command{
+	Use:   "configaudit",
+	Short: "List misconfigurations by check, with per-namespace summaries",
+	Long: `List the failed checks of Trivy's ConfigAuditReports grouped by check ID,
+with the resources failing each, and summarize the failures per namespace.
+
+Checks are grouped in categories: security-context (privileges, capabilities,
+users, seccomp), network (host network and ports) and resources (requests and
+limits); everything else is other. --category keeps only the given ones.
+
+Examples:
+  trix configaudit -A
+  trix configaudit -A --category security-context,network
+  trix configaudit -n payments --check KSV014`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, c := range configAuditCategories {
+			if !slices.Contains(trivy.CheckCategories, c) {
+				fmt.Printf("Error: unknown category %q (expected %s)\n", c, strings.Join(trivy.CheckCategories, ", "))
+				return
+			}
+		}
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		findings, err := trivy.NewTrivyComplianceScanner(trivyClient).Scan(ctx, ns)
+		if err != nil && !partialResults(err) {
+			fmt.Printf("Error listing config audit reports: %v\n", err)
+			return
+		}
+		if len(findings) == 0 && err == nil {
+			explainEmpty(ctx, trivyClient)
+		}
+
+		var matched []trivy.Finding
+		for _, f := range findings {
+			if configAuditCheck != "" && trivy.NormalizeCheckID(f.ID) != trivy.NormalizeCheckID(configAuditCheck) {
+				continue
+			}
+			if len(configAuditCategories) > 0 && !slices.Contains(configAuditCategories, trivy.CheckCategory(f.ID)) {
+				continue
+			}
+			matched = append(matched, f)
+		}
+		result := summarizeConfigAudit(matched)
+
+		if structuredOutput() {
+			printStructured(result)
+			return
+		}
+		if len(result.Checks) == 0 {
+			fmt.Println("No failed config audit checks found.")
+			return
+		}
+		fmt.Println(formatConfigAudit(result, configAuditCheck != ""))
+	},
+}
+
+// summarizeConfigAudit groups failed checks by check ID, most severe and most
+// widespread first, and by namespace. A check failing in several containers
+// of one resource counts once for it.
+func summarizeConfigAudit(findings []trivy.Finding) ConfigAuditResult {
+	checks := make(map[string]*ConfigAuditCheck)
+	namespaces := make(map[string]*ConfigAuditNamespace)
+	nsChecks := make(map[string]map[string]bool)
+	nsResources := make(map[string]map[string]bool)
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		resource := f.Namespace + "/" + f.Resource()
+		if seen[f.ID+"|"+resource] {
+			continue
+		}
+		seen[f.ID+"|"+resource] = true
+
+		c, ok := checks[f.ID]
+		if !ok {
+			c = &ConfigAuditCheck{ID: f.ID, Title: f.Title, Severity: string(f.Severity), Category: trivy.CheckCategory(f.ID), Remediation: f.Remediation}
+			checks[f.ID] = c
+		}
+		c.Resources = append(c.Resources, resource)
+
+		n, ok := namespaces[f.Namespace]
+		if !ok {
+			n = &ConfigAuditNamespace{Namespace: f.Namespace, BySeverity: make(map[string]int)}
+			namespaces[f.Namespace] = n
+			nsChecks[f.Namespace] = make(map[string]bool)
+			nsResources[f.Namespace] = make(map[string]bool)
+		}
+		n.Failures++
+		n.BySeverity[string(f.Severity)]++
+		nsChecks[f.Namespace][f.ID] = true
+		nsResources[f.Namespace][resource] = true
+	}
+
+	result := ConfigAuditResult{Checks: []ConfigAuditCheck{}, Namespaces: []ConfigAuditNamespace{}}
+	for _, c := range checks {
+		sort.Strings(c.Resources)
+		result.Checks = append(result.Checks, *c)
+	}
+	sort.Slice(result.Checks, func(i, j int) bool {
+		a, b := result.Checks[i], result.Checks[j]
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		if len(a.Resources) != len(b.Resources) {
+			return len(a.Resources) > len(b.Resources)
+		}
+		return a.ID < b.ID
+	})
+	for name, n := range namespaces {
+		n.Checks = len(nsChecks[name])
+		n.Resources = len(nsResources[name])
+		result.Namespaces = append(result.Namespaces, *n)
+	}
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		a, b := result.Namespaces[i], result.Namespaces[j]
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		return a.Namespace < b.Namespace
+	})
+	return result
+}
+
+// formatConfigAudit renders the checks and namespace summaries. With
+// resources set, the resources failing each check are listed too.
+func formatConfigAudit(result ConfigAuditResult, resources bool) string {
+	checks := ui.NewTable("Check", "Severity", "Category", "Title", "Resources")
+	for _, c := range result.Checks {
+		checks.AddRow(c.ID, c.Severity, c.Category, c.Title, fmt.Sprintf("%d", len(c.Resources)))
+	}
+	content := checks.Render()
+	if resources {
+		for _, c := range result.Checks {
+			content += "\n" + ui.Section(c.ID+" resources") + "\n"
+			for _, r := range c.Resources {
+				content += "  " + r + "\n"
+			}
+			if c.Remediation != "" {
+				content += ui.Muted.Render("Fix: "+c.Remediation) + "\n"
+			}
+		}
+	}
+
+	namespaces := ui.NewTable("Namespace", "Failures", "Checks", "Resources", "Critical", "High", "Medium", "Low")
+	for _, n := range result.Namespaces {
+		namespaces.AddRow(n.Namespace, fmt.Sprintf("%d", n.Failures), fmt.Sprintf("%d", n.Checks), fmt.Sprintf("%d", n.Resources),
+			fmt.Sprintf("%d", n.BySeverity[string(trivy.SeverityCritical)]),
+			fmt.Sprintf("%d", n.BySeverity[string(trivy.SeverityHigh)]),
+			fmt.Sprintf("%d", n.BySeverity[string(trivy.SeverityMedium)]),
+			fmt.Sprintf("%d", n.BySeverity[string(trivy.SeverityLow)]))
+	}
+	content += "\n" + ui.Section("By Namespace") + "\n" + namespaces.Render()
+	return ui.Box(fmt.Sprintf("Misconfigurations (%d)", len(result.Checks)), content, 140)
+}
+
+func init() {
+	rootCmd.AddCommand(configAuditCmd)
+	configAuditCmd.Flags().StringSliceVar(&configAuditCategories, "category", nil, "Only include checks in these categories: security-context, network, resources, other")
+	configAuditCmd.Flags().StringVar(&configAuditCheck, "check", "", "Only include one check and list the resources failing it (e.g. KSV014)")
+	addScanFlags(configAuditCmd)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeconfigPath string
+	kubeContext    string
+	kubeCluster    string
+	contextShown   bool // newK8sClient printed the context
+)
+
+var contextsCmd = &cobra.Command{
+	Use:   "contexts",
+	Short: "List the kubeconfig contexts, marking the one trix uses",
+	Long: `List the contexts in the kubeconfig with their cluster, user and namespace.
+The one marked * is the context trix uses: the kubeconfig's current context,
+or the one selected with --context.
+
+Every command takes --kubeconfig, --context and --cluster to target another
+cluster without changing the kubeconfig or exporting KUBECONFIG. They can also
+be set with KUBECONFIG, TRIX_CONTEXT and TRIX_CLUSTER.
+
+Examples:
+  trix contexts
+  trix contexts --kubeconfig ~/.kube/prod.yaml
+  trix query summary -A --context prod-eu`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts, err := kubectl.ListContexts()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if structuredOutput() {
+			printStructured(contexts)
+			return
+		}
+		if len(contexts) == 0 {
+			fmt.Println("No contexts in the kubeconfig")
+			return
+		}
+		table := ui.NewTable("", "Name", "Cluster", "User", "Namespace")
+		for _, c := range contexts {
+			current := ""
+			if c.Current {
+				current = "*"
+			}
+			table.AddRow(current, c.Name, c.Cluster, c.User, c.Namespace)
+		}
+		fmt.Println(ui.Box(fmt.Sprintf("Contexts (%d)", len(contexts)), table.Render(), 120))
+	},
+}
+
+// applyKubeFlags passes --kubeconfig, --context and --cluster on through the
+// environment, so the client, the commands 'trix ask' runs and helm and
+// kubectl use the same cluster
+func applyKubeFlags() {
+	if kubeconfigPath != "" {
+		if abs, err := filepath.Abs(kubeconfigPath); err == nil {
+			kubeconfigPath = abs
+		}
+		_ = os.Setenv("KUBECONFIG", kubeconfigPath)
+	}
+	if kubeContext != "" {
+		_ = os.Setenv("TRIX_CONTEXT", kubeContext)
+	}
+	if kubeCluster != "" {
+		_ = os.Setenv("TRIX_CLUSTER", kubeCluster)
+	}
+}
+
+// completeContexts completes the context names in the kubeconfig
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	applyKubeFlags()
+	contexts, err := kubectl.ListContexts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, len(contexts))
+	for i, c := range contexts {
+		names[i] = c.Name
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(contextsCmd)
+
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file (default KUBECONFIG or ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use (or TRIX_CONTEXT; see 'trix contexts')")
+	rootCmd.PersistentFlags().StringVar(&kubeCluster, "cluster", "", "Kubeconfig cluster to use instead of the context's (or TRIX_CLUSTER)")
+	_ = rootCmd.RegisterFlagCompletionFunc("context", completeContexts)
+
+	cobra.OnInitialize(applyKubeFlags)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/enrich"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var noEnrichCache bool
+
+// CVEDetail is the advisory of a vulnerability and where it occurs
+type CVEDetail struct {
+	Advisory    *enrich.Advisory                `json:"advisory,omitempty"`
+	Occurrences []aggregate.UniqueVulnerability `json:"occurrences"`
+}
+
+var queryCVECmd = &cobra.Command{
+	Use:   "cve <id>",
+	Short: "Show advisory details of a vulnerability and where it occurs",
+	Long: `Look up a vulnerability (CVE or GHSA ID) in OSV.dev and the NVD for the
+details Trivy reports leave out, such as the full description, CVSS vector,
+CWEs and references, and list the images in the cluster it affects.
+
+Lookups are cached for a week under ~/.cache/trix/cve. Set NVD_API_KEY to
+raise the NVD rate limit.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeVulnIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := enrich.NormalizeID(args[0])
+		ctx := context.Background()
+
+		cfg := enrich.Config{NVDAPIKey: os.Getenv("NVD_API_KEY")}
+		if noEnrichCache {
+			cfg.CacheDir = "-"
+		}
+		var detail CVEDetail
+		advisory, err := enrich.NewClient(cfg).Lookup(ctx, id)
+		switch {
+		case errors.Is(err, enrich.ErrNotFound):
+			fmt.Fprintf(os.Stderr, "Warning: %s is not in OSV or the NVD\n", id)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		default:
+			detail.Advisory = advisory
+		}
+
+		ns := resolveNamespace()
+		occurrences, err := cveOccurrences(ctx, id, ns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		detail.Occurrences = occurrences
+
+		if structuredOutput() {
+			printStructured(detail)
+			return
+		}
+		fmt.Println(ui.Box(id, formatCVEDetail(detail), 100))
+	},
+}
+
+// cveOccurrences returns each image and package a vulnerability is found in
+func cveOccurrences(ctx context.Context, id, ns string) ([]aggregate.UniqueVulnerability, error) {
+	k8sClient, err := newK8sClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	cveIDs = []string{id}
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	findings, err := trivy.NewTrivyVulnScanner(trivyClient).Scan(ctx, ns)
+	if err != nil && !partialResults(err) {
+		return nil, err
+	}
+	dedup := aggregate.NewDeduplicator()
+	dedup.Add(findings...)
+	return dedup.Vulnerabilities(), nil
+}
+
+// formatCVEDetail renders an advisory and its occurrences for the terminal
+func formatCVEDetail(detail CVEDetail) string {
+	var content strings.Builder
+
+	if a := detail.Advisory; a != nil {
+		if a.Summary != "" {
+			content.WriteString(ui.Title.Render(a.Summary) + "\n\n")
+		}
+		if a.Severity != "" || a.Score > 0 {
+			line := ui.Severity(a.Severity).Render(a.Severity)
+			if a.Score > 0 {
+				line += fmt.Sprintf(" (CVSS %.1f)", a.Score)
+			}
+			content.WriteString(line + "\n")
+		}
+		if a.Vector != "" {
+			content.WriteString(ui.Muted.Render(a.Vector) + "\n")
+		}
+		if !a.Published.IsZero() {
+			content.WriteString(ui.Muted.Render("Published "+a.Published.Format("2006-01-02")) + "\n")
+		}
+		if len(a.Aliases) > 0 {
+			content.WriteString(ui.Muted.Render("Aliases: "+strings.Join(a.Aliases, ", ")) + "\n")
+		}
+		if len(a.CWEs) > 0 {
+			content.WriteString(ui.Muted.Render("Weaknesses: "+strings.Join(a.CWEs, ", ")) + "\n")
+		}
+		if a.Description != "" {
+			content.WriteString("\n" + strings.TrimSpace(a.Description) + "\n")
+		}
+
+		if len(a.Affected) > 0 {
+			content.WriteString("\n" + ui.Section("Fixed Versions") + "\n")
+			for _, p := range a.Affected {
+				fixed := strings.Join(p.Fixed, ", ")
+				if fixed == "" {
+					fixed = "no fix"
+				}
+				content.WriteString(fmt.Sprintf("  %s/%s: %s\n", p.Ecosystem, p.Name, fixed))
+			}
+		}
+
+		if len(a.References) > 0 {
+			content.WriteString("\n" + ui.Section("References") + "\n")
+			for i, ref := range a.References {
+				if i == 10 {
+					content.WriteString(ui.Muted.Render(fmt.Sprintf("  ... and %d more", len(a.References)-10)) + "\n")
+					break
+				}
+				content.WriteString("  " + ref + "\n")
+			}
+		}
+		content.WriteString("\n" + ui.Muted.Render("Sources: "+strings.Join(a.Sources, ", ")) + "\n\n")
+	}
+
+	content.WriteString(ui.Section("In This Cluster") + "\n")
+	if len(detail.Occurrences) == 0 {
+		content.WriteString(ui.Muted.Render("  Not found in any vulnerability report") + "\n")
+	}
+	for _, o := range detail.Occurrences {
+		fixed := o.FixedVersion
+		if fixed == "" {
+			fixed = "no fix"
+		}
+		content.WriteString(fmt.Sprintf("  %s  %s %s → %s\n", o.Image, o.PkgName, o.InstalledVersion, fixed))
+		content.WriteString(ui.Muted.Render("    "+strings.Join(o.Workloads, ", ")) + "\n")
+	}
+	return content.String()
+}
+
+func init() {
+	queryCmd.AddCommand(queryCVECmd)
+	queryCVECmd.Flags().BoolVar(&noEnrichCache, "no-cache", false, "Look the vulnerability up again instead of using the cache")
+}
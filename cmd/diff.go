@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffBaseline string
+	diffFailOn   string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff --baseline <file> [current-file]",
+	Short: "Show new, resolved and changed findings since a baseline",
+	Long: `Compare the current findings against a baseline saved with 'trix snapshot'
+and list what is new, what was resolved, whose severity changed and which
+findings spread to more workloads. Pass a second snapshot file to compare two
+saved scans instead of the cluster.
+
+The cluster is scanned in the namespace the baseline was taken in, unless -n
+or -A say otherwise.
+
+With --fail-on, trix exits with status 1 when the diff has regressions at or
+above that severity: new findings, findings in new workloads or raised
+severities. Resolved findings never fail, so CI only fails on regressions.
+Errors, such as an unreadable baseline, exit with status 2.
+
+Examples:
+  trix diff --baseline baseline.json
+  trix diff --baseline baseline.json --fail-on high
+  trix diff --baseline main.json pr.json -o json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var failOn trivy.Severity
+		if diffFailOn != "" {
+			sev, err := trivy.ParseSeverity(diffFailOn)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(2)
+			}
+			failOn = sev
+		}
+		baseline, err := snapshot.Load(diffBaseline)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		var current *snapshot.Snapshot
+		if len(args) > 0 {
+			current, err = snapshot.Load(args[0])
+		} else {
+			current, err = currentSnapshot(baseline)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		diff := snapshot.Compare(baseline, current)
+		if structuredOutput() {
+			if !printStructured(diff) {
+				os.Exit(2)
+			}
+		} else {
+			fmt.Println(formatDiff(diff, baseline))
+		}
+
+		if failOn != "" && diff.Regressions(failOn) {
+			fmt.Fprintf(os.Stderr, "Regressions at %s or above since the baseline\n", failOn)
+			os.Exit(1)
+		}
+	},
+}
+
+// currentSnapshot scans the cluster in the baseline's namespace, or the one
+// set on the command line
+func currentSnapshot(baseline *snapshot.Snapshot) (*snapshot.Snapshot, error) {
+	k8sClient, err := newK8sClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	currentCtx, err := k8sClient.GetCurrentContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+	}
+	if baseline.Context != "" && currentCtx != "" && baseline.Context != currentCtx {
+		fmt.Fprintf(os.Stderr, "Warning: the baseline is of context %s, the current context is %s\n", baseline.Context, currentCtx)
+	}
+
+	ns := baseline.Namespace
+	if allNamespaces || rootCmd.PersistentFlags().Changed("namespace") {
+		ns = resolveNamespace()
+	}
+	return snapshot.New(currentCtx, ns, scanFindings(context.Background(), trivyClient, ns)), nil
+}
+
+// formatDiff renders a diff for the terminal, one section per kind of change
+func formatDiff(d snapshot.Diff, baseline *snapshot.Snapshot) string {
+	var out strings.Builder
+	out.WriteString(ui.Muted.Render(fmt.Sprintf("Compared with the baseline of %s", baseline.CreatedAt.Local().Format("2006-01-02 15:04"))) + "\n\n")
+	if d.Empty() {
+		out.WriteString("No changes since the baseline")
+		return out.String()
+	}
+
+	issueTable := func(issues []snapshot.Issue) string {
+		table := ui.NewTable("Severity", "ID", "Type", "Where", "Workloads")
+		for _, issue := range issues {
+			table.AddRow(string(issue.Severity), issue.ID, string(issue.Type), issueLocation(issue), strings.Join(issue.Workloads, ", "))
+		}
+		return table.Render()
+	}
+	if len(d.New) > 0 {
+		out.WriteString(ui.Section(fmt.Sprintf("New (%d)", len(d.New))) + "\n" + issueTable(d.New) + "\n")
+	}
+	if len(d.NewWorkloads) > 0 {
+		table := ui.NewTable("Severity", "ID", "Type", "Where", "New Workloads")
+		for _, change := range d.NewWorkloads {
+			table.AddRow(string(change.Severity), change.ID, string(change.Type), issueLocation(change.Issue), strings.Join(change.Added, ", "))
+		}
+		out.WriteString(ui.Section(fmt.Sprintf("In New Workloads (%d)", len(d.NewWorkloads))) + "\n" + table.Render() + "\n")
+	}
+	if len(d.SeverityChanged) > 0 {
+		table := ui.NewTable("Severity", "Was", "ID", "Type", "Where")
+		for _, change := range d.SeverityChanged {
+			table.AddRow(string(change.Severity), string(change.Previous), change.ID, string(change.Type), issueLocation(change.Issue))
+		}
+		out.WriteString(ui.Section(fmt.Sprintf("Severity Changed (%d)", len(d.SeverityChanged))) + "\n" + table.Render() + "\n")
+	}
+	if len(d.Resolved) > 0 {
+		out.WriteString(ui.Section(fmt.Sprintf("Resolved (%d)", len(d.Resolved))) + "\n" + issueTable(d.Resolved) + "\n")
+	}
+	out.WriteString(fmt.Sprintf("%d new, %d in new workloads, %d severity changes, %d resolved",
+		len(d.New), len(d.NewWorkloads), len(d.SeverityChanged), len(d.Resolved)))
+	return out.String()
+}
+
+// issueLocation returns the package and image of a vulnerability, or the
+// title of a check
+func issueLocation(issue snapshot.Issue) string {
+	if issue.Package != "" {
+		return issue.Package + " in " + issue.Image
+	}
+	return issue.Title
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffBaseline, "baseline", "", "Snapshot to compare against, saved with 'trix snapshot'")
+	diffCmd.Flags().StringVar(&diffFailOn, "fail-on", "", "Exit with status 1 on regressions at or above this severity (e.g. high)")
+	addScanFlags(diffCmd)
+	_ = diffCmd.MarkFlagRequired("baseline")
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	"github.com/spf13/cobra"
+)
+
+// doctorTimeout bounds each check so a hung endpoint doesn't hang doctor.
+const doctorTimeout = 30 * time.Second
+
+// doctorResources are the resources trix reads besides the reports, and what
+// it reads them for.
+var doctorResources = []struct{ group, resource, purpose string }{
+	{"apps", "replicasets", "to name the Deployments findings belong to"},
+	{"batch", "jobs", "to name the CronJobs findings belong to"},
+	{"", "pods", "for the images of trix images and direct scans"},
+	{"", "namespaces", "for namespace posture and shell completion"},
+	{"networking.k8s.io", "networkpolicies", "for NetworkPolicy coverage"},
+	{"rbac.authorization.k8s.io", "clusterrolebindings", "for the cluster-admin bindings of trix rbac"},
+}
+
+// doctorEndpoints are the services trix calls besides the cluster and the LLM
+// provider, and what it calls them for.
+var doctorEndpoints = []struct{ name, url, purpose string }{
+	{"OSV.dev", "https://api.osv.dev", "advisory details in trix cve and explain"},
+	{"NVD", "https://services.nvd.nist.gov", "CVSS details in trix cve and explain"},
+	{"FIRST EPSS", "https://api.first.org", "exploit probabilities in trix triage"},
+	{"CISA KEV", "https://www.cisa.gov", "known exploited vulnerabilities in trix triage"},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check cluster access, permissions, Trivy Operator and LLM provider setup",
+	Long: `Run preflight checks before relying on trix, each reported as passed (✅),
+a warning (⚠️) or failed (❌) with a hint on how to fix it:
+
+  Kubernetes     The kubeconfig loads and the cluster is reachable
+  Permissions    The current user may list every report type, and the
+                 resources trix reads to name workloads (warnings)
+  Trivy Operator It is installed, running and writing fresh reports
+  LLM            The provider accepts the API key and serves --model; no
+                 provider is only a warning, as only the AI commands need one
+  Network        The advisory services trix uses are reachable (warnings)
+
+Permissions are checked cluster-wide, or in the namespace given with -n. The
+LLM provider is selected as for 'trix ask': --provider, the config file, or the
+provider configured in the environment.
+
+doctor exits with status 1 when a check failed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checks := &doctorChecks{}
+		if k8sClient := checkCluster(checks); k8sClient != nil {
+			checkPermissions(checks, k8sClient, explicitNamespace())
+			checkOperator(checks, k8sClient)
+		}
+		checkLLM(cmd, checks)
+		checkNetwork(checks)
+
+		switch {
+		case checks.failed > 0:
+			fmt.Printf("\nFailed: %d, warnings: %d\n", checks.failed, checks.warned)
+			os.Exit(1)
+		case checks.warned > 0:
+			fmt.Printf("\nAll checks passed, warnings: %d\n", checks.warned)
+		default:
+			fmt.Println("\nAll checks passed.")
+		}
+	},
+}
+
+// doctorChecks prints the outcome of each check and counts the problems.
+type doctorChecks struct {
+	failed int
+	warned int
+}
+
+func (d *doctorChecks) pass(format string, args ...any) {
+	fmt.Printf("✅ "+format+"\n", args...)
+}
+
+func (d *doctorChecks) warn(format string, args ...any) {
+	d.warned++
+	fmt.Printf("⚠️  "+format+"\n", args...)
+}
+
+func (d *doctorChecks) fail(format string, args ...any) {
+	d.failed++
+	fmt.Printf("❌ "+format+"\n", args...)
+}
+
+// hint tells how to fix the problem printed last.
+func (d *doctorChecks) hint(format string, args ...any) {
+	fmt.Printf("   → "+format+"\n", args...)
+}
+
+// checkCluster checks that the kubeconfig loads and the cluster answers, and
+// returns the client if so.
+func checkCluster(checks *doctorChecks) *kubectl.Client {
+	k8sClient, err := kubectl.NewClient()
+	if err != nil {
+		checks.fail("Kubernetes: %v", err)
+		checks.hint("set KUBECONFIG or check ~/.kube/config")
+		return nil
+	}
+	kubeContext, _ := k8sClient.GetCurrentContext()
+	version, err := k8sClient.Clientset().Discovery().ServerVersion()
+	if err != nil {
+		checks.fail("Kubernetes: context %s is not reachable: %v", kubeContext, err)
+		checks.hint("check that the cluster is up and your credentials haven't expired, e.g. with 'kubectl get ns'")
+		return nil
+	}
+	checks.pass("Kubernetes: context %s (server %s)", kubeContext, version.GitVersion)
+	return k8sClient
+}
+
+// checkPermissions checks that the current user may list every report type,
+// which trix needs, and the other resources it reads, which it can do
+// without.
+func checkPermissions(checks *doctorChecks, k8sClient *kubectl.Client, namespace string) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	var denied []string
+	for _, gvr := range v1alpha1.AllReports {
+		ns := namespace
+		if v1alpha1.ClusterScoped(gvr) {
+			ns = ""
+		}
+		allowed, _, err := k8sClient.CanList(ctx, gvr.Group, gvr.Resource, ns)
+		if err != nil {
+			checks.warn("Permissions: %v", err)
+			return
+		}
+		if !allowed {
+			denied = append(denied, gvr.Resource+"."+gvr.Group)
+		}
+	}
+	if len(denied) == 0 {
+		checks.pass("Permissions: can list all %d report types %s", len(v1alpha1.AllReports), accessScope(namespace))
+	} else {
+		checks.fail("Permissions: can't list %d of %d report types %s", len(denied), len(v1alpha1.AllReports), accessScope(namespace))
+		checks.hint("grant get and list on them, e.g. kubectl create clusterrole trix-reader --verb=get,list --resource=%s", strings.Join(denied, ","))
+	}
+
+	for _, r := range doctorResources {
+		ns := namespace
+		if r.resource == "namespaces" || r.resource == "clusterrolebindings" {
+			ns = ""
+		}
+		allowed, _, err := k8sClient.CanList(ctx, r.group, r.resource, ns)
+		if err != nil {
+			checks.warn("Permissions: %v", err)
+			return
+		}
+		if !allowed {
+			checks.warn("Permissions: can't list %s %s, used %s", r.resource, accessScope(ns), r.purpose)
+		}
+	}
+}
+
+// accessScope describes where access was checked.
+func accessScope(namespace string) string {
+	if namespace == "" {
+		return "cluster-wide"
+	}
+	return "in namespace " + namespace
+}
+
+// checkOperator checks that Trivy Operator is installed, running and writing
+// fresh reports.
+func checkOperator(checks *doctorChecks, k8sClient *kubectl.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	health := trivy.NewClient(k8sClient).CheckOperatorHealth(ctx)
+	switch {
+	case !health.Installed:
+		checks.fail("Trivy Operator: not installed")
+	case health.Healthy():
+		version := health.Version
+		if version == "" {
+			version = "unknown version"
+		}
+		checks.pass("Trivy Operator: %s, %d vulnerability reports (newest %s ago)", version, health.Reports, time.Since(health.NewestReport).Round(time.Minute))
+		return
+	default:
+		checks.warn("Trivy Operator: installed with problems")
+	}
+	for _, problem := range health.Problems {
+		checks.hint("%s", problem)
+	}
+}
+
+// checkLLM checks every configured LLM provider.
+func checkLLM(cmd *cobra.Command, checks *doctorChecks) {
+	if err := applyConfig(cmd); err != nil {
+		checks.fail("Config: %v", err)
+		return
+	}
+	if err := llm.ConfigureHTTP(llm.HTTPConfig{ProxyURL: proxyURL, CAFile: caFile}); err != nil {
+		checks.fail("LLM: %v", err)
+		return
+	}
+
+	provider := llmProvider
+	if provider == "" {
+		var err error
+		if provider, err = detectProvider(); err != nil {
+			checks.warn("LLM: no provider selected; only the AI commands (ask, explain, triage, ...) need one")
+			checks.hint("%v", err)
+			return
+		}
+	}
+
+	var opts []llm.Option
+	apiKey, err := readAPIKey()
+	if err != nil {
+		checks.fail("LLM: %v", err)
+		return
+	}
+	if apiKey != "" {
+		opts = append(opts, llm.WithAPIKey(apiKey))
+	}
+
+	names := strings.Split(provider, ",")
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		// As with 'ask', --model only applies to a single provider
+		model := llmModel
+		if len(names) > 1 {
+			model = ""
+		}
+		checkProvider(checks, name, model, opts)
+	}
+}
+
+// checkProvider pings one provider and checks that it serves model.
+func checkProvider(checks *doctorChecks, provider, model string, opts []llm.Option) {
+	client, err := llm.New(provider, providerOptions(provider, model, opts)...)
+	if err != nil {
+		checks.fail("%s: %v", provider, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	models, err := llm.ListModels(ctx, client)
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		// No model list; a minimal request checks the key and model instead
+		if err := llm.Ping(ctx, client); err != nil {
+			checks.fail("%s: %v", provider, err)
+			if errors.Is(err, llm.ErrAuth) {
+				checks.hint("check the provider's API key")
+			}
+			return
+		}
+		checks.pass("%s: API key accepted", provider)
+		return
+	case err != nil:
+		checks.fail("%s: %v", provider, err)
+		if errors.Is(err, llm.ErrAuth) {
+			checks.hint("check the provider's API key")
+		}
+		return
+	}
+
+	checks.pass("%s: API key accepted (%d models available)", provider, len(models))
+	// Aliases (e.g. claude-sonnet-4-0) and Ollama's implicit :latest tag aren't listed
+	if model != "" && !slices.Contains(models, model) && !slices.Contains(models, model+":latest") {
+		checks.warn("%s: model %q is not in the provider's model list", provider, model)
+	}
+}
+
+// checkNetwork checks that the advisory services are reachable. Any HTTP
+// response counts; only connection failures are reported.
+func checkNetwork(checks *doctorChecks) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	errs := make([]error, len(doctorEndpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range doctorEndpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Head(endpoint.url)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	var reachable []string
+	for i, endpoint := range doctorEndpoints {
+		if errs[i] != nil {
+			checks.warn("Network: %s is unreachable, needed for %s", endpoint.name, endpoint.purpose)
+			checks.hint("%v; behind a proxy, set HTTPS_PROXY", errs[i])
+			continue
+		}
+		reachable = append(reachable, endpoint.name)
+	}
+	if len(reachable) == len(doctorEndpoints) {
+		checks.pass("Network: %s reachable", strings.Join(reachable, ", "))
+	}
+}
+
+// completeModels completes --model with the models the provider serves.
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := applyConfig(cmd); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	provider := llmProvider
+	if provider == "" {
+		var err error
+		if provider, err = detectProvider(); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	if strings.Contains(provider, ",") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Listing doesn't use the model, but some providers require one
+	client, err := llm.New(provider, providerOptions(provider, "any", nil)...)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	models, err := llm.ListModels(ctx, client)
+	if err != nil && provider == "openai" {
+		models = llm.OpenAIModels
+	}
+	return models, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	// Provider selection is shared with 'ask'
+	doctorCmd.Flags().StringVar(&llmModel, "model", "", "LLM model to check")
+	doctorCmd.Flags().StringVar(&llmProvider, "provider", "", "LLM provider to check, or a comma-separated fallback chain (auto-detects if not set)")
+	doctorCmd.Flags().StringVar(&ollamaURL, "ollama-url", "", "Ollama server URL (default: http://localhost:11434)")
+	doctorCmd.Flags().StringVar(&llmBaseURL, "base-url", "", "Base URL of an OpenAI-compatible, Hugging Face or llama.cpp endpoint")
+	doctorCmd.Flags().StringVar(&apiKeyFile, "api-key-file", "", "Read the provider's API key from a file")
+	doctorCmd.Flags().StringVar(&apiKeySecret, "api-key-secret", "", "Read the provider's API key from a Kubernetes Secret: [namespace/]name[:key]")
+	doctorCmd.Flags().StringVar(&proxyURL, "proxy", "", "Proxy for LLM requests (default: HTTPS_PROXY/NO_PROXY)")
+	doctorCmd.Flags().StringVar(&caFile, "ca-file", "", "PEM CA bundle to trust for LLM endpoints (or set TRIX_CA_FILE)")
+	_ = doctorCmd.RegisterFlagCompletionFunc("model", completeModels)
+}
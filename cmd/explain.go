@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/davealtena/trix/internal/enrich"
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/prompt"
+	"github.com/davealtena/trix/internal/tools/exposure"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+// maxExplainFindings caps the findings sent to the LLM; the most severe are kept
+const maxExplainFindings = 40
+
+// maxExplainExposures caps the workloads whose exposure is checked for a CVE
+const maxExplainExposures = 5
+
+// workloadKinds maps the names kubectl accepts to the kind of a workload
+var workloadKinds = map[string]string{
+	"deployment": "Deployment", "deployments": "Deployment", "deploy": "Deployment",
+	"statefulset": "StatefulSet", "statefulsets": "StatefulSet", "sts": "StatefulSet",
+	"daemonset": "DaemonSet", "daemonsets": "DaemonSet", "ds": "DaemonSet",
+	"cronjob": "CronJob", "cronjobs": "CronJob", "cj": "CronJob",
+	"job": "Job", "jobs": "Job",
+	"replicaset": "ReplicaSet", "replicasets": "ReplicaSet", "rs": "ReplicaSet",
+	"pod": "Pod", "pods": "Pod", "po": "Pod",
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <CVE-ID | kind/name>",
+	Short: "Explain the impact of a CVE or a workload's findings and how to fix them",
+	Long: `Gather the findings of a vulnerability or a workload, with the advisory text
+and how the affected workloads are exposed, and ask the LLM for an impact
+assessment and concrete remediation steps. The answer is streamed as it is
+written.
+
+The LLM is configured as for 'trix ask' (--provider, --model, the config
+file and the provider's API key variable).
+
+Examples:
+  trix explain CVE-2024-45337 -A
+  trix explain deployment/payments -n prod`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeExplainArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		ns := resolveNamespace()
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+
+		var data *prompt.Impact
+		if isVulnerabilityID(args[0]) {
+			data, err = explainVulnerability(ctx, k8sClient, enrich.NormalizeID(args[0]), ns)
+		} else {
+			data, err = explainWorkload(ctx, k8sClient, args[0], namespace)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		text, err := prompt.Render("impact", data)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		client, err := newLLMClient(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Explaining %s (%d findings)...\n\n", data.Subject, len(data.Findings)+data.Omitted)
+		messages := []llm.Message{{Role: llm.RoleUser, Content: text}}
+		if streaming, ok := client.(llm.StreamingClient); ok {
+			_, err = streaming.ChatStream(ctx, messages, nil, func(delta string) { fmt.Print(delta) })
+			fmt.Println()
+		} else {
+			var resp *llm.Response
+			if resp, err = client.Chat(ctx, messages, nil); err == nil {
+				renderer, _ = glamour.NewTermRenderer(markdownStyle(), glamour.WithWordWrap(100))
+				printResponse(resp.Content)
+			}
+		}
+		if err != nil {
+			printLLMError(err)
+		}
+	},
+}
+
+// isVulnerabilityID reports whether arg is a CVE or GHSA ID rather than a resource
+func isVulnerabilityID(arg string) bool {
+	upper := strings.ToUpper(arg)
+	return strings.HasPrefix(upper, "CVE-") || strings.HasPrefix(upper, "GHSA-")
+}
+
+// explainVulnerability gathers the advisory of a vulnerability, the workloads
+// it is found in and their exposure
+func explainVulnerability(ctx context.Context, k8sClient *kubectl.Client, id, ns string) (*prompt.Impact, error) {
+	data := &prompt.Impact{Subject: id}
+	advisory, err := enrich.NewClient(enrich.Config{NVDAPIKey: os.Getenv("NVD_API_KEY")}).Lookup(ctx, id)
+	switch {
+	case errors.Is(err, enrich.ErrNotFound):
+		fmt.Fprintf(os.Stderr, "Warning: %s is not in OSV or the NVD\n", id)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	default:
+		data.Advisory = strings.TrimSpace(advisory.Summary + "\n" + advisory.Description)
+	}
+
+	cveIDs = []string{id}
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := trivy.NewTrivyVulnScanner(trivyClient).Scan(ctx, ns)
+	if err != nil && !partialResults(err) {
+		return nil, err
+	}
+	data.Findings, data.Omitted = impactFindings(findings)
+	data.Exposure = workloadExposure(ctx, k8sClient, findings)
+	return data, nil
+}
+
+// explainWorkload gathers the findings of a workload and its exposure
+func explainWorkload(ctx context.Context, k8sClient *kubectl.Client, resource, ns string) (*prompt.Impact, error) {
+	kind, name, err := parseWorkload(resource)
+	if err != nil {
+		return nil, fmt.Errorf("expected a CVE ID or %w", err)
+	}
+
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	matched := workloadFindings(scanFindings(ctx, trivyClient, ns), kind, name)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no findings for %s/%s in namespace %s", kind, name, ns)
+	}
+
+	data := &prompt.Impact{Subject: fmt.Sprintf("%s/%s in namespace %s", kind, name, ns)}
+	data.Findings, data.Omitted = impactFindings(matched)
+	data.Exposure = workloadExposure(ctx, k8sClient, matched)
+	return data, nil
+}
+
+// parseWorkload parses a kind/name argument, accepting the kind names kubectl does
+func parseWorkload(resource string) (string, string, error) {
+	kindName, name, ok := strings.Cut(resource, "/")
+	kind := workloadKinds[strings.ToLower(kindName)]
+	if !ok || name == "" || kind == "" {
+		return "", "", fmt.Errorf("kind/name (e.g. deployment/payments), got %q", resource)
+	}
+	return kind, name, nil
+}
+
+// workloadFindings returns the findings of a workload: those owned by it, or
+// reported against it directly when the owner isn't resolved
+func workloadFindings(findings []trivy.Finding, kind, name string) []trivy.Finding {
+	var matched []trivy.Finding
+	for _, f := range findings {
+		if f.Owner != nil && f.Owner.Kind == kind && f.Owner.Name == name {
+			matched = append(matched, f)
+		} else if f.Owner == nil && strings.EqualFold(f.ResourceKind, kind) && f.ResourceName == name {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// impactFindings converts findings for the prompt, most severe first. The
+// same finding in several containers or ReplicaSets is listed once, and only
+// the first maxExplainFindings are kept; the rest are counted.
+func impactFindings(findings []trivy.Finding) ([]prompt.Finding, int) {
+	seen := make(map[string]bool)
+	var result []prompt.Finding
+	for _, f := range findings {
+		kind, name := f.ResourceKind, f.ResourceName
+		if f.Owner != nil {
+			kind, name = f.Owner.Kind, f.Owner.Name
+		}
+		title := f.Title
+		if title == "" {
+			title = f.Description
+		}
+		key := f.ID + "|" + f.Namespace + "/" + kind + "/" + name + "|" + title
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, prompt.Finding{
+			ID:           f.ID,
+			Title:        title,
+			Severity:     string(f.Severity),
+			Score:        f.Score,
+			Namespace:    f.Namespace,
+			ResourceKind: kind,
+			ResourceName: name,
+			Remediation:  f.Remediation,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if ri, rj := trivy.Severity(result[i].Severity).Rank(), trivy.Severity(result[j].Severity).Rank(); ri != rj {
+			return ri > rj
+		}
+		return result[i].Score > result[j].Score
+	})
+	if len(result) > maxExplainFindings {
+		return result[:maxExplainFindings], len(result) - maxExplainFindings
+	}
+	return result, 0
+}
+
+// workloadExposure describes how the workloads of the findings are reachable,
+// one line per workload, checking at most maxExplainExposures of them. It is
+// empty when nothing could be checked, e.g. when reading a bundle.
+func workloadExposure(ctx context.Context, k8sClient *kubectl.Client, findings []trivy.Finding) string {
+	summaries := exposureByWorkload(ctx, k8sClient, findings, maxExplainExposures)
+	seen := make(map[string]bool)
+	var lines []string
+	for _, f := range findings {
+		if f.Owner == nil {
+			continue
+		}
+		key := f.Namespace + "/" + f.Owner.String()
+		if result, ok := summaries[key]; ok && !seen[key] {
+			seen[key] = true
+			lines = append(lines, key+": "+result.Summary)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exposureByWorkload analyzes how the owning workloads of the findings are
+// reachable, keyed by namespace/Kind/name, checking at most limit of them in
+// the order they are found. Workloads that can't be analyzed are left out.
+func exposureByWorkload(ctx context.Context, k8sClient *kubectl.Client, findings []trivy.Finding, limit int) map[string]*exposure.Result {
+	summaries := make(map[string]*exposure.Result)
+	// Bundles hold no Services or Ingresses
+	if os.Getenv("TRIX_BUNDLE") != "" {
+		return summaries
+	}
+	analyzer := exposure.NewClusterAnalyzer(k8sClient.Clientset(), k8sClient.DynamicClient())
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if f.Owner == nil || len(seen) >= limit {
+			continue
+		}
+		key := f.Namespace + "/" + f.Owner.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		labels, err := exposure.WorkloadLabels(ctx, k8sClient.Clientset(), f.Owner.Kind, f.Owner.Name, f.Namespace)
+		if err != nil {
+			continue
+		}
+		result, err := analyzer.Analyze(ctx, exposure.Workload{Kind: f.Owner.Kind, Name: f.Owner.Name, Namespace: f.Namespace, Labels: labels})
+		if err != nil {
+			continue
+		}
+		summaries[key] = result
+	}
+	return summaries
+}
+
+// newLLMClient applies the config file and HTTP flags and creates the LLM
+// client, as 'trix ask' does
+func newLLMClient(cmd *cobra.Command) (llm.Client, error) {
+	if err := applyConfig(cmd); err != nil {
+		return nil, err
+	}
+	llm.MaxAttempts = maxAttempts
+	if err := llm.ConfigureHTTP(llm.HTTPConfig{
+		ProxyURL:           proxyURL,
+		CAFile:             caFile,
+		InsecureSkipVerify: insecureTLS,
+		ConnectTimeout:     connectTimeout,
+	}); err != nil {
+		return nil, err
+	}
+	return createLLMClient(generationOptions(cmd))
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	addLLMFlags(explainCmd)
+}
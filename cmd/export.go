@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/davealtena/trix/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOut    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --format sarif|json|csv|cyclonedx",
+	Short: "Export all findings as SARIF, JSON, CSV or CycloneDX",
+	Long: `Run all scanners and write the findings in one file, for CI artifacts and
+other tools:
+
+  sarif      SARIF 2.1.0, one result per finding (GitHub code scanning, IDEs)
+  json       The findings with their summary
+  csv        One row per finding, for spreadsheets
+  cyclonedx  CycloneDX 1.5 BOM of the vulnerable images, packages and CVEs
+
+CycloneDX only describes vulnerabilities; the other formats include every
+finding. Without --out the export is written to stdout.
+
+Examples:
+  trix export --format sarif --out trix.sarif -A
+  trix export --format csv --out findings.csv -n payments
+  trix export --format cyclonedx --min-severity high > bom.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(export.Formats, exportFormat) {
+			fmt.Printf("Error: unknown format %q (expected sarif, json, csv or cyclonedx)\n", exportFormat)
+			return
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+		}
+
+		ns := resolveNamespace()
+		findings := scanFindings(context.Background(), trivyClient, ns)
+		meta := export.Meta{Version: Version, Context: currentCtx, Namespace: ns, Time: time.Now()}
+
+		if exportOut == "" || exportOut == "-" {
+			if err := export.Write(os.Stdout, exportFormat, findings, meta); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			return
+		}
+
+		f, err := os.Create(exportOut)
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", exportOut, err)
+			return
+		}
+		if err := export.Write(f, exportFormat, findings, meta); err != nil {
+			_ = f.Close()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", exportOut, err)
+			return
+		}
+		fmt.Printf("Exported %d findings to %s\n", len(findings), exportOut)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "Export format: sarif, json, csv or cyclonedx")
+	exportCmd.Flags().StringVar(&exportOut, "out", "", "File to write (default stdout)")
+	addScanFlags(exportCmd)
+	exportCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/remediate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixOutputDir   string
+	fixMinSeverity string
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix <kind/name>",
+	Short: "Write remediation patches for a workload's findings",
+	Long: `Turn a workload's findings into changes that fix them, written as files
+ready to apply:
+
+  patch.yaml        A strategic merge patch for the workload: securityContext
+                    settings and resource requests and limits for failed
+                    config audit checks, and image tag bumps where the fixed
+                    version of the image's application is a release tag.
+  Dockerfile.fixes  Package upgrades for the vulnerable OS packages of each
+                    image, and the fixed versions of its language packages.
+
+Checks the patch can't fix are listed for manual follow-up. Resource values
+are starting points to tune, and bumped image tags should be checked to exist
+before applying the patch with:
+
+  kubectl patch <kind> <name> -n <namespace> --patch-file <dir>/patch.yaml
+
+Examples:
+  trix fix deployment/payments -n prod
+  trix fix sts/redis -n cache --min-severity medium -d ./fixes`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkloads,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !requireCluster("fix") {
+			return
+		}
+		kind, name, err := parseWorkload(args[0])
+		if err != nil {
+			fmt.Printf("Error: expected %v\n", err)
+			return
+		}
+		severity := aggregate.DefaultUpgradeSeverity
+		if fixMinSeverity != "" {
+			severity = trivy.Severity(strings.ToUpper(fixMinSeverity))
+		}
+		ctx := context.Background()
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		workload, err := remediate.GetWorkload(ctx, k8sClient.Clientset(), kind, name, namespace)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		findings := workloadFindings(scanFindings(ctx, trivyClient, namespace), kind, name)
+		var checks []remediate.Check
+		dedup := aggregate.NewDeduplicator()
+		for _, f := range findings {
+			if f.Type == trivy.FindingTypeCompliance {
+				checks = append(checks, remediate.Check{ID: f.ID, Title: f.Title})
+			}
+		}
+		dedup.Add(findings...)
+		plan := remediate.NewPlan(workload, checks, aggregate.UpgradePlan(dedup.Vulnerabilities(), severity))
+
+		if !plan.HasPatch() && !plan.HasDockerfile() {
+			fmt.Printf("Nothing to fix automatically for %s/%s\n", kind, name)
+			printManualChecks(plan)
+			return
+		}
+
+		dir := fixOutputDir
+		if dir == "" {
+			dir = fmt.Sprintf("trix-fix-%s-%s", strings.ToLower(kind), name)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Printf("Error creating %s: %v\n", dir, err)
+			return
+		}
+
+		var content strings.Builder
+		if plan.HasPatch() {
+			patch, err := plan.Patch()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			path := filepath.Join(dir, "patch.yaml")
+			if err := os.WriteFile(path, patch, 0o644); err != nil {
+				fmt.Printf("Error writing %s: %v\n", path, err)
+				return
+			}
+			content.WriteString(ui.Section("Patch") + "\n")
+			for _, fixed := range plan.Fixed {
+				content.WriteString("  " + fixed + "\n")
+			}
+			content.WriteString("\n  " + ui.Muted.Render(plan.ApplyCommand(path)) + "\n")
+		}
+		if plan.HasDockerfile() {
+			path := filepath.Join(dir, "Dockerfile.fixes")
+			if err := os.WriteFile(path, plan.Dockerfile(), 0o644); err != nil {
+				fmt.Printf("Error writing %s: %v\n", path, err)
+				return
+			}
+			if content.Len() > 0 {
+				content.WriteString("\n")
+			}
+			content.WriteString(ui.Section("Image Upgrades") + "\n")
+			for _, image := range plan.Images {
+				if len(image.Upgrades) > 0 {
+					content.WriteString(fmt.Sprintf("  %s: %d packages\n", image.Image, len(image.Upgrades)))
+				}
+			}
+			content.WriteString("\n  " + ui.Muted.Render("See "+path) + "\n")
+		}
+		fmt.Println(ui.Box(fmt.Sprintf("%s/%s", kind, name), content.String(), 100))
+		printManualChecks(plan)
+	},
+}
+
+// printManualChecks lists the failed checks a plan leaves to the user
+func printManualChecks(plan *remediate.Plan) {
+	if len(plan.Manual) == 0 {
+		return
+	}
+	fmt.Println(ui.Section("Fix Manually"))
+	for _, check := range plan.Manual {
+		fmt.Println("  " + check)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.Flags().StringVarP(&fixOutputDir, "dir", "d", "", "Directory to write the fixes to (default trix-fix-<kind>-<name>)")
+	fixCmd.Flags().StringVar(&fixMinSeverity, "min-severity", "", "Lowest vulnerability severity to upgrade packages for (default HIGH)")
+}
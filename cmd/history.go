@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/davealtena/trix/internal/history"
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyDir   string
+	historySince string
+	historyBy    string
+	historyLimit int
+	historyKeep  string
+)
+
+// historyChartWidth is the most bars a trend line has
+const historyChartWidth = 40
+
+// historyNamespaceLines is the most namespaces 'trix history --by namespace'
+// draws a trend line for
+const historyNamespaceLines = 10
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show finding counts over time, from scans recorded with 'trix history record'",
+	Long: `Show how the finding counts of the current context changed over the scans
+recorded with 'trix history record': a trend line per severity (or per
+namespace with --by namespace) and a table of the scans, to show progress
+over time, e.g. to auditors.
+
+Scans are kept per context in ~/.local/share/trix/history, or in
+TRIX_HISTORY_DIR or --dir. Each is a snapshot, so 'trix diff --baseline' can
+compare against any of them.
+
+Examples:
+  trix history record -A
+  trix history --since 90d
+  trix history -n payments --by namespace
+  trix history --since 2026-01-01 -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyBy != "severity" && historyBy != "namespace" {
+			fmt.Printf("Error: invalid --by %q (use severity or namespace)\n", historyBy)
+			return
+		}
+		since, err := parseHistoryTime(historySince, time.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Printf("Error getting context: %v\n", err)
+			return
+		}
+		dir, err := historyContextDir(currentCtx)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		keep := inNamespaceScope
+		if ns := explicitNamespace(); ns != "" {
+			keep = func(namespace string) bool { return namespace == ns }
+		}
+		points, err := history.Load(dir, since, keep)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if structuredOutput() {
+			if points == nil {
+				points = []history.Point{}
+			}
+			printStructured(points)
+			return
+		}
+		if len(points) == 0 {
+			fmt.Printf("No scans of context %s recorded in %s; record one with 'trix history record'\n", currentCtx, dir)
+			return
+		}
+		fmt.Println(ui.Box("History: "+currentCtx, formatHistory(points, historyBy, historyLimit), 120))
+	},
+}
+
+var historyRecordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Scan and add the findings to the history",
+	Long: `Run all scanners and save the findings to the history of the current
+context, for 'trix history' to show. Run it on a schedule, e.g. daily from
+cron or a CronJob, to build up the history.
+
+With --keep, scans older than that are deleted afterwards.
+
+Examples:
+  trix history record -A
+  trix history record -n payments --keep 365d`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var keepFrom time.Time
+		if historyKeep != "" {
+			var err error
+			if keepFrom, err = parseHistoryTime(historyKeep, time.Now()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Printf("Error getting context: %v\n", err)
+			return
+		}
+		dir, err := historyContextDir(currentCtx)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		ns := resolveNamespace()
+		findings := scanFindings(context.Background(), trivyClient, ns)
+		path, err := history.Record(dir, snapshot.New(currentCtx, ns, findings))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Recorded %d findings to %s\n", len(findings), path)
+
+		if !keepFrom.IsZero() {
+			deleted, err := history.Prune(dir, keepFrom)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if deleted > 0 {
+				fmt.Printf("Deleted %d scans from before %s\n", deleted, keepFrom.Format("2006-01-02"))
+			}
+		}
+	},
+}
+
+// historyContextDir returns the history directory of a context
+func historyContextDir(contextName string) (string, error) {
+	root := historyDir
+	if root == "" {
+		var err error
+		if root, err = history.DefaultDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(root, contextFileName(contextName)), nil
+}
+
+// parseHistoryTime parses a date (2026-01-01), a number of days ago (90d) or
+// a duration ago (12h) into a time. Empty is the zero time.
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected YYYY-MM-DD, a number of days such as 90d, or a duration such as 12h)", s)
+}
+
+// formatHistory renders the trend lines of the scans and a table of the
+// last limit of them
+func formatHistory(points []history.Point, by string, limit int) string {
+	var out strings.Builder
+	first, last := points[0], points[len(points)-1]
+	out.WriteString(ui.Muted.Render(fmt.Sprintf("%d scans from %s to %s",
+		len(points), first.Time.Local().Format("2006-01-02"), last.Time.Local().Format("2006-01-02"))) + "\n\n")
+
+	out.WriteString(ui.Section("Trend") + "\n")
+	trendLine := func(label string, style lipgloss.Style, values []int) {
+		change := values[len(values)-1] - values[0]
+		out.WriteString(fmt.Sprintf("  %s  %-*s  %d → %d (%s)\n", style.Render(fmt.Sprintf("%-12s", label)),
+			historyChartWidth, ui.Sparkline(values, historyChartWidth), values[0], values[len(values)-1], signed(change)))
+	}
+	if by == "namespace" {
+		for _, namespace := range historyNamespaces(points) {
+			values := make([]int, len(points))
+			for i, p := range points {
+				values[i] = p.ByNamespace[namespace]
+			}
+			label := namespace
+			if label == "" {
+				label = "(cluster)"
+			}
+			trendLine(label, ui.Info, values)
+		}
+	} else {
+		for _, sev := range historySeverities {
+			values := make([]int, len(points))
+			for i, p := range points {
+				values[i] = p.BySeverity[sev]
+			}
+			trendLine(string(sev), ui.Severity(string(sev)), values)
+		}
+	}
+	totals := make([]int, len(points))
+	for i, p := range points {
+		totals[i] = p.Total
+	}
+	trendLine("Total", ui.Title, totals)
+	out.WriteString("\n")
+
+	shown := points
+	if limit > 0 && len(shown) > limit {
+		shown = shown[len(shown)-limit:]
+	}
+	out.WriteString(ui.Section(fmt.Sprintf("Scans (%d of %d)", len(shown), len(points))) + "\n")
+	table := ui.NewTable("Date", "Scope", "CRITICAL", "HIGH", "MEDIUM", "LOW", "Total", "Change")
+	offset := len(points) - len(shown)
+	for i, p := range shown {
+		change := ""
+		if offset+i > 0 {
+			change = signed(p.Total - points[offset+i-1].Total)
+		}
+		scope := p.Scope
+		if scope == "" {
+			scope = "all"
+		}
+		table.AddRow(p.Time.Local().Format("2006-01-02 15:04"), scope,
+			strconv.Itoa(p.BySeverity[trivy.SeverityCritical]), strconv.Itoa(p.BySeverity[trivy.SeverityHigh]),
+			strconv.Itoa(p.BySeverity[trivy.SeverityMedium]), strconv.Itoa(p.BySeverity[trivy.SeverityLow]),
+			strconv.Itoa(p.Total), change)
+	}
+	out.WriteString(table.Render())
+	out.WriteString("\n" + ui.Muted.Render("Compare with the latest scan: trix diff --baseline "+last.Path))
+	return out.String()
+}
+
+// historySeverities orders the trend lines of 'trix history'
+var historySeverities = []trivy.Severity{trivy.SeverityCritical, trivy.SeverityHigh, trivy.SeverityMedium, trivy.SeverityLow}
+
+// historyNamespaces returns the namespaces with the most findings in the
+// latest scan, up to historyNamespaceLines, then those of earlier scans
+func historyNamespaces(points []history.Point) []string {
+	latest := make(map[string]int)
+	var namespaces []string
+	for _, p := range points {
+		for namespace := range p.ByNamespace {
+			if _, ok := latest[namespace]; !ok {
+				namespaces = append(namespaces, namespace)
+			}
+			latest[namespace] = 0
+		}
+	}
+	for namespace, n := range points[len(points)-1].ByNamespace {
+		latest[namespace] = n
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if latest[namespaces[i]] != latest[namespaces[j]] {
+			return latest[namespaces[i]] > latest[namespaces[j]]
+		}
+		return namespaces[i] < namespaces[j]
+	})
+	if len(namespaces) > historyNamespaceLines {
+		namespaces = namespaces[:historyNamespaceLines]
+	}
+	return namespaces
+}
+
+// signed formats a change in count with its sign, e.g. +3 or -5
+func signed(n int) string {
+	if n > 0 {
+		return "+" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyRecordCmd)
+	historyCmd.PersistentFlags().StringVar(&historyDir, "dir", "", "History directory (default TRIX_HISTORY_DIR or ~/.local/share/trix/history)")
+
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show scans since a date (2026-01-01) or a time ago (90d, 12h)")
+	historyCmd.Flags().StringVar(&historyBy, "by", "severity", "Draw a trend line per severity or per namespace")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "Latest scans to list in the table, 0 for all")
+
+	historyRecordCmd.Flags().StringVar(&historyKeep, "keep", "", "Delete scans from before a date (2026-01-01) or a time ago (365d)")
+	addScanFlags(historyRecordCmd)
+	historyRecordCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
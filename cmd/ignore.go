@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/ignore"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ignoreFile          string
+	noIgnore            bool
+	ignoreNamespace     string
+	ignoreResource      string
+	ignoreImage         string
+	ignoreJustification string
+	ignoreExpires       string
+	ignoreDryRun        bool
+)
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage accepted risks in .trix-ignore.yaml",
+	Long: `Manage the ignore file: findings that were reviewed and accepted, each with a
+justification and an optional expiry date. Every command leaves out the
+findings an active rule matches, so accepted risks stop showing up in queries,
+summaries, reports and exports. Use --no-ignore to see them anyway.
+
+trix reads .trix-ignore.yaml in the current directory, or the file given with
+--ignore-file (or TRIX_IGNORE_FILE). A rule matches a CVE, check or secret
+rule ID, optionally only in a namespace, resource or image:
+
+  ignores:
+  - id: CVE-2024-45337
+    namespace: prod
+    resource: Deployment/api
+    justification: The SSH server isn't used by the API
+    expires: "2026-12-31"
+
+Scopes are globs where * matches anything, e.g. team-* or *nginx:1.25*.
+Expired rules stop applying; remove them with 'trix ignore prune'.`,
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <CVE-ID | check ID | secret rule ID>",
+	Short: "Accept the findings of a CVE or check",
+	Long: `Add a rule to the ignore file. A justification is required; the scope flags
+narrow the rule down, and --expires takes a date (2026-12-31) or a number of
+days (90d).
+
+Examples:
+  trix ignore add CVE-2024-45337 -n prod --resource Deployment/api \
+    --justification "The SSH server isn't used by the API" --expires 90d
+  trix ignore add KSV014 --namespace 'team-*' --justification "Needs a writable root filesystem"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeVulnIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		expires, err := parseExpiry(ignoreExpires, now)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		rule := ignore.Rule{
+			ID:            args[0],
+			Namespace:     ignoreNamespace,
+			Resource:      ignoreResource,
+			Image:         ignoreImage,
+			Justification: ignoreJustification,
+			Expires:       expires,
+			Added:         now.Format(ignore.DateFormat),
+		}
+		if err := rule.Validate(); err != nil {
+			fmt.Printf("Error: %v (use --justification)\n", err)
+			return
+		}
+		if rule.Expired(now) {
+			fmt.Printf("Error: %s is in the past\n", rule.Expires)
+			return
+		}
+
+		path := ignoreFilePath()
+		file, err := ignore.Load(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		file.Rules = append(file.Rules, rule)
+		if err := file.Save(path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		until := ""
+		if rule.Expires != "" {
+			until = " until " + rule.Expires
+		}
+		fmt.Printf("Ignoring %s (%s)%s in %s\n", rule.ID, rule.Scope(), until, path)
+	},
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the rules of the ignore file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ignoreFilePath()
+		file, err := ignore.Load(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if structuredOutput() {
+			rules := file.Rules
+			if rules == nil {
+				rules = []ignore.Rule{}
+			}
+			printStructured(rules)
+			return
+		}
+		if len(file.Rules) == 0 {
+			fmt.Printf("No rules in %s\n", path)
+			return
+		}
+		fmt.Println(formatIgnoreRules(file.Rules, path, time.Now()))
+	},
+}
+
+var ignorePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired rules from the ignore file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ignoreFilePath()
+		file, err := ignore.Load(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		expired := file.Prune(time.Now())
+		if len(expired) == 0 {
+			fmt.Printf("No expired rules in %s\n", path)
+			return
+		}
+		for _, r := range expired {
+			fmt.Printf("  %s (%s), expired %s\n", r.ID, r.Scope(), r.Expires)
+		}
+		if ignoreDryRun {
+			fmt.Printf("Would remove %d expired rules from %s\n", len(expired), path)
+			return
+		}
+		if err := file.Save(path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Removed %d expired rules from %s\n", len(expired), path)
+	},
+}
+
+// ignoreFilePath returns the ignore file to use: --ignore-file, then
+// TRIX_IGNORE_FILE, then .trix-ignore.yaml in the current directory
+func ignoreFilePath() string {
+	if ignoreFile != "" {
+		return ignoreFile
+	}
+	if path := os.Getenv("TRIX_IGNORE_FILE"); path != "" {
+		return path
+	}
+	return ignore.DefaultPath
+}
+
+// loadIgnoreRules loads the ignore file for filtering, or nil when there are
+// no active rules or --no-ignore is set. Expired rules are reported.
+func loadIgnoreRules() (*ignore.File, error) {
+	if noIgnore || os.Getenv("TRIX_NO_IGNORE") != "" {
+		return nil, nil
+	}
+	path := ignoreFilePath()
+	file, err := ignore.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := len(file.Active(now))
+	if expired := len(file.Rules) - active; expired > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d rules in %s have expired and no longer apply; remove them with 'trix ignore prune'\n", expired, path)
+	}
+	if active == 0 {
+		return nil, nil
+	}
+	return file, nil
+}
+
+// parseExpiry parses an expiry date (2026-12-31) or a number of days from
+// now (90d) into a date
+func parseExpiry(s string, now time.Time) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("invalid expiry %q (expected YYYY-MM-DD or a number of days such as 90d)", s)
+		}
+		return now.AddDate(0, 0, n).Format(ignore.DateFormat), nil
+	}
+	if _, err := time.Parse(ignore.DateFormat, s); err != nil {
+		return "", fmt.Errorf("invalid expiry %q (expected YYYY-MM-DD or a number of days such as 90d)", s)
+	}
+	return s, nil
+}
+
+// formatIgnoreRules renders the rules with whether each still applies
+func formatIgnoreRules(rules []ignore.Rule, path string, now time.Time) string {
+	table := ui.NewTable("ID", "Scope", "Justification", "Expires", "Status")
+	expired := 0
+	for _, r := range rules {
+		status, expires := "active", r.Expires
+		if expires == "" {
+			expires = "never"
+		}
+		if r.Expired(now) {
+			status = "expired"
+			expired++
+		}
+		table.AddRow(r.ID, r.Scope(), r.Justification, expires, status)
+	}
+	content := table.Render()
+	if expired > 0 {
+		content += "\n" + ui.Muted.Render(fmt.Sprintf("Expired: %d, no longer applied. Remove them with 'trix ignore prune'.", expired))
+	}
+	return ui.Box(fmt.Sprintf("Ignore Rules (%d) in %s", len(rules), path), content, 140)
+}
+
+func init() {
+	rootCmd.AddCommand(ignoreCmd)
+	ignoreCmd.AddCommand(ignoreAddCmd, ignoreListCmd, ignorePruneCmd)
+	rootCmd.PersistentFlags().StringVar(&ignoreFile, "ignore-file", "", "Ignore file of accepted risks (default .trix-ignore.yaml, or TRIX_IGNORE_FILE)")
+	rootCmd.PersistentFlags().BoolVar(&noIgnore, "no-ignore", false, "Include the findings the ignore file accepts")
+
+	ignoreAddCmd.Flags().StringVarP(&ignoreNamespace, "namespace", "n", "", "Only in namespaces matching this pattern (default all)")
+	ignoreAddCmd.Flags().StringVar(&ignoreResource, "resource", "", "Only for resources matching Kind/name, e.g. Deployment/api")
+	ignoreAddCmd.Flags().StringVar(&ignoreImage, "image", "", "Only in images matching this pattern, e.g. '*nginx:1.25*'")
+	ignoreAddCmd.Flags().StringVar(&ignoreJustification, "justification", "", "Why the risk is accepted (required)")
+	ignoreAddCmd.Flags().StringVar(&ignoreExpires, "expires", "", "Last day the rule applies: YYYY-MM-DD or a number of days, e.g. 90d (default never)")
+	ignorePruneCmd.Flags().BoolVar(&ignoreDryRun, "dry-run", false, "Show the expired rules without removing them")
+
+	cobra.OnInitialize(func() {
+		// Through the environment so the commands 'trix ask' runs use them too
+		if ignoreFile != "" {
+			if abs, err := filepath.Abs(ignoreFile); err == nil {
+				ignoreFile = abs
+			}
+			_ = os.Setenv("TRIX_IGNORE_FILE", ignoreFile)
+		}
+		if noIgnore {
+			_ = os.Setenv("TRIX_NO_IGNORE", "1")
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/registry"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesCheckRegistry bool
+)
+
+// ImageInfo is an image in the cluster with the registries it's pulled from
+// and, with --check-registry, the newest patch release of its tag
+type ImageInfo struct {
+	aggregate.ImageSummary
+	Registries    []string `json:"registries"`
+	PatchedTag    string   `json:"patchedTag,omitempty"`
+	RegistryError string   `json:"registryError,omitempty"`
+}
+
+// ImagesView is the image-centric view of the vulnerability reports
+type ImagesView struct {
+	Images     []aggregate.ImageSummary `json:"images"`
+	BaseImages []aggregate.BaseImage    `json:"baseImages"`
+}
+
+var queryImagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Group vulnerabilities by image and base image",
+	Long: `List the unique images in the cluster, keyed by digest, with the workloads
+that run them and their vulnerabilities counted once per image.
+
+Images are also grouped by base image (the OS release they are built on),
+with the vulnerabilities in OS packages: rebuilding on a patched base image
+fixes those in every workload at once, instead of one deployment at a time.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		index, ok := indexImages(ctx, trivyClient, ns)
+		if !ok {
+			return
+		}
+		view := ImagesView{Images: index.Images(), BaseImages: index.BaseImages()}
+
+		if structuredOutput() {
+			printStructured(view)
+			return
+		}
+		fmt.Println(formatImagesView(view))
+	},
+}
+
+// indexImages indexes the vulnerability reports of a namespace, or of all
+// namespaces if it's empty, by image. ok is false if they can't be listed.
+func indexImages(ctx context.Context, trivyClient *trivy.Client, ns string) (*aggregate.ImageIndex, bool) {
+	var reports []v1alpha1.VulnerabilityReport
+	var err error
+	if scanner := imageScanner(trivyClient); scanner != nil {
+		reports, err = scanner.Reports(ctx, ns)
+	} else {
+		reports, err = trivyClient.ListVulnerabilityReports(ctx, ns)
+	}
+	if err != nil && !partialResults(err) {
+		fmt.Printf("Error listing vulnerability reports: %v\n", err)
+		return nil, false
+	}
+	if len(reports) == 0 {
+		explainEmpty(ctx, trivyClient)
+	}
+
+	index := aggregate.NewImageIndex()
+	index.Add(reports...)
+	return index, true
+}
+
+// formatImagesView renders the images and base images for the terminal
+func formatImagesView(view ImagesView) string {
+	var out strings.Builder
+
+	table := ui.NewTable("Image", "OS", "Workloads", "Critical", "High", "Medium", "Low", "Fixable")
+	for _, img := range view.Images {
+		osName := img.OS
+		if img.EOSL {
+			osName += " (EOSL)"
+		}
+		table.AddRow(img.Image, osName, fmt.Sprintf("%d", len(img.Workloads)),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityCritical)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityHigh)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityMedium)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityLow)]),
+			fmt.Sprintf("%d", img.Fixable))
+	}
+	out.WriteString(ui.Box(fmt.Sprintf("Images (%d)", len(view.Images)), table.Render(), 160) + "\n")
+
+	if len(view.BaseImages) == 0 {
+		return out.String()
+	}
+	var content strings.Builder
+	for _, base := range view.BaseImages {
+		name := base.OS
+		if base.EOSL {
+			name += " " + ui.Severity("CRITICAL").Render("(end of life, no security updates)")
+		}
+		content.WriteString(ui.Title.Render(name) + "\n")
+		content.WriteString(fmt.Sprintf("  %d images, %d workloads, %d OS package vulnerabilities (%d critical, %d high)\n",
+			len(base.Images), base.Workloads, base.Vulnerabilities,
+			base.BySeverity[string(trivy.SeverityCritical)], base.BySeverity[string(trivy.SeverityHigh)]))
+		content.WriteString(ui.Muted.Render("  "+strings.Join(base.Images, ", ")) + "\n\n")
+	}
+	out.WriteString(ui.Box("Base Images", strings.TrimSuffix(content.String(), "\n"), 160))
+	return out.String()
+}
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "List the images in the cluster with their vulnerabilities",
+	Long: `List the unique images in the cluster, keyed by digest, with the registries
+they're pulled from, how many workloads run them and their vulnerabilities
+counted once per image.
+
+With --check-registry, trix asks each image's registry for its tags and shows
+the newest patch release in the same line as the running tag, e.g. 1.25.5 for
+1.25.3 or 3.19.4-alpine for 3.19.1-alpine. Registries are read anonymously,
+so private repositories show as unknown.
+
+Examples:
+  trix images -A
+  trix images -n payments --check-registry
+  trix images -A --check-registry -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		index, ok := indexImages(ctx, trivyClient, ns)
+		if !ok {
+			return
+		}
+
+		var images []ImageInfo
+		for _, img := range index.Images() {
+			info := ImageInfo{ImageSummary: img}
+			for _, ref := range append([]string{img.Image}, img.References...) {
+				if host := registryName(ref); !slices.Contains(info.Registries, host) {
+					info.Registries = append(info.Registries, host)
+				}
+			}
+			images = append(images, info)
+		}
+		if imagesCheckRegistry {
+			checkPatchedTags(ctx, images)
+		}
+
+		if structuredOutput() {
+			printStructured(images)
+			return
+		}
+		if len(images) == 0 {
+			fmt.Println("No images found")
+			return
+		}
+		fmt.Println(formatImages(images, imagesCheckRegistry))
+	},
+}
+
+// registryName returns the registry of an image reference, with Docker Hub
+// as docker.io
+func registryName(ref string) string {
+	host, _, _ := registry.ParseReference(ref)
+	if host == "registry-1.docker.io" {
+		return "docker.io"
+	}
+	return host
+}
+
+// checkPatchedTags looks up the tags of each image's repository and sets the
+// newest patch release of its tag. Repositories are asked once, however many
+// images share them.
+func checkPatchedTags(ctx context.Context, images []ImageInfo) {
+	type lookup struct {
+		tags []string
+		err  error
+	}
+	repos := make(map[string]*lookup)
+	failed := 0
+	for i := range images {
+		img := &images[i]
+		_, _, tag := registry.ParseReference(img.Image)
+		if strings.Contains(img.Image, "@") {
+			continue // Pinned by digest, there's no tag to compare
+		}
+		repo := registry.NewRepository(img.Image)
+		key := repo.Host + "/" + repo.Name
+		l, ok := repos[key]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Checking %s...\n", key)
+			l = &lookup{}
+			l.tags, l.err = repo.Tags(ctx)
+			repos[key] = l
+			if l.err != nil {
+				failed++
+			}
+		}
+		if l.err != nil {
+			img.RegistryError = l.err.Error()
+			continue
+		}
+		img.PatchedTag, _ = registry.PatchedTag(tag, l.tags)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list the tags of %d repositories; private registries aren't supported\n", failed)
+	}
+}
+
+// formatImages renders the images as a table
+func formatImages(images []ImageInfo, checked bool) string {
+	headers := []string{"Image", "Digest", "Registries", "Workloads", "Critical", "High", "Medium", "Low", "Fixable"}
+	if checked {
+		headers = append(headers, "Patched Tag")
+	}
+	table := ui.NewTable(headers...)
+	for _, img := range images {
+		digest := img.Digest
+		if digest == "" {
+			digest = "-"
+		} else if len(digest) > 19 {
+			digest = digest[:19] // sha256: and 12 hex digits
+		}
+		row := []string{img.Image, digest, strings.Join(img.Registries, ", "), fmt.Sprintf("%d", len(img.Workloads)),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityCritical)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityHigh)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityMedium)]),
+			fmt.Sprintf("%d", img.BySeverity[string(trivy.SeverityLow)]),
+			fmt.Sprintf("%d", img.Fixable)}
+		if checked {
+			patched := "-"
+			switch {
+			case img.RegistryError != "":
+				patched = "unknown"
+			case img.PatchedTag != "":
+				patched = img.PatchedTag
+			}
+			row = append(row, patched)
+		}
+		table.AddRow(row...)
+	}
+	return ui.Box(fmt.Sprintf("Images (%d)", len(images)), table.Render(), 160)
+}
+
+func init() {
+	queryCmd.AddCommand(queryImagesCmd)
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.Flags().BoolVar(&imagesCheckRegistry, "check-registry", false, "Look up newer patch releases of each tag in its registry")
+	addScanFlags(imagesCmd)
+	imagesCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
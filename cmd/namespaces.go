@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var namespacesSort string
+
+// NamespacePosture is the security posture of one namespace
+type NamespacePosture struct {
+	Namespace  string         `json:"namespace"`
+	Risk       float64        `json:"risk"` // 0-100, see aggregate.RiskScore
+	Findings   int            `json:"findings"`
+	BySeverity map[string]int `json:"bySeverity"`
+	Reports    int            `json:"reports"`
+	LastScan   *time.Time     `json:"lastScan,omitempty"`
+	Covered    bool           `json:"covered"`            // Trivy Operator scans the namespace
+	Coverage   string         `json:"coverage,omitempty"` // Why not, or what's missing
+}
+
+var namespacesCmd = &cobra.Command{
+	Use:     "namespaces",
+	Aliases: []string{"ns"},
+	Short:   "List namespaces with their findings, risk score and scan coverage",
+	Long: `List every namespace with its findings per severity, a risk score, when it
+was last scanned and whether Trivy Operator covers it, so teams can see their
+own posture at a glance.
+
+The risk score runs from 0 to 100: the severity-weighted number of findings
+(CRITICAL 10, HIGH 5, MEDIUM 2, LOW 1) on a log scale, so one CRITICAL finding
+scores 35 and ten score 67. Coverage comes from the operator's
+OPERATOR_TARGET_NAMESPACES and OPERATOR_EXCLUDE_NAMESPACES settings.
+
+Examples:
+  trix namespaces
+  trix namespaces --sort name -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if namespacesSort != "risk" && namespacesSort != "name" {
+			fmt.Printf("Error: unknown sort %q (expected risk or name)\n", namespacesSort)
+			return
+		}
+		ctx := context.Background()
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		postures := make(map[string]*NamespacePosture)
+		posture := func(ns string) *NamespacePosture {
+			p, ok := postures[ns]
+			if !ok {
+				p = &NamespacePosture{Namespace: ns, BySeverity: make(map[string]int)}
+				postures[ns] = p
+			}
+			return p
+		}
+		list, err := k8sClient.Clientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list namespaces, showing those with reports: %v\n", err)
+		} else {
+			for _, ns := range list.Items {
+				if inNamespaceScope(ns.Name) {
+					posture(ns.Name)
+				}
+			}
+		}
+
+		for _, f := range scanFindings(ctx, trivyClient, "") {
+			if f.Namespace == "" {
+				continue // Cluster-scoped
+			}
+			p := posture(f.Namespace)
+			p.Findings++
+			p.BySeverity[string(f.Severity)]++
+		}
+		scans, err := trivyClient.ScansByNamespace(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list reports: %v\n", err)
+		}
+		for ns, s := range scans {
+			p := posture(ns)
+			p.Reports = s.Reports
+			lastScan := s.LastScan
+			p.LastScan = &lastScan
+		}
+		scope, err := trivyClient.OperatorScope(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to find the Trivy Operator deployment: %v\n", err)
+		}
+
+		result := make([]NamespacePosture, 0, len(postures))
+		for _, p := range postures {
+			p.Risk = aggregate.RiskScore(p.BySeverity)
+			switch {
+			case scope == nil:
+				p.Coverage = "no operator"
+			default:
+				p.Covered, p.Coverage = scope.Covers(p.Namespace)
+				if p.Covered && p.Reports == 0 {
+					p.Coverage = "no reports yet"
+				}
+			}
+			result = append(result, *p)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if namespacesSort == "risk" && result[i].Risk != result[j].Risk {
+				return result[i].Risk > result[j].Risk
+			}
+			return result[i].Namespace < result[j].Namespace
+		})
+
+		if structuredOutput() {
+			printStructured(result)
+			return
+		}
+		if len(result) == 0 {
+			fmt.Println("No namespaces found")
+			return
+		}
+		fmt.Println(formatNamespaces(result))
+	},
+}
+
+// formatNamespaces renders the namespace postures as a table
+func formatNamespaces(postures []NamespacePosture) string {
+	table := ui.NewTable("Namespace", "Risk", "Critical", "High", "Medium", "Low", "Last Scan", "Covered")
+	uncovered := 0
+	for _, p := range postures {
+		lastScan := "never"
+		if p.LastScan != nil {
+			lastScan = trivy.FormatAge(time.Since(*p.LastScan)) + " ago"
+		}
+		covered := "yes"
+		if !p.Covered {
+			covered = "no"
+			uncovered++
+		}
+		if p.Coverage != "" {
+			covered += " (" + p.Coverage + ")"
+		}
+		table.AddRow(p.Namespace, fmt.Sprintf("%.0f", p.Risk),
+			fmt.Sprintf("%d", p.BySeverity[string(trivy.SeverityCritical)]),
+			fmt.Sprintf("%d", p.BySeverity[string(trivy.SeverityHigh)]),
+			fmt.Sprintf("%d", p.BySeverity[string(trivy.SeverityMedium)]),
+			fmt.Sprintf("%d", p.BySeverity[string(trivy.SeverityLow)]),
+			lastScan, covered)
+	}
+	content := table.Render()
+	if uncovered > 0 {
+		content += "\n" + ui.Muted.Render(fmt.Sprintf("Not scanned by Trivy Operator: %d, whose findings are unknown rather than zero", uncovered))
+	}
+	return ui.Box(fmt.Sprintf("Namespaces (%d)", len(postures)), content, 120)
+}
+
+func init() {
+	rootCmd.AddCommand(namespacesCmd)
+	namespacesCmd.Flags().StringVar(&namespacesSort, "sort", "risk", "Sort by risk or name")
+	addScanFlags(namespacesCmd)
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/kubebench"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nodesNamespace string
+	nodesSelector  string
+	nodesImage     string
+	nodesTimeout   time.Duration
+	nodesTTL       time.Duration
+	nodesExisting  bool
+	nodesFromFiles []string
+	nodesDetails   string
+)
+
+// NodeBenchmark is the kube-bench results of the nodes, as shown by 'trix
+// nodes benchmark'
+type NodeBenchmark struct {
+	Compliance ComplianceResult       `json:"compliance"`
+	Nodes      []kubebench.NodeResult `json:"nodes"`
+}
+
+var nodesCmd = &cobra.Command{
+	Use:   "nodes",
+	Short: "Check the configuration of the cluster's nodes",
+}
+
+var nodesBenchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Run the CIS benchmark on every node with kube-bench",
+	Long: `Run kube-bench on the nodes and show its CIS benchmark results like 'trix
+compliance' does. Trivy Operator's compliance reports can't see the nodes'
+configuration files, kubelet flags and control plane processes; kube-bench
+checks those on the node itself.
+
+A Job is created per node in the namespace of -n, pinned to the node, in the
+host's PID namespace and with the node's configuration directories mounted
+read-only. Jobs are removed --ttl after finishing; until then, --existing
+reads their results again without running kube-bench, as does 'trix
+compliance --nodes'. Running needs permission to create Jobs and read pod
+logs, and privileged pods must be allowed in the namespace.
+
+A control fails when it fails on any node; the Failed column counts the
+nodes. Results of kube-bench run elsewhere (kube-bench run --json, e.g. on
+managed nodes) can be read with --from-file.
+
+Examples:
+  trix nodes benchmark
+  trix nodes benchmark --node-selector node-role.kubernetes.io/control-plane
+  trix nodes benchmark --existing --details 4.2.1
+  trix nodes benchmark --from-file node-1.json --from-file node-2.json -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		var results []kubebench.NodeResult
+		var errs []error
+		if len(nodesFromFiles) > 0 {
+			results, errs = readKubeBenchFiles(nodesFromFiles)
+		} else {
+			if !requireCluster("nodes benchmark") {
+				return
+			}
+			k8sClient, err := kubectl.NewClient()
+			if err != nil {
+				fmt.Printf("Error creating K8s client: %v\n", err)
+				return
+			}
+			runner := kubebench.NewRunner(k8sClient.Clientset(), nodesNamespace, nodesImage, nodesTTL)
+			if nodesExisting {
+				results, errs = runner.Existing(ctx, nodesNamespace)
+			} else {
+				nodes, err := runner.ListNodes(ctx, nodesSelector)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				if len(nodes) == 0 {
+					fmt.Println("No nodes match the selector.")
+					return
+				}
+				if !structuredOutput() {
+					fmt.Fprintf(os.Stderr, "Running kube-bench on %d nodes in namespace %s...\n", len(nodes), nodesNamespace)
+				}
+				results, errs = runner.Run(ctx, nodes, nodesTimeout)
+			}
+		}
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if len(results) == 0 {
+			if nodesExisting {
+				fmt.Println("No kube-bench results found; run 'trix nodes benchmark' first.")
+			} else {
+				fmt.Println("No kube-bench results.")
+			}
+			return
+		}
+
+		benchmark := NodeBenchmark{Compliance: nodeCompliance(results), Nodes: results}
+		if nodesDetails != "" && !filterControl(&benchmark.Compliance, nodesDetails) {
+			fmt.Printf("Control %s not found.\n", nodesDetails)
+			return
+		}
+
+		if structuredOutput() {
+			printStructured(benchmark)
+			return
+		}
+		if nodesDetails != "" {
+			fmt.Println(formatControlDetails(benchmark.Compliance))
+			return
+		}
+		fmt.Println(formatNodeResults(results))
+		fmt.Println(formatComplianceResult(benchmark.Compliance))
+	},
+}
+
+// nodeCompliance merges the kube-bench results of the nodes into the
+// compliance result of their benchmark
+func nodeCompliance(results []kubebench.NodeResult) ComplianceResult {
+	controls, failures := kubebench.Compliance(results)
+	result := ComplianceResult{
+		Name:     "kube-bench-" + kubebench.Benchmark(results),
+		Title:    fmt.Sprintf("Node checks (%d nodes)", len(results)),
+		Sections: trivy.BenchmarkSections(controls),
+		Controls: controls,
+		Failures: failures,
+	}
+	for _, c := range controls {
+		switch c.Status {
+		case trivy.ControlPass:
+			result.Pass++
+		case trivy.ControlFail:
+			result.Fail++
+		default:
+			result.Manual++
+		}
+	}
+	result.Score = trivy.BenchmarkScore(result.Pass, result.Fail)
+	return result
+}
+
+// filterControl narrows a compliance result down to one control and the
+// resources failing it, reporting whether it has the control
+func filterControl(result *ComplianceResult, id string) bool {
+	var controls []trivy.BenchmarkControl
+	for _, c := range result.Controls {
+		if c.ID == id {
+			controls = append(controls, c)
+		}
+	}
+	result.Controls = controls
+	result.Failures = map[string][]trivy.ControlResource{id: result.Failures[id]}
+	return len(controls) > 0
+}
+
+// readKubeBenchFiles reads kube-bench JSON output from files, naming each
+// node after its file
+func readKubeBenchFiles(paths []string) ([]kubebench.NodeResult, []error) {
+	var results []kubebench.NodeResult
+	var errs []error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report, err := kubebench.Parse(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		node := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		var modified time.Time
+		if info, err := os.Stat(path); err == nil {
+			modified = info.ModTime()
+		}
+		results = append(results, kubebench.NodeResult{Node: node, Time: modified, Report: *report})
+	}
+	return results, errs
+}
+
+// formatNodeResults renders the check counts of each node
+func formatNodeResults(results []kubebench.NodeResult) string {
+	table := ui.NewTable("Node", "Type", "Pass", "Fail", "Warn", "Checked")
+	for _, r := range results {
+		var types []string
+		counts := make(map[string]int)
+		for _, c := range r.Report.Controls {
+			if c.NodeType != "" {
+				types = append(types, c.NodeType)
+			}
+			for _, group := range c.Groups {
+				for _, check := range group.Checks {
+					counts[check.Status]++
+				}
+			}
+		}
+		checked := "-"
+		if !r.Time.IsZero() {
+			checked = trivy.FormatAge(time.Since(r.Time)) + " ago"
+		}
+		table.AddRow(r.Node, strings.Join(types, ","),
+			fmt.Sprintf("%d", counts[kubebench.StatusPass]),
+			fmt.Sprintf("%d", counts[kubebench.StatusFail]),
+			fmt.Sprintf("%d", counts[kubebench.StatusWarn]+counts[kubebench.StatusInfo]),
+			checked)
+	}
+	return ui.Box(fmt.Sprintf("Nodes (%d)", len(results)), table.Render(), 110)
+}
+
+func init() {
+	rootCmd.AddCommand(nodesCmd)
+	nodesCmd.AddCommand(nodesBenchmarkCmd)
+	nodesBenchmarkCmd.Flags().StringVarP(&nodesNamespace, "namespace", "n", "default", "Namespace to run the kube-bench jobs in")
+	nodesBenchmarkCmd.Flags().StringVar(&nodesSelector, "node-selector", "", "Only run on nodes matching this label selector")
+	nodesBenchmarkCmd.Flags().StringVar(&nodesImage, "image", kubebench.DefaultImage, "kube-bench image to run")
+	nodesBenchmarkCmd.Flags().DurationVar(&nodesTimeout, "timeout", 5*time.Minute, "How long to wait for the jobs to finish")
+	nodesBenchmarkCmd.Flags().DurationVar(&nodesTTL, "ttl", 24*time.Hour, "How long finished jobs and their results are kept")
+	nodesBenchmarkCmd.Flags().BoolVar(&nodesExisting, "existing", false, "Read the results of the jobs of an earlier run instead of running kube-bench")
+	nodesBenchmarkCmd.Flags().StringSliceVar(&nodesFromFiles, "from-file", nil, "Read kube-bench --json output from a file, one per node (repeatable)")
+	nodesBenchmarkCmd.Flags().StringVar(&nodesDetails, "details", "", "List the nodes failing this control (e.g. 4.2.1)")
+	nodesBenchmarkCmd.MarkFlagsMutuallyExclusive("existing", "from-file")
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/config"
+	"github.com/davealtena/trix/internal/notify"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notifyChannels  []string
+	notifyLimit     int
+	notifySendEmpty bool
+	notifyDryRun    bool
+)
+
+// notifyBuiltinChannels are the channels that work without configuration, by
+// type and the environment variable holding their URL
+var notifyBuiltinChannels = map[string]struct{ typ, env string }{
+	"slack":   {"slack", "TRIX_SLACK_WEBHOOK"},
+	"webhook": {"webhook", "TRIX_NOTIFY_WEBHOOK"},
+}
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify --channel <name>",
+	Short: "Scan and send a summary of the findings to Slack, a webhook or a command",
+	Long: `Scan, then send the severity counts and the most severe findings to one or
+more channels, so a cron job or CronJob can report on a cluster without glue
+scripts. Nothing is sent when nothing is found, unless --send-empty is set.
+
+Channels are configured by name in the config file (~/.config/trix/config.yaml):
+
+  notify:
+    channels:
+      slack:
+        type: slack
+        url: ${SLACK_WEBHOOK_URL}
+      security-team:
+        url: https://hooks.example.com/trix
+      mail:
+        command: mail -s "trix findings" security@example.com
+
+A slack channel posts a Block Kit message to an incoming webhook. A webhook
+channel gets a JSON POST of the summary, whose text field makes it a valid
+Slack or Mattermost message. A command gets the summary as JSON on stdin and
+its text in TRIX_NOTIFY_TEXT. Without configuration, --channel slack and
+--channel webhook post to TRIX_SLACK_WEBHOOK and TRIX_NOTIFY_WEBHOOK.
+
+trix exits with status 1 when a channel couldn't be sent to.
+
+Examples:
+  trix notify --channel slack --min-severity critical -A
+  trix notify --channel slack --channel mail -n prod --min-severity high
+  trix notify -A --min-severity high --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(notifyChannels) == 0 && !notifyDryRun {
+			fmt.Println("Error: no channel to send to; use --channel, or --dry-run to print the summary")
+			return
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		senders := make(map[string]notify.Sender)
+		for _, name := range notifyChannels {
+			sender, err := notifySender(name, cfg.Notify.Channels)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			senders[name] = sender
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+		}
+
+		ns := resolveNamespace()
+		ctx := context.Background()
+		findings := scanFindings(ctx, trivyClient, ns)
+		if len(findings) == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
+		summary := notifySummary(findings, notifyLimit)
+		summary.Context = currentCtx
+		summary.Namespace = ns
+		summary.MinSeverity = minSeverity
+		summary.Severities = severities
+
+		if notifyDryRun {
+			fmt.Println(summary.Text())
+			return
+		}
+		if summary.Total == 0 && !notifySendEmpty {
+			fmt.Println("No findings, nothing sent")
+			return
+		}
+		failed := false
+		for _, name := range notifyChannels {
+			if err := senders[name].Send(ctx, summary); err != nil {
+				fmt.Printf("Error sending to %s: %v\n", name, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Sent %d findings to %s\n", summary.Total, name)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// notifySender returns the sender of a channel configured in the config file,
+// or of the built-in slack and webhook channels
+func notifySender(name string, channels map[string]config.Channel) (notify.Sender, error) {
+	channel, ok := channels[name]
+	if !ok {
+		builtin, ok := notifyBuiltinChannels[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown channel %q; configure it under notify.channels in %s", name, config.Path())
+		}
+		channel = config.Channel{Type: builtin.typ, URL: os.Getenv(builtin.env)}
+		if channel.URL == "" {
+			return nil, fmt.Errorf("channel %s isn't configured; set %s or configure it in %s", name, builtin.env, config.Path())
+		}
+	}
+
+	switch {
+	case channel.Command != "" && channel.URL != "":
+		return nil, fmt.Errorf("channel %s has both a url and a command", name)
+	case channel.Command != "":
+		return notify.NewCommand(channel.Command), nil
+	case channel.URL == "":
+		return nil, fmt.Errorf("channel %s has neither a url nor a command", name)
+	}
+	url := os.ExpandEnv(channel.URL)
+	if url == "" {
+		return nil, fmt.Errorf("the url of channel %s is empty after expanding %s", name, channel.URL)
+	}
+	switch channel.Type {
+	case "slack":
+		return notify.NewSlack(url), nil
+	case "", "webhook":
+		return notify.NewWebhook(url), nil
+	}
+	return nil, fmt.Errorf("channel %s has unknown type %q (use slack or webhook)", name, channel.Type)
+}
+
+// notifySummary counts the findings by severity and lists the first limit
+// of them, most severe first. The same ID at the same severity is listed
+// once, with every resource it was found in.
+func notifySummary(findings []trivy.Finding, limit int) notify.Summary {
+	summary := notify.Summary{
+		Total:      len(findings),
+		BySeverity: aggregate.Summarize(findings, aggregate.DefaultOptions).BySeverity,
+		Findings:   []notify.Finding{},
+		Time:       time.Now(),
+	}
+
+	grouped := make(map[string]*notify.Finding)
+	var order []*notify.Finding
+	for _, f := range findings {
+		key := string(f.Severity) + "|" + f.ID
+		g, ok := grouped[key]
+		if !ok {
+			g = &notify.Finding{Severity: string(f.Severity), ID: f.ID, Title: f.Title}
+			grouped[key] = g
+			order = append(order, g)
+		}
+		resource := f.Resource()
+		if f.Namespace != "" {
+			resource = f.Namespace + "/" + resource
+		}
+		if !slices.Contains(g.Resources, resource) {
+			g.Resources = append(g.Resources, resource)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		return len(a.Resources) > len(b.Resources)
+	})
+	for i, g := range order {
+		if i == limit {
+			summary.Omitted = len(order) - limit
+			break
+		}
+		summary.Findings = append(summary.Findings, *g)
+	}
+	return summary
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCmd)
+	notifyCmd.Flags().StringSliceVar(&notifyChannels, "channel", nil, "Channel to send to: one configured in the config file, slack or webhook (repeatable)")
+	notifyCmd.Flags().IntVar(&notifyLimit, "limit", 10, "Findings to list in the message")
+	notifyCmd.Flags().BoolVar(&notifySendEmpty, "send-empty", false, "Also send when nothing was found")
+	notifyCmd.Flags().BoolVar(&notifyDryRun, "dry-run", false, "Print the summary instead of sending it")
+	addScanFlags(notifyCmd)
+	notifyCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	operatorNamespace string
+	operatorVersion   string
+	operatorValues    []string
+	operatorManifests bool
+	operatorDryRun    bool
+	operatorYes       bool
+)
+
+// OperatorStatus is the state of Trivy Operator in the cluster
+type OperatorStatus struct {
+	Installed    bool       `json:"installed"`
+	Deployment   string     `json:"deployment,omitempty"`
+	Namespace    string     `json:"namespace,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	Ready        int32      `json:"ready"`
+	Desired      int32      `json:"desired"`
+	MissingCRDs  []string   `json:"missingCRDs,omitempty"`
+	Reports      int        `json:"reports"`
+	NewestReport *time.Time `json:"newestReport,omitempty"`
+	Healthy      bool       `json:"healthy"`
+	Problems     []string   `json:"problems,omitempty"`
+}
+
+var operatorCmd = &cobra.Command{
+	Use:   "operator",
+	Short: "Install, upgrade and check Trivy Operator",
+	Long: `Manage the Trivy Operator that writes the reports trix reads.
+
+'trix operator install' installs it with Helm from the Aqua chart repository,
+into trivy-system and waiting until it's ready, so a new cluster is set up
+with one command. Without Helm, --manifests applies the static manifests of a
+release with kubectl instead. helm and kubectl are found on PATH (or set
+TRIX_HELM to the helm binary) and use the same kubeconfig as trix.`,
+}
+
+var operatorInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install Trivy Operator",
+	Long: `Install Trivy Operator with its Helm chart, with the chart's defaults unless
+values are given with --set. The namespace is created if needed and the
+command waits up to 5 minutes for the operator to be ready. The first reports
+follow within a few minutes; check with 'trix operator status'.
+
+Examples:
+  trix operator install
+  trix operator install --version 0.27.0 --set operator.scanJobsConcurrentLimit=3
+  trix operator install --manifests
+  trix operator install --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runOperatorInstall(false)
+	},
+}
+
+var operatorUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade Trivy Operator",
+	Long: `Upgrade Trivy Operator to the latest chart, or the one given with --version.
+Values set at install are kept (helm --reset-then-reuse-values, Helm 3.14 or
+newer); --set changes them. With --manifests the manifests of the given
+release are applied over the installed ones.
+
+Examples:
+  trix operator upgrade
+  trix operator upgrade --version 0.29.0 --set trivy.ignoreUnfixed=true`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runOperatorInstall(true)
+	},
+}
+
+var operatorStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether Trivy Operator is installed, ready and writing reports",
+	Long: `Show the Trivy Operator deployment, its version and ready replicas, the
+report CRDs, and how many vulnerability reports there are and how fresh the
+newest is, with what to do about any problem.
+
+status exits with status 1 when the operator isn't installed or has problems.
+
+Examples:
+  trix operator status
+  trix operator status -o json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !requireCluster("operator status") {
+			return
+		}
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		health := operatorHealth(k8sClient)
+
+		if structuredOutput() {
+			printStructured(newOperatorStatus(health))
+		} else {
+			printOperatorHealth(health)
+		}
+		if !health.Healthy() {
+			os.Exit(1)
+		}
+	},
+}
+
+// runOperatorInstall installs or upgrades the operator with helm or kubectl,
+// after checking what is installed now
+func runOperatorInstall(upgrade bool) {
+	action := "install"
+	if upgrade {
+		action = "upgrade"
+	}
+	if !requireCluster("operator " + action) {
+		return
+	}
+	k8sClient, err := kubectl.NewClient()
+	if err != nil {
+		fmt.Printf("Error creating K8s client: %v\n", err)
+		return
+	}
+	currentCtx, err := k8sClient.GetCurrentContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+	}
+
+	current := operatorHealth(k8sClient)
+	namespace := operatorNamespace
+	switch {
+	case !upgrade && current.Deployment != "":
+		fmt.Printf("Trivy Operator %s is already installed in %s; use 'trix operator upgrade' to upgrade it\n", operatorVersionName(current.Version), current.Namespace)
+		return
+	case upgrade && current.Deployment == "":
+		fmt.Println("Error: Trivy Operator is not installed; use 'trix operator install'")
+		return
+	case upgrade && namespace == "":
+		namespace = current.Namespace // Upgrade where it was installed
+	}
+
+	command, err := trivy.InstallCommand(trivy.InstallOptions{
+		Context:   os.Getenv("TRIX_CONTEXT"),
+		Cluster:   os.Getenv("TRIX_CLUSTER"),
+		Namespace: namespace,
+		Version:   operatorVersion,
+		Values:    operatorValues,
+		Manifests: operatorManifests,
+		Upgrade:   upgrade,
+		DryRun:    operatorDryRun,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if upgrade {
+		fmt.Printf("This will upgrade Trivy Operator %s in %s (context %s).\n", operatorVersionName(current.Version), current.Namespace, currentCtx)
+	} else {
+		fmt.Printf("This will install Trivy Operator into context %s.\n", currentCtx)
+	}
+	fmt.Printf("Running: %s\n", strings.Join(command, " "))
+	if !operatorYes && !operatorDryRun {
+		fmt.Print("Continue? [y/N]: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	run := exec.Command(command[0], command[1:]...)
+	run.Stdout = os.Stdout
+	run.Stderr = os.Stderr
+	run.Stdin = os.Stdin
+	if err := run.Run(); err != nil {
+		fmt.Printf("Error: %s failed: %v\n", action, err)
+		os.Exit(1)
+	}
+	if operatorDryRun {
+		return
+	}
+
+	health := operatorHealth(k8sClient)
+	fmt.Println()
+	printOperatorHealth(health)
+	if health.Installed && health.Reports == 0 {
+		fmt.Println("\nThe first reports are written within a few minutes; follow them with 'trix watch'.")
+	}
+}
+
+// operatorHealth checks the operator's health, within doctor's timeout
+func operatorHealth(k8sClient *kubectl.Client) *trivy.OperatorHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	return trivy.NewClient(k8sClient).CheckOperatorHealth(ctx)
+}
+
+// newOperatorStatus converts the operator's health for JSON output
+func newOperatorStatus(health *trivy.OperatorHealth) OperatorStatus {
+	status := OperatorStatus{
+		Installed:   health.Installed,
+		Deployment:  health.Deployment,
+		Namespace:   health.Namespace,
+		Version:     health.Version,
+		Ready:       health.Ready,
+		Desired:     health.Desired,
+		MissingCRDs: health.MissingCRDs,
+		Reports:     health.Reports,
+		Healthy:     health.Healthy(),
+		Problems:    health.Problems,
+	}
+	if !health.NewestReport.IsZero() {
+		status.NewestReport = &health.NewestReport
+	}
+	return status
+}
+
+// operatorVersionName returns the version, or says it's unknown
+func operatorVersionName(version string) string {
+	if version == "" {
+		return "(unknown version)"
+	}
+	return version
+}
+
+func init() {
+	rootCmd.AddCommand(operatorCmd)
+	operatorCmd.AddCommand(operatorInstallCmd)
+	operatorCmd.AddCommand(operatorUpgradeCmd)
+	operatorCmd.AddCommand(operatorStatusCmd)
+
+	for _, c := range []*cobra.Command{operatorInstallCmd, operatorUpgradeCmd} {
+		c.Flags().StringVarP(&operatorNamespace, "namespace", "n", "", "Namespace to install into (default trivy-system, or where it's installed when upgrading)")
+		c.Flags().StringVar(&operatorVersion, "version", "", "Chart version, or release with --manifests (default the latest chart, or "+trivy.OperatorManifestVersion+" with --manifests)")
+		c.Flags().StringArrayVar(&operatorValues, "set", nil, "Set a Helm chart value, e.g. operator.scanJobsConcurrentLimit=3 (repeatable)")
+		c.Flags().BoolVar(&operatorManifests, "manifests", false, "Apply the static manifests with kubectl instead of installing with Helm")
+		c.Flags().BoolVar(&operatorDryRun, "dry-run", false, "Show what would change without changing the cluster")
+		c.Flags().BoolVarP(&operatorYes, "yes", "y", false, "Skip confirmation prompt")
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/davealtena/trix/internal/render"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// output is the format of the global --output flag: table, wide, json or
+// yaml. It's normalized at startup, so commands compare it with the render
+// formats.
+var output string
+
+// noColor turns off colors and box drawing, as NO_COLOR and piping do
+var noColor bool
+
+// structuredOutput reports whether results are printed for machines (JSON or
+// YAML) rather than as tables
+func structuredOutput() bool {
+	return render.Format(output).Structured()
+}
+
+// printStructured prints v in the JSON or YAML of --output, reporting whether
+// it could
+func printStructured(v any) bool {
+	if err := render.Write(os.Stdout, render.Format(output), v); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// applyOutputFlag checks --output and --no-color and sets up the tables for
+// them
+func applyOutputFlag() {
+	format, err := render.ParseFormat(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	output = string(format)
+	ui.Wide = format == render.Wide
+	if noColor {
+		ui.SetPlain()
+	}
+}
+
+// markdownStyle is the style of the markdown the LLM answers in: without
+// colors for plain output
+func markdownStyle() glamour.TermRendererOption {
+	if ui.Plain() {
+		return glamour.WithStandardStyle(styles.NoTTYStyle)
+	}
+	return glamour.WithAutoStyle()
+}
+
+// completeOutputFormats completes the formats of --output
+func completeOutputFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	formats := make([]string, len(render.Formats))
+	for i, f := range render.Formats {
+		formats[i] = string(f)
+	}
+	return formats, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", string(render.Table), "Output format: table, wide (no shortening, extra columns), json or yaml")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Plain text output without colors or box drawing (default when NO_COLOR is set or not in a terminal)")
+	_ = rootCmd.RegisterFlagCompletionFunc("output", completeOutputFormats)
+	cobra.OnInitialize(applyOutputFlag)
+}
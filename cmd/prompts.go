@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/davealtena/trix/internal/prompt"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "List the prompt templates used for AI analysis",
+	Long: `List the built-in prompt templates and any user overrides.
+
+Override a template by saving <name>.tmpl in ~/.config/trix/prompts
+(or TRIX_PROMPTS_DIR). Start from the built-in with:
+
+  trix prompts show remediation > ~/.config/trix/prompts/remediation.tmpl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templates, err := prompt.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		table := ui.NewTable("NAME", "VERSION", "SOURCE")
+		for _, t := range templates {
+			version := "override"
+			if t.Version > 0 {
+				version = fmt.Sprintf("v%d", t.Version)
+			}
+			table.AddRow(t.Name, version, t.Source)
+		}
+		fmt.Println(table.Render())
+	},
+}
+
+var promptsShowCmd = &cobra.Command{
+	Use:   "show <name[@vN]>",
+	Short: "Print a prompt template",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		t, err := prompt.Get(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Print(t.Text)
+	},
+}
+
+var promptsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify that prompt templates and overrides render correctly",
+	Long: `Render every prompt template and override with sample data and check
+that it has the sections trix relies on. Exits with status 1 when a template
+fails, so overrides can be checked in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templates, err := prompt.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		failed := false
+		for i := range templates {
+			if err := prompt.Check(&templates[i]); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				failed = true
+				continue
+			}
+			fmt.Printf("✅ %s (%s)\n", templates[i].Name, templates[i].Source)
+		}
+		if failed {
+			fmt.Println("\nFix the templates above or remove the overrides to use the built-ins.")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.AddCommand(promptsShowCmd)
+	promptsCmd.AddCommand(promptsCheckCmd)
+}
@@ -2,23 +2,38 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/config"
+	"github.com/davealtena/trix/internal/render"
 	"github.com/davealtena/trix/internal/tools/kubectl"
 	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 	"github.com/davealtena/trix/internal/ui"
+	"github.com/davealtena/trix/internal/vex"
 	"github.com/spf13/cobra"
 )
 
 var (
-	namespace     string
 	showDetails   bool
-	allNamespaces bool
-	output        string
 	packageFilter string
 	showFull      bool
+	topRoles      int
+	controlID     string
+	purlFilter    string
+	uniqueVulns   bool
+	labelSelector string
+	fieldSelector string
+	chunkSize     int64 = trivy.DefaultPageSize // Also used by commands without the flag
+	concurrency   int   = 1
+	trivyServer   string
+
+	cveIDs    []string
+	fixedOnly bool
 )
 
 var queryCmd = &cobra.Command{
@@ -31,10 +46,10 @@ var queryCmd = &cobra.Command{
 type VulnReport struct {
 	Name            string                `json:"name"`
 	Namespace       string                `json:"namespace"`
-	Critical        int64                 `json:"critical"`
-	High            int64                 `json:"high"`
-	Medium          int64                 `json:"medium"`
-	Low             int64                 `json:"low"`
+	Critical        int                   `json:"critical"`
+	High            int                   `json:"high"`
+	Medium          int                   `json:"medium"`
+	Low             int                   `json:"low"`
 	Vulnerabilities []trivy.Vulnerability `json:"vulnerabilities,omitempty"`
 }
 
@@ -42,10 +57,10 @@ type VulnReport struct {
 type ComplianceReport struct {
 	Name      string                  `json:"name"`
 	Namespace string                  `json:"namespace"`
-	Critical  int64                   `json:"critical"`
-	High      int64                   `json:"high"`
-	Medium    int64                   `json:"medium"`
-	Low       int64                   `json:"low"`
+	Critical  int                     `json:"critical"`
+	High      int                     `json:"high"`
+	Medium    int                     `json:"medium"`
+	Low       int                     `json:"low"`
 	Checks    []trivy.ComplianceCheck `json:"checks,omitempty"`
 }
 
@@ -53,108 +68,67 @@ var queryVulnsCmd = &cobra.Command{
 	Use:   "vulns",
 	Short: "List vulnerability reports from Trivy Operator",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating K8s client: %v\n", err)
 			return
 		}
-		trivyClient := trivy.NewClient(k8sClient)
-
-		ctx := context.Background()
-
-		currentCtx, err := k8sClient.GetCurrentContext()
+		trivyClient, err := newTrivyClient(k8sClient)
 		if err != nil {
-			fmt.Printf("Error getting context: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
-		// Only show context info in text mode
-		if output != "json" {
-			fmt.Printf("Using context: %s\n", currentCtx)
-		}
+		ctx := context.Background()
 
-		// Determine namespace based on flag
-		ns := namespace
-		if allNamespaces {
-			ns = "" // Empty string = all namespaces in k8s API
-			if output != "json" {
-				fmt.Printf("Namespace: all\n\n")
-			}
-		} else {
-			if output != "json" {
-				fmt.Printf("Namespace: %s\n\n", ns)
-			}
+		ns := resolveNamespace()
+		if !structuredOutput() {
+			fmt.Printf("Scope: %s\n\n", namespaceScope())
 		}
 
 		reports, err := trivyClient.ListVulnerabilityReports(ctx, ns)
-		if err != nil {
+		if err != nil && !partialResults(err) {
 			fmt.Printf("Error listing vulnerability reports: %v\n", err)
 			return
 		}
+		if len(reports) == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
+
+		if uniqueVulns {
+			printUniqueVulnerabilities(reports)
+			return
+		}
 
 		// Collect all reports for JSON output
 		var vulnReports []VulnReport
 
-		if output != "json" {
+		if !structuredOutput() {
 			fmt.Printf("Found %d vulnerability reports:\n", len(reports))
 		}
 
 		for i, report := range reports {
-			// Extract metadata
-			metadata, ok := report["metadata"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			name, _ := metadata["name"].(string)
-			ns, _ := metadata["namespace"].(string)
-
-			// Extract report data
-			reportData, ok := report["report"].(map[string]interface{})
-			if !ok {
-				if output != "json" {
-					fmt.Printf("%d. %s (no report data)\n", i+1, name)
-				}
-				continue
-			}
-
-			// Extract summary from report
-			summary, ok := reportData["summary"].(map[string]interface{})
-			if !ok {
-				if output != "json" {
-					fmt.Printf("%d. %s (no summary)\n", i+1, name)
-				}
-				continue
-			}
-
-			// Convert to int
-			critical, _ := summary["criticalCount"].(int64)
-			high, _ := summary["highCount"].(int64)
-			medium, _ := summary["mediumCount"].(int64)
-			low, _ := summary["lowCount"].(int64)
-
+			summary := report.Report.Summary
 			vulnReport := VulnReport{
-				Name:      name,
-				Namespace: ns,
-				Critical:  critical,
-				High:      high,
-				Medium:    medium,
-				Low:       low,
+				Name:      report.Name,
+				Namespace: report.Namespace,
+				Critical:  summary.CriticalCount,
+				High:      summary.HighCount,
+				Medium:    summary.MediumCount,
+				Low:       summary.LowCount,
 			}
 
 			// Parse vulnerabilities if requested or JSON output
-			if showDetails || output == "json" {
-				vulns, err := trivyClient.ParseVulnerabilities(report)
-				if err != nil && output != "json" {
-					fmt.Printf("Error parsing vulnerabilities: %v\n", err)
-					continue
-				}
-				vulnReport.Vulnerabilities = vulns
+			if showDetails || structuredOutput() {
+				vulnReport.Vulnerabilities = trivy.ConvertVulnerabilities(&report)
 			}
 
 			vulnReports = append(vulnReports, vulnReport)
 
 			// Text output
-			if output != "json" {
-				fmt.Printf("%d. %s Critical: %d High: %d Medium: %d Low: %d\n", i+1, name, critical, high, medium, low)
+			if !structuredOutput() {
+				fmt.Printf("%d. %s Critical: %d High: %d Medium: %d Low: %d\n", i+1, vulnReport.Name,
+					vulnReport.Critical, vulnReport.High, vulnReport.Medium, vulnReport.Low)
 
 				if showDetails && len(vulnReport.Vulnerabilities) > 0 {
 					fmt.Printf("   Parsed %d vulnerabilities (Showing first 3):\n", len(vulnReport.Vulnerabilities))
@@ -169,13 +143,8 @@ var queryVulnsCmd = &cobra.Command{
 		}
 
 		// JSON output
-		if output == "json" {
-			jsonData, err := json.MarshalIndent(vulnReports, "", "  ")
-			if err != nil {
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
-			}
-			fmt.Println(string(jsonData))
+		if structuredOutput() {
+			printStructured(vulnReports)
 		}
 	},
 }
@@ -184,40 +153,26 @@ var queryComplianceCmd = &cobra.Command{
 	Use:   "compliance",
 	Short: "List compliance reports from Trivy Operator",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating K8s client: %v\n", err)
 			return
 		}
-		trivyClient := trivy.NewClient(k8sClient)
-
-		ctx := context.Background()
-
-		currentCtx, err := k8sClient.GetCurrentContext()
+		trivyClient, err := newTrivyClient(k8sClient)
 		if err != nil {
-			fmt.Printf("Error getting context: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
-		// Only show context info in text mode
-		if output != "json" {
-			fmt.Printf("Using context: %s\n", currentCtx)
-		}
+		ctx := context.Background()
 
-		// Determine namespace based on flag
-		ns := namespace
-		if allNamespaces {
-			ns = "" // Empty string = all namespaces in k8s API
-			if output != "json" {
-				fmt.Printf("Namespace: all\n\n")
-			}
-		} else {
-			if output != "json" {
-				fmt.Printf("Namespace: %s\n\n", ns)
-			}
+		ns := resolveNamespace()
+		if !structuredOutput() {
+			fmt.Printf("Scope: %s\n\n", namespaceScope())
 		}
 
 		reports, err := trivyClient.ListConfigAuditReports(ctx, ns)
-		if err != nil {
+		if err != nil && !partialResults(err) {
 			fmt.Printf("Error listing compliance reports: %v\n", err)
 			return
 		}
@@ -225,67 +180,32 @@ var queryComplianceCmd = &cobra.Command{
 		// Collect all reports for JSON output
 		var complianceReports []ComplianceReport
 
-		if output != "json" {
+		if !structuredOutput() {
 			fmt.Printf("Found %d compliance reports:\n", len(reports))
 		}
 
 		for i, report := range reports {
-			// Extract metadata
-			metadata, ok := report["metadata"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			name, _ := metadata["name"].(string)
-			ns, _ := metadata["namespace"].(string)
-
-			// Extract report data
-			reportData, ok := report["report"].(map[string]interface{})
-			if !ok {
-				if output != "json" {
-					fmt.Printf("%d. %s (no report data)\n", i+1, name)
-				}
-				continue
-			}
-
-			// Extract summary from report
-			summary, ok := reportData["summary"].(map[string]interface{})
-			if !ok {
-				if output != "json" {
-					fmt.Printf("%d. %s (no summary)\n", i+1, name)
-				}
-				continue
-			}
-
-			// Convert to int
-			critical, _ := summary["criticalCount"].(int64)
-			high, _ := summary["highCount"].(int64)
-			medium, _ := summary["mediumCount"].(int64)
-			low, _ := summary["lowCount"].(int64)
-
+			summary := report.Report.Summary
 			complianceReport := ComplianceReport{
-				Name:      name,
-				Namespace: ns,
-				Critical:  critical,
-				High:      high,
-				Medium:    medium,
-				Low:       low,
+				Name:      report.Name,
+				Namespace: report.Namespace,
+				Critical:  summary.CriticalCount,
+				High:      summary.HighCount,
+				Medium:    summary.MediumCount,
+				Low:       summary.LowCount,
 			}
 
 			// Parse checks if requested or JSON output
-			if showDetails || output == "json" {
-				checks, err := trivyClient.ParseComplianceChecks(report)
-				if err != nil && output != "json" {
-					fmt.Printf("Error parsing compliance checks: %v\n", err)
-					continue
-				}
-				complianceReport.Checks = checks
+			if showDetails || structuredOutput() {
+				complianceReport.Checks = trivy.ConvertChecks(report.Report.Checks)
 			}
 
 			complianceReports = append(complianceReports, complianceReport)
 
 			// Text output
-			if output != "json" {
-				fmt.Printf("%d. %s Critical: %d High: %d Medium: %d Low: %d\n", i+1, name, critical, high, medium, low)
+			if !structuredOutput() {
+				fmt.Printf("%d. %s Critical: %d High: %d Medium: %d Low: %d\n", i+1, complianceReport.Name,
+					complianceReport.Critical, complianceReport.High, complianceReport.Medium, complianceReport.Low)
 
 				if showDetails && len(complianceReport.Checks) > 0 {
 					fmt.Printf("   Parsed %d checks (Showing first 3):\n", len(complianceReport.Checks))
@@ -300,13 +220,8 @@ var queryComplianceCmd = &cobra.Command{
 		}
 
 		// JSON output
-		if output == "json" {
-			jsonData, err := json.MarshalIndent(complianceReports, "", "  ")
-			if err != nil {
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
-			}
-			fmt.Println(string(jsonData))
+		if structuredOutput() {
+			printStructured(complianceReports)
 		}
 	},
 }
@@ -315,57 +230,46 @@ var queryFindingsCmd = &cobra.Command{
 	Use:   "findings",
 	Short: "Query all security findings (unified view)",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating k8s client: %v\n", err)
 			return
 		}
-		trivyClient := trivy.NewClient(k8sClient)
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
 		ctx := context.Background()
 
 		// Determine namespace
-		ns := namespace
-		if allNamespaces {
-			ns = ""
-		}
-
-		// Create all scanners - they all implement the Scanner interface
-		scanners := []trivy.Scanner{
-			// Namespaced scanners
-			trivy.NewTrivyVulnScanner(trivyClient),
-			trivy.NewTrivyComplianceScanner(trivyClient),
-			trivy.NewTrivySecretScanner(trivyClient),
-			trivy.NewTrivyRbacScanner(trivyClient),
-			trivy.NewTrivyInfraScanner(trivyClient),
-			// Cluster-scoped scanners
-			trivy.NewClusterVulnScanner(trivyClient),
-			trivy.NewClusterComplianceScanner(trivyClient),
-			trivy.NewClusterRbacScanner(trivyClient),
-			trivy.NewClusterInfraScanner(trivyClient),
-			// Benchmark scanner (CIS/NSA)
-			trivy.NewBenchmarkScanner(trivyClient),
-		}
+		ns := resolveNamespace()
+
+		scanners := scannersFor(trivyClient)
 
 		var allFindings []trivy.Finding
 
 		// Run each scanner
 		for _, scanner := range scanners {
-			if output != "json" {
+			if !structuredOutput() {
 				fmt.Printf("Running %s scanner..\n", scanner.Name())
 			}
 
 			findings, err := scanner.Scan(ctx, ns)
-			if err != nil {
+			if err != nil && !partialResults(err) {
 				fmt.Printf("Error in %s: %v\n", scanner.Name(), err)
 				continue
 			}
 
 			allFindings = append(allFindings, findings...)
 		}
+		if len(allFindings) == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
 
 		// Output results
-		if output == "json" {
+		if structuredOutput() {
 			// Strip RawData by default to reduce output size (use --full to include)
 			outputFindings := allFindings
 			if !showFull {
@@ -375,15 +279,13 @@ var queryFindingsCmd = &cobra.Command{
 					outputFindings[i].RawData = nil
 				}
 			}
-			jsonData, err := json.MarshalIndent(outputFindings, "", "  ")
-			if err != nil {
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
-			}
-			fmt.Println(string(jsonData))
+			printStructured(outputFindings)
 		} else {
 			// Build table output
 			table := ui.NewTable("Severity", "Type", "Title", "Resource")
+			if output == string(render.Wide) {
+				table = ui.NewTable("Severity", "Type", "ID", "Title", "Resource")
+			}
 
 			// Limit to first 50 for readability
 			limit := 50
@@ -393,11 +295,12 @@ var queryFindingsCmd = &cobra.Command{
 
 			for _, f := range allFindings[:limit] {
 				// Truncate title if too long
-				title := f.Title
-				if len(title) > 40 {
-					title = title[:37] + "..."
+				title := ui.Truncate(f.Title, 40)
+				if output == string(render.Wide) {
+					table.AddRow(string(f.Severity), string(f.Type), f.ID, title, f.Resource())
+					continue
 				}
-				table.AddRow(string(f.Severity), string(f.Type), title, f.ResourceName)
+				table.AddRow(string(f.Severity), string(f.Type), title, f.Resource())
 			}
 
 			// Render in a box
@@ -407,106 +310,43 @@ var queryFindingsCmd = &cobra.Command{
 	},
 }
 
-// Summary represents aggregated findings data
-type Summary struct {
-	BySeverity    map[string]int  `json:"bySeverity"`
-	ByType        map[string]int  `json:"byType"`
-	TopResources  []ResourceCount `json:"topResources"`
-	TotalFindings int             `json:"totalFindings"`
-}
-
-// ResourceCount tracks findings per resource
-type ResourceCount struct {
-	Resource string `json:"resource"`
-	Count    int    `json:"count"`
-}
-
 var querySummaryCmd = &cobra.Command{
 	Use:   "summary",
 	Short: "Show aggregated security findings summary",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating k8s client: %v\n", err)
 			return
 		}
-		trivyClient := trivy.NewClient(k8sClient)
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
 		ctx := context.Background()
 
-		ns := namespace
-		if allNamespaces {
-			ns = ""
-		}
-
-		// Create all scanners
-		scanners := []trivy.Scanner{
-			trivy.NewTrivyVulnScanner(trivyClient),
-			trivy.NewTrivyComplianceScanner(trivyClient),
-			trivy.NewTrivySecretScanner(trivyClient),
-			trivy.NewTrivyRbacScanner(trivyClient),
-			trivy.NewTrivyInfraScanner(trivyClient),
-			trivy.NewClusterVulnScanner(trivyClient),
-			trivy.NewClusterComplianceScanner(trivyClient),
-			trivy.NewClusterRbacScanner(trivyClient),
-			trivy.NewClusterInfraScanner(trivyClient),
-			trivy.NewBenchmarkScanner(trivyClient),
-		}
+		ns := resolveNamespace()
 
-		var allFindings []trivy.Finding
+		scanners := scannersFor(trivyClient)
 
-		// Run each scanner
+		// Aggregate scanner by scanner, so all findings are never held at once
+		agg := aggregate.New()
 		for _, scanner := range scanners {
 			findings, err := scanner.Scan(ctx, ns)
-			if err != nil {
+			if err != nil && !partialResults(err) {
 				continue
 			}
-			allFindings = append(allFindings, findings...)
-		}
-
-		// Aggregate by severity
-		bySeverity := make(map[string]int)
-		for _, f := range allFindings {
-			bySeverity[string(f.Severity)]++
-		}
-
-		// Aggregate by type
-		byType := make(map[string]int)
-		for _, f := range allFindings {
-			byType[string(f.Type)]++
-		}
-
-		// Count by resource (for top affected)
-		// Exclude benchmark findings - they're framework-level, not resource-level
-		resourceCounts := make(map[string]int)
-		for _, f := range allFindings {
-			if f.Type == trivy.FindingTypeBenchmark {
-				continue // Skip benchmarks - not actual K8s resources
-			}
-			key := f.ResourceName
-			if f.Namespace != "" {
-				key = f.Namespace + "/" + f.ResourceName
-			}
-			resourceCounts[key]++
+			agg.Add(findings...)
 		}
-
-		// Sort and get top 10
-		topResources := getTopResources(resourceCounts, 10)
-
-		summary := Summary{
-			BySeverity:    bySeverity,
-			ByType:        byType,
-			TopResources:  topResources,
-			TotalFindings: len(allFindings),
+		summary := agg.Summary(aggregate.DefaultOptions)
+		if summary.TotalFindings == 0 {
+			explainEmpty(ctx, trivyClient)
 		}
 
-		if output == "json" {
-			jsonData, err := json.MarshalIndent(summary, "", "  ")
-			if err != nil {
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
-			}
-			fmt.Println(string(jsonData))
+		if structuredOutput() {
+			printStructured(summary)
 			return
 		}
 
@@ -514,12 +354,12 @@ var querySummaryCmd = &cobra.Command{
 		var content strings.Builder
 
 		// Total count
-		content.WriteString(fmt.Sprintf("Total Findings: %s\n\n", ui.Info.Render(fmt.Sprintf("%d", len(allFindings)))))
+		content.WriteString(fmt.Sprintf("Total Findings: %s\n\n", ui.Info.Render(fmt.Sprintf("%d", summary.TotalFindings))))
 
 		// By Severity section
 		content.WriteString(ui.Section("By Severity") + "\n")
 		for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"} {
-			if count, ok := bySeverity[sev]; ok {
+			if count, ok := summary.BySeverity[sev]; ok {
 				content.WriteString(ui.SeverityLine(sev, count) + "\n")
 			}
 		}
@@ -527,51 +367,62 @@ var querySummaryCmd = &cobra.Command{
 		// By Type section
 		content.WriteString("\n" + ui.Section("By Type") + "\n")
 		for _, typ := range []string{"vulnerability", "compliance", "rbac", "secret", "infra", "benchmark"} {
-			if count, ok := byType[typ]; ok {
+			if count, ok := summary.ByType[typ]; ok {
 				content.WriteString(ui.TypeLine(typ, count) + "\n")
 			}
 		}
 
-		// Top Resources section
-		if len(topResources) > 0 {
-			content.WriteString("\n" + ui.Section("Top Affected Resources") + "\n")
-			for _, rc := range topResources {
+		// Vulnerabilities section: unique vs occurrences, and fix status
+		if summary.Fixable+summary.Unfixable > 0 {
+			content.WriteString("\n" + ui.Section("Vulnerabilities") + "\n")
+			content.WriteString(ui.TypeLine("unique", summary.UniqueVulnerabilities) + "\n")
+			content.WriteString(ui.TypeLine("occurrences", summary.Fixable+summary.Unfixable) + "\n")
+			content.WriteString(ui.TypeLine("fixable", summary.Fixable) + "\n")
+			content.WriteString(ui.TypeLine("no fix yet", summary.Unfixable) + "\n")
+		}
+
+		// Top namespaces, only useful across several
+		if len(summary.ByNamespace) > 1 {
+			content.WriteString("\n" + ui.Section("Top Namespaces") + "\n")
+			for _, rc := range aggregate.Top(summary.ByNamespace, 5) {
 				content.WriteString(ui.ResourceLine(rc.Resource, rc.Count, 40) + "\n")
 			}
 		}
 
-		// Wrap in a box and print
-		fmt.Println(ui.Box("Security Findings Summary", content.String(), 60))
-	},
-}
+		// Top images by vulnerabilities
+		if len(summary.ByImage) > 0 {
+			content.WriteString("\n" + ui.Section("Top Vulnerable Images") + "\n")
+			for _, rc := range aggregate.Top(summary.ByImage, 5) {
+				content.WriteString(ui.ResourceLine(rc.Resource, rc.Count, 40) + "\n")
+			}
+		}
 
-// getTopResources returns the top N resources by finding count
-func getTopResources(counts map[string]int, n int) []ResourceCount {
-	var result []ResourceCount
-	for resource, count := range counts {
-		result = append(result, ResourceCount{Resource: resource, Count: count})
-	}
+		// Top Resources section
+		if len(summary.TopResources) > 0 {
+			content.WriteString("\n" + ui.Section("Top Affected Resources") + "\n")
+			for _, rc := range summary.TopResources {
+				content.WriteString(ui.ResourceLine(rc.Resource, rc.Count, 40) + "\n")
+			}
+		}
 
-	// Simple bubble sort for top N (good enough for this use case)
-	for i := 0; i < len(result); i++ {
-		for j := i + 1; j < len(result); j++ {
-			if result[j].Count > result[i].Count {
-				result[i], result[j] = result[j], result[i]
+		// Infrastructure section (nodes, control plane)
+		if len(summary.TopInfra) > 0 {
+			content.WriteString("\n" + ui.Section("Top Affected Infrastructure") + "\n")
+			for _, rc := range summary.TopInfra {
+				content.WriteString(ui.ResourceLine(rc.Resource, rc.Count, 40) + "\n")
 			}
 		}
-	}
 
-	if len(result) > n {
-		result = result[:n]
-	}
-	return result
+		// Wrap in a box and print
+		fmt.Println(ui.Box("Security Findings Summary", content.String(), 60))
+	},
 }
 
 var queryNetworkCmd = &cobra.Command{
 	Use:   "network",
 	Short: "Analyze NetworkPolicy coverage",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating k8s client: %v\n", err)
 			return
@@ -579,10 +430,7 @@ var queryNetworkCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		ns := namespace
-		if allNamespaces {
-			ns = ""
-		}
+		ns := resolveNamespace()
 
 		coverage, err := k8sClient.AnalyzeCoverage(ctx, ns)
 		if err != nil {
@@ -590,9 +438,8 @@ var queryNetworkCmd = &cobra.Command{
 			return
 		}
 
-		if output == "json" {
-			jsonData, _ := json.MarshalIndent(coverage, "", "  ")
-			fmt.Println(string(jsonData))
+		if structuredOutput() {
+			printStructured(coverage)
 			return
 		}
 
@@ -608,26 +455,226 @@ var queryNetworkCmd = &cobra.Command{
 	},
 }
 
+// NamespaceRoles lists the most over-privileged roles of a namespace
+type NamespaceRoles struct {
+	Namespace string                 `json:"namespace"` // Empty for cluster-scoped roles
+	Roles     []trivy.RoleAssessment `json:"roles"`
+}
+
+var queryRbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Show the most over-privileged roles per namespace",
+	Long: `Summarize Trivy RbacAssessmentReports and ClusterRbacAssessmentReports,
+listing the roles with the most severe failed checks in each namespace.
+ClusterRoles are included with -A.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+
+		roles, err := trivyClient.AssessRoles(ctx, ns)
+		if err != nil && !partialResults(err) {
+			fmt.Printf("Error listing RBAC assessment reports: %v\n", err)
+			return
+		}
+
+		worst := trivy.WorstRolesByNamespace(roles, topRoles)
+		namespaces := make([]string, 0, len(worst))
+		for n := range worst {
+			namespaces = append(namespaces, n)
+		}
+		sort.Strings(namespaces) // Cluster-scoped ("") first
+
+		if structuredOutput() {
+			var result []NamespaceRoles
+			for _, n := range namespaces {
+				result = append(result, NamespaceRoles{Namespace: n, Roles: worst[n]})
+			}
+			printStructured(result)
+			return
+		}
+
+		if len(roles) == 0 {
+			fmt.Println("No roles with failed RBAC checks found.")
+			return
+		}
+
+		// Text output
+		for _, n := range namespaces {
+			if n == "" {
+				fmt.Println("Cluster-scoped:")
+			} else {
+				fmt.Printf("Namespace: %s\n", n)
+			}
+			for _, role := range worst[n] {
+				fmt.Printf("  %s/%s  Critical: %d High: %d Medium: %d Low: %d\n",
+					role.Kind, role.Name, role.Critical, role.High, role.Medium, role.Low)
+				if showDetails {
+					for _, c := range role.Checks {
+						fmt.Printf("    - [%s] %s: %s\n", c.Severity, c.CheckID, c.Title)
+					}
+				}
+			}
+			fmt.Println()
+		}
+		fmt.Printf("Total: %d roles with failed checks\n", len(roles))
+	},
+}
+
+// BenchmarkReport represents a CIS/NSA compliance report with per-control status
+type BenchmarkReport struct {
+	Name     string                             `json:"name"`
+	Controls []trivy.BenchmarkControl           `json:"controls"`
+	Failures map[string][]trivy.ControlResource `json:"failures,omitempty"` // Failing resources by control ID
+}
+
+var queryBenchmarkCmd = &cobra.Command{
+	Use:   "benchmark [name]",
+	Short: "Show CIS/NSA benchmark controls and the resources failing them",
+	Long: `Show the status of every control in Trivy ClusterComplianceReports (CIS, NSA),
+optionally only for the named report. Use --control to list the resources
+failing a control, or --details to list them for every failed control.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		ctx := context.Background()
+
+		reports, err := trivyClient.ListBenchmarkReports(ctx)
+		if err != nil {
+			fmt.Printf("Error listing benchmark reports: %v\n", err)
+			return
+		}
+
+		var benchmarks []BenchmarkReport
+		for _, report := range reports {
+			name, controls := report.Name, trivy.ConvertBenchmarkControls(&report)
+			if len(args) > 0 && name != args[0] {
+				continue
+			}
+
+			benchmark := BenchmarkReport{Name: name, Controls: controls}
+			if controlID != "" {
+				// Drill down into one control
+				benchmark.Controls = nil
+				for _, c := range controls {
+					if c.ID == controlID {
+						benchmark.Controls = append(benchmark.Controls, c)
+					}
+				}
+				if len(benchmark.Controls) == 0 {
+					continue
+				}
+			}
+			if controlID != "" || showDetails {
+				var failed []trivy.BenchmarkControl
+				for _, c := range benchmark.Controls {
+					if c.Status == trivy.ControlFail {
+						failed = append(failed, c)
+					}
+				}
+				if benchmark.Failures, err = trivyClient.ControlFailures(ctx, failed); err != nil {
+					fmt.Printf("Error finding failing resources: %v\n", err)
+					return
+				}
+			}
+			benchmarks = append(benchmarks, benchmark)
+		}
+
+		if structuredOutput() {
+			printStructured(benchmarks)
+			return
+		}
+
+		if len(benchmarks) == 0 {
+			switch {
+			case controlID != "":
+				fmt.Printf("Control %s not found.\n", controlID)
+			case len(args) > 0:
+				fmt.Printf("Benchmark report %s not found.\n", args[0])
+			default:
+				fmt.Println("No benchmark reports found. Is compliance reporting enabled in Trivy Operator?")
+			}
+			return
+		}
+
+		// Text output
+		for _, b := range benchmarks {
+			table := ui.NewTable("ID", "Severity", "Status", "Failed", "Control")
+			counts := make(map[string]int)
+			for _, c := range b.Controls {
+				name := ui.Truncate(c.Name, 50)
+				table.AddRow(c.ID, c.Severity, c.Status, fmt.Sprintf("%d", c.TotalFail), name)
+				counts[c.Status]++
+			}
+			header := fmt.Sprintf("%s (%d pass, %d fail, %d manual)", b.Name, counts[trivy.ControlPass], counts[trivy.ControlFail], counts[trivy.ControlManual])
+			fmt.Println(ui.Box(header, table.Render(), 100))
+
+			for _, c := range b.Controls {
+				resources, ok := b.Failures[c.ID]
+				if !ok {
+					continue
+				}
+				fmt.Printf("\n%s %s - failing resources:\n", c.ID, c.Name)
+				for _, r := range resources {
+					resource := r.Kind + "/" + r.Name
+					if r.Namespace != "" {
+						resource = r.Namespace + "/" + resource
+					}
+					fmt.Printf("  %s [%s] %s\n", resource, r.CheckID, r.Title)
+					for _, msg := range r.Messages {
+						fmt.Printf("    %s\n", msg)
+					}
+				}
+			}
+			if controlID != "" && len(b.Failures[controlID]) == 0 {
+				fmt.Println("\nNo failing resources found for this control.")
+			}
+		}
+	},
+}
+
 var querySbomCmd = &cobra.Command{
 	Use:   "sbom",
 	Short: "List software components from SBOM reports",
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating K8s client: %v\n", err)
 			return
 		}
-		trivyClient := trivy.NewClient(k8sClient)
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
 
 		ctx := context.Background()
 
-		ns := namespace
-		if allNamespaces {
-			ns = ""
-		}
+		ns := resolveNamespace()
 
 		reports, err := trivyClient.ListSbomReports(ctx, ns)
-		if err != nil {
+		if err != nil && !partialResults(err) {
 			fmt.Printf("Error listing SBOM reports: %v\n", err)
 			return
 		}
@@ -638,13 +685,10 @@ var querySbomCmd = &cobra.Command{
 			reports = append(reports, clusterReports...)
 		}
 
-		if output == "json" {
+		if structuredOutput() {
 			var sboms []trivy.SBOMReport
 			for _, report := range reports {
-				sbom, err := trivyClient.ParseSBOMReport(report)
-				if err != nil {
-					continue
-				}
+				sbom := trivy.ConvertSBOMReport(&report)
 				// Apply package filter to JSON output too
 				if packageFilter != "" {
 					var filtered []trivy.SBOMComponent
@@ -660,18 +704,14 @@ var querySbomCmd = &cobra.Command{
 				}
 				sboms = append(sboms, *sbom)
 			}
-			jsonData, _ := json.MarshalIndent(sboms, "", "  ")
-			fmt.Println(string(jsonData))
+			printStructured(sboms)
 			return
 		}
 
 		// Text output
 		totalComponents := 0
 		for _, report := range reports {
-			sbom, err := trivyClient.ParseSBOMReport(report)
-			if err != nil {
-				continue
-			}
+			sbom := trivy.ConvertSBOMReport(&report)
 
 			// Filter by package name if specified
 			if packageFilter != "" {
@@ -700,6 +740,100 @@ var querySbomCmd = &cobra.Command{
 	},
 }
 
+var queryPackagesCmd = &cobra.Command{
+	Use:   "packages [name]",
+	Short: "Show which workloads contain a package",
+	Long: `Search the SBOM reports of the whole cluster for a package, by name or by
+package URL, and list the workloads and images that contain it.
+
+Examples:
+  trix query packages log4j-core
+  trix query packages --purl pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(args) == 0) == (purlFilter == "") {
+			fmt.Println("Error: specify either a package name or --purl")
+			return
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		ctx := context.Background()
+
+		inventory, err := trivyClient.BuildPackageInventory(ctx)
+		if err != nil && !partialResults(err) {
+			fmt.Printf("Error listing SBOM reports: %v\n", err)
+			return
+		}
+
+		var usages []trivy.PackageUsage
+		query := purlFilter
+		if purlFilter != "" {
+			usages = inventory.FindPURL(purlFilter)
+		} else {
+			query = args[0]
+			usages = inventory.FindPackage(query)
+		}
+
+		if structuredOutput() {
+			printStructured(usages)
+			return
+		}
+
+		if len(usages) == 0 {
+			fmt.Printf("No workloads contain '%s' (searched %d SBOM reports)\n", query, len(inventory.Reports))
+			return
+		}
+
+		// Text output
+		table := ui.NewTable("Workload", "Container", "Image", "Package", "Version")
+		for _, u := range usages {
+			workload := u.Kind + "/" + u.Workload
+			if u.Namespace != "" {
+				workload = u.Namespace + "/" + workload
+			}
+			table.AddRow(workload, u.Container, u.Image, u.Package.Name, u.Package.Version)
+		}
+		header := fmt.Sprintf("Workloads containing %s (%d)", query, len(usages))
+		fmt.Println(ui.Box(header, table.Render(), 120))
+	},
+}
+
+// printUniqueVulnerabilities lists each CVE once per image and package, with
+// the workloads it affects
+func printUniqueVulnerabilities(reports []v1alpha1.VulnerabilityReport) {
+	dedup := aggregate.NewDeduplicator()
+	occurrences := 0
+	for _, report := range reports {
+		for _, v := range trivy.ConvertVulnerabilities(&report) {
+			dedup.AddVulnerability(v, report.Namespace+"/"+report.Name)
+			occurrences++
+		}
+	}
+	vulns := dedup.Vulnerabilities()
+
+	if structuredOutput() {
+		printStructured(vulns)
+		return
+	}
+
+	table := ui.NewTable("Severity", "CVE", "Package", "Image", "Workloads")
+	for _, v := range vulns {
+		table.AddRow(v.Severity, v.VulnerabilityID, v.PkgName+" "+v.InstalledVersion, v.Image, fmt.Sprintf("%d", len(v.Workloads)))
+	}
+	header := fmt.Sprintf("Unique vulnerabilities (%d, %d occurrences)", len(vulns), occurrences)
+	fmt.Println(ui.Box(header, table.Render(), 140))
+}
+
 func init() {
 	rootCmd.AddCommand(queryCmd)
 	queryCmd.AddCommand(queryVulnsCmd)
@@ -708,13 +842,163 @@ func init() {
 	queryCmd.AddCommand(querySbomCmd)
 	queryCmd.AddCommand(querySummaryCmd)
 	queryCmd.AddCommand(queryNetworkCmd)
+	queryCmd.AddCommand(queryRbacCmd)
+	queryCmd.AddCommand(queryBenchmarkCmd)
+	queryCmd.AddCommand(queryPackagesCmd)
 
 	// Global flag for all query subcommands
-	queryCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "default", "Kubernetes namespace")
-	queryCmd.PersistentFlags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Query across all namespaces")
-	queryCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "Output format (json)")
+	addScanFlags(queryCmd)
+	queryCmd.PersistentFlags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+	queryCmd.PersistentFlags().StringSliceVar(&cveIDs, "cve", nil, "Only include these CVEs (repeatable)")
+	queryCmd.PersistentFlags().BoolVar(&fixedOnly, "fixable", false, "Only include vulnerabilities with a fixed version")
+	queryCmd.PersistentFlags().BoolVar(&fixedOnly, "fixed-only", false, "Only include vulnerabilities with a fixed version")
+	_ = queryCmd.PersistentFlags().MarkDeprecated("fixed-only", "use --fixable instead")
 	querySbomCmd.Flags().StringVar(&packageFilter, "package", "", "Filter by package name")
 	querySbomCmd.Flags().BoolVarP(&showDetails, "details", "d", false, "Show all components")
+	queryPackagesCmd.Flags().StringVar(&purlFilter, "purl", "", "Find the package by package URL (version optional)")
 	queryVulnsCmd.Flags().BoolVarP(&showDetails, "details", "d", false, "Show detailed CVE information")
+	queryVulnsCmd.Flags().BoolVar(&uniqueVulns, "unique", false, "List each CVE once per image and package, with the affected workloads")
+	queryRbacCmd.Flags().IntVar(&topRoles, "top", 3, "Number of roles to show per namespace")
+	queryRbacCmd.Flags().BoolVarP(&showDetails, "details", "d", false, "Show the failed checks of each role")
+	queryBenchmarkCmd.Flags().StringVar(&controlID, "control", "", "Show the resources failing this control (e.g. 5.2.2)")
+	queryBenchmarkCmd.Flags().BoolVarP(&showDetails, "details", "d", false, "Show the resources failing each control")
 	queryFindingsCmd.Flags().BoolVar(&showFull, "full", false, "Include full RawData in JSON output")
 }
+
+// allScanners returns every scanner, namespaced and cluster-scoped
+func allScanners(trivyClient *trivy.Client) []trivy.Scanner {
+	return []trivy.Scanner{
+		// Namespaced scanners
+		trivy.NewTrivyVulnScanner(trivyClient),
+		trivy.NewTrivyComplianceScanner(trivyClient),
+		trivy.NewTrivySecretScanner(trivyClient),
+		trivy.NewTrivyRbacScanner(trivyClient),
+		trivy.NewTrivyInfraScanner(trivyClient),
+		// Cluster-scoped scanners
+		trivy.NewClusterVulnScanner(trivyClient),
+		trivy.NewClusterComplianceScanner(trivyClient),
+		trivy.NewClusterRbacScanner(trivyClient),
+		trivy.NewClusterInfraScanner(trivyClient),
+		// Benchmark scanner (CIS/NSA)
+		trivy.NewBenchmarkScanner(trivyClient),
+	}
+}
+
+// partialResults prints a warning for a partial result and reports whether
+// err left results that can still be shown
+func partialResults(err error) bool {
+	if !trivy.IsPartial(err) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	return true
+}
+
+// scannersFor returns the scanners to use: every report scanner, or when Trivy
+// Operator isn't installed and the trivy binary is, a direct scan of the pod
+// images
+func scannersFor(trivyClient *trivy.Client) []trivy.Scanner {
+	if scanner := imageScanner(trivyClient); scanner != nil {
+		return []trivy.Scanner{scanner}
+	}
+	return allScanners(trivyClient)
+}
+
+// imageScanner returns a direct scanner of the pod images when Trivy Operator
+// isn't installed and the trivy binary is, and nil otherwise
+func imageScanner(trivyClient *trivy.Client) *trivy.ImageScanner {
+	if trivyClient.OperatorInstalled() {
+		return nil
+	}
+	binary, err := trivy.TrivyBinary()
+	if err != nil {
+		return nil
+	}
+	scanner := trivy.NewImageScanner(trivyClient, binary)
+	server, token := trivyServerSettings()
+	scanner.SetServer(server, token)
+	if server != "" {
+		fmt.Fprintf(os.Stderr, "Trivy Operator is not installed; scanning pod images with the Trivy server at %s (vulnerabilities only)\n", server)
+	} else {
+		fmt.Fprintln(os.Stderr, "Trivy Operator is not installed; scanning pod images directly with trivy (vulnerabilities only)")
+	}
+	return scanner
+}
+
+// trivyServerSettings returns the Trivy server and token for direct image
+// scans. Precedence is flag, then environment variable, then config file.
+func trivyServerSettings() (server, token string) {
+	server = trivyServer
+	if server == "" {
+		server = os.Getenv("TRIX_TRIVY_SERVER")
+	}
+	token = os.Getenv("TRIX_TRIVY_TOKEN")
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return server, token
+	}
+	if server == "" {
+		server = cfg.Trivy.Server
+	}
+	if token == "" {
+		token = cfg.Trivy.Token
+	}
+	return server, token
+}
+
+// explainEmpty checks the operator after a query found nothing and prints
+// any problems, so a missing or broken operator isn't mistaken for a clean
+// cluster
+func explainEmpty(ctx context.Context, trivyClient *trivy.Client) {
+	for _, problem := range trivyClient.CheckOperatorHealth(ctx).Problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+	}
+}
+
+// addScanFlags registers the flags newTrivyClient scopes the reports by, for
+// every command that reads them. They are persistent so subcommands, such as
+// those of query, inherit them.
+func addScanFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&labelSelector, "selector", "l", "", "Only include reports matching this label selector (e.g. app=payments)")
+	cmd.PersistentFlags().StringVar(&fieldSelector, "field-selector", "", "Only include reports matching this field selector")
+	cmd.PersistentFlags().Int64Var(&chunkSize, "chunk-size", trivy.DefaultPageSize, "Fetch reports in pages of this size; 0 fetches all at once")
+	cmd.PersistentFlags().IntVar(&concurrency, "concurrency", 1, "Read this many namespaces in parallel; above 1, -A lists each namespace separately")
+}
+
+// newTrivyClient creates a Trivy client scoped by the selector and filter flags
+func newTrivyClient(k8sClient *kubectl.Client) (*trivy.Client, error) {
+	trivyClient := trivy.NewClient(k8sClient)
+	trivyClient.SetPageSize(chunkSize)
+	trivyClient.SetConcurrency(concurrency)
+	if err := trivyClient.SetSelector(labelSelector, fieldSelector); err != nil {
+		return nil, err
+	}
+	filter := trivy.FilterOptions{
+		MinSeverity: trivy.Severity(minSeverity),
+		Severities:  severityFilter(),
+		CVEIDs:      cveIDs,
+		FixedOnly:   fixedOnly,
+		Namespaces:  filterNamespaces,
+		Exclude:     excludeNamespaces,
+	}
+	if len(vexDocuments) > 0 {
+		set, err := vex.Load(context.Background(), vexDocuments...)
+		if err != nil {
+			return nil, err
+		}
+		filter.Suppress = set
+	}
+	rules, err := loadIgnoreRules()
+	if err != nil {
+		return nil, err
+	}
+	if rules != nil {
+		filter.Ignore = rules
+	}
+	if err := trivyClient.SetFilter(filter); err != nil {
+		return nil, err
+	}
+	return trivyClient, nil
+}
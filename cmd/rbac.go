@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/prompt"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rbacFocus        string
+	rbacExplain      bool
+	rbacExplainLimit int
+)
+
+// RBACFinding is a failed RBAC check of a role, or a binding to cluster-admin
+type RBACFinding struct {
+	Risk        string   `json:"risk,omitempty"` // cluster-admin, wildcard or secrets
+	Severity    string   `json:"severity"`
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Namespace   string   `json:"namespace,omitempty"` // Empty for cluster-scoped roles and bindings
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Subjects    []string `json:"subjects,omitempty"` // Of bindings
+	Description string   `json:"description,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	Explanation string   `json:"explanation,omitempty"`
+}
+
+var rbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Show RBAC risks: cluster-admin bindings, wildcard verbs and secrets access",
+	Long: `Show the failed checks of Trivy's RBAC assessment reports, with the riskiest
+first: bindings to cluster-admin, roles with wildcard verbs or resources, and
+roles that can read or change Secrets. Bindings to cluster-admin are read from
+the cluster directly, since Trivy assesses roles rather than bindings.
+ClusterRoles and ClusterRoleBindings are included with -A.
+
+With --explain the LLM explains each finding to the team owning the role: what
+it allows, how it could be abused and how to narrow it down. It is configured
+as for 'trix ask' (--provider, --model, the config file and the provider's API
+key variable).
+
+Examples:
+  trix rbac -A
+  trix rbac -A --focus secrets
+  trix rbac -n payments --explain`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch rbacFocus {
+		case "", trivy.RBACRiskClusterAdmin, trivy.RBACRiskWildcard, trivy.RBACRiskSecrets:
+		default:
+			fmt.Printf("Error: unknown focus %q (expected cluster-admin, wildcard or secrets)\n", rbacFocus)
+			return
+		}
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		roles, err := trivyClient.AssessRoles(ctx, ns)
+		if err != nil && !partialResults(err) {
+			fmt.Printf("Error listing RBAC assessment reports: %v\n", err)
+			return
+		}
+		bindings, err := k8sClient.ListClusterAdminBindings(ctx, ns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		var findings []RBACFinding
+		for _, f := range rbacFindings(roles, bindings) {
+			if rbacFocus == "" || f.Risk == rbacFocus {
+				findings = append(findings, f)
+			}
+		}
+
+		if rbacExplain && len(findings) > 0 {
+			if !explainRBACFindings(cmd, findings) {
+				return
+			}
+		}
+
+		if structuredOutput() {
+			if findings == nil {
+				findings = []RBACFinding{}
+			}
+			printStructured(findings)
+			return
+		}
+		if len(findings) == 0 {
+			fmt.Println("No RBAC findings.")
+			return
+		}
+		fmt.Println(formatRBACFindings(findings))
+		if rbacExplain {
+			printRBACExplanations(findings)
+		}
+	},
+}
+
+// rbacFindings turns failed role checks and cluster-admin bindings into
+// findings: those with a risk first, then by severity
+func rbacFindings(roles []trivy.RoleAssessment, bindings []kubectl.AdminBinding) []RBACFinding {
+	var findings []RBACFinding
+	for _, b := range bindings {
+		severity := trivy.SeverityHigh // cluster-admin in one namespace
+		if b.Kind == "ClusterRoleBinding" {
+			severity = trivy.SeverityCritical
+		}
+		findings = append(findings, RBACFinding{
+			Risk:        trivy.RBACRiskClusterAdmin,
+			Severity:    string(severity),
+			ID:          "cluster-admin-binding",
+			Title:       "Grants cluster-admin to " + strings.Join(b.Subjects, ", "),
+			Namespace:   b.Namespace,
+			Kind:        b.Kind,
+			Name:        b.Name,
+			Subjects:    b.Subjects,
+			Description: "cluster-admin allows every action on every resource. Subjects bound to it can read all Secrets and take over the cluster.",
+			Remediation: "Bind a role granting only the verbs and resources the subjects need.",
+		})
+	}
+	for _, role := range roles {
+		for _, c := range role.Checks {
+			findings = append(findings, RBACFinding{
+				Risk:        trivy.RBACRisk(c.CheckID),
+				Severity:    c.Severity,
+				ID:          c.CheckID,
+				Title:       c.Title,
+				Namespace:   role.Namespace,
+				Kind:        role.Kind,
+				Name:        role.Name,
+				Description: c.Description,
+				Remediation: c.Remediation,
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if (a.Risk != "") != (b.Risk != "") {
+			return a.Risk != ""
+		}
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return findings
+}
+
+// explainRBACFindings has the LLM explain the first rbacExplainLimit findings.
+// It reports false when the LLM failed, after printing the error.
+func explainRBACFindings(cmd *cobra.Command, findings []RBACFinding) bool {
+	client, err := newLLMClient(cmd)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	ctx := context.Background()
+	n := min(len(findings), rbacExplainLimit)
+	for i := range findings[:n] {
+		f := &findings[i]
+		fmt.Fprintf(os.Stderr, "Explaining %d/%d: %s on %s/%s...\n", i+1, n, f.ID, f.Kind, f.Name)
+		text, err := prompt.Render("explain", prompt.Finding{
+			ID:           f.ID,
+			Title:        f.Title,
+			Severity:     f.Severity,
+			Namespace:    f.Namespace,
+			ResourceKind: f.Kind,
+			ResourceName: f.Name,
+			Description:  f.Description,
+			Remediation:  f.Remediation,
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return false
+		}
+		resp, err := client.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: text}}, nil)
+		if err != nil {
+			printLLMError(err)
+			return false
+		}
+		f.Explanation = resp.Content
+	}
+	if len(findings) > n {
+		fmt.Fprintf(os.Stderr, "Explained the first %d of %d findings (--explain-limit)\n", n, len(findings))
+	}
+	return true
+}
+
+// formatRBACFindings renders the risk counts and the findings as a table
+func formatRBACFindings(findings []RBACFinding) string {
+	risks := make(map[string]map[string]bool) // Risk to the roles and bindings with it
+	table := ui.NewTable("Severity", "Risk", "Namespace", "Role / Binding", "Check")
+	for _, f := range findings {
+		resource := f.Kind + "/" + f.Name
+		if f.Risk != "" {
+			if risks[f.Risk] == nil {
+				risks[f.Risk] = make(map[string]bool)
+			}
+			risks[f.Risk][f.Namespace+"/"+resource] = true
+		}
+		risk, ns := f.Risk, f.Namespace
+		if risk == "" {
+			risk = "-"
+		}
+		if ns == "" {
+			ns = "(cluster)"
+		}
+		table.AddRow(f.Severity, risk, ns, resource, f.ID+": "+f.Title)
+	}
+	summary := fmt.Sprintf("Roles and bindings with cluster-admin: %d, wildcard verbs: %d, secrets access: %d\n\n",
+		len(risks[trivy.RBACRiskClusterAdmin]), len(risks[trivy.RBACRiskWildcard]), len(risks[trivy.RBACRiskSecrets]))
+	return ui.Box(fmt.Sprintf("RBAC Findings (%d)", len(findings)), summary+table.Render(), 160)
+}
+
+// printRBACExplanations prints the LLM's explanation of each finding
+func printRBACExplanations(findings []RBACFinding) {
+	renderer, _ = glamour.NewTermRenderer(markdownStyle(), glamour.WithWordWrap(100))
+	for _, f := range findings {
+		if f.Explanation == "" {
+			continue
+		}
+		title := fmt.Sprintf("%s on %s/%s", f.ID, f.Kind, f.Name)
+		if f.Namespace != "" {
+			title += " in " + f.Namespace
+		}
+		fmt.Println(ui.Section(title))
+		printResponse(f.Explanation)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rbacCmd)
+	rbacCmd.Flags().StringVar(&rbacFocus, "focus", "", "Only show one risk: cluster-admin, wildcard or secrets")
+	rbacCmd.Flags().BoolVar(&rbacExplain, "explain", false, "Have the LLM explain each finding")
+	rbacCmd.Flags().IntVar(&rbacExplainLimit, "explain-limit", 10, "Findings to explain with --explain, riskiest first")
+	addScanFlags(rbacCmd)
+	addLLMFlags(rbacCmd)
+}
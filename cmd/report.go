@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/prompt"
+	"github.com/davealtena/trix/internal/report"
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat   string
+	reportOut      string
+	reportBaseline string
+	reportTitle    string
+	reportTop      int
+	reportAI       bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write a security report for a security review, in Markdown or HTML",
+	Long: `Run all scanners and write a report with an executive summary, severity
+trends, the top risks and a remediation plan, for attaching to a security
+review.
+
+Trends compare against a baseline saved with 'trix snapshot' (--baseline);
+without one the report shows the current counts. With --ai the LLM writes the
+prose of the summary, risks and plan next to the numbers; it is configured as
+for 'trix ask' (--provider, --model, the config file and the provider's API
+key variable).
+
+Examples:
+  trix report -A --out report.md
+  trix report -A --format html --out report.html --baseline baseline.json
+  trix report -n payments --ai --format html --out payments.html`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportFormat != "markdown" && reportFormat != "html" {
+			fmt.Printf("Error: unknown format %q (expected markdown or html)\n", reportFormat)
+			return
+		}
+		var baseline *snapshot.Snapshot
+		if reportBaseline != "" {
+			var err error
+			if baseline, err = snapshot.Load(reportBaseline); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		ctx := context.Background()
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+		}
+
+		ns := resolveNamespace()
+		r := report.New(scanFindings(ctx, trivyClient, ns), report.Options{
+			Title:     reportTitle,
+			Version:   Version,
+			Context:   currentCtx,
+			Namespace: ns,
+			Time:      time.Now(),
+			Baseline:  baseline,
+			Top:       reportTop,
+		})
+
+		if reportAI {
+			text, err := prompt.Render("report", prompt.Report{Scope: r.Scope(), Facts: r.Facts()})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			client, err := newLLMClient(cmd)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Fprintln(os.Stderr, "Writing the report prose...")
+			resp, err := client.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: text}}, nil)
+			if err != nil {
+				printLLMError(err)
+				return
+			}
+			r.Narrative = report.ParseNarrative(resp.Content)
+		}
+
+		content := r.Markdown()
+		if reportFormat == "html" {
+			if content, err = r.HTML(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		if reportOut == "" || reportOut == "-" {
+			fmt.Print(content)
+			return
+		}
+		if err := os.WriteFile(reportOut, []byte(content), 0o644); err != nil {
+			fmt.Printf("Error writing report: %v\n", err)
+			return
+		}
+		fmt.Printf("Wrote the report to %s\n", reportOut)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "markdown", "Report format: markdown or html")
+	reportCmd.Flags().StringVar(&reportOut, "out", "", "File to write (default stdout)")
+	reportCmd.Flags().StringVar(&reportBaseline, "baseline", "", "Snapshot saved with 'trix snapshot' to show trends against")
+	reportCmd.Flags().StringVar(&reportTitle, "title", "", "Report title (default \"Security Report: <context>\")")
+	reportCmd.Flags().IntVar(&reportTop, "top", 10, "Entries per top-risk table")
+	reportCmd.Flags().BoolVar(&reportAI, "ai", false, "Have the LLM write the prose of the summary, risks and plan")
+	addScanFlags(reportCmd)
+	addLLMFlags(reportCmd)
+}
@@ -15,6 +15,7 @@ and compliance issues using Trivy and custom CIS checks.`,
 }
 
 func Execute() {
+	registerCompletions(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
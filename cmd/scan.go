@@ -8,14 +8,11 @@ import (
 	"strings"
 
 	"github.com/davealtena/trix/internal/tools/kubectl"
-	"github.com/davealtena/trix/internal/tools/trivy"
 	"github.com/spf13/cobra"
 )
 
 var (
-	scanYes           bool
-	scanAllNamespaces bool
-	scanNamespace     string
+	scanYes bool
 )
 
 var scanCmd = &cobra.Command{
@@ -90,20 +87,24 @@ var scanAllCmd = &cobra.Command{
 }
 
 func runScan(scanType string) {
+	if !requireCluster("scan") {
+		return
+	}
 	k8sClient, err := kubectl.NewClient()
 	if err != nil {
 		fmt.Printf("Error creating k8s client: %v\n", err)
 		return
 	}
-	trivyClient := trivy.NewClient(k8sClient)
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
 
 	ctx := context.Background()
 
 	// Determine namespace
-	ns := scanNamespace
-	if scanAllNamespaces {
-		ns = ""
-	}
+	ns := resolveNamespace()
 
 	// Count reports first
 	counts, err := trivyClient.CountAllReports(ctx, ns)
@@ -149,11 +150,11 @@ func runScan(scanType string) {
 	}
 
 	// Show what will be deleted
-	nsDisplay := scanNamespace
-	if scanAllNamespaces {
-		nsDisplay = "all namespaces"
+	currentCtx, err := k8sClient.GetCurrentContext()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
 	}
-	fmt.Printf("This will delete %d %s in %s and trigger Trivy rescans.\n", toDelete, description, nsDisplay)
+	fmt.Printf("This will delete %d %s in %s (context %s) and trigger Trivy rescans.\n", toDelete, description, namespaceScope(), currentCtx)
 
 	// Confirm unless --yes flag
 	if !scanYes {
@@ -227,6 +228,5 @@ func init() {
 
 	// Flags for scan command
 	scanCmd.PersistentFlags().BoolVarP(&scanYes, "yes", "y", false, "Skip confirmation prompt")
-	scanCmd.PersistentFlags().BoolVarP(&scanAllNamespaces, "all-namespaces", "A", false, "Scan across all namespaces")
-	scanCmd.PersistentFlags().StringVarP(&scanNamespace, "namespace", "n", "default", "Kubernetes namespace")
+	addScanFlags(scanCmd)
 }
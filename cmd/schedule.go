@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/davealtena/trix/internal/config"
+	"github.com/davealtena/trix/internal/history"
+	"github.com/davealtena/trix/internal/schedule"
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleCron        string
+	scheduleMinSeverity string
+	scheduleChannels    []string
+	scheduleLimit       int
+	scheduleSendEmpty   bool
+	scheduleRunNow      bool
+)
+
+// scheduledJob is a job and its parsed cron expression
+type scheduledJob struct {
+	config.Job
+	cron *schedule.Cron
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run scans on a cron schedule, recording history and sending notifications",
+	Long: `Run continuously, e.g. as a Deployment in the cluster, and scan on cron
+schedules. Every scan is recorded in the history (see 'trix history') and
+its summary sent to the job's notify channels (see 'trix notify'). A job's
+min_severity only applies to what it sends; the history records everything.
+
+Jobs are configured in the config file, which in the cluster is a mounted
+ConfigMap with TRIX_CONFIG pointing at it. The file is read again every
+minute, so editing the ConfigMap takes effect without a restart:
+
+  schedule:
+    jobs:
+      - name: nightly
+        cron: "0 2 * * *"
+        channels: [slack]
+        min_severity: HIGH
+      - name: payments
+        cron: "*/30 * * * *"
+        namespace: payments
+        channels: [security-team]
+  notify:
+    channels:
+      slack:
+        type: slack
+        url: ${SLACK_WEBHOOK_URL}
+
+A job without a namespace scans all namespaces. A single job can also be
+given with flags: --cron with -n or -A, --min-severity and --channel.
+Like min_severity, --min-severity only applies to what the job sends;
+--severity and TRIX_MIN_SEVERITY are ignored, so the history stays complete.
+
+Cron expressions have five fields (minute hour day-of-month month
+day-of-week) in the local time zone (TZ), or are a shorthand such as @daily.
+A scan that runs past a job's next time delays it rather than overlapping.
+
+Examples:
+  trix schedule
+  trix schedule --cron "0 6 * * 1-5" -A --min-severity high --channel slack
+  trix schedule --run-now`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !requireCluster("schedule") {
+			return
+		}
+		// The scans record every finding; thresholds only apply to what
+		// the jobs send
+		minSeverity, severities = "", nil
+		cfg, jobs, err := loadScheduledJobs()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(jobs) == 0 {
+			fmt.Printf("Error: no jobs to run; use --cron, or configure schedule.jobs in %s\n", config.Path())
+			return
+		}
+
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get context: %v\n", err)
+		}
+		if currentCtx == "" {
+			currentCtx = "in-cluster"
+		}
+		fmt.Printf("Using context: %s\n", currentCtx)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		printScheduledJobs(jobs, time.Now())
+		if scheduleRunNow {
+			for _, job := range jobs {
+				runScheduledJob(ctx, k8sClient, currentCtx, job, cfg.Notify.Channels)
+			}
+		}
+
+		last := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(last.Truncate(time.Minute).Add(time.Minute))):
+			}
+			now := time.Now()
+
+			reloaded, reloadedJobs, err := loadScheduledJobs()
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "Warning: keeping the previous jobs: %v\n", err)
+			case !reflect.DeepEqual(reloaded, cfg):
+				cfg, jobs = reloaded, reloadedJobs
+				fmt.Printf("%s  Configuration changed\n", now.Format(time.DateTime))
+				printScheduledJobs(jobs, now)
+			}
+
+			for _, job := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				if next := job.cron.Next(last); !next.IsZero() && !next.After(now) {
+					runScheduledJob(ctx, k8sClient, currentCtx, job, cfg.Notify.Channels)
+				}
+			}
+			last = now
+		}
+	},
+}
+
+// loadScheduledJobs reads the config file and returns it with the jobs in it
+// and the one given with --cron, checking that each can run
+func loadScheduledJobs() (*config.Config, []scheduledJob, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	configured := cfg.Schedule.Jobs
+	if scheduleCron != "" {
+		ns := resolveNamespace()
+		configured = append(configured, config.Job{
+			Name:        "flags",
+			Cron:        scheduleCron,
+			Namespace:   ns,
+			MinSeverity: scheduleMinSeverity,
+			Channels:    scheduleChannels,
+			Limit:       scheduleLimit,
+			SendEmpty:   scheduleSendEmpty,
+		})
+	}
+
+	var jobs []scheduledJob
+	names := make(map[string]bool)
+	for i, job := range configured {
+		if job.Name == "" {
+			job.Name = fmt.Sprintf("job-%d", i+1)
+		}
+		if names[job.Name] {
+			return nil, nil, fmt.Errorf("more than one job is named %s", job.Name)
+		}
+		names[job.Name] = true
+
+		cron, err := schedule.Parse(job.Cron)
+		if err != nil {
+			return nil, nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		if job.MinSeverity != "" {
+			sev, err := trivy.ParseSeverity(job.MinSeverity)
+			if err != nil {
+				return nil, nil, fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			job.MinSeverity = string(sev)
+		}
+		if job.Limit <= 0 {
+			job.Limit = 10
+		}
+		for _, name := range job.Channels {
+			if _, err := notifySender(name, cfg.Notify.Channels); err != nil {
+				return nil, nil, fmt.Errorf("job %s: %w", job.Name, err)
+			}
+		}
+		jobs = append(jobs, scheduledJob{Job: job, cron: cron})
+	}
+	return cfg, jobs, nil
+}
+
+// printScheduledJobs prints each job with its schedule and next run
+func printScheduledJobs(jobs []scheduledJob, now time.Time) {
+	for _, job := range jobs {
+		next := "never"
+		if t := job.cron.Next(now); !t.IsZero() {
+			next = t.Format(time.DateTime)
+		}
+		fmt.Printf("Job %s: %s in %s, next at %s\n", job.Name, job.cron, scheduleScope(job.Namespace), next)
+	}
+}
+
+// runScheduledJob scans, records the findings in the history and sends them
+// to the job's channels. Failures are logged, so the next run still happens.
+func runScheduledJob(ctx context.Context, k8sClient *kubectl.Client, contextName string, job scheduledJob, channels map[string]config.Channel) {
+	logf := func(w *os.File, format string, args ...any) {
+		fmt.Fprintf(w, "%s  %s: %s\n", time.Now().Format(time.DateTime), job.Name, fmt.Sprintf(format, args...))
+	}
+	logf(os.Stdout, "scanning %s", scheduleScope(job.Namespace))
+
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		logf(os.Stderr, "Error: %v", err)
+		return
+	}
+	findings := scanFindings(ctx, trivyClient, job.Namespace)
+	if ctx.Err() != nil {
+		return
+	}
+
+	dir, err := historyContextDir(contextName)
+	if err == nil {
+		var path string
+		if path, err = history.Record(dir, snapshot.New(contextName, job.Namespace, findings)); err == nil {
+			logf(os.Stdout, "recorded %d findings to %s", len(findings), path)
+		}
+	}
+	if err != nil {
+		logf(os.Stderr, "Error recording history: %v", err)
+	}
+
+	// The history has every finding, so its counts compare across jobs;
+	// only the notifications leave out those below the job's min severity
+	if job.MinSeverity != "" {
+		var kept []trivy.Finding
+		for _, f := range findings {
+			if f.Severity.Rank() >= trivy.Severity(job.MinSeverity).Rank() {
+				kept = append(kept, f)
+			}
+		}
+		findings = kept
+	}
+	if len(job.Channels) == 0 || len(findings) == 0 && !job.SendEmpty {
+		return
+	}
+	summary := notifySummary(findings, job.Limit)
+	summary.Context = contextName
+	summary.Namespace = job.Namespace
+	summary.MinSeverity = job.MinSeverity
+	for _, name := range job.Channels {
+		sender, err := notifySender(name, channels)
+		if err == nil {
+			err = sender.Send(ctx, summary)
+		}
+		if err != nil {
+			logf(os.Stderr, "Error sending to %s: %v", name, err)
+			continue
+		}
+		logf(os.Stdout, "sent %d findings to %s", summary.Total, name)
+	}
+}
+
+// scheduleScope describes the namespace a job scans
+func scheduleScope(namespace string) string {
+	if namespace == "" {
+		return "all namespaces"
+	}
+	return "namespace " + namespace
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.Flags().StringVar(&scheduleCron, "cron", "", "Run a job on this cron schedule, besides those in the config file (e.g. \"0 6 * * *\" or @daily)")
+	scheduleCmd.Flags().StringVar(&scheduleMinSeverity, "min-severity", "", "Leave out findings below this severity in what the --cron job sends (CRITICAL, HIGH, MEDIUM, LOW)")
+	_ = scheduleCmd.RegisterFlagCompletionFunc("min-severity", completeSeverities)
+	scheduleCmd.Flags().StringSliceVar(&scheduleChannels, "channel", nil, "Channel the --cron job sends to: one configured in the config file, slack or webhook (repeatable)")
+	scheduleCmd.Flags().IntVar(&scheduleLimit, "limit", 10, "Findings to list in the --cron job's messages")
+	scheduleCmd.Flags().BoolVar(&scheduleSendEmpty, "send-empty", false, "Also send when the --cron job finds nothing")
+	scheduleCmd.Flags().BoolVar(&scheduleRunNow, "run-now", false, "Run every job once at startup too")
+	scheduleCmd.Flags().StringVar(&historyDir, "history-dir", "", "History directory (default TRIX_HISTORY_DIR or ~/.local/share/trix/history)")
+	addScanFlags(scheduleCmd)
+	scheduleCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace         string
+	allNamespaces     bool
+	filterNamespaces  []string
+	excludeNamespaces []string
+)
+
+// applyNamespaceFlags checks the namespace flags every command shares.
+// --namespaces picks the namespaces itself, and --exclude-namespaces scopes
+// -A unless -n names a namespace. Exclusions are passed on through
+// TRIX_EXCLUDE_NAMESPACES, so the commands 'trix ask' runs skip them too.
+func applyNamespaceFlags() {
+	for _, pattern := range excludeNamespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --exclude-namespaces pattern %q: %v\n", pattern, err)
+			os.Exit(1)
+		}
+	}
+	if len(filterNamespaces) > 0 {
+		allNamespaces = true
+	}
+	if len(excludeNamespaces) > 0 {
+		if !rootCmd.PersistentFlags().Changed("namespace") {
+			allNamespaces = true
+		}
+		_ = os.Setenv("TRIX_EXCLUDE_NAMESPACES", strings.Join(excludeNamespaces, ","))
+	} else if env := os.Getenv("TRIX_EXCLUDE_NAMESPACES"); env != "" {
+		excludeNamespaces = strings.Split(env, ",")
+	}
+}
+
+// resolveNamespace returns the namespace of -n, or "" for all namespaces
+func resolveNamespace() string {
+	if allNamespaces {
+		return ""
+	}
+	return namespace
+}
+
+// explicitNamespace returns the namespace of -n only if it was given, for
+// commands that cover all namespaces by default
+func explicitNamespace() string {
+	if !rootCmd.PersistentFlags().Changed("namespace") {
+		return ""
+	}
+	return resolveNamespace()
+}
+
+// inNamespaceScope reports whether a namespace is one --namespaces and
+// --exclude-namespaces keep
+func inNamespaceScope(ns string) bool {
+	if len(filterNamespaces) > 0 && !slices.Contains(filterNamespaces, ns) {
+		return false
+	}
+	for _, pattern := range excludeNamespaces {
+		if ok, _ := path.Match(pattern, ns); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// namespaceScope describes the namespaces the flags select, for messages
+func namespaceScope() string {
+	switch {
+	case len(filterNamespaces) > 0:
+		return "namespaces " + strings.Join(filterNamespaces, ", ")
+	case !allNamespaces:
+		return "namespace " + namespace
+	case len(excludeNamespaces) > 0:
+		return "all namespaces except " + strings.Join(excludeNamespaces, ", ")
+	}
+	return "all namespaces"
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "default", "Kubernetes namespace")
+	rootCmd.PersistentFlags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Across all namespaces")
+	rootCmd.PersistentFlags().StringSliceVar(&filterNamespaces, "namespaces", nil, "Only these namespaces (comma-separated; implies -A)")
+	rootCmd.PersistentFlags().StringSliceVar(&excludeNamespaces, "exclude-namespaces", nil, "Skip namespaces matching these patterns, e.g. 'kube-*' (comma-separated; implies -A unless -n is set)")
+
+	cobra.OnInitialize(applyNamespaceFlags)
+}
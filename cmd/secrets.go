@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsFailOnAny bool
+)
+
+// SecretTypeCount is how often one type of secret was found, and in how many
+// workloads
+type SecretTypeCount struct {
+	Type      string `json:"type"`
+	Count     int    `json:"count"`
+	Workloads int    `json:"workloads"`
+}
+
+// SecretsResult is the output of 'trix secrets'
+type SecretsResult struct {
+	Total   int                    `json:"total"`
+	ByType  []SecretTypeCount      `json:"byType"`
+	Secrets []trivy.SecretLocation `json:"secrets"`
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Summarize secrets exposed in container images",
+	Long: `Summarize the ExposedSecretReports of Trivy Operator: which types of secret
+(AWS keys, tokens, private keys, ...) were found, and in which workload, image
+and file.
+
+Secret values are never shown, in any output format: rotate the secret and
+inspect the file in the image to see it.
+
+With --fail-on-any, trix exits with status 1 when any secret is found, and
+with status 2 when the reports can't be read, for use in CI.
+
+Examples:
+  trix secrets -A
+  trix secrets -n payments -o json
+  trix secrets -A --fail-on-any`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fail := func(format string, a ...any) {
+			fmt.Printf(format, a...)
+			if secretsFailOnAny {
+				os.Exit(2)
+			}
+		}
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fail("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fail("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		locations, err := trivyClient.ListSecretLocations(ctx, ns)
+		if err != nil && !partialResults(err) {
+			fail("Error listing exposed secrets reports: %v\n", err)
+			return
+		}
+		sort.Slice(locations, func(i, j int) bool {
+			a, b := locations[i], locations[j]
+			if a.Severity != b.Severity {
+				return trivy.Severity(a.Severity).Rank() > trivy.Severity(b.Severity).Rank()
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			if a.Workload != b.Workload {
+				return a.Workload < b.Workload
+			}
+			return a.Target < b.Target
+		})
+		result := SecretsResult{Total: len(locations), ByType: countSecretTypes(locations), Secrets: locations}
+		if result.Secrets == nil {
+			result.Secrets = []trivy.SecretLocation{}
+		}
+
+		if structuredOutput() {
+			if !printStructured(result) && secretsFailOnAny {
+				os.Exit(2)
+			}
+		} else if len(locations) == 0 {
+			fmt.Println("No exposed secrets found")
+		} else {
+			fmt.Println(formatSecrets(result))
+		}
+
+		if secretsFailOnAny && len(locations) > 0 {
+			fmt.Fprintf(os.Stderr, "Found %d exposed secrets\n", len(locations))
+			os.Exit(1)
+		}
+	},
+}
+
+// countSecretTypes counts the secrets and workloads per type, most found first
+func countSecretTypes(locations []trivy.SecretLocation) []SecretTypeCount {
+	counts := make(map[string]*SecretTypeCount)
+	workloads := make(map[string]map[string]bool)
+	for _, l := range locations {
+		c, ok := counts[l.Type]
+		if !ok {
+			c = &SecretTypeCount{Type: l.Type}
+			counts[l.Type] = c
+			workloads[l.Type] = make(map[string]bool)
+		}
+		c.Count++
+		workloads[l.Type][l.Namespace+"/"+l.Workload] = true
+	}
+	result := make([]SecretTypeCount, 0, len(counts))
+	for typ, c := range counts {
+		c.Workloads = len(workloads[typ])
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// formatSecrets renders the secret types and where each secret was found
+func formatSecrets(result SecretsResult) string {
+	types := ui.NewTable("Type", "Secrets", "Workloads")
+	for _, t := range result.ByType {
+		types.AddRow(t.Type, fmt.Sprintf("%d", t.Count), fmt.Sprintf("%d", t.Workloads))
+	}
+	locations := ui.NewTable("Severity", "Type", "Namespace", "Workload", "Image", "File")
+	for _, l := range result.Secrets {
+		locations.AddRow(l.Severity, l.Type, l.Namespace, l.Workload, l.Image, l.Target)
+	}
+	content := types.Render() + "\n" + ui.Section("Locations") + "\n" + locations.Render() +
+		"\n" + ui.Muted.Render("Values are redacted. Rotate each secret, then remove it from the image.")
+	return ui.Box(fmt.Sprintf("Exposed Secrets (%d)", result.Total), content, 160)
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.Flags().BoolVar(&secretsFailOnAny, "fail-on-any", false, "Exit with status 1 when any secret is found")
+	addScanFlags(secretsCmd)
+}
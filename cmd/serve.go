@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/davealtena/trix/internal/server"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr     string
+	serveInterval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve findings over a read-only REST API and web dashboard",
+	Long: `Scan on an interval and serve the results over HTTP, so trix can run in the
+cluster as a shared, read-only security view.
+
+The dashboard at / shows the summary, the severity trends since trix started
+and a searchable findings table. The JSON API has:
+
+  GET /api/v1/summary    Counts by severity, type and namespace, and the risk score
+  GET /api/v1/findings   Findings, most severe first; filter with severity (minimum),
+                         type, namespace and q, page with limit and offset
+  GET /api/v1/trends     Severity counts of each scan
+  GET /healthz, /readyz  Liveness, and readiness once the first scan is done
+
+Raw report data is left out of findings, so matched secret values aren't
+served. In a pod, trix uses its service account; it needs to list the Trivy
+reports and, to name the owning workloads, ReplicaSets, Jobs and Pods.
+
+Examples:
+  trix serve -A
+  trix serve -A --addr :9090 --interval 15m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if serveInterval < time.Minute {
+			fmt.Println("Error: --interval must be at least 1m")
+			return
+		}
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			currentCtx = "in-cluster" // No kubeconfig when running in a pod
+		}
+
+		ns := resolveNamespace()
+		srv := server.New(server.Options{
+			Scan: func(ctx context.Context) []trivy.Finding {
+				return scanFindings(ctx, trivyClient, ns)
+			},
+			Interval:  serveInterval,
+			Context:   currentCtx,
+			Namespace: ns,
+			Version:   Version,
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go srv.Run(ctx)
+
+		httpServer := &http.Server{
+			Addr:              serveAddr,
+			Handler:           srv.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		// ListenAndServe returns as soon as Shutdown starts, so wait for it to
+		// finish draining the open requests
+		shutdown := make(chan error, 1)
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdown <- httpServer.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Printf("Serving on %s, scanning every %s (Ctrl+C to stop)\n", serveAddr, serveInterval)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := <-shutdown; err != nil {
+			fmt.Printf("Error shutting down: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 5*time.Minute, "Time between scans")
+	addScanFlags(serveCmd)
+	serveCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/davealtena/trix/internal/session"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List saved interactive sessions",
+	Long: `List conversations saved by 'trix ask -i'.
+
+Resume one with: trix ask --resume <id> (or --resume last)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sessions, err := session.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No saved sessions.")
+			return
+		}
+
+		table := ui.NewTable("ID", "UPDATED", "MODEL", "QUESTION")
+		for _, s := range sessions {
+			model := s.Model
+			if model == "" {
+				model = s.Provider
+			}
+			table.AddRow(s.ID, s.UpdatedAt.Format("2006-01-02 15:04"), model, s.Title())
+		}
+		fmt.Println(table.Render())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+}
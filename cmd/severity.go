@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	minSeverity string
+	severities  []string
+)
+
+// applySeverityFlags checks --min-severity and --severity and normalizes
+// them to upper case. They're passed on through TRIX_MIN_SEVERITY and
+// TRIX_SEVERITY, so the commands 'trix ask' runs filter the same way.
+func applySeverityFlags() {
+	if minSeverity == "" {
+		minSeverity = os.Getenv("TRIX_MIN_SEVERITY")
+	}
+	if len(severities) == 0 && os.Getenv("TRIX_SEVERITY") != "" {
+		severities = strings.Split(os.Getenv("TRIX_SEVERITY"), ",")
+	}
+
+	if minSeverity != "" {
+		sev, err := trivy.ParseSeverity(minSeverity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --min-severity: %v\n", err)
+			os.Exit(1)
+		}
+		minSeverity = string(sev)
+		_ = os.Setenv("TRIX_MIN_SEVERITY", minSeverity)
+	}
+	for i, s := range severities {
+		sev, err := trivy.ParseSeverity(strings.TrimSpace(s))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --severity: %v\n", err)
+			os.Exit(1)
+		}
+		severities[i] = string(sev)
+	}
+	if len(severities) > 0 {
+		_ = os.Setenv("TRIX_SEVERITY", strings.Join(severities, ","))
+	}
+}
+
+// severityFilter returns the severities of --severity for the trivy filter
+func severityFilter() []trivy.Severity {
+	filter := make([]trivy.Severity, len(severities))
+	for i, s := range severities {
+		filter[i] = trivy.Severity(s)
+	}
+	return filter
+}
+
+// completeSeverities completes the severity names
+func completeSeverities(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"critical", "high", "medium", "low", "unknown"}, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&minSeverity, "min-severity", "", "Leave out findings below this severity: critical, high, medium or low (or TRIX_MIN_SEVERITY)")
+	rootCmd.PersistentFlags().StringSliceVar(&severities, "severity", nil, "Only include findings of these severities, e.g. critical,high (or TRIX_SEVERITY)")
+	_ = rootCmd.RegisterFlagCompletionFunc("min-severity", completeSeverities)
+	_ = rootCmd.RegisterFlagCompletionFunc("severity", completeList(completeSeverities))
+
+	cobra.OnInitialize(applySeverityFlags)
+}
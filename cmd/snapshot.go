@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [file]",
+	Short: "Save the current findings to a file as a baseline",
+	Long: `Run all scanners and save the findings to a file (default trix-snapshot.json).
+A later scan can be compared against it to see new, resolved and changed
+findings, e.g. to fail CI only on regressions.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "trix-snapshot.json"
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Printf("Error getting context: %v\n", err)
+		}
+
+		ns := resolveNamespace()
+
+		findings := scanFindings(context.Background(), trivyClient, ns)
+		snap := snapshot.New(currentCtx, ns, findings)
+		if err := snap.Save(path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Saved %d findings to %s\n", len(snap.Entries), path)
+	},
+}
+
+// scanFindings runs every scanner and returns their findings. Scanners that
+// fail are reported on stderr, so they don't mix with exported output, and
+// skipped.
+func scanFindings(ctx context.Context, trivyClient *trivy.Client, ns string) []trivy.Finding {
+	var findings []trivy.Finding
+	for _, scanner := range scannersFor(trivyClient) {
+		found, err := scanner.Scan(ctx, ns)
+		if err != nil && !partialResults(err) {
+			fmt.Fprintf(os.Stderr, "Error in %s: %v\n", scanner.Name(), err)
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	return findings
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	addScanFlags(snapshotCmd)
+	snapshotCmd.Flags().StringVar(&trivyServer, "trivy-server", "", "Trivy server to scan images with when Trivy Operator isn't installed (or TRIX_TRIVY_SERVER)")
+}
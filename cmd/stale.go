@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var maxReportAge = trivy.DefaultMaxReportAge
+
+var queryStaleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "Find vulnerability reports that may no longer match what is running",
+	Long: `Flag vulnerability reports that are older than --max-age, or whose workload
+runs another image than the one scanned (for example after a new push of a
+mutable tag). Findings from these reports may be out of date.
+
+Rescan them with 'trix scan stale'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ns := resolveNamespace()
+		_, stale, ok := findStaleReports(ns)
+		if !ok {
+			return
+		}
+
+		if structuredOutput() {
+			printStructured(stale)
+			return
+		}
+		if len(stale) == 0 {
+			fmt.Printf("No stale vulnerability reports (max age %s)\n", maxReportAge)
+			return
+		}
+
+		table := ui.NewTable("Report", "Resource", "Image", "Scanned", "Reason")
+		for _, r := range stale {
+			table.AddRow(r.Namespace+"/"+r.Name, r.Resource, r.Image, r.ScannedAt.Format(time.DateTime), strings.Join(r.Reasons, "; "))
+		}
+		fmt.Println(ui.Box(fmt.Sprintf("Stale vulnerability reports (%d)", len(stale)), table.Render(), 160))
+		fmt.Fprintf(os.Stderr, "Warning: findings from these reports may be out of date; rescan them with 'trix scan stale'\n")
+	},
+}
+
+var scanStaleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "Rescan stale vulnerability reports",
+	Long: `Delete the vulnerability reports 'trix query stale' flags, so Trivy Operator
+rescans just those workloads.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !requireCluster("scan") {
+			return
+		}
+		ns := resolveNamespace()
+		trivyClient, stale, ok := findStaleReports(ns)
+		if !ok {
+			return
+		}
+		if len(stale) == 0 {
+			fmt.Printf("No stale vulnerability reports (max age %s)\n", maxReportAge)
+			return
+		}
+
+		for _, r := range stale {
+			fmt.Printf("  %s/%s: %s\n", r.Namespace, r.Name, strings.Join(r.Reasons, "; "))
+		}
+		fmt.Printf("This will delete %d stale vulnerability reports and trigger Trivy rescans.\n", len(stale))
+		if !scanYes {
+			fmt.Print("Continue? [y/N]: ")
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(strings.ToLower(response))
+			if response != "y" && response != "yes" {
+				fmt.Println("Aborted.")
+				return
+			}
+		}
+
+		deleted := 0
+		for _, r := range stale {
+			if err := trivyClient.DeleteVulnerabilityReport(context.Background(), r.Namespace, r.Name); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				continue
+			}
+			deleted++
+		}
+		fmt.Printf("Deleted %d reports. Trivy Operator will rescan automatically.\n", deleted)
+	},
+}
+
+// findStaleReports returns the client and the stale vulnerability reports,
+// printing any error. ok is false if there is nothing to show.
+func findStaleReports(ns string) (*trivy.Client, []trivy.StaleReport, bool) {
+	k8sClient, err := newK8sClient()
+	if err != nil {
+		fmt.Printf("Error creating k8s client: %v\n", err)
+		return nil, nil, false
+	}
+	trivyClient, err := newTrivyClient(k8sClient)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return nil, nil, false
+	}
+	stale, err := trivyClient.StaleReports(context.Background(), ns, maxReportAge)
+	if err != nil && !partialResults(err) {
+		fmt.Printf("Error finding stale reports: %v\n", err)
+		return nil, nil, false
+	}
+	return trivyClient, stale, true
+}
+
+func init() {
+	queryCmd.AddCommand(queryStaleCmd)
+	scanCmd.AddCommand(scanStaleCmd)
+	queryStaleCmd.Flags().DurationVar(&maxReportAge, "max-age", trivy.DefaultMaxReportAge, "Flag reports scanned longer ago than this")
+	scanStaleCmd.Flags().DurationVar(&maxReportAge, "max-age", trivy.DefaultMaxReportAge, "Rescan reports scanned longer ago than this")
+}
@@ -3,8 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/davealtena/trix/internal/tools/kubectl"
 	"github.com/davealtena/trix/internal/tools/trivy"
 	"github.com/spf13/cobra"
 )
@@ -14,7 +15,7 @@ var statusCmd = &cobra.Command{
 	Short: "Check status of security tools in the cluster",
 	Long:  `Verify that Trivy Operator and other security tools are installed and working.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		k8sClient, err := kubectl.NewClient()
+		k8sClient, err := newK8sClient()
 		if err != nil {
 			fmt.Printf("Error creating k8s client: %v\n", err)
 			return
@@ -25,18 +26,37 @@ var statusCmd = &cobra.Command{
 		fmt.Println("Checking security tooling status..")
 
 		// Check Trivy Operator
-		trivyOk, trivyVersion := trivyClient.CheckTrivyOperator(ctx)
-		if trivyOk {
-			fmt.Printf("✅ Trivy Operator: installed (version: %s)\n", trivyVersion)
+		printOperatorHealth(trivyClient.CheckOperatorHealth(ctx))
+	},
+}
 
-			// Simple version check (works for 0.x.y format)
-			if trivyVersion != "unknown" && trivyVersion < trivy.MinTrivyOperatorVersion {
-				fmt.Printf("   ⚠️  Warning: version %s is below minimum %s\n", trivyVersion, trivy.MinTrivyOperatorVersion)
-			}
-		} else {
-			fmt.Printf("❌ Trivy Operator: not found or not working\n")
+// printOperatorHealth prints whether Trivy Operator is healthy, its
+// deployment and reports, and a hint per problem
+func printOperatorHealth(health *trivy.OperatorHealth) {
+	switch {
+	case !health.Installed:
+		fmt.Printf("❌ Trivy Operator: not installed\n")
+	case health.Healthy():
+		fmt.Printf("✅ Trivy Operator: healthy\n")
+	default:
+		fmt.Printf("⚠️  Trivy Operator: installed with problems\n")
+	}
+	if health.Deployment != "" {
+		version := health.Version
+		if version == "" {
+			version = "unknown"
 		}
-	},
+		fmt.Printf("   Deployment: %s/%s (version: %s, ready: %d/%d)\n", health.Namespace, health.Deployment, version, health.Ready, health.Desired)
+	}
+	if health.Installed && len(health.MissingCRDs) > 0 {
+		fmt.Printf("   Missing CRDs: %s\n", strings.Join(health.MissingCRDs, ", "))
+	}
+	if health.Reports > 0 {
+		fmt.Printf("   Vulnerability reports: %d (newest %s ago)\n", health.Reports, time.Since(health.NewestReport).Round(time.Minute))
+	}
+	for _, problem := range health.Problems {
+		fmt.Printf("   → %s\n", problem)
+	}
 }
 
 func init() {
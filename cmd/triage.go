@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/davealtena/trix/internal/enrich"
+	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/prompt"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxTriageExposures caps the workloads whose exposure is checked for triage
+const maxTriageExposures = 25
+
+// triagePriorities are the priorities the LLM assigns, most urgent first
+var triagePriorities = []string{"fix-now", "fix-soon", "accept-risk", "false-positive"}
+
+var (
+	triageLimit            int
+	triageBatchSize        int
+	triageCriticalityLabel string
+	triageNoEnrich         bool
+)
+
+// TriagedFinding is a finding with the priority the LLM gave it
+type TriagedFinding struct {
+	Rank          int     `json:"rank"`
+	Priority      string  `json:"priority"` // fix-now, fix-soon, accept-risk, false-positive; empty if the LLM skipped it
+	Risk          int     `json:"risk"`     // 0 to 100
+	Reason        string  `json:"reason,omitempty"`
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	Severity      string  `json:"severity"`
+	Score         float64 `json:"score,omitempty"` // CVSS score
+	EPSS          float64 `json:"epss,omitempty"`
+	KEV           bool    `json:"kev,omitempty"`
+	Namespace     string  `json:"namespace,omitempty"`
+	Resource      string  `json:"resource"`
+	Exposure      string  `json:"exposure,omitempty"`      // How the workload is reachable
+	ExposureLevel string  `json:"exposureLevel,omitempty"` // external, nodePort, clusterInternal or none
+	Criticality   string  `json:"criticality,omitempty"`
+}
+
+// TriageReport is the output of 'trix triage'
+type TriageReport struct {
+	Findings []TriagedFinding `json:"findings"`
+	Omitted  int              `json:"omitted,omitempty"` // Lower-ranked findings not sent to the LLM
+	Summary  string           `json:"summary"`
+}
+
+// triageResult is the JSON the LLM answers each batch with
+type triageResult struct {
+	Findings []struct {
+		Index    int    `json:"index"`
+		Priority string `json:"priority"`
+		Risk     int    `json:"risk"`
+		Reason   string `json:"reason"`
+	} `json:"findings"`
+	Summary string `json:"summary"`
+}
+
+// triageSchema is the JSON schema of triageResult
+var triageSchema = llm.Schema{
+	Name:        "triage_result",
+	Description: "Findings ranked by real-world risk",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"findings": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"index":    map[string]interface{}{"type": "integer", "description": "Index of the finding in the list"},
+						"priority": map[string]interface{}{"type": "string", "enum": triagePriorities},
+						"risk":     map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 100},
+						"reason":   map[string]interface{}{"type": "string", "description": "One sentence naming the deciding facts"},
+					},
+					"required": []string{"index", "priority", "risk", "reason"},
+				},
+			},
+			"summary": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"findings", "summary"},
+	},
+}
+
+var triageCmd = &cobra.Command{
+	Use:   "triage",
+	Short: "Rank findings by real-world risk with the LLM",
+	Long: `Send the findings to the LLM with the context that decides their real risk
+and get back a ranked priority list (fix-now, fix-soon, accept-risk,
+false-positive), with a risk score and a one-line justification for each.
+
+The context of each finding is:
+  - whether the CVE is in CISA's Known Exploited Vulnerabilities catalog
+  - its EPSS score, the probability of exploitation in the next 30 days
+  - how its workload is exposed (internet, node port, cluster-internal)
+  - the criticality label of its namespace (--criticality-label)
+
+KEV and EPSS are looked up online; use --no-enrich in air-gapped clusters.
+The most severe findings are triaged first: --limit caps how many are sent,
+in batches of --batch-size. The LLM is configured as for 'trix ask'.
+
+Examples:
+  trix triage -A
+  trix triage -n prod --limit 50 -o json
+  trix triage -A --criticality-label tier`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if triageBatchSize < 1 {
+			fmt.Println("Error: --batch-size must be at least 1")
+			return
+		}
+		ctx := context.Background()
+		ns := resolveNamespace()
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating k8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		findings := scanFindings(ctx, trivyClient, ns)
+		if len(findings) == 0 {
+			explainEmpty(ctx, trivyClient)
+			fmt.Println("No findings to triage")
+			return
+		}
+		client, err := newLLMClient(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		candidates := triageCandidates(ctx, k8sClient, findings)
+		report := TriageReport{Findings: candidates}
+		if triageLimit > 0 && len(candidates) > triageLimit {
+			report.Findings, report.Omitted = candidates[:triageLimit], len(candidates)-triageLimit
+		}
+
+		var summaries []string
+		for start := 0; start < len(report.Findings); start += triageBatchSize {
+			batch := report.Findings[start:min(start+triageBatchSize, len(report.Findings))]
+			fmt.Fprintf(os.Stderr, "Triaging findings %d-%d of %d...\n", start+1, start+len(batch), len(report.Findings))
+			summary, err := triageBatch(ctx, client, batch)
+			if err != nil {
+				printLLMError(err)
+				return
+			}
+			if summary != "" {
+				summaries = append(summaries, summary)
+			}
+		}
+		report.Summary = strings.Join(summaries, "\n\n")
+		rankTriagedFindings(report.Findings)
+
+		if structuredOutput() {
+			printStructured(report)
+			return
+		}
+		fmt.Println(formatTriageReport(report))
+	},
+}
+
+// triageCandidates deduplicates the findings (the same finding in several
+// containers or ReplicaSets is triaged once), adds their context and orders
+// them for triage: known exploited first, then by severity, EPSS and CVSS
+func triageCandidates(ctx context.Context, k8sClient *kubectl.Client, findings []trivy.Finding) []TriagedFinding {
+	seen := make(map[string]bool)
+	var candidates []TriagedFinding
+	var ids []string
+	for _, f := range findings {
+		title := f.Title
+		if title == "" {
+			title = f.Description
+		}
+		key := f.ID + "|" + f.Namespace + "/" + f.Resource() + "|" + title
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, TriagedFinding{
+			ID:        f.ID,
+			Title:     title,
+			Severity:  string(f.Severity),
+			Score:     f.Score,
+			Namespace: f.Namespace,
+			Resource:  f.Resource(),
+		})
+		ids = append(ids, f.ID)
+	}
+
+	if !triageNoEnrich {
+		client := enrich.NewClient(enrich.Config{})
+		kev, err := client.KEV(ctx, ids)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		epss, err := client.EPSS(ctx, ids)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for i := range candidates {
+			id := enrich.NormalizeID(candidates[i].ID)
+			_, candidates[i].KEV = kev[id]
+			candidates[i].EPSS = epss[id].Score
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.KEV != b.KEV {
+			return a.KEV
+		}
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		if a.EPSS != b.EPSS {
+			return a.EPSS > b.EPSS
+		}
+		return a.Score > b.Score
+	})
+
+	// Exposure is checked for the workloads of the most urgent findings first
+	ordered := make([]trivy.Finding, 0, len(candidates))
+	for _, c := range candidates {
+		if kind, name, ok := strings.Cut(c.Resource, "/"); ok {
+			ordered = append(ordered, trivy.Finding{Namespace: c.Namespace, Owner: &trivy.Owner{Kind: kind, Name: name}})
+		}
+	}
+	exposures := exposureByWorkload(ctx, k8sClient, ordered, maxTriageExposures)
+	criticality := namespaceCriticality(ctx, k8sClient)
+	for i := range candidates {
+		if result, ok := exposures[candidates[i].Namespace+"/"+candidates[i].Resource]; ok {
+			candidates[i].Exposure, candidates[i].ExposureLevel = result.Summary, string(result.Level)
+		}
+		candidates[i].Criticality = criticality[candidates[i].Namespace]
+	}
+	return candidates
+}
+
+// namespaceCriticality returns the value of the criticality label of each
+// namespace that has it. Without access to namespaces it is empty.
+func namespaceCriticality(ctx context.Context, k8sClient *kubectl.Client) map[string]string {
+	result := make(map[string]string)
+	if triageCriticalityLabel == "" {
+		return result
+	}
+	list, err := k8sClient.Clientset().CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: triageCriticalityLabel})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read namespace labels: %v\n", err)
+		return result
+	}
+	for _, ns := range list.Items {
+		result[ns.Name] = ns.Labels[triageCriticalityLabel]
+	}
+	return result
+}
+
+// triageBatch asks the LLM to triage a batch of findings and records the
+// priorities in place. It returns the LLM's summary of the batch.
+func triageBatch(ctx context.Context, client llm.Client, batch []TriagedFinding) (string, error) {
+	data := prompt.Triage{}
+	for _, f := range batch {
+		kind, name, _ := strings.Cut(f.Resource, "/")
+		if name == "" {
+			kind, name = "", f.Resource
+		}
+		data.Findings = append(data.Findings, prompt.Finding{
+			ID:           f.ID,
+			Title:        f.Title,
+			Severity:     f.Severity,
+			Score:        f.Score,
+			Namespace:    f.Namespace,
+			ResourceKind: kind,
+			ResourceName: name,
+			Exposure:     f.Exposure,
+			EPSS:         f.EPSS,
+			KEV:          f.KEV,
+			Criticality:  f.Criticality,
+		})
+	}
+	text, err := prompt.Render("triage", data)
+	if err != nil {
+		return "", err
+	}
+
+	var result triageResult
+	if _, err := llm.ChatStructured(ctx, client, []llm.Message{{Role: llm.RoleUser, Content: text}}, triageSchema, &result); err != nil {
+		return "", err
+	}
+	for _, r := range result.Findings {
+		if r.Index < 0 || r.Index >= len(batch) {
+			continue
+		}
+		f := &batch[r.Index]
+		f.Priority = strings.ToLower(r.Priority)
+		f.Risk = max(0, min(100, r.Risk))
+		f.Reason = r.Reason
+	}
+	return result.Summary, nil
+}
+
+// rankTriagedFindings sorts the findings by priority, then by risk, and
+// numbers them. Findings the LLM skipped come last, in their original order.
+func rankTriagedFindings(findings []TriagedFinding) {
+	order := func(priority string) int {
+		for i, p := range triagePriorities {
+			if p == priority {
+				return i
+			}
+		}
+		return len(triagePriorities)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if oi, oj := order(findings[i].Priority), order(findings[j].Priority); oi != oj {
+			return oi < oj
+		}
+		return findings[i].Risk > findings[j].Risk
+	})
+	for i := range findings {
+		findings[i].Rank = i + 1
+	}
+}
+
+// formatTriageReport renders the ranked findings, their reasons and the
+// summary for the terminal
+func formatTriageReport(report TriageReport) string {
+	table := ui.NewTable("#", "Priority", "Risk", "Finding", "Severity", "Resource", "Context")
+	for _, f := range report.Findings {
+		priority := f.Priority
+		if priority == "" {
+			priority = "unranked"
+		}
+		resource := f.Resource
+		if f.Namespace != "" {
+			resource = f.Namespace + "/" + resource
+		}
+		var signals []string
+		if f.KEV {
+			signals = append(signals, "KEV")
+		}
+		if f.EPSS > 0 {
+			signals = append(signals, fmt.Sprintf("EPSS %.2f", f.EPSS))
+		}
+		if f.ExposureLevel != "" {
+			signals = append(signals, f.ExposureLevel)
+		}
+		if f.Criticality != "" {
+			signals = append(signals, "criticality "+f.Criticality)
+		}
+		table.AddRow(fmt.Sprintf("%d", f.Rank), priority, fmt.Sprintf("%d", f.Risk), f.ID, f.Severity, resource, strings.Join(signals, ", "))
+	}
+
+	var out strings.Builder
+	out.WriteString(table.Render() + "\n\n")
+	out.WriteString(ui.Section("Reasons") + "\n")
+	for _, f := range report.Findings {
+		if f.Reason != "" {
+			out.WriteString(fmt.Sprintf("  %d. %s: %s\n", f.Rank, f.ID, f.Reason))
+		}
+	}
+	if report.Omitted > 0 {
+		out.WriteString("\n" + ui.Muted.Render(fmt.Sprintf("%d lower-ranked findings were not triaged; raise --limit to include them", report.Omitted)) + "\n")
+	}
+	if report.Summary != "" {
+		out.WriteString("\n" + ui.Box("Summary", report.Summary, 100))
+	}
+	return out.String()
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+	triageCmd.Flags().IntVar(&triageLimit, "limit", 100, "Triage at most this many findings, most severe first (0 triages all)")
+	triageCmd.Flags().IntVar(&triageBatchSize, "batch-size", 25, "Findings sent to the LLM per request")
+	triageCmd.Flags().StringVar(&triageCriticalityLabel, "criticality-label", "criticality", "Namespace label that says how critical its workloads are")
+	triageCmd.Flags().BoolVar(&triageNoEnrich, "no-enrich", false, "Don't look up KEV and EPSS data online")
+	addScanFlags(triageCmd)
+	addLLMFlags(triageCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var queryUpgradesCmd = &cobra.Command{
+	Use:   "upgrades",
+	Short: "Show the package upgrades that clear the critical and high vulnerabilities of each image",
+	Long: `Compute, per image, the smallest set of package upgrades (installed version
+to fixed version) that clears its CRITICAL and HIGH vulnerabilities. A package
+with several vulnerabilities is upgraded once, to the highest fixed version
+needed. Packages with vulnerabilities no version fixes yet are listed
+separately.
+
+Use --min-severity to plan for another severity, e.g. --min-severity medium.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+		severity := aggregate.DefaultUpgradeSeverity
+		if minSeverity != "" {
+			severity = trivy.Severity(minSeverity)
+		}
+
+		scanners := []trivy.Scanner{trivy.NewTrivyVulnScanner(trivyClient)}
+		if !trivyClient.OperatorInstalled() {
+			scanners = scannersFor(trivyClient)
+		}
+		dedup := aggregate.NewDeduplicator()
+		for _, scanner := range scanners {
+			findings, err := scanner.Scan(ctx, ns)
+			if err != nil && !partialResults(err) {
+				fmt.Printf("Error scanning %s: %v\n", scanner.Name(), err)
+				return
+			}
+			dedup.Add(findings...)
+		}
+		if dedup.Len() == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
+		plan := aggregate.UpgradePlan(dedup.Vulnerabilities(), severity)
+
+		if structuredOutput() {
+			printStructured(plan)
+			return
+		}
+		if len(plan) == 0 {
+			fmt.Printf("No %s or higher vulnerabilities found\n", severity)
+			return
+		}
+		for _, image := range plan {
+			fmt.Println(ui.Box(image.Image, formatImageUpgrades(image), 120))
+		}
+	},
+}
+
+// formatImageUpgrades renders the upgrade plan of one image for the terminal
+func formatImageUpgrades(image aggregate.ImageUpgrades) string {
+	var content strings.Builder
+	content.WriteString(ui.Muted.Render("Workloads: "+strings.Join(image.Workloads, ", ")) + "\n\n")
+
+	if len(image.Upgrades) > 0 {
+		table := ui.NewTable("Severity", "Package", "Installed", "Upgrade To", "Clears")
+		for _, u := range image.Upgrades {
+			table.AddRow(u.Severity, u.PkgName, u.InstalledVersion, u.FixedVersion, fmt.Sprintf("%d", len(u.VulnerabilityIDs)))
+		}
+		content.WriteString(table.Render() + "\n")
+	}
+	if len(image.Unfixable) > 0 {
+		content.WriteString("\n" + ui.Section("No Fix Available") + "\n")
+		for _, u := range image.Unfixable {
+			content.WriteString(fmt.Sprintf("  %s %s: %s\n", u.PkgName, u.InstalledVersion, strings.Join(u.VulnerabilityIDs, ", ")))
+		}
+	}
+	return content.String()
+}
+
+func init() {
+	queryCmd.AddCommand(queryUpgradesCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var vexDocuments []string
+
+// applyVEXFlag makes the --vex files absolute and passes the documents on
+// through TRIX_VEX, so the commands 'trix ask' runs leave out the same
+// vulnerabilities
+func applyVEXFlag() {
+	if len(vexDocuments) == 0 {
+		if env := os.Getenv("TRIX_VEX"); env != "" {
+			vexDocuments = strings.Split(env, ",")
+		}
+		return
+	}
+	for i, doc := range vexDocuments {
+		if strings.Contains(doc, "://") {
+			continue
+		}
+		if abs, err := filepath.Abs(doc); err == nil {
+			vexDocuments[i] = abs
+		}
+	}
+	_ = os.Setenv("TRIX_VEX", strings.Join(vexDocuments, ","))
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringSliceVar(&vexDocuments, "vex", nil, "Leave out vulnerabilities an OpenVEX or CSAF document marks not_affected or fixed (file or oci://ref; repeatable, or TRIX_VEX)")
+
+	cobra.OnInitialize(applyVEXFlag)
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/enrich"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vulnsImage string
+	vulnsSort  string
+	vulnsLimit int
+)
+
+// VulnFinding is one vulnerability of one workload, as listed by 'trix vulns'
+type VulnFinding struct {
+	ID               string       `json:"id"`
+	Severity         string       `json:"severity"`
+	Score            float64      `json:"score,omitempty"` // CVSS score
+	EPSS             *enrich.EPSS `json:"epss,omitempty"`  // Only with --sort epss
+	PublishedDate    string       `json:"publishedDate,omitempty"`
+	PkgName          string       `json:"pkgName"`
+	InstalledVersion string       `json:"installedVersion"`
+	FixedVersion     string       `json:"fixedVersion,omitempty"`
+	Image            string       `json:"image"`
+	Namespace        string       `json:"namespace"`
+	Resource         string       `json:"resource"`
+	Title            string       `json:"title,omitempty"`
+}
+
+// published returns when the vulnerability was published, or the zero time
+func (v VulnFinding) published() time.Time {
+	t, _ := time.Parse(time.RFC3339, v.PublishedDate)
+	return t
+}
+
+var vulnsCmd = &cobra.Command{
+	Use:   "vulns",
+	Short: "List individual vulnerability findings with filtering and sorting",
+	Long: `List every vulnerability of every workload, one per row, filtered by
+severity, image, CVE and fix availability and sorted by severity (default),
+CVSS score, EPSS score or age.
+
+--sort epss fetches the EPSS score (the probability of exploitation in the
+next 30 days) of each CVE from FIRST, so it needs internet access. --sort age
+lists the longest known vulnerabilities first.
+
+Examples:
+  trix vulns -A --severity critical,high --fixable
+  trix vulns -n prod --image nginx --sort epss --limit 20
+  trix vulns -A --cve CVE-2024-45337 -o json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains([]string{"severity", "cvss", "epss", "age"}, vulnsSort) {
+			fmt.Printf("Error: unknown sort %q (use severity, cvss, epss or age)\n", vulnsSort)
+			return
+		}
+
+		k8sClient, err := newK8sClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		ctx := context.Background()
+
+		ns := resolveNamespace()
+
+		scanners := []trivy.Scanner{trivy.NewTrivyVulnScanner(trivyClient)}
+		if !trivyClient.OperatorInstalled() {
+			scanners = scannersFor(trivyClient)
+		}
+		var vulns []VulnFinding
+		for _, scanner := range scanners {
+			findings, err := scanner.Scan(ctx, ns)
+			if err != nil && !partialResults(err) {
+				fmt.Printf("Error scanning %s: %v\n", scanner.Name(), err)
+				return
+			}
+			for _, f := range findings {
+				v, ok := f.RawData.(trivy.Vulnerability)
+				if !ok {
+					continue
+				}
+				if vulnsImage != "" && !strings.Contains(v.Image, vulnsImage) {
+					continue
+				}
+				vulns = append(vulns, VulnFinding{
+					ID:               v.VulnerabilityID,
+					Severity:         v.Severity,
+					Score:            v.Score,
+					PublishedDate:    v.PublishedDate,
+					PkgName:          v.PkgName,
+					InstalledVersion: v.InstalledVersion,
+					FixedVersion:     v.FixedVersion,
+					Image:            v.Image,
+					Namespace:        f.Namespace,
+					Resource:         f.Resource(),
+					Title:            v.Title,
+				})
+			}
+		}
+		if len(vulns) == 0 {
+			explainEmpty(ctx, trivyClient)
+		}
+
+		if vulnsSort == "epss" {
+			addEPSS(ctx, vulns)
+		}
+		sortVulnFindings(vulns, vulnsSort)
+		total := len(vulns)
+		if vulnsLimit > 0 && len(vulns) > vulnsLimit {
+			vulns = vulns[:vulnsLimit]
+		}
+
+		if structuredOutput() {
+			printStructured(vulns)
+			return
+		}
+		if total == 0 {
+			fmt.Println("No vulnerabilities found")
+			return
+		}
+		fmt.Println(formatVulnFindings(vulns))
+		if len(vulns) < total {
+			fmt.Println(ui.Muted.Render(fmt.Sprintf("Showing %d of %d findings; raise --limit to see more", len(vulns), total)))
+		}
+	},
+}
+
+// addEPSS looks up the EPSS scores of the findings. Without them the findings
+// are still listed, so a failed lookup is only a warning.
+func addEPSS(ctx context.Context, vulns []VulnFinding) {
+	ids := make([]string, len(vulns))
+	for i, v := range vulns {
+		ids[i] = v.ID
+	}
+	scores, err := enrich.NewClient(enrich.Config{}).EPSS(ctx, ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for i := range vulns {
+		if score, ok := scores[enrich.NormalizeID(vulns[i].ID)]; ok {
+			vulns[i].EPSS = &score
+		}
+	}
+}
+
+// sortVulnFindings sorts the findings by the given key, most urgent first.
+// Ties are broken by severity and then by ID, so the order is stable.
+func sortVulnFindings(vulns []VulnFinding, by string) {
+	sort.SliceStable(vulns, func(i, j int) bool {
+		a, b := vulns[i], vulns[j]
+		switch by {
+		case "cvss":
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+		case "epss":
+			if ea, eb := epssScore(a), epssScore(b); ea != eb {
+				return ea > eb
+			}
+		case "age":
+			// Oldest first; unknown dates last
+			pa, pb := a.published(), b.published()
+			if pa.IsZero() != pb.IsZero() {
+				return pb.IsZero()
+			}
+			if !pa.Equal(pb) {
+				return pa.Before(pb)
+			}
+		}
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.ID < b.ID
+	})
+}
+
+// epssScore returns the EPSS score of a finding, or -1 if it has none
+func epssScore(v VulnFinding) float64 {
+	if v.EPSS == nil {
+		return -1
+	}
+	return v.EPSS.Score
+}
+
+// formatVulnFindings renders the findings as a table for the terminal
+func formatVulnFindings(vulns []VulnFinding) string {
+	showEPSS := slices.ContainsFunc(vulns, func(v VulnFinding) bool { return v.EPSS != nil })
+	headers := []string{"Severity", "ID", "CVSS"}
+	if showEPSS {
+		headers = append(headers, "EPSS")
+	}
+	headers = append(headers, "Age", "Package", "Installed", "Fixed", "Image", "Resource")
+
+	table := ui.NewTable(headers...)
+	for _, v := range vulns {
+		row := []string{v.Severity, v.ID, "-"}
+		if v.Score > 0 {
+			row[2] = fmt.Sprintf("%.1f", v.Score)
+		}
+		if showEPSS {
+			epss := "-"
+			if v.EPSS != nil {
+				epss = fmt.Sprintf("%.2f%%", v.EPSS.Score*100)
+			}
+			row = append(row, epss)
+		}
+		age := "-"
+		if published := v.published(); !published.IsZero() {
+			age = fmt.Sprintf("%dd", int(time.Since(published).Hours()/24))
+		}
+		resource := v.Resource
+		if v.Namespace != "" {
+			resource = v.Namespace + "/" + resource
+		}
+		table.AddRow(append(row, age, v.PkgName, v.InstalledVersion, v.FixedVersion, v.Image, resource)...)
+	}
+	return table.Render()
+}
+
+func init() {
+	rootCmd.AddCommand(vulnsCmd)
+	vulnsCmd.Flags().StringVar(&vulnsImage, "image", "", "Only list vulnerabilities of images containing this string")
+	vulnsCmd.Flags().StringSliceVar(&cveIDs, "cve", nil, "Only list these CVEs (repeatable)")
+	vulnsCmd.Flags().BoolVar(&fixedOnly, "fixable", false, "Only list vulnerabilities with a fixed version")
+	vulnsCmd.Flags().StringVar(&vulnsSort, "sort", "severity", "Sort by severity, cvss, epss or age")
+	vulnsCmd.Flags().IntVar(&vulnsLimit, "limit", 0, "Only list the first N findings (0 lists all)")
+	addScanFlags(vulnsCmd)
+}
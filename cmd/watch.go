@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/davealtena/trix/internal/notify"
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	"github.com/davealtena/trix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// maxAlertIDs caps the CVE IDs listed in a notification
+const maxAlertIDs = 5
+
+var (
+	watchExisting      bool
+	watchWebhook       string
+	watchNotifyCommand string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream changes to Trivy reports as they happen",
+	Long: `Watch Trivy Operator reports and print a line whenever one is added,
+updated or deleted, e.g. to follow a rescan. Uses informers, so the API
+server is watched rather than polled.
+
+Like the other commands, the counts leave out findings the ignore file,
+--vex documents or severity flags filter. When a report gains CRITICAL
+findings, the line is flagged and, with --notify-webhook or
+--notify-command, a notification is sent. The webhook gets a JSON POST
+whose text field makes it a valid Slack or Mattermost message. The command runs with the alert in TRIX_ALERT_* environment
+variables, e.g. --notify-command 'notify-send trix "$TRIX_ALERT_TEXT"'.
+
+Press Ctrl+C to stop.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !requireCluster("watch") {
+			return
+		}
+		k8sClient, err := kubectl.NewClient()
+		if err != nil {
+			fmt.Printf("Error creating K8s client: %v\n", err)
+			return
+		}
+		trivyClient, err := newTrivyClient(k8sClient)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		currentCtx, err := k8sClient.GetCurrentContext()
+		if err != nil {
+			fmt.Printf("Error getting context: %v\n", err)
+		}
+		fmt.Printf("Using context: %s\n", currentCtx)
+
+		ns := resolveNamespace()
+		fmt.Printf("Scope: %s\n\n", namespaceScope())
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		watcher := trivyClient.NewWatcher(ns, 0)
+		if err := watcher.Start(ctx); err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("Error starting watch: %v\n", err)
+			}
+			return
+		}
+		fmt.Println("Watching for report changes (Ctrl+C to stop)...")
+
+		var notifiers []notify.Notifier
+		if watchWebhook == "" {
+			watchWebhook = os.Getenv("TRIX_NOTIFY_WEBHOOK")
+		}
+		if watchWebhook != "" {
+			notifiers = append(notifiers, notify.NewWebhook(watchWebhook))
+		}
+		if watchNotifyCommand != "" {
+			notifiers = append(notifiers, notify.NewCommand(watchNotifyCommand))
+		}
+
+		// CRITICAL counts of the reports seen, to alert only when they rise
+		criticals := make(map[string]int)
+		for event := range watcher.Events() {
+			key := event.Resource.Resource + "/" + event.Report.GetNamespace() + "/" + event.Report.GetName()
+			previous := criticals[key]
+			critical := 0
+			if summary, ok := event.Summary(); ok {
+				critical = summary.CriticalCount
+			}
+			if event.Type == trivy.EventDeleted {
+				delete(criticals, key)
+			} else {
+				criticals[key] = critical
+			}
+
+			if event.Initial && !watchExisting {
+				continue
+			}
+			printReportEvent(event)
+			if event.Initial || event.Type == trivy.EventDeleted || critical <= previous {
+				continue
+			}
+			alert := notify.Alert{
+				Kind:      event.Report.GetKind(),
+				Namespace: event.Report.GetNamespace(),
+				Name:      event.Report.GetName(),
+				Critical:  critical,
+				New:       critical - previous,
+				IDs:       criticalIDs(event),
+				Context:   currentCtx,
+				Time:      time.Now(),
+			}
+			fmt.Println(ui.Critical.Render(fmt.Sprintf("          ! %d new CRITICAL", alert.New)))
+			for _, n := range notifiers {
+				if err := n.Notify(ctx, alert); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+		}
+	},
+}
+
+// criticalIDs returns the first CRITICAL vulnerability IDs of a
+// vulnerability report. Other reports have none.
+func criticalIDs(event trivy.ReportEvent) []string {
+	if event.Resource != v1alpha1.VulnerabilityReports && event.Resource != v1alpha1.ClusterVulnerabilityReports {
+		return nil
+	}
+	report, err := v1alpha1.FromUnstructured[v1alpha1.VulnerabilityReport](event.Report.Object)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, v := range report.Report.Vulnerabilities {
+		if v.Severity == string(trivy.SeverityCritical) && !slices.Contains(ids, v.VulnerabilityID) {
+			ids = append(ids, v.VulnerabilityID)
+			if len(ids) == maxAlertIDs {
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// printReportEvent prints one report change as a single line, with the
+// severity counts colored when they are nonzero
+func printReportEvent(event trivy.ReportEvent) {
+	report := event.Report
+	name := report.GetName()
+	if report.GetNamespace() != "" {
+		name = report.GetNamespace() + "/" + name
+	}
+	eventType := fmt.Sprintf("%-8s", event.Type)
+	if event.Type == trivy.EventDeleted {
+		eventType = ui.Muted.Render(eventType)
+	}
+	line := fmt.Sprintf("%s  %s %-30s %s", ui.Muted.Render(time.Now().Format("15:04:05")), eventType, report.GetKind(), name)
+	if summary, ok := event.Summary(); ok && event.Type != trivy.EventDeleted {
+		count := func(label, severity string, n int) string {
+			text := fmt.Sprintf("%s:%d", label, n)
+			if n == 0 {
+				return ui.Muted.Render(text)
+			}
+			return ui.Severity(severity).Render(text)
+		}
+		line += fmt.Sprintf("  (%s %s %s %s)",
+			count("C", "CRITICAL", summary.CriticalCount),
+			count("H", "HIGH", summary.HighCount),
+			count("M", "MEDIUM", summary.MediumCount),
+			count("L", "LOW", summary.LowCount))
+	}
+	fmt.Println(line)
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	addScanFlags(watchCmd)
+	watchCmd.Flags().BoolVar(&watchExisting, "existing", false, "Also print the reports that exist when the watch starts")
+	watchCmd.Flags().StringVar(&watchWebhook, "notify-webhook", "", "POST an alert to this URL when a report gains CRITICAL findings (or TRIX_NOTIFY_WEBHOOK)")
+	watchCmd.Flags().StringVar(&watchNotifyCommand, "notify-command", "", "Run this shell command when a report gains CRITICAL findings")
+}
@@ -3,57 +3,24 @@ package agent
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/davealtena/trix/internal/llm"
+	"github.com/davealtena/trix/internal/prompt"
 	"github.com/davealtena/trix/internal/tools"
 )
 
-const systemPrompt = `You are a Kubernetes security investigator. You help users understand security findings in their clusters.
-
-When investigating SECURITY FINDINGS:
-1. Start with trix_summary to understand the overall security posture
-2. Use trix_findings with severity filter to get a compact list of issues
-3. Use trix_finding_detail ONLY when you need full details about a specific finding
-4. Use kubectl_list to find resources, then kubectl_get for ONE specific resource
-5. Use kubectl_logs only if investigating runtime issues
-
-When investigating SBOM (software inventory):
-1. Start with trix_sbom_summary for overview (total images, component types, top packages)
-2. Use trix_sbom_search to find specific packages (e.g., "is log4j in my cluster?")
-3. Use trix_sbom_image ONLY when you need full SBOM for ONE specific image
-
-When investigating Kubernetes resources:
-1. Use kubectl_list to get compact table of resources (names, namespaces, status)
-2. Use kubectl_get ONLY for ONE specific resource by name (returns full YAML)
-3. NEVER use kubectl_get without a specific name - it will error
-
-When PRIORITIZING vulnerabilities:
-1. Use check_exposure to see if a workload is externally reachable
-2. ALWAYS report CRITICAL CVEs, but add exposure context:
-   - External: "CRITICAL - internet-facing, patch immediately"
-   - NodePort: "CRITICAL - may be external depending on network"
-   - ClusterIP: "CRITICAL - internal only, lower urgency"
-   - None: "CRITICAL - not network accessible, lowest urgency"
-3. check_exposure on Deployment covers its ReplicaSets/Pods - don't check both
-
-Tool usage guidelines (TOKEN EFFICIENCY IS CRITICAL):
-- trix_summary, trix_sbom_summary, kubectl_list, check_exposure → COMPACT, use first
-- trix_findings (with filters) → COMPACT table, efficient for overviews
-- trix_finding_detail, kubectl_get, trix_sbom_image → FULL details, use for ONE item only
-- NEVER fetch full data when a summary or filtered list will answer the question
-
-CRITICAL - RBAC findings:
-- ClusterRoles named cluster-admin, admin, edit, view, system:* are BUILT-IN to Kubernetes - not actionable.
-- BEFORE listing RBAC as a risk: run kubectl_list clusterrolebindings and CHECK the subjects
-- System subjects (system:*, kube-system/*, kubernetes-admin) are EXPECTED and SAFE
-- If only system subjects are bound: DO NOT list RBAC as a risk at all. Skip it entirely.
-- Only list RBAC as a risk if you find NON-system users/groups/serviceaccounts bound to powerful roles.
-
-Be concise and focus on ACTIONABLE insights. Don't just say "review" - actually check and tell the user what needs to change.
-NEVER end with questions like "Would you like me to..." or "Do you want me to..." - just provide the complete answer.
-NEVER use emojis in your responses.
-When asked for "top N risks/issues", only list actual problems. Don't pad with "no issues found" items.
-EFFICIENCY: Aim to answer in 5-7 tool calls max. Don't fetch the same data twice. Be decisive.`
+// systemPrompt returns the investigation prompt, honoring user overrides
+func systemPrompt() string {
+	p, err := prompt.Render("investigate", nil)
+	if err == nil {
+		return p
+	}
+	fmt.Printf("Warning: %v (using built-in prompt)\n", err)
+	t, _ := prompt.Builtin("investigate")
+	p, _ = t.Render(nil)
+	return p
+}
 
 // Token limits
 const (
@@ -61,12 +28,16 @@ const (
 	warnTokenThreshold = 50000 // Warn when input exceeds this
 )
 
+// maxIterations limits tool-calling rounds per question
+const maxIterations = 10
+
 // Conversation holds state for multi-return conversations
 type Conversation struct {
 	agent             *Agent
 	messages          []llm.Message
 	TotalInputTokens  int
 	TotalOutputTokens int
+	TotalCost         float64
 }
 
 // NewConversation start a new converstation
@@ -74,74 +45,79 @@ func (a *Agent) NewConversation() *Conversation {
 	return &Conversation{
 		agent: a,
 		messages: []llm.Message{
-			{Role: llm.RoleSystem, Content: systemPrompt},
+			{Role: llm.RoleSystem, Content: systemPrompt()},
 		},
 	}
 }
 
-// Ask adds a question and returns
-func (c *Conversation) Ask(ctx context.Context, question string) (string, error) {
-	c.messages = append(c.messages, llm.Message{Role: llm.RoleUser, Content: question})
-
-	for i := 0; i < 10; i++ {
-		response, err := c.agent.client.Chat(ctx, c.messages, c.agent.registry.Tools())
-		if err != nil {
-			return "", fmt.Errorf("LLM error: %w", err)
-		}
-
-		// Track token usage
-		c.TotalInputTokens += response.Usage.InputTokens
-		c.TotalOutputTokens += response.Usage.OutputTokens
-
-		if len(response.ToolCalls) == 0 {
-			// Add final assistant response to history
-			c.messages = append(c.messages, llm.Message{
-				Role:    llm.RoleAssistant,
-				Content: response.Content,
-			})
-			// Show token usage
-			fmt.Printf("  [tokens: %d in, %d out | total: %d in, %d out]\n",
-				response.Usage.InputTokens, response.Usage.OutputTokens,
-				c.TotalInputTokens, c.TotalOutputTokens)
-			// Warn if context is getting large
-			if response.Usage.InputTokens > warnTokenThreshold {
-				fmt.Printf("  [warning: context is large, consider using 'clear' to reset]\n")
-			}
-			return response.Content, nil
+// ResumeConversation continues a saved conversation. The saved system prompt
+// is replaced by the current one so prompt updates apply to old sessions.
+func (a *Agent) ResumeConversation(messages []llm.Message, usage llm.Totals) *Conversation {
+	conv := a.NewConversation()
+	for _, msg := range messages {
+		if msg.Role != llm.RoleSystem {
+			conv.messages = append(conv.messages, msg)
 		}
+	}
+	conv.TotalInputTokens = usage.InputTokens
+	conv.TotalOutputTokens = usage.OutputTokens
+	conv.TotalCost = usage.Cost
+	return conv
+}
 
-		c.messages = append(c.messages, llm.Message{
-			Role:      llm.RoleAssistant,
-			Content:   response.Content,
-			ToolCalls: response.ToolCalls,
-		})
-		for _, tc := range response.ToolCalls {
-			paramInfo := formatToolParams(tc.Name, tc.Parameters)
-			fmt.Printf("  → %s\n", paramInfo)
+// Messages returns the conversation history, including tool calls and results
+func (c *Conversation) Messages() []llm.Message {
+	return c.messages
+}
 
-			result, err := c.agent.registry.Execute(ctx, tc.Name, tc.Parameters)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+// Usage returns the token usage and cost of the conversation so far
+func (c *Conversation) Usage() llm.Totals {
+	return llm.Totals{
+		InputTokens:  c.TotalInputTokens,
+		OutputTokens: c.TotalOutputTokens,
+		Cost:         c.TotalCost,
+	}
+}
 
-			if len(result) > maxToolOutputBytes {
-				result = result[:maxToolOutputBytes] + "\n... (truncated)"
-			}
+// Ask adds a question, optionally with images such as dashboard screenshots, and returns
+func (c *Conversation) Ask(ctx context.Context, question string, images ...llm.Image) (string, error) {
+	c.messages = append(c.messages, llm.Message{Role: llm.RoleUser, Content: question, Images: images})
+
+	result, err := llm.RunAgent(ctx, c.agent.client, c.messages, c.agent.registry.Tools(), c.agent.registry, c.agent.agentOptions())
+	c.messages = result.Messages
+	c.TotalInputTokens += result.Usage.InputTokens
+	c.TotalOutputTokens += result.Usage.OutputTokens
+	c.TotalCost += result.Usage.Cost
+	if err != nil {
+		return "", err
+	}
 
-			c.messages = append(c.messages, llm.Message{
-				Role:       llm.RoleTool,
-				Content:    result,
-				ToolCallID: tc.ID,
-			})
-		}
+	// Show token usage
+	if c.TotalCost > 0 {
+		fmt.Printf("  [tokens: %d in, %d out | total: %d in, %d out | cost: $%.4f]\n",
+			result.LastUsage.InputTokens, result.LastUsage.OutputTokens,
+			c.TotalInputTokens, c.TotalOutputTokens, c.TotalCost)
+	} else {
+		fmt.Printf("  [tokens: %d in, %d out | total: %d in, %d out]\n",
+			result.LastUsage.InputTokens, result.LastUsage.OutputTokens,
+			c.TotalInputTokens, c.TotalOutputTokens)
 	}
-	return "", fmt.Errorf("agent loop exceeded maximum iterations")
+	// Warn if context is getting large
+	if result.LastUsage.InputTokens > warnTokenThreshold {
+		fmt.Printf("  [warning: context is large, consider using 'clear' to reset]\n")
+	}
+	return result.Content, nil
 }
 
 // Agent handles the conversation loop with the LLM
 type Agent struct {
 	client   llm.Client
 	registry *tools.Registry
+	context  *llm.ContextManager
+	usage    llm.Totals // Across all questions asked of this agent
+
+	showThinking bool
+	onDelta      func(string)
 }
 
 // New creates a new agent
@@ -149,65 +125,105 @@ func New(client llm.Client) *Agent {
 	return &Agent{
 		client:   client,
 		registry: tools.NewRegistry(),
+		context:  llm.NewContextManager(""),
 	}
 }
 
-// Ask processes a user question and returns the response
-func (a *Agent) Ask(ctx context.Context, question string) (string, error) {
-	messages := []llm.Message{
-		{Role: llm.RoleSystem, Content: systemPrompt},
-		{Role: llm.RoleUser, Content: question},
-	}
+// Usage returns the token usage and cost of every request made by this agent
+func (a *Agent) Usage() llm.Totals {
+	return a.usage
+}
 
-	var totalIn, totalOut int
+// SetShowThinking sets whether the model's reasoning is printed in full
+// rather than as a one-line summary
+func (a *Agent) SetShowThinking(show bool) {
+	a.showThinking = show
+}
 
-	// Agent loop - keep going until we get a text response
-	for i := 0; i < 10; i++ { // Max 10 iterations to prevent infinite loops
-		response, err := a.client.Chat(ctx, messages, a.registry.Tools())
-		if err != nil {
-			return "", fmt.Errorf("LLM error: %w", err)
-		}
+// SetStream streams answers to onDelta as they are generated, when the
+// client can stream. The returned answers then hold the same text again.
+func (a *Agent) SetStream(onDelta func(string)) {
+	a.onDelta = onDelta
+}
 
-		totalIn += response.Usage.InputTokens
-		totalOut += response.Usage.OutputTokens
+// Streaming reports whether answers are streamed, so they needn't be printed
+// again
+func (a *Agent) Streaming() bool {
+	_, ok := a.client.(llm.StreamingClient)
+	return ok && a.onDelta != nil
+}
 
-		// If no tool calls, we're done
-		if len(response.ToolCalls) == 0 {
-			fmt.Printf("  [tokens: %d in, %d out]\n", totalIn, totalOut)
-			return response.Content, nil
+// printThinking shows the model's reasoning, collapsed unless requested
+func (a *Agent) printThinking(thinking []llm.Thinking) {
+	var text []string
+	for _, t := range thinking {
+		if t.Text != "" {
+			text = append(text, strings.TrimSpace(t.Text))
 		}
+	}
+	if len(text) == 0 {
+		return
+	}
 
-		// Add assistant message with tool calls
-		messages = append(messages, llm.Message{
-			Role:      llm.RoleAssistant,
-			Content:   response.Content,
-			ToolCalls: response.ToolCalls,
-		})
+	reasoning := strings.Join(text, "\n\n")
+	if !a.showThinking {
+		fmt.Printf("  ▸ thought for ~%d tokens (--show-thinking to expand)\n", llm.EstimateTokens(reasoning))
+		return
+	}
+	fmt.Println("  ▾ thinking")
+	for _, line := range strings.Split(reasoning, "\n") {
+		fmt.Printf("  │ %s\n", line)
+	}
+}
 
-		// Execute each tool and add results
-		for _, tc := range response.ToolCalls {
-			// Show tool name with key parameters
-			paramInfo := formatToolParams(tc.Name, tc.Parameters)
-			fmt.Printf("  → %s\n", paramInfo)
+// SetContextManager sets how conversations are kept within the model's context window
+func (a *Agent) SetContextManager(m *llm.ContextManager) {
+	a.context = m
+}
 
-			result, err := a.registry.Execute(ctx, tc.Name, tc.Parameters)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-			}
+// Ask processes a user question, optionally with images, and returns the response
+func (a *Agent) Ask(ctx context.Context, question string, images ...llm.Image) (string, error) {
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: systemPrompt()},
+		{Role: llm.RoleUser, Content: question, Images: images},
+	}
 
-			// Truncate very long results
-			if len(result) > maxToolOutputBytes {
-				result = result[:maxToolOutputBytes] + "\n... (truncated)"
-			}
+	result, err := llm.RunAgent(ctx, a.client, messages, a.registry.Tools(), a.registry, a.agentOptions())
+	if err != nil {
+		return "", err
+	}
 
-			messages = append(messages, llm.Message{
-				Role:       llm.RoleTool,
-				Content:    result,
-				ToolCallID: tc.ID,
-			})
-		}
+	if result.Usage.Cost > 0 {
+		fmt.Printf("  [tokens: %d in, %d out | cost: $%.4f]\n", result.Usage.InputTokens, result.Usage.OutputTokens, result.Usage.Cost)
+	} else {
+		fmt.Printf("  [tokens: %d in, %d out]\n", result.Usage.InputTokens, result.Usage.OutputTokens)
+	}
+	return result.Content, nil
+}
+
+// agentOptions configures the tool-calling loop shared by Ask and Conversation.Ask
+func (a *Agent) agentOptions() llm.AgentOptions {
+	return llm.AgentOptions{
+		MaxIterations: maxIterations,
+		MaxToolOutput: maxToolOutputBytes,
+		Context:       a.context,
+		OnResponse: func(resp *llm.Response) {
+			a.usage.Add(resp.Usage)
+			a.printThinking(resp.Thinking)
+			// End a streamed line before tool calls or usage are printed
+			if a.Streaming() && resp.Content != "" && !strings.HasSuffix(resp.Content, "\n") {
+				fmt.Println()
+			}
+		},
+		OnDelta: a.onDelta,
+		OnToolCall: func(tc llm.ToolCall) {
+			// Show tool name with key parameters
+			fmt.Printf("  → %s\n", formatToolParams(tc.Name, tc.Parameters))
+			if tc.ArgumentsError != "" {
+				fmt.Printf("  [warning: invalid arguments, asking the model to retry: %s]\n", tc.ArgumentsError)
+			}
+		},
 	}
-	return "", fmt.Errorf("agent loop exceeded maximum iterations")
 }
 
 // formatToolParams creates a readable description of a tool call
@@ -245,19 +261,37 @@ func formatToolParams(name string, params map[string]interface{}) string {
 	case "trix_findings":
 		sev, _ := params["severity"].(string)
 		typ, _ := params["type"].(string)
+		selector, _ := params["selector"].(string)
+		cmd := "trix query findings -A"
 		if sev != "" && typ != "" {
-			return fmt.Sprintf("trix query findings --severity=%s --type=%s", sev, typ)
+			cmd = fmt.Sprintf("trix query findings --severity=%s --type=%s", sev, typ)
 		} else if sev != "" {
-			return fmt.Sprintf("trix query findings --severity=%s", sev)
+			cmd = fmt.Sprintf("trix query findings --severity=%s", sev)
 		} else if typ != "" {
-			return fmt.Sprintf("trix query findings --type=%s", typ)
+			cmd = fmt.Sprintf("trix query findings --type=%s", typ)
 		}
-		return "trix query findings -A"
+		if selector != "" {
+			cmd += " -l " + selector
+		}
+		return cmd
 	case "trix_summary":
 		return "trix query summary -A"
 	case "trix_finding_detail":
 		id, _ := params["id"].(string)
 		return fmt.Sprintf("trix finding detail %s", id)
+	case "trix_cve_details":
+		id, _ := params["id"].(string)
+		return fmt.Sprintf("trix query cve %s -A", id)
+	case "trix_upgrade_plan":
+		if ns, _ := params["namespace"].(string); ns != "" {
+			return fmt.Sprintf("trix query upgrades -n %s", ns)
+		}
+		return "trix query upgrades -A"
+	case "trix_rbac_roles":
+		if ns, _ := params["namespace"].(string); ns != "" {
+			return fmt.Sprintf("trix query rbac -n %s", ns)
+		}
+		return "trix query rbac -A"
 	case "trix_sbom_summary":
 		return "trix sbom summary"
 	case "trix_sbom_search":
@@ -0,0 +1,175 @@
+// Package aggregate summarizes security findings: counts by severity, type,
+// namespace and image, the most affected resources, and how many
+// vulnerabilities have a fix. It backs 'trix query summary' and anything else
+// that needs the same numbers.
+package aggregate
+
+import (
+	"maps"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Summary is the aggregate of a set of findings
+type Summary struct {
+	TotalFindings int             `json:"totalFindings"`
+	BySeverity    map[string]int  `json:"bySeverity"`
+	ByType        map[string]int  `json:"byType"`
+	ByNamespace   map[string]int  `json:"byNamespace,omitempty"`
+	ByImage       map[string]int  `json:"byImage,omitempty"` // Vulnerabilities per image
+	Fixable       int             `json:"fixable"`           // Vulnerabilities with a fixed version
+	Unfixable     int             `json:"unfixable"`
+	TopResources  []ResourceCount `json:"topResources"`
+	TopInfra      []ResourceCount `json:"topInfra,omitempty"` // Nodes and control plane components
+
+	// Vulnerabilities counted once per CVE, image and package rather than
+	// once per workload; ByType["vulnerability"] counts every occurrence
+	UniqueVulnerabilities int `json:"uniqueVulnerabilities"`
+}
+
+// ResourceCount tracks findings per resource
+type ResourceCount struct {
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+}
+
+// Options sets how many entries the top-N lists keep
+type Options struct {
+	TopResources int
+	TopInfra     int
+}
+
+// DefaultOptions are the top-N sizes shown by 'trix query summary'
+var DefaultOptions = Options{TopResources: 10, TopInfra: 5}
+
+// Aggregator accumulates findings, so scanners can be added one at a time
+// without keeping all findings in memory
+type Aggregator struct {
+	summary   Summary
+	resources map[string]int
+	infra     map[string]int
+	dedup     *Deduplicator
+}
+
+// New creates an empty aggregator
+func New() *Aggregator {
+	return &Aggregator{
+		summary: Summary{
+			BySeverity:  make(map[string]int),
+			ByType:      make(map[string]int),
+			ByNamespace: make(map[string]int),
+			ByImage:     make(map[string]int),
+		},
+		resources: make(map[string]int),
+		infra:     make(map[string]int),
+		dedup:     NewDeduplicator(),
+	}
+}
+
+// Add counts findings
+func (a *Aggregator) Add(findings ...trivy.Finding) {
+	for _, f := range findings {
+		a.summary.TotalFindings++
+		a.summary.BySeverity[string(f.Severity)]++
+		a.summary.ByType[string(f.Type)]++
+		if f.Namespace != "" {
+			a.summary.ByNamespace[f.Namespace]++
+		}
+
+		key := f.Resource()
+		if f.Namespace != "" {
+			key = f.Namespace + "/" + key
+		}
+
+		if v, ok := f.RawData.(trivy.Vulnerability); ok {
+			a.dedup.AddVulnerability(v, key)
+			if v.Image != "" {
+				a.summary.ByImage[v.Image]++
+			}
+			if v.FixedVersion != "" {
+				a.summary.Fixable++
+			} else {
+				a.summary.Unfixable++
+			}
+		}
+
+		// Benchmarks are framework-level, not resources. Infra findings are
+		// counted separately from workloads.
+		if f.Type == trivy.FindingTypeBenchmark {
+			continue
+		}
+		if f.Type == trivy.FindingTypeInfra {
+			a.infra[f.ResourceKind+"/"+key]++
+			continue
+		}
+		a.resources[key]++
+	}
+}
+
+// Summary returns the aggregate of the findings added so far
+func (a *Aggregator) Summary(opts Options) Summary {
+	summary := a.summary
+	summary.BySeverity = maps.Clone(a.summary.BySeverity)
+	summary.ByType = maps.Clone(a.summary.ByType)
+	summary.ByNamespace = maps.Clone(a.summary.ByNamespace)
+	summary.ByImage = maps.Clone(a.summary.ByImage)
+	summary.TopResources = Top(a.resources, opts.TopResources)
+	summary.TopInfra = Top(a.infra, opts.TopInfra)
+	summary.UniqueVulnerabilities = a.dedup.Len()
+	return summary
+}
+
+// Vulnerabilities returns the vulnerabilities added so far, deduplicated
+// across workloads
+func (a *Aggregator) Vulnerabilities() []UniqueVulnerability {
+	return a.dedup.Vulnerabilities()
+}
+
+// Summarize aggregates a set of findings
+func Summarize(findings []trivy.Finding, opts Options) Summary {
+	a := New()
+	a.Add(findings...)
+	return a.Summary(opts)
+}
+
+// Top returns the n entries with the highest counts, ties by name
+func Top(counts map[string]int, n int) []ResourceCount {
+	var result []ResourceCount
+	for resource, count := range counts {
+		result = append(result, ResourceCount{Resource: resource, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Resource < result[j].Resource
+	})
+
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// severityWeights are what a finding of each severity adds to a risk score
+var severityWeights = map[trivy.Severity]float64{
+	trivy.SeverityCritical: 10,
+	trivy.SeverityHigh:     5,
+	trivy.SeverityMedium:   2,
+	trivy.SeverityLow:      1,
+}
+
+// RiskScore rates a set of findings from 0 to 100 by their count per
+// severity. The severity-weighted count (CRITICAL 10, HIGH 5, MEDIUM 2,
+// LOW 1) is put on a log scale, so one CRITICAL finding scores 35, ten score
+// 67, and a weight of 1000 or more scores 100.
+func RiskScore(bySeverity map[string]int) float64 {
+	weight := 0.0
+	for sev, count := range bySeverity {
+		weight += severityWeights[trivy.Severity(strings.ToUpper(sev))] * float64(count)
+	}
+	return math.Min(100, math.Round(100*math.Log10(1+weight)/3))
+}
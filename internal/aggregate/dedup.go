@@ -0,0 +1,113 @@
+package aggregate
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// UniqueVulnerability is one CVE in one package of one image, however many
+// workloads run that image
+type UniqueVulnerability struct {
+	VulnerabilityID  string   `json:"vulnerabilityID"`
+	PkgName          string   `json:"pkgName"`
+	InstalledVersion string   `json:"installedVersion"`
+	FixedVersion     string   `json:"fixedVersion"`
+	Severity         string   `json:"severity"`
+	Score            float64  `json:"score,omitempty"`
+	Title            string   `json:"title"`
+	Image            string   `json:"image"`
+	Digest           string   `json:"digest,omitempty"`
+	Class            string   `json:"class,omitempty"`    // os-pkgs or lang-pkgs
+	OSFamily         string   `json:"osFamily,omitempty"` // OS of the image
+	Workloads        []string `json:"workloads"`          // namespace/report of each affected workload
+	Occurrences      int      `json:"occurrences"`        // Findings merged into this one
+}
+
+// Deduplicator merges the occurrences of a vulnerability across workloads,
+// keyed by CVE, image digest (or the image name without one) and package
+type Deduplicator struct {
+	byKey map[string]*UniqueVulnerability
+}
+
+// NewDeduplicator creates an empty deduplicator
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{byKey: make(map[string]*UniqueVulnerability)}
+}
+
+// Add merges the vulnerability findings; other findings are ignored
+func (d *Deduplicator) Add(findings ...trivy.Finding) {
+	for _, f := range findings {
+		if v, ok := f.RawData.(trivy.Vulnerability); ok {
+			workload := f.Resource()
+			if f.Namespace != "" {
+				workload = f.Namespace + "/" + workload
+			}
+			d.AddVulnerability(v, workload)
+		}
+	}
+}
+
+// AddVulnerability merges one occurrence of a vulnerability in a workload
+func (d *Deduplicator) AddVulnerability(v trivy.Vulnerability, workload string) {
+	image := v.Digest
+	if image == "" {
+		image = v.Image
+	}
+	key := v.VulnerabilityID + "|" + image + "|" + v.PkgName
+
+	u, ok := d.byKey[key]
+	if !ok {
+		u = &UniqueVulnerability{
+			VulnerabilityID:  v.VulnerabilityID,
+			PkgName:          v.PkgName,
+			InstalledVersion: v.InstalledVersion,
+			FixedVersion:     v.FixedVersion,
+			Severity:         v.Severity,
+			Score:            v.Score,
+			Title:            v.Title,
+			Image:            v.Image,
+			Digest:           v.Digest,
+			Class:            v.Class,
+			OSFamily:         v.OSFamily,
+		}
+		d.byKey[key] = u
+	}
+	u.Occurrences++
+	if workload != "" && !slices.Contains(u.Workloads, workload) {
+		u.Workloads = append(u.Workloads, workload)
+	}
+}
+
+// Len returns the number of unique vulnerabilities
+func (d *Deduplicator) Len() int {
+	return len(d.byKey)
+}
+
+// Vulnerabilities returns the unique vulnerabilities, most severe first, then
+// by the number of affected workloads
+func (d *Deduplicator) Vulnerabilities() []UniqueVulnerability {
+	result := make([]UniqueVulnerability, 0, len(d.byKey))
+	for _, u := range d.byKey {
+		workloads := slices.Clone(u.Workloads)
+		sort.Strings(workloads)
+		v := *u
+		v.Workloads = workloads
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		if len(a.Workloads) != len(b.Workloads) {
+			return len(a.Workloads) > len(b.Workloads)
+		}
+		if a.VulnerabilityID != b.VulnerabilityID {
+			return a.VulnerabilityID < b.VulnerabilityID
+		}
+		return a.Image+a.PkgName < b.Image+b.PkgName
+	})
+	return result
+}
@@ -0,0 +1,199 @@
+package aggregate
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+)
+
+// ImageSummary is one image in the cluster, keyed by digest, with the
+// workloads that run it and its vulnerabilities counted once
+type ImageSummary struct {
+	Image               string         `json:"image"`
+	Digest              string         `json:"digest,omitempty"`
+	References          []string       `json:"references,omitempty"` // Other tags of the same digest
+	OS                  string         `json:"os,omitempty"`         // e.g. "debian 12.5"
+	EOSL                bool           `json:"eosl,omitempty"`       // The OS no longer gets security updates
+	Workloads           []string       `json:"workloads"`            // namespace/Kind/name
+	BySeverity          map[string]int `json:"bySeverity"`
+	Vulnerabilities     int            `json:"vulnerabilities"`
+	BaseVulnerabilities int            `json:"baseVulnerabilities"` // In OS packages, fixed by a new base image
+	Fixable             int            `json:"fixable"`
+}
+
+// BaseImage is an OS release shared by several images. Its vulnerabilities
+// are in OS packages, so rebuilding the images on a patched base clears them
+// in every workload at once.
+type BaseImage struct {
+	OS              string         `json:"os"`
+	EOSL            bool           `json:"eosl,omitempty"`
+	Images          []string       `json:"images"`
+	Workloads       int            `json:"workloads"`
+	BySeverity      map[string]int `json:"bySeverity"`      // Unique CVE and package across the images
+	Vulnerabilities int            `json:"vulnerabilities"` // Unique CVE and package across the images
+}
+
+// ImageIndex groups vulnerability reports by image
+type ImageIndex struct {
+	images map[string]*indexedImage
+}
+
+type indexedImage struct {
+	ImageSummary
+	vulns map[string]bool   // CVE|package, counted once however many reports
+	base  map[string]string // Severity of each CVE|package in an OS package
+}
+
+// NewImageIndex creates an empty image index
+func NewImageIndex() *ImageIndex {
+	return &ImageIndex{images: make(map[string]*indexedImage)}
+}
+
+// Add indexes vulnerability reports
+func (x *ImageIndex) Add(reports ...v1alpha1.VulnerabilityReport) {
+	for _, report := range reports {
+		data := report.Report
+		image := data.Artifact.Image(data.Registry)
+		key := data.Artifact.Digest
+		if key == "" {
+			key = image
+		}
+		if key == "" {
+			continue
+		}
+
+		img, ok := x.images[key]
+		if !ok {
+			img = &indexedImage{
+				ImageSummary: ImageSummary{
+					Image:      image,
+					Digest:     data.Artifact.Digest,
+					BySeverity: make(map[string]int),
+				},
+				vulns: make(map[string]bool),
+				base:  make(map[string]string),
+			}
+			if data.OS.Family != "" {
+				img.OS = strings.TrimSpace(data.OS.Family + " " + data.OS.Name)
+				img.EOSL = data.OS.EOSL
+			}
+			x.images[key] = img
+		} else if image != img.Image && !slices.Contains(img.References, image) {
+			img.References = append(img.References, image)
+		}
+
+		namespace, kind, name := v1alpha1.ScannedResource(report.ObjectMeta)
+		workload := name
+		if kind != "" {
+			workload = kind + "/" + name
+		}
+		if namespace != "" {
+			workload = namespace + "/" + workload
+		}
+		if !slices.Contains(img.Workloads, workload) {
+			img.Workloads = append(img.Workloads, workload)
+		}
+
+		for _, v := range data.Vulnerabilities {
+			vkey := v.VulnerabilityID + "|" + v.Resource
+			if img.vulns[vkey] {
+				continue
+			}
+			img.vulns[vkey] = true
+			img.Vulnerabilities++
+			img.BySeverity[strings.ToUpper(v.Severity)]++
+			if v.FixedVersion != "" {
+				img.Fixable++
+			}
+			if osPackage(v, data.OS) {
+				img.BaseVulnerabilities++
+				img.base[vkey] = strings.ToUpper(v.Severity)
+			}
+		}
+	}
+}
+
+// osPackage reports whether a vulnerability is in an OS package. Reports of
+// older operators have no class, but OS targets end in "(family version)".
+func osPackage(v v1alpha1.Vulnerability, os v1alpha1.OS) bool {
+	if v.Class != "" {
+		return v.Class == "os-pkgs"
+	}
+	return os.Family != "" && strings.Contains(v.Target, "("+os.Family)
+}
+
+// Images returns the indexed images, those with the most critical and high
+// vulnerabilities first, then by the number of workloads
+func (x *ImageIndex) Images() []ImageSummary {
+	result := make([]ImageSummary, 0, len(x.images))
+	for _, img := range x.images {
+		s := img.ImageSummary
+		s.Workloads = slices.Clone(img.Workloads)
+		sort.Strings(s.Workloads)
+		s.References = slices.Clone(img.References)
+		sort.Strings(s.References)
+		s.BySeverity = maps.Clone(img.BySeverity)
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		for _, sev := range []trivy.Severity{trivy.SeverityCritical, trivy.SeverityHigh} {
+			if a.BySeverity[string(sev)] != b.BySeverity[string(sev)] {
+				return a.BySeverity[string(sev)] > b.BySeverity[string(sev)]
+			}
+		}
+		if len(a.Workloads) != len(b.Workloads) {
+			return len(a.Workloads) > len(b.Workloads)
+		}
+		return a.Image < b.Image
+	})
+	return result
+}
+
+// BaseImages groups the images by OS release, the releases run by the most
+// workloads first. Images without a detected OS, such as scratch images of a
+// static binary, are left out.
+func (x *ImageIndex) BaseImages() []BaseImage {
+	type group struct {
+		BaseImage
+		vulns map[string]string
+	}
+	byOS := make(map[string]*group)
+	for _, img := range x.images {
+		if img.OS == "" {
+			continue
+		}
+		g, ok := byOS[img.OS]
+		if !ok {
+			g = &group{
+				BaseImage: BaseImage{OS: img.OS, EOSL: img.EOSL, BySeverity: make(map[string]int)},
+				vulns:     make(map[string]string),
+			}
+			byOS[img.OS] = g
+		}
+		g.Images = append(g.Images, img.Image)
+		g.Workloads += len(img.Workloads)
+		maps.Copy(g.vulns, img.base)
+	}
+
+	result := make([]BaseImage, 0, len(byOS))
+	for _, g := range byOS {
+		for _, severity := range g.vulns {
+			g.BySeverity[severity]++
+		}
+		g.Vulnerabilities = len(g.vulns)
+		sort.Strings(g.Images)
+		result = append(result, g.BaseImage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Workloads != result[j].Workloads {
+			return result[i].Workloads > result[j].Workloads
+		}
+		return result[i].OS < result[j].OS
+	})
+	return result
+}
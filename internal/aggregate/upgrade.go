@@ -0,0 +1,248 @@
+package aggregate
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// DefaultUpgradeSeverity is the lowest severity an upgrade plan clears
+const DefaultUpgradeSeverity = trivy.SeverityHigh
+
+// ImageUpgrades is the smallest set of package upgrades that clears the
+// vulnerabilities of an image at or above a severity
+type ImageUpgrades struct {
+	Image     string           `json:"image"`
+	Digest    string           `json:"digest,omitempty"`
+	OSFamily  string           `json:"osFamily,omitempty"` // e.g. debian or alpine
+	Workloads []string         `json:"workloads"`
+	Upgrades  []PackageUpgrade `json:"upgrades"`
+	Unfixable []PackageUpgrade `json:"unfixable,omitempty"` // Packages with vulnerabilities no version fixes yet
+}
+
+// PackageUpgrade is one package to upgrade and the vulnerabilities it clears.
+// For unfixable packages FixedVersion is empty.
+type PackageUpgrade struct {
+	PkgName          string   `json:"pkgName"`
+	InstalledVersion string   `json:"installedVersion"`
+	FixedVersion     string   `json:"fixedVersion,omitempty"`
+	Class            string   `json:"class,omitempty"` // os-pkgs or lang-pkgs
+	Severity         string   `json:"severity"`        // Most severe vulnerability cleared
+	VulnerabilityIDs []string `json:"vulnerabilityIDs"`
+}
+
+// UpgradePlan computes, per image, the package upgrades that clear every
+// vulnerability at or above minSeverity. A package fixed by several versions
+// is upgraded once, to the highest of them.
+func UpgradePlan(vulns []UniqueVulnerability, minSeverity trivy.Severity) []ImageUpgrades {
+	type pkgKey struct{ name, installed string }
+	type imagePlan struct {
+		ImageUpgrades
+		upgrades  map[pkgKey]*PackageUpgrade
+		unfixable map[pkgKey]*PackageUpgrade
+	}
+
+	byImage := make(map[string]*imagePlan)
+	var order []string
+	for _, v := range vulns {
+		if trivy.Severity(v.Severity).Rank() < minSeverity.Rank() {
+			continue
+		}
+		key := v.Digest
+		if key == "" {
+			key = v.Image
+		}
+		plan, ok := byImage[key]
+		if !ok {
+			plan = &imagePlan{
+				ImageUpgrades: ImageUpgrades{Image: v.Image, Digest: v.Digest, OSFamily: v.OSFamily},
+				upgrades:      make(map[pkgKey]*PackageUpgrade),
+				unfixable:     make(map[pkgKey]*PackageUpgrade),
+			}
+			byImage[key] = plan
+			order = append(order, key)
+		}
+		for _, w := range v.Workloads {
+			if !slices.Contains(plan.Workloads, w) {
+				plan.Workloads = append(plan.Workloads, w)
+			}
+		}
+
+		pkg := pkgKey{v.PkgName, v.InstalledVersion}
+		fixed := fixingVersion(v.InstalledVersion, v.FixedVersion)
+		packages := plan.upgrades
+		if fixed == "" {
+			packages = plan.unfixable
+		}
+		u, ok := packages[pkg]
+		if !ok {
+			u = &PackageUpgrade{PkgName: v.PkgName, InstalledVersion: v.InstalledVersion, Class: v.Class}
+			packages[pkg] = u
+		}
+		if fixed != "" && CompareVersions(fixed, u.FixedVersion) > 0 {
+			u.FixedVersion = fixed
+		}
+		if trivy.Severity(v.Severity).Rank() > trivy.Severity(u.Severity).Rank() {
+			u.Severity = v.Severity
+		}
+		if !slices.Contains(u.VulnerabilityIDs, v.VulnerabilityID) {
+			u.VulnerabilityIDs = append(u.VulnerabilityIDs, v.VulnerabilityID)
+		}
+	}
+
+	result := make([]ImageUpgrades, 0, len(order))
+	for _, key := range order {
+		plan := byImage[key]
+		sort.Strings(plan.Workloads)
+		plan.Upgrades = sortedUpgrades(plan.upgrades)
+		plan.Unfixable = sortedUpgrades(plan.unfixable)
+		result = append(result, plan.ImageUpgrades)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i].Workloads) != len(result[j].Workloads) {
+			return len(result[i].Workloads) > len(result[j].Workloads)
+		}
+		return result[i].Image < result[j].Image
+	})
+	return result
+}
+
+// sortedUpgrades returns the upgrades most severe first, then by package
+func sortedUpgrades[K comparable](packages map[K]*PackageUpgrade) []PackageUpgrade {
+	result := make([]PackageUpgrade, 0, len(packages))
+	for _, u := range packages {
+		sort.Strings(u.VulnerabilityIDs)
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if ra, rb := trivy.Severity(a.Severity).Rank(), trivy.Severity(b.Severity).Rank(); ra != rb {
+			return ra > rb
+		}
+		return a.PkgName < b.PkgName
+	})
+	return result
+}
+
+// fixingVersion picks the version to upgrade to from Trivy's fixed versions,
+// which list one version per release branch (e.g. "1.21.12, 1.22.5"): the
+// lowest above the installed version, so a package stays on its branch
+func fixingVersion(installed, fixed string) string {
+	var best string
+	for _, candidate := range strings.Split(fixed, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" || CompareVersions(candidate, installed) <= 0 {
+			continue
+		}
+		if best == "" || CompareVersions(candidate, best) < 0 {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// CompareVersions compares two package versions, returning -1, 0 or 1. It
+// understands the common parts of semver, Debian, RPM and Alpine versions:
+// an epoch ("1:2.3"), numeric runs compared as numbers, and "~" sorting
+// before anything, as in "1.0~rc1" < "1.0". An empty version is the lowest.
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	if a == b {
+		return 0
+	}
+	if a == "" || b == "" {
+		if a == "" {
+			return -1
+		}
+		return 1
+	}
+
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if c := compareNumeric(epochA, epochB); c != 0 {
+		return c
+	}
+	a, b = restA, restB
+
+	for a != "" || b != "" {
+		// Non-digit runs compare character by character, "~" first
+		var runA, runB string
+		runA, a = splitRun(a, false)
+		runB, b = splitRun(b, false)
+		if c := compareText(runA, runB); c != 0 {
+			return c
+		}
+		runA, a = splitRun(a, true)
+		runB, b = splitRun(b, true)
+		if c := compareNumeric(runA, runB); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// splitEpoch splits "1:2.3" into "1" and "2.3"; without an epoch it is "0"
+func splitEpoch(v string) (string, string) {
+	if epoch, rest, ok := strings.Cut(v, ":"); ok && epoch != "" && strings.Trim(epoch, "0123456789") == "" {
+		return epoch, rest
+	}
+	return "0", v
+}
+
+// splitRun splits the leading run of digits (or non-digits) off v
+func splitRun(v string, digits bool) (string, string) {
+	i := strings.IndexFunc(v, func(r rune) bool { return unicode.IsDigit(r) != digits })
+	if i < 0 {
+		return v, ""
+	}
+	return v[:i], v[i:]
+}
+
+// compareNumeric compares runs of digits by value, however long
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareText compares non-digit runs: "~" sorts before the end of the run,
+// letters before other characters
+func compareText(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if c := compareChar(a, b, i); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareChar(a, b string, i int) int {
+	order := func(s string) int {
+		switch {
+		case i >= len(s):
+			return 0
+		case s[i] == '~':
+			return -1
+		case unicode.IsLetter(rune(s[i])):
+			return int(s[i])
+		default:
+			return int(s[i]) + 256
+		}
+	}
+	oa, ob := order(a), order(b)
+	switch {
+	case oa < ob:
+		return -1
+	case oa > ob:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,376 @@
+// Package bundle exports the Trivy Operator reports of a cluster, with the
+// cluster metadata trix needs to interpret them, to a compressed archive, and
+// serves an archive back as an offline cluster. Every read command can then
+// analyze findings away from the cluster, e.g. when it is in a restricted
+// network.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FormatVersion is the version of the archive layout; Open rejects newer ones
+const FormatVersion = 1
+
+// pageSize is how many objects are listed per request while exporting
+const pageSize = 500
+
+// Metadata describes where and when a bundle was exported
+type Metadata struct {
+	FormatVersion int            `json:"formatVersion"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	Context       string         `json:"context"`
+	ServerVersion string         `json:"serverVersion,omitempty"`
+	TrixVersion   string         `json:"trixVersion,omitempty"`
+	Namespace     string         `json:"namespace,omitempty"` // Empty if all namespaces were exported
+	Reports       map[string]int `json:"reports"`             // Reports per resource, for the CRDs the cluster served
+}
+
+// Options configures an export
+type Options struct {
+	Namespace   string // Only export this namespace; empty exports all
+	TrixVersion string
+}
+
+// clusterObjects is the cluster state a bundle keeps besides the reports:
+// just what trix reads, without pod specs or annotations, which may hold
+// secrets in environment variables or last-applied configurations
+type clusterObjects struct {
+	Namespaces      []corev1.Namespace           `json:"namespaces"`
+	Pods            []corev1.Pod                 `json:"pods"`
+	ReplicaSets     []appsv1.ReplicaSet          `json:"replicaSets"`
+	Jobs            []batchv1.Job                `json:"jobs"`
+	Deployments     []appsv1.Deployment          `json:"deployments"` // The Trivy Operator's, for its health
+	NetworkPolicies []networkingv1.NetworkPolicy `json:"networkPolicies"`
+}
+
+// Archive entries
+const (
+	metadataFile = "metadata.json"
+	clusterFile  = "cluster.json"
+	reportsDir   = "reports/"
+)
+
+// Export writes a bundle of the cluster's reports to w
+func Export(ctx context.Context, w io.Writer, k8sClient *kubectl.Client, opts Options) (*Metadata, error) {
+	meta := &Metadata{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		TrixVersion:   opts.TrixVersion,
+		Namespace:     opts.Namespace,
+		Reports:       make(map[string]int),
+	}
+	meta.Context, _ = k8sClient.GetCurrentContext()
+	if version, err := k8sClient.Clientset().Discovery().ServerVersion(); err == nil {
+		meta.ServerVersion = version.GitVersion
+	}
+
+	served, err := k8sClient.Clientset().Discovery().ServerResourcesForGroupVersion(v1alpha1.Group + "/" + v1alpha1.Version)
+	if apierrors.IsNotFound(err) {
+		return nil, trivy.ErrOperatorNotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover Trivy report resources: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, resource := range served.APIResources {
+		if strings.Contains(resource.Name, "/") {
+			continue // Subresources
+		}
+		gvr := schema.GroupVersionResource{Group: v1alpha1.Group, Version: v1alpha1.Version, Resource: resource.Name}
+		namespace := opts.Namespace
+		if !resource.Namespaced {
+			namespace = ""
+		}
+		list, err := listReports(ctx, k8sClient, gvr, namespace)
+		if err != nil {
+			return nil, err
+		}
+		meta.Reports[resource.Name] = len(list.Items)
+		data, err := list.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", resource.Name, err)
+		}
+		if err := writeFile(tw, reportsDir+resource.Name+".json", data); err != nil {
+			return nil, err
+		}
+	}
+
+	objects, err := exportCluster(ctx, k8sClient, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(tw, clusterFile, objects); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(tw, metadataFile, meta); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return meta, nil
+}
+
+// listReports lists every report of a resource, page by page
+func listReports(ctx context.Context, k8sClient *kubectl.Client, gvr schema.GroupVersionResource, namespace string) (*unstructured.UnstructuredList, error) {
+	all := &unstructured.UnstructuredList{}
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := k8sClient.DynamicClient().Resource(gvr).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+		all.SetAPIVersion(list.GetAPIVersion())
+		all.SetKind(list.GetKind())
+		for _, item := range list.Items {
+			item.SetManagedFields(nil)
+			all.Items = append(all.Items, item)
+		}
+		if list.GetContinue() == "" {
+			return all, nil
+		}
+		opts.Continue = list.GetContinue()
+	}
+}
+
+// exportCluster reads the cluster state trix uses besides the reports: pods
+// and their owners for workload names and staleness, namespaces, the
+// operator deployment and NetworkPolicies. Kinds the user may not list are
+// left out rather than failing the export.
+func exportCluster(ctx context.Context, k8sClient *kubectl.Client, namespace string) (*clusterObjects, error) {
+	cs := k8sClient.Clientset()
+	objects := &clusterObjects{}
+
+	skip := func(what string, err error) error {
+		if apierrors.IsForbidden(err) {
+			fmt.Fprintf(os.Stderr, "Warning: not allowed to list %s; the bundle won't include them\n", what)
+			return nil
+		}
+		return fmt.Errorf("failed to list %s: %w", what, err)
+	}
+
+	if namespace == "" {
+		list, err := cs.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if err := skip("namespaces", err); err != nil {
+				return nil, err
+			}
+		} else {
+			for _, ns := range list.Items {
+				objects.Namespaces = append(objects.Namespaces, corev1.Namespace{ObjectMeta: strip(ns.ObjectMeta)})
+			}
+		}
+	} else {
+		objects.Namespaces = []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: namespace}}}
+	}
+
+	err := eachPage(func(opts metav1.ListOptions) (string, error) {
+		list, err := cs.CoreV1().Pods(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, pod := range list.Items {
+			objects.Pods = append(objects.Pods, stripPod(pod))
+		}
+		return list.Continue, nil
+	})
+	if err != nil {
+		if err := skip("pods", err); err != nil {
+			return nil, err
+		}
+	}
+
+	err = eachPage(func(opts metav1.ListOptions) (string, error) {
+		list, err := cs.AppsV1().ReplicaSets(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, rs := range list.Items {
+			objects.ReplicaSets = append(objects.ReplicaSets, appsv1.ReplicaSet{ObjectMeta: strip(rs.ObjectMeta)})
+		}
+		return list.Continue, nil
+	})
+	if err != nil {
+		if err := skip("replicasets", err); err != nil {
+			return nil, err
+		}
+	}
+
+	err = eachPage(func(opts metav1.ListOptions) (string, error) {
+		list, err := cs.BatchV1().Jobs(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, job := range list.Items {
+			objects.Jobs = append(objects.Jobs, batchv1.Job{ObjectMeta: strip(job.ObjectMeta)})
+		}
+		return list.Continue, nil
+	})
+	if err != nil {
+		if err := skip("jobs", err); err != nil {
+			return nil, err
+		}
+	}
+
+	netpols, err := cs.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if err := skip("networkpolicies", err); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, np := range netpols.Items {
+			objects.NetworkPolicies = append(objects.NetworkPolicies, networkingv1.NetworkPolicy{ObjectMeta: strip(np.ObjectMeta), Spec: np.Spec})
+		}
+	}
+
+	// The operator runs outside the exported namespace, so look everywhere
+	deploys, err := cs.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=trivy-operator"})
+	if err == nil {
+		for _, d := range deploys.Items {
+			objects.Deployments = append(objects.Deployments, stripDeployment(d))
+		}
+	}
+	return objects, nil
+}
+
+// eachPage calls list with the continue token of the previous page until
+// there are no more
+func eachPage(list func(metav1.ListOptions) (string, error)) error {
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		next, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		opts.Continue = next
+	}
+}
+
+// strip keeps the identity, labels and owners of an object
+func strip(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              meta.Name,
+		Namespace:         meta.Namespace,
+		UID:               meta.UID,
+		Labels:            meta.Labels,
+		OwnerReferences:   meta.OwnerReferences,
+		CreationTimestamp: meta.CreationTimestamp,
+	}
+}
+
+// stripPod keeps a pod's containers' names and images and their status
+func stripPod(pod corev1.Pod) corev1.Pod {
+	p := corev1.Pod{ObjectMeta: strip(pod.ObjectMeta)}
+	for _, c := range pod.Spec.InitContainers {
+		p.Spec.InitContainers = append(p.Spec.InitContainers, corev1.Container{Name: c.Name, Image: c.Image})
+	}
+	for _, c := range pod.Spec.Containers {
+		p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: c.Name, Image: c.Image})
+	}
+	p.Status.Phase = pod.Status.Phase
+	for _, s := range pod.Status.InitContainerStatuses {
+		p.Status.InitContainerStatuses = append(p.Status.InitContainerStatuses, corev1.ContainerStatus{Name: s.Name, Image: s.Image, ImageID: s.ImageID})
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Name: s.Name, Image: s.Image, ImageID: s.ImageID})
+	}
+	return p
+}
+
+// stripDeployment keeps what the operator health check reads
+func stripDeployment(deploy appsv1.Deployment) appsv1.Deployment {
+	d := appsv1.Deployment{ObjectMeta: strip(deploy.ObjectMeta)}
+	d.Spec.Replicas = deploy.Spec.Replicas
+	for _, c := range deploy.Spec.Template.Spec.Containers {
+		d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, corev1.Container{Name: c.Name, Image: c.Image})
+	}
+	d.Status.ReadyReplicas = deploy.Status.ReadyReplicas
+	return d
+}
+
+func writeJSON(tw *tar.Writer, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return writeFile(tw, name, data)
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// readArchive reads the entries of a bundle into memory
+func readArchive(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a trix bundle: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	if _, ok := files[metadataFile]; !ok {
+		return nil, fmt.Errorf("not a trix bundle: no %s", metadataFile)
+	}
+	return files, nil
+}
@@ -0,0 +1,121 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Bundle is an exported bundle, opened for reading
+type Bundle struct {
+	Metadata Metadata
+	client   *kubectl.Client
+}
+
+// Open reads a bundle into memory and serves it as an offline cluster
+func Open(file string) (*Bundle, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	files, err := readArchive(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	b := &Bundle{}
+	if err := json.Unmarshal(files[metadataFile], &b.Metadata); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse %s: %w", file, metadataFile, err)
+	}
+	if b.Metadata.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%s: bundle format %d is newer than this trix supports (%d); upgrade trix", file, b.Metadata.FormatVersion, FormatVersion)
+	}
+
+	// Every report CRD must be registered for the fake client to list it,
+	// even the ones the bundle has no reports of
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, gvr := range v1alpha1.AllReports {
+		listKinds[gvr] = "UnknownList"
+	}
+	var reports []runtime.Object
+	served := &metav1.APIResourceList{GroupVersion: v1alpha1.Group + "/" + v1alpha1.Version}
+	for name, data := range files {
+		resource, ok := strings.CutPrefix(name, reportsDir)
+		if !ok {
+			continue
+		}
+		resource = strings.TrimSuffix(resource, ".json")
+		var list unstructured.UnstructuredList
+		if err := list.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse %s: %w", file, name, err)
+		}
+		gvr := schema.GroupVersionResource{Group: v1alpha1.Group, Version: v1alpha1.Version, Resource: resource}
+		if list.GetKind() != "" {
+			listKinds[gvr] = list.GetKind()
+		}
+		served.APIResources = append(served.APIResources, metav1.APIResource{
+			Name:       resource,
+			Namespaced: !v1alpha1.ClusterScoped(gvr),
+			Kind:       strings.TrimSuffix(list.GetKind(), "List"),
+		})
+		for i := range list.Items {
+			reports = append(reports, &list.Items[i])
+		}
+	}
+
+	var objects clusterObjects
+	if data, ok := files[clusterFile]; ok {
+		if err := json.Unmarshal(data, &objects); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse %s: %w", file, clusterFile, err)
+		}
+	}
+	var typed []runtime.Object
+	for i := range objects.Namespaces {
+		typed = append(typed, &objects.Namespaces[i])
+	}
+	for i := range objects.Pods {
+		typed = append(typed, &objects.Pods[i])
+	}
+	for i := range objects.ReplicaSets {
+		typed = append(typed, &objects.ReplicaSets[i])
+	}
+	for i := range objects.Jobs {
+		typed = append(typed, &objects.Jobs[i])
+	}
+	for i := range objects.Deployments {
+		typed = append(typed, &objects.Deployments[i])
+	}
+	for i := range objects.NetworkPolicies {
+		typed = append(typed, &objects.NetworkPolicies[i])
+	}
+
+	clientset := fake.NewClientset(typed...)
+	discovery := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &version.Info{GitVersion: b.Metadata.ServerVersion}
+	if len(served.APIResources) > 0 {
+		discovery.Resources = []*metav1.APIResourceList{served}
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, reports...)
+
+	b.client = kubectl.NewOfflineClient(clientset, dynamicClient, "bundle:"+b.Metadata.Context)
+	return b, nil
+}
+
+// Client returns a client that reads from the bundle. Changes made through it,
+// such as deleted reports, only last until the process exits.
+func (b *Bundle) Client() *kubectl.Client {
+	return b.client
+}
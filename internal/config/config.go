@@ -0,0 +1,101 @@
+// Package config loads the trix configuration file.
+//
+// The file lives at ~/.config/trix/config.yaml (or the path in TRIX_CONFIG)
+// and holds defaults for command-line flags. Flags always take precedence.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the contents of the configuration file.
+type Config struct {
+	LLM      LLM      `json:"llm"`
+	Trivy    Trivy    `json:"trivy"`
+	Notify   Notify   `json:"notify"`
+	Schedule Schedule `json:"schedule"`
+}
+
+// LLM holds defaults for the AI provider.
+type LLM struct {
+	Provider    string `json:"provider,omitempty"`     // e.g. "anthropic", or a fallback chain "anthropic,ollama"
+	Model       string `json:"model,omitempty"`        // e.g. "gpt-4o"
+	BaseURL     string `json:"base_url,omitempty"`     // OpenAI-compatible, Hugging Face or llama.cpp endpoint
+	OllamaURL   string `json:"ollama_url,omitempty"`   // Ollama server
+	Proxy       string `json:"proxy,omitempty"`        // Proxy for LLM requests
+	CAFile      string `json:"ca_file,omitempty"`      // Extra CA bundle for LLM endpoints
+	MaxAttempts int    `json:"max_attempts,omitempty"` // Attempts per request on transient errors
+}
+
+// Trivy holds defaults for scanning images directly with the trivy binary.
+type Trivy struct {
+	Server string `json:"server,omitempty"` // Trivy server to scan with, e.g. http://trivy.corp:4954
+	Token  string `json:"token,omitempty"`  // Token the server requires
+}
+
+// Notify holds the channels 'trix notify' sends to, by name.
+type Notify struct {
+	Channels map[string]Channel `json:"channels,omitempty"`
+}
+
+// Channel is where 'trix notify' sends a summary. Exactly one of URL and
+// Command is set.
+type Channel struct {
+	Type    string `json:"type,omitempty"`    // slack or webhook (default) for a URL
+	URL     string `json:"url,omitempty"`     // Webhook URL; $VAR and ${VAR} are expanded
+	Command string `json:"command,omitempty"` // Shell command, e.g. "mail -s trix security@example.com"
+}
+
+// Schedule holds the jobs 'trix schedule' runs.
+type Schedule struct {
+	Jobs []Job `json:"jobs,omitempty"`
+}
+
+// Job is a scan 'trix schedule' runs on a cron schedule. Its findings are
+// recorded in the history and sent to its channels.
+type Job struct {
+	Name        string   `json:"name"`
+	Cron        string   `json:"cron"`                   // e.g. "0 6 * * *" or "@daily"
+	Namespace   string   `json:"namespace,omitempty"`    // Empty for all namespaces
+	MinSeverity string   `json:"min_severity,omitempty"` // Leave out findings below this severity
+	Channels    []string `json:"channels,omitempty"`     // Names of notify channels
+	Limit       int      `json:"limit,omitempty"`        // Findings to list in notifications (default 10)
+	SendEmpty   bool     `json:"send_empty,omitempty"`   // Also notify when nothing was found
+}
+
+// Path returns the location of the configuration file.
+func Path() string {
+	if path := os.Getenv("TRIX_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trix", "config.yaml")
+}
+
+// Load reads the configuration file. A missing file yields an empty configuration.
+func Load() (*Config, error) {
+	cfg := &Config{}
+	path := Path()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
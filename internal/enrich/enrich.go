@@ -0,0 +1,287 @@
+// Package enrich looks up vulnerability metadata the Trivy reports lack, such
+// as the full description, CVSS vector, CWEs and references, from OSV.dev and
+// the NVD, and the EPSS exploit probability from FIRST. Advisory lookups are
+// rate limited and cached on disk, so reports and LLM prompts get real
+// advisory text instead of a guess. CISA's Known Exploited Vulnerabilities
+// catalog marks CVEs exploited in the wild.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultCacheTTL is how long looked up advisories are reused
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// ErrNotFound is returned when no source knows a vulnerability
+var ErrNotFound = errors.New("vulnerability not found in OSV or NVD")
+
+// Advisory is what is known about a vulnerability, merged from all sources
+type Advisory struct {
+	ID          string    `json:"id"`
+	Aliases     []string  `json:"aliases,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Severity    string    `json:"severity,omitempty"` // CRITICAL, HIGH, ...
+	Score       float64   `json:"score,omitempty"`    // CVSS base score
+	Vector      string    `json:"vector,omitempty"`   // CVSS vector
+	CWEs        []string  `json:"cwes,omitempty"`
+	Published   time.Time `json:"published,omitempty"`
+	Modified    time.Time `json:"modified,omitempty"`
+	Affected    []Package `json:"affected,omitempty"`
+	References  []string  `json:"references,omitempty"`
+	Sources     []string  `json:"sources"` // Where the data came from: osv, nvd
+}
+
+// Package is an affected package and the versions that fix it
+type Package struct {
+	Ecosystem string   `json:"ecosystem"`
+	Name      string   `json:"name"`
+	Fixed     []string `json:"fixed,omitempty"`
+}
+
+// Config configures a Client. Zero values use the defaults.
+type Config struct {
+	CacheDir  string        // Defaults to DefaultCacheDir; "-" disables the cache
+	CacheTTL  time.Duration // Defaults to DefaultCacheTTL
+	NVDAPIKey string        // Raises the NVD rate limit from 5 to 50 requests per 30s
+	OSVURL    string        // Defaults to https://api.osv.dev
+	NVDURL    string        // Defaults to https://services.nvd.nist.gov
+	EPSSURL   string        // Defaults to https://api.first.org
+	KEVURL    string        // Defaults to CISA's catalog feed
+}
+
+// Client looks up advisories
+type Client struct {
+	cfg  Config
+	http *http.Client
+	osv  *rate.Limiter
+	nvd  *rate.Limiter
+	epss *rate.Limiter
+	kev  *rate.Limiter
+}
+
+// DefaultCacheDir returns the cache directory (~/.cache/trix/cve on Linux)
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "trix", "cve"), nil
+}
+
+// NewClient creates a client
+func NewClient(cfg Config) *Client {
+	if cfg.CacheDir == "" {
+		// Without a cache directory lookups still work, just uncached
+		cfg.CacheDir, _ = DefaultCacheDir()
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+	if cfg.OSVURL == "" {
+		cfg.OSVURL = "https://api.osv.dev"
+	}
+	if cfg.NVDURL == "" {
+		cfg.NVDURL = "https://services.nvd.nist.gov"
+	}
+	if cfg.EPSSURL == "" {
+		cfg.EPSSURL = "https://api.first.org"
+	}
+	if cfg.KEVURL == "" {
+		cfg.KEVURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+	}
+
+	// NVD allows 5 requests per 30 seconds, or 50 with an API key
+	nvdEvery := 6 * time.Second
+	if cfg.NVDAPIKey != "" {
+		nvdEvery = 600 * time.Millisecond
+	}
+	return &Client{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 30 * time.Second},
+		osv:  rate.NewLimiter(rate.Limit(10), 10),
+		nvd:  rate.NewLimiter(rate.Every(nvdEvery), 1),
+		epss: rate.NewLimiter(rate.Limit(5), 5),
+		kev:  rate.NewLimiter(rate.Limit(1), 1),
+	}
+}
+
+// cacheEntry is the on-disk format of a lookup. A nil advisory records that
+// no source knew the ID, so it isn't asked for again until the entry expires.
+type cacheEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Advisory  *Advisory `json:"advisory"`
+}
+
+// Lookup returns the advisory of a vulnerability ID such as CVE-2024-45337 or
+// GHSA-v778-237x-gjrc. OSV is asked first; the NVD fills in what it lacks for
+// CVEs. If one source fails, the other's data is returned.
+func (c *Client) Lookup(ctx context.Context, id string) (*Advisory, error) {
+	id = NormalizeID(id)
+	if entry, ok := c.cached(id); ok {
+		if entry.Advisory == nil {
+			return nil, ErrNotFound
+		}
+		return entry.Advisory, nil
+	}
+
+	advisory, osvErr := c.lookupOSV(ctx, id)
+	if errors.Is(osvErr, ErrNotFound) {
+		osvErr = nil
+	}
+
+	var nvdErr error
+	if strings.HasPrefix(id, "CVE-") && (advisory == nil || advisory.incomplete()) {
+		var nvd *Advisory
+		nvd, nvdErr = c.lookupNVD(ctx, id)
+		if errors.Is(nvdErr, ErrNotFound) {
+			nvdErr = nil
+		}
+		advisory = merge(advisory, nvd)
+	}
+
+	if advisory == nil {
+		if err := errors.Join(osvErr, nvdErr); err != nil {
+			return nil, fmt.Errorf("failed to look up %s: %w", id, err)
+		}
+		c.store(id, nil)
+		return nil, ErrNotFound
+	}
+	// Don't cache what a failed source may still complete
+	if osvErr == nil && nvdErr == nil {
+		c.store(id, advisory)
+	}
+	return advisory, nil
+}
+
+// NormalizeID uppercases CVE IDs. Others keep their case, since GHSA IDs are
+// lowercase after the prefix.
+func NormalizeID(id string) string {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(strings.ToUpper(id), "CVE-") {
+		return strings.ToUpper(id)
+	}
+	return id
+}
+
+// incomplete reports whether the advisory lacks what the NVD could add
+func (a *Advisory) incomplete() bool {
+	return a.Description == "" || a.Score == 0 || len(a.References) == 0
+}
+
+// merge fills in the gaps of a from b. Either may be nil.
+func merge(a, b *Advisory) *Advisory {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.Description == "" {
+		a.Description = b.Description
+	}
+	if a.Score == 0 && b.Score > 0 {
+		// Keep the score, vector and severity consistent
+		a.Score, a.Vector = b.Score, b.Vector
+		if b.Severity != "" {
+			a.Severity = b.Severity
+		}
+	}
+	if a.Severity == "" {
+		a.Severity = b.Severity
+	}
+	if a.Published.IsZero() {
+		a.Published = b.Published
+	}
+	a.CWEs = union(a.CWEs, b.CWEs)
+	a.References = union(a.References, b.References)
+	a.Sources = union(a.Sources, b.Sources)
+	return a
+}
+
+// union appends the values of b missing from a
+func union(a, b []string) []string {
+	for _, v := range b {
+		if !slices.Contains(a, v) {
+			a = append(a, v)
+		}
+	}
+	return a
+}
+
+// cached returns the cache entry of an ID if it exists and hasn't expired
+func (c *Client) cached(id string) (*cacheEntry, bool) {
+	if c.cfg.CacheDir == "" || c.cfg.CacheDir == "-" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.cachePath(id))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.CreatedAt) >= c.cfg.CacheTTL {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store caches a lookup. Caching is best effort; a failed write is ignored.
+func (c *Client) store(id string, advisory *Advisory) {
+	if c.cfg.CacheDir == "" || c.cfg.CacheDir == "-" {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{CreatedAt: time.Now(), Advisory: advisory})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.cfg.CacheDir, 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.cachePath(id), data, 0o600)
+}
+
+func (c *Client) cachePath(id string) string {
+	// IDs are like CVE-2024-1234 or GHSA-xxxx; keep them safe as file names
+	return filepath.Join(c.cfg.CacheDir, strings.NewReplacer("/", "_", "\\", "_").Replace(id)+".json")
+}
+
+// get fetches a JSON document after waiting for the limiter. A 404 is
+// ErrNotFound.
+func (c *Client) get(ctx context.Context, limiter *rate.Limiter, url string, header http.Header, v any) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,63 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// epssBatchSize is how many CVEs are asked for per request; FIRST pages
+// responses at 100
+const epssBatchSize = 100
+
+// EPSS is the probability that a CVE is exploited in the next 30 days
+type EPSS struct {
+	Score      float64 `json:"score"`      // 0 to 1
+	Percentile float64 `json:"percentile"` // Share of CVEs scoring lower
+	Date       string  `json:"date"`       // Day the score was computed
+}
+
+// epssResponse is the part of a FIRST EPSS API response used here
+type epssResponse struct {
+	Data []struct {
+		CVE        string `json:"cve"`
+		EPSS       string `json:"epss"`
+		Percentile string `json:"percentile"`
+		Date       string `json:"date"`
+	} `json:"data"`
+}
+
+// EPSS returns the EPSS scores of CVEs from FIRST, keyed by CVE ID. Other IDs
+// and CVEs FIRST doesn't score are left out. Scores change daily, so they
+// aren't cached.
+func (c *Client) EPSS(ctx context.Context, ids []string) (map[string]EPSS, error) {
+	var cves []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		id = NormalizeID(id)
+		if strings.HasPrefix(id, "CVE-") && !seen[id] {
+			seen[id] = true
+			cves = append(cves, id)
+		}
+	}
+
+	scores := make(map[string]EPSS, len(cves))
+	for start := 0; start < len(cves); start += epssBatchSize {
+		batch := cves[start:min(start+epssBatchSize, len(cves))]
+		var resp epssResponse
+		if err := c.get(ctx, c.epss, c.cfg.EPSSURL+"/data/v1/epss?cve="+url.QueryEscape(strings.Join(batch, ",")), nil, &resp); err != nil {
+			return scores, fmt.Errorf("failed to fetch EPSS scores: %w", err)
+		}
+		for _, d := range resp.Data {
+			score, err := strconv.ParseFloat(d.EPSS, 64)
+			if err != nil {
+				continue
+			}
+			percentile, _ := strconv.ParseFloat(d.Percentile, 64)
+			scores[NormalizeID(d.CVE)] = EPSS{Score: score, Percentile: percentile, Date: d.Date}
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,96 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// kevCacheTTL is how long the KEV catalog is reused; CISA adds entries a few
+// times a week
+const kevCacheTTL = 24 * time.Hour
+
+// KEV is a CVE's entry in CISA's Known Exploited Vulnerabilities catalog
+type KEV struct {
+	Name            string `json:"name"`                      // e.g. "Apache Log4j2 Remote Code Execution Vulnerability"
+	DateAdded       string `json:"dateAdded"`                 // Day it was added, YYYY-MM-DD
+	DueDate         string `json:"dueDate,omitempty"`         // Remediation deadline for US federal agencies
+	KnownRansomware bool   `json:"knownRansomware,omitempty"` // Used in ransomware campaigns
+}
+
+// kevCatalog is the part of the CISA catalog feed used here
+type kevCatalog struct {
+	Vulnerabilities []struct {
+		CVEID                      string `json:"cveID"`
+		VulnerabilityName          string `json:"vulnerabilityName"`
+		DateAdded                  string `json:"dateAdded"`
+		DueDate                    string `json:"dueDate"`
+		KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+	} `json:"vulnerabilities"`
+}
+
+// kevCacheEntry is the on-disk format of the catalog
+type kevCacheEntry struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Catalog   map[string]KEV `json:"catalog"`
+}
+
+// KEV returns the entries of the CISA Known Exploited Vulnerabilities
+// catalog that match ids, keyed by CVE ID. The catalog is downloaded whole
+// and cached for a day.
+func (c *Client) KEV(ctx context.Context, ids []string) (map[string]KEV, error) {
+	catalog, err := c.kevCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]KEV)
+	for _, id := range ids {
+		id = NormalizeID(id)
+		if entry, ok := catalog[id]; ok {
+			result[id] = entry
+		}
+	}
+	return result, nil
+}
+
+// kevCatalog returns the whole catalog, from the cache when it is fresh
+func (c *Client) kevCatalog(ctx context.Context) (map[string]KEV, error) {
+	path := ""
+	if c.cfg.CacheDir != "" && c.cfg.CacheDir != "-" {
+		path = filepath.Join(c.cfg.CacheDir, "kev-catalog.json")
+		if data, err := os.ReadFile(path); err == nil {
+			var entry kevCacheEntry
+			if err := json.Unmarshal(data, &entry); err == nil && time.Since(entry.CreatedAt) < kevCacheTTL {
+				return entry.Catalog, nil
+			}
+		}
+	}
+
+	var feed kevCatalog
+	if err := c.get(ctx, c.kev, c.cfg.KEVURL, nil, &feed); err != nil {
+		return nil, fmt.Errorf("failed to fetch the KEV catalog: %w", err)
+	}
+	catalog := make(map[string]KEV, len(feed.Vulnerabilities))
+	for _, v := range feed.Vulnerabilities {
+		catalog[NormalizeID(v.CVEID)] = KEV{
+			Name:            v.VulnerabilityName,
+			DateAdded:       v.DateAdded,
+			DueDate:         v.DueDate,
+			KnownRansomware: strings.EqualFold(v.KnownRansomwareCampaignUse, "Known"),
+		}
+	}
+
+	// Caching is best effort, as for advisories
+	if path != "" {
+		if data, err := json.Marshal(kevCacheEntry{CreatedAt: time.Now(), Catalog: catalog}); err == nil {
+			if err := os.MkdirAll(c.cfg.CacheDir, 0o700); err == nil {
+				_ = os.WriteFile(path, data, 0o600)
+			}
+		}
+	}
+	return catalog, nil
+}
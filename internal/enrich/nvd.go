@@ -0,0 +1,104 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// nvdCVSS is one CVSS metric of an NVD record
+type nvdCVSS struct {
+	CVSSData struct {
+		BaseScore    float64 `json:"baseScore"`
+		VectorString string  `json:"vectorString"`
+		BaseSeverity string  `json:"baseSeverity"` // v3 only
+	} `json:"cvssData"`
+	BaseSeverity string `json:"baseSeverity"` // v2 only
+}
+
+// nvdResponse is the part of an NVD CVE API 2.0 response used here
+type nvdResponse struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Published    string `json:"published"`
+			LastModified string `json:"lastModified"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+			Metrics struct {
+				V31 []nvdCVSS `json:"cvssMetricV31"`
+				V30 []nvdCVSS `json:"cvssMetricV30"`
+				V2  []nvdCVSS `json:"cvssMetricV2"`
+			} `json:"metrics"`
+			Weaknesses []struct {
+				Description []struct {
+					Value string `json:"value"`
+				} `json:"description"`
+			} `json:"weaknesses"`
+			References []struct {
+				URL string `json:"url"`
+			} `json:"references"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// nvdTime is the timestamp format of the NVD API, which has no time zone
+const nvdTime = "2006-01-02T15:04:05.000"
+
+// lookupNVD fetches a CVE from the NVD
+func (c *Client) lookupNVD(ctx context.Context, id string) (*Advisory, error) {
+	var header http.Header
+	if c.cfg.NVDAPIKey != "" {
+		header = http.Header{"apiKey": {c.cfg.NVDAPIKey}}
+	}
+	var resp nvdResponse
+	if err := c.get(ctx, c.nvd, c.cfg.NVDURL+"/rest/json/cves/2.0?cveId="+url.QueryEscape(id), header, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Vulnerabilities) == 0 {
+		return nil, ErrNotFound
+	}
+	cve := resp.Vulnerabilities[0].CVE
+
+	a := &Advisory{ID: id, Sources: []string{"nvd"}}
+	for _, d := range cve.Descriptions {
+		if d.Lang == "en" {
+			a.Description = d.Value
+			break
+		}
+	}
+	a.Published, _ = time.Parse(nvdTime, cve.Published)
+	a.Modified, _ = time.Parse(nvdTime, cve.LastModified)
+
+	// Prefer the newest CVSS version
+	for _, metrics := range [][]nvdCVSS{cve.Metrics.V31, cve.Metrics.V30, cve.Metrics.V2} {
+		if len(metrics) == 0 {
+			continue
+		}
+		m := metrics[0]
+		a.Score = m.CVSSData.BaseScore
+		a.Vector = m.CVSSData.VectorString
+		a.Severity = strings.ToUpper(m.CVSSData.BaseSeverity)
+		if a.Severity == "" {
+			a.Severity = strings.ToUpper(m.BaseSeverity)
+		}
+		break
+	}
+
+	for _, w := range cve.Weaknesses {
+		for _, d := range w.Description {
+			// NVD-CWE-Other and NVD-CWE-noinfo say nothing
+			if strings.HasPrefix(d.Value, "CWE-") {
+				a.CWEs = union(a.CWEs, []string{d.Value})
+			}
+		}
+	}
+	for _, r := range cve.References {
+		a.References = union(a.References, []string{r.URL})
+	}
+	return a, nil
+}
@@ -0,0 +1,91 @@
+package enrich
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// osvVulnerability is the part of an OSV record used here
+type osvVulnerability struct {
+	ID         string    `json:"id"`
+	Aliases    []string  `json:"aliases"`
+	Summary    string    `json:"summary"`
+	Details    string    `json:"details"`
+	Published  time.Time `json:"published"`
+	Modified   time.Time `json:"modified"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"` // A CVSS vector
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	DatabaseSpecific struct {
+		CWEIDs   []string `json:"cwe_ids"`
+		Severity string   `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// lookupOSV fetches a vulnerability from OSV.dev
+func (c *Client) lookupOSV(ctx context.Context, id string) (*Advisory, error) {
+	var v osvVulnerability
+	if err := c.get(ctx, c.osv, c.cfg.OSVURL+"/v1/vulns/"+url.PathEscape(id), nil, &v); err != nil {
+		return nil, err
+	}
+
+	a := &Advisory{
+		ID:          id,
+		Aliases:     v.Aliases,
+		Summary:     v.Summary,
+		Description: v.Details,
+		CWEs:        v.DatabaseSpecific.CWEIDs,
+		Published:   v.Published,
+		Modified:    v.Modified,
+		Sources:     []string{"osv"},
+	}
+	if v.ID != id {
+		a.Aliases = union(a.Aliases, []string{v.ID})
+	}
+	// GitHub advisories say MODERATE where Trivy says MEDIUM
+	switch severity := strings.ToUpper(v.DatabaseSpecific.Severity); severity {
+	case "MODERATE":
+		a.Severity = "MEDIUM"
+	default:
+		a.Severity = severity
+	}
+	for _, s := range v.Severity {
+		if strings.HasPrefix(s.Type, "CVSS_V3") || (a.Vector == "" && strings.HasPrefix(s.Type, "CVSS")) {
+			a.Vector = s.Score
+		}
+	}
+	for _, r := range v.References {
+		a.References = union(a.References, []string{r.URL})
+	}
+	for _, affected := range v.Affected {
+		p := Package{Ecosystem: affected.Package.Ecosystem, Name: affected.Package.Name}
+		for _, r := range affected.Ranges {
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					p.Fixed = union(p.Fixed, []string{e.Fixed})
+				}
+			}
+		}
+		if p.Name != "" {
+			a.Affected = append(a.Affected, p)
+		}
+	}
+	return a, nil
+}
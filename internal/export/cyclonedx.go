@@ -0,0 +1,171 @@
+package export
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"github.com/google/uuid"
+)
+
+type cdxBOM struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+}
+
+type cdxMetadata struct {
+	Timestamp  string        `json:"timestamp"`
+	Tools      cdxTools      `json:"tools"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxVulnerability struct {
+	ID             string        `json:"id"`
+	Source         *cdxSource    `json:"source,omitempty"`
+	Ratings        []cdxRating   `json:"ratings"`
+	Description    string        `json:"description,omitempty"`
+	Recommendation string        `json:"recommendation,omitempty"`
+	Affects        []cdxAffect   `json:"affects"`
+	Properties     []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type cdxRating struct {
+	Score    float64 `json:"score,omitempty"`
+	Severity string  `json:"severity"`
+}
+
+type cdxAffect struct {
+	Ref string `json:"ref"`
+}
+
+// cycloneDXBOM converts the vulnerabilities among findings to a CycloneDX
+// 1.5 BOM: each image is a container component with its vulnerable packages
+// nested in it, and each CVE lists the packages it affects. Findings other
+// than vulnerabilities have no place in a BOM and are left out.
+func cycloneDXBOM(findings []trivy.Finding, meta Meta) cdxBOM {
+	dedup := aggregate.NewDeduplicator()
+	dedup.Add(findings...)
+
+	images := make(map[string]*cdxComponent)
+	packages := make(map[string]bool)
+	byID := make(map[string]*cdxVulnerability)
+	var ids []string
+	for _, v := range dedup.Vulnerabilities() {
+		image, ok := images[v.Image]
+		if !ok {
+			image = &cdxComponent{Type: "container", BOMRef: v.Image, Name: v.Image}
+			images[v.Image] = image
+		}
+		ref := v.Image + "#" + v.PkgName + "@" + v.InstalledVersion
+		if !packages[ref] {
+			packages[ref] = true
+			image.Components = append(image.Components, cdxComponent{Type: "library", BOMRef: ref, Name: v.PkgName, Version: v.InstalledVersion})
+		}
+
+		vuln, ok := byID[v.VulnerabilityID]
+		if !ok {
+			vuln = &cdxVulnerability{
+				ID:          v.VulnerabilityID,
+				Source:      cdxSourceFor(v.VulnerabilityID),
+				Ratings:     []cdxRating{{Score: v.Score, Severity: strings.ToLower(v.Severity)}},
+				Description: v.Title,
+			}
+			byID[v.VulnerabilityID] = vuln
+			ids = append(ids, v.VulnerabilityID)
+		}
+		if v.FixedVersion != "" && vuln.Recommendation == "" {
+			vuln.Recommendation = "Update " + v.PkgName + " to " + v.FixedVersion
+		}
+		if !slices.Contains(vuln.Affects, cdxAffect{Ref: ref}) {
+			vuln.Affects = append(vuln.Affects, cdxAffect{Ref: ref})
+		}
+		for _, workload := range v.Workloads {
+			vuln.Properties = append(vuln.Properties, cdxProperty{Name: "trix:workload", Value: workload})
+		}
+	}
+
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid.NewString(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: meta.Time.UTC().Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "trix", Version: meta.Version}}},
+		},
+		Components:      make([]cdxComponent, 0, len(images)),
+		Vulnerabilities: make([]cdxVulnerability, 0, len(ids)),
+	}
+	if meta.Context != "" {
+		bom.Metadata.Properties = append(bom.Metadata.Properties, cdxProperty{Name: "trix:context", Value: meta.Context})
+	}
+	if meta.Namespace != "" {
+		bom.Metadata.Properties = append(bom.Metadata.Properties, cdxProperty{Name: "trix:namespace", Value: meta.Namespace})
+	}
+	for _, image := range images {
+		sort.Slice(image.Components, func(i, j int) bool { return image.Components[i].BOMRef < image.Components[j].BOMRef })
+		bom.Components = append(bom.Components, *image)
+	}
+	sort.Slice(bom.Components, func(i, j int) bool { return bom.Components[i].Name < bom.Components[j].Name })
+	for _, id := range ids {
+		vuln := byID[id]
+		vuln.Properties = uniqueProperties(vuln.Properties)
+		bom.Vulnerabilities = append(bom.Vulnerabilities, *vuln)
+	}
+	return bom
+}
+
+// cdxSourceFor returns the database a vulnerability ID comes from, when known
+func cdxSourceFor(id string) *cdxSource {
+	switch {
+	case strings.HasPrefix(id, "CVE-"):
+		return &cdxSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + id}
+	case strings.HasPrefix(id, "GHSA-"):
+		return &cdxSource{Name: "GitHub", URL: "https://github.com/advisories/" + id}
+	default:
+		return nil
+	}
+}
+
+// uniqueProperties sorts properties and drops duplicates, as a CVE in several
+// packages of one workload lists the workload once per package
+func uniqueProperties(props []cdxProperty) []cdxProperty {
+	sort.Slice(props, func(i, j int) bool { return props[i].Value < props[j].Value })
+	var unique []cdxProperty
+	for i, p := range props {
+		if i == 0 || p != props[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	return unique
+}
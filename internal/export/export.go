@@ -0,0 +1,130 @@
+// Package export writes a set of findings in formats other tools read:
+// SARIF for code scanning dashboards, CycloneDX for SBOM and vulnerability
+// tooling, CSV for spreadsheets and JSON for everything else.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Formats are the formats Write supports
+var Formats = []string{"sarif", "json", "csv", "cyclonedx"}
+
+// Meta describes the scan the findings come from
+type Meta struct {
+	Version   string    // trix version
+	Context   string    // Kubernetes context scanned
+	Namespace string    // Empty for all namespaces
+	Time      time.Time // When the findings were collected
+}
+
+// Write writes the findings to w in a format
+func Write(w io.Writer, format string, findings []trivy.Finding, meta Meta) error {
+	switch format {
+	case "sarif":
+		return writeJSON(w, sarifLog(findings, meta))
+	case "json":
+		return writeJSON(w, jsonReport(findings, meta))
+	case "csv":
+		return writeCSV(w, findings)
+	case "cyclonedx":
+		return writeJSON(w, cycloneDXBOM(findings, meta))
+	default:
+		return fmt.Errorf("unknown export format %q (expected sarif, json, csv or cyclonedx)", format)
+	}
+}
+
+// Report is the JSON export: the findings with their summary
+type Report struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Version     string            `json:"version"`
+	Context     string            `json:"context,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Summary     aggregate.Summary `json:"summary"`
+	Findings    []trivy.Finding   `json:"findings"`
+}
+
+func jsonReport(findings []trivy.Finding, meta Meta) Report {
+	if findings == nil {
+		findings = []trivy.Finding{}
+	}
+	return Report{
+		GeneratedAt: meta.Time,
+		Version:     meta.Version,
+		Context:     meta.Context,
+		Namespace:   meta.Namespace,
+		Summary:     aggregate.Summarize(findings, aggregate.DefaultOptions),
+		Findings:    findings,
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// csvHeader are the CSV columns, one row per finding. The package columns
+// are empty for findings other than vulnerabilities.
+var csvHeader = []string{"type", "id", "severity", "score", "namespace", "kind", "resource", "title", "image", "package", "installed", "fixed"}
+
+func writeCSV(w io.Writer, findings []trivy.Finding) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	for _, f := range findings {
+		score := ""
+		if f.Score > 0 {
+			score = strconv.FormatFloat(f.Score, 'f', 1, 64)
+		}
+		kind, name := resourceKindName(f)
+		row := []string{string(f.Type), f.ID, string(f.Severity), score, f.Namespace, kind, name, f.Title, "", "", "", ""}
+		if v, ok := f.RawData.(trivy.Vulnerability); ok {
+			row[8], row[9], row[10], row[11] = v.Image, v.PkgName, v.InstalledVersion, v.FixedVersion
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// resourceKindName returns the kind and name a finding is shown against:
+// the owning controller when resolved, the report's resource otherwise
+func resourceKindName(f trivy.Finding) (string, string) {
+	if f.Owner != nil {
+		return f.Owner.Kind, f.Owner.Name
+	}
+	return f.ResourceKind, f.ResourceName
+}
+
+// resourcePath returns the finding's resource as [namespace/]Kind/name
+func resourcePath(f trivy.Finding) string {
+	kind, name := resourceKindName(f)
+	path := name
+	if kind != "" {
+		path = kind + "/" + name
+	}
+	if f.Namespace != "" {
+		path = f.Namespace + "/" + path
+	}
+	return path
+}
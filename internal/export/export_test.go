@@ -0,0 +1,201 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// testFindings are a vulnerability in two workloads running the same image
+// and a misconfiguration of one of them
+func testFindings() []trivy.Finding {
+	vuln := func(namespace, owner string) trivy.Finding {
+		v := trivy.Vulnerability{
+			VulnerabilityID:  "CVE-2024-45337",
+			PkgName:          "golang.org/x/crypto",
+			InstalledVersion: "v0.30.0",
+			FixedVersion:     "0.31.0",
+			Severity:         "CRITICAL",
+			Score:            9.1,
+			Title:            "Misuse of ServerConfig.PublicKeyCallback",
+			Image:            "ghcr.io/example/api:1.4.2",
+		}
+		return trivy.Finding{
+			ID:           v.VulnerabilityID,
+			Type:         trivy.FindingTypeVulnerability,
+			Severity:     trivy.SeverityCritical,
+			Score:        v.Score,
+			Namespace:    namespace,
+			ResourceKind: "ReplicaSet",
+			ResourceName: owner + "-7d9f8c",
+			Owner:        &trivy.Owner{Kind: "Deployment", Name: owner},
+			Title:        v.Title,
+			RawData:      v,
+		}
+	}
+	return []trivy.Finding{
+		vuln("prod", "api"),
+		vuln("staging", "api"),
+		{
+			ID:           "KSV014",
+			Type:         trivy.FindingTypeCompliance,
+			Severity:     trivy.SeverityHigh,
+			Namespace:    "prod",
+			ResourceKind: "Deployment",
+			ResourceName: "api",
+			Title:        "Root file system is not read-only",
+			Description:  "An immutable root file system prevents applications from writing to their local disk.",
+			Remediation:  "Set readOnlyRootFilesystem to true.",
+		},
+	}
+}
+
+var testMeta = Meta{Version: "1.2.0", Context: "prod-eu", Time: time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)}
+
+func TestSARIF(t *testing.T) {
+	log := sarifLog(testFindings(), testMeta)
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("got version %q with %d runs, want 2.1.0 with 1 run", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if got := run.Properties["kubernetesContext"]; got != "prod-eu" {
+		t.Errorf("kubernetesContext = %q, want prod-eu", got)
+	}
+
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 || rules[0].ID != "CVE-2024-45337" || rules[1].ID != "KSV014" {
+		t.Fatalf("rules = %+v, want CVE-2024-45337 and KSV014", rules)
+	}
+	if got := rules[0].Properties["security-severity"]; got != "9.1" {
+		t.Errorf("security-severity = %q, want the CVSS score 9.1", got)
+	}
+	if got := rules[1].Properties["security-severity"]; got != "8.0" {
+		t.Errorf("security-severity = %q, want 8.0 for HIGH without a score", got)
+	}
+	if rules[1].Help == nil || rules[1].Help.Text != "Set readOnlyRootFilesystem to true." {
+		t.Errorf("help = %+v, want the remediation", rules[1].Help)
+	}
+
+	tests := []struct {
+		ruleID  string
+		level   string
+		message string
+		path    string
+	}{
+		{"CVE-2024-45337", "error", "CVE-2024-45337 golang.org/x/crypto in prod/Deployment/api (installed v0.30.0, fixed in 0.31.0)", "prod/Deployment/api"},
+		{"CVE-2024-45337", "error", "CVE-2024-45337 golang.org/x/crypto in staging/Deployment/api (installed v0.30.0, fixed in 0.31.0)", "staging/Deployment/api"},
+		{"KSV014", "error", "KSV014: Root file system is not read-only in prod/Deployment/api", "prod/Deployment/api"},
+	}
+	if len(run.Results) != len(tests) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(tests))
+	}
+	for i, tt := range tests {
+		r := run.Results[i]
+		if r.RuleID != tt.ruleID || r.Level != tt.level || r.Message.Text != tt.message {
+			t.Errorf("result %d = %s %s %q, want %s %s %q", i, r.RuleID, r.Level, r.Message.Text, tt.ruleID, tt.level, tt.message)
+		}
+		if got := r.Locations[0].LogicalLocations[0].FullyQualifiedName; got != tt.path {
+			t.Errorf("result %d location = %q, want %q", i, got, tt.path)
+		}
+		if got := r.Locations[0].PhysicalLocation.ArtifactLocation.URI; got != tt.path {
+			t.Errorf("result %d artifact URI = %q, want %q", i, got, tt.path)
+		}
+	}
+}
+
+func TestSARIFLevel(t *testing.T) {
+	tests := []struct {
+		severity trivy.Severity
+		want     string
+	}{
+		{trivy.SeverityCritical, "error"},
+		{trivy.SeverityHigh, "error"},
+		{trivy.SeverityMedium, "warning"},
+		{trivy.SeverityLow, "note"},
+		{trivy.SeverityUnknown, "note"},
+	}
+	for _, tt := range tests {
+		if got := sarifLevelFor(tt.severity); got != tt.want {
+			t.Errorf("sarifLevelFor(%s) = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestCycloneDX(t *testing.T) {
+	bom := cycloneDXBOM(testFindings(), testMeta)
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("got %s %s, want CycloneDX 1.5", bom.BOMFormat, bom.SpecVersion)
+	}
+	if bom.Metadata.Timestamp != "2026-03-10T12:00:00Z" {
+		t.Errorf("timestamp = %q", bom.Metadata.Timestamp)
+	}
+
+	// Both workloads run one image, so it holds the package once
+	if len(bom.Components) != 1 {
+		t.Fatalf("got %d components, want the one image", len(bom.Components))
+	}
+	image := bom.Components[0]
+	if image.Type != "container" || image.Name != "ghcr.io/example/api:1.4.2" || len(image.Components) != 1 {
+		t.Fatalf("image = %+v, want the container with one package", image)
+	}
+	ref := "ghcr.io/example/api:1.4.2#golang.org/x/crypto@v0.30.0"
+	if pkg := image.Components[0]; pkg.BOMRef != ref || pkg.Version != "v0.30.0" {
+		t.Errorf("package = %+v, want %s", pkg, ref)
+	}
+
+	// The misconfiguration has no place in a BOM
+	if len(bom.Vulnerabilities) != 1 {
+		t.Fatalf("got %d vulnerabilities, want 1", len(bom.Vulnerabilities))
+	}
+	v := bom.Vulnerabilities[0]
+	if v.ID != "CVE-2024-45337" || v.Source == nil || v.Source.Name != "NVD" {
+		t.Errorf("vulnerability = %+v, want CVE-2024-45337 from NVD", v)
+	}
+	if len(v.Ratings) != 1 || v.Ratings[0].Severity != "critical" || v.Ratings[0].Score != 9.1 {
+		t.Errorf("ratings = %+v, want critical 9.1", v.Ratings)
+	}
+	if v.Recommendation != "Update golang.org/x/crypto to 0.31.0" {
+		t.Errorf("recommendation = %q", v.Recommendation)
+	}
+	if len(v.Affects) != 1 || v.Affects[0].Ref != ref {
+		t.Errorf("affects = %+v, want %s", v.Affects, ref)
+	}
+	want := []cdxProperty{{"trix:workload", "prod/Deployment/api"}, {"trix:workload", "staging/Deployment/api"}}
+	if len(v.Properties) != len(want) || v.Properties[0] != want[0] || v.Properties[1] != want[1] {
+		t.Errorf("properties = %+v, want %+v", v.Properties, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, testFindings(), testMeta); err != nil {
+				t.Fatal(err)
+			}
+			if format == "csv" {
+				rows, err := csv.NewReader(&buf).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != 4 || rows[1][6] != "api" || rows[1][9] != "golang.org/x/crypto" {
+					t.Errorf("rows = %q, want a header and 3 findings", rows)
+				}
+				return
+			}
+			if !json.Valid(buf.Bytes()) {
+				t.Errorf("%s export isn't valid JSON:\n%s", format, buf.String())
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, "xml", nil, testMeta); err == nil {
+		t.Error("Write() with an unknown format succeeded, want an error")
+	}
+}
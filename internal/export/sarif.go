@@ -0,0 +1,192 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifText         `json:"shortDescription"`
+	FullDescription      *sarifText        `json:"fullDescription,omitempty"`
+	Help                 *sarifText        `json:"help,omitempty"`
+	DefaultConfiguration sarifLevel        `json:"defaultConfiguration"`
+	Properties           map[string]string `json:"properties"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifLevel struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLog converts findings to a SARIF 2.1.0 log with one rule per finding
+// ID and one result per finding. Findings are in the cluster rather than in
+// files, so results carry logical locations ([namespace/]Kind/name), and the
+// same path as the artifact URI for tools that require a physical location,
+// such as GitHub code scanning.
+func sarifLog(findings []trivy.Finding, meta Meta) sarifReport {
+	rules := make(map[string]sarifRule)
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		if _, ok := rules[f.ID]; !ok {
+			rules[f.ID] = sarifRuleFor(f)
+		}
+		_, name := resourceKindName(f)
+		path := resourcePath(f)
+		results = append(results, sarifResult{
+			RuleID:  f.ID,
+			Level:   sarifLevelFor(f.Severity),
+			Message: sarifText{Text: sarifMessage(f)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: path}},
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               name,
+					FullyQualifiedName: path,
+					Kind:               "resource",
+				}},
+			}},
+		})
+	}
+
+	driver := sarifDriver{
+		Name:           "trix",
+		Version:        meta.Version,
+		InformationURI: "https://github.com/davealtena/trix",
+		Rules:          make([]sarifRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool { return driver.Rules[i].ID < driver.Rules[j].ID })
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: results}
+	if meta.Context != "" {
+		run.Properties = map[string]string{"kubernetesContext": meta.Context}
+	}
+	return sarifReport{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}
+}
+
+func sarifRuleFor(f trivy.Finding) sarifRule {
+	title := f.Title
+	if title == "" {
+		title = f.ID
+	}
+	rule := sarifRule{
+		ID:                   f.ID,
+		ShortDescription:     sarifText{Text: title},
+		DefaultConfiguration: sarifLevel{Level: sarifLevelFor(f.Severity)},
+		Properties: map[string]string{
+			// GitHub code scanning ranks security alerts by this score
+			"security-severity": securitySeverity(f),
+			"type":              string(f.Type),
+		},
+	}
+	if f.Type != trivy.FindingTypeVulnerability && f.Description != "" {
+		rule.FullDescription = &sarifText{Text: f.Description}
+	}
+	if f.Remediation != "" && f.Type != trivy.FindingTypeVulnerability {
+		rule.Help = &sarifText{Text: f.Remediation}
+	}
+	return rule
+}
+
+// sarifMessage describes one occurrence of a finding
+func sarifMessage(f trivy.Finding) string {
+	if v, ok := f.RawData.(trivy.Vulnerability); ok {
+		msg := fmt.Sprintf("%s %s in %s (installed %s", f.ID, v.PkgName, resourcePath(f), v.InstalledVersion)
+		if v.FixedVersion != "" {
+			msg += ", fixed in " + v.FixedVersion
+		}
+		return msg + ")"
+	}
+	return fmt.Sprintf("%s: %s in %s", f.ID, f.Title, resourcePath(f))
+}
+
+// sarifLevelFor maps a severity to a SARIF level
+func sarifLevelFor(sev trivy.Severity) string {
+	switch sev {
+	case trivy.SeverityCritical, trivy.SeverityHigh:
+		return "error"
+	case trivy.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity returns the CVSS score of a finding, or a score in the
+// range of its severity when there is none
+func securitySeverity(f trivy.Finding) string {
+	if f.Score > 0 {
+		return strconv.FormatFloat(f.Score, 'f', 1, 64)
+	}
+	switch f.Severity {
+	case trivy.SeverityCritical:
+		return "9.5"
+	case trivy.SeverityHigh:
+		return "8.0"
+	case trivy.SeverityMedium:
+		return "5.5"
+	case trivy.SeverityLow:
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
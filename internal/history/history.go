@@ -0,0 +1,142 @@
+// Package history keeps the snapshots of past scans on disk, so finding
+// counts can be shown over time, per severity and per namespace.
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// fileTimeFormat names the snapshot files, so they sort by time
+const fileTimeFormat = "20060102T150405Z"
+
+// DefaultDir returns the history directory: TRIX_HISTORY_DIR, or
+// $XDG_DATA_HOME/trix/history (~/.local/share/trix/history)
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("TRIX_HISTORY_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "trix", "history"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find history directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "trix", "history"), nil
+}
+
+// Point is the finding counts of one recorded scan
+type Point struct {
+	Time        time.Time              `json:"time"`
+	Scope       string                 `json:"scope,omitempty"` // Namespace scanned, empty for all
+	Path        string                 `json:"path"`            // The snapshot, e.g. for 'trix diff --baseline'
+	Total       int                    `json:"total"`
+	BySeverity  map[trivy.Severity]int `json:"bySeverity"`
+	ByNamespace map[string]int         `json:"byNamespace"` // "" for cluster-scoped resources
+}
+
+// Record saves a snapshot to a history directory and returns its path
+func Record(dir string, s *snapshot.Snapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+	path := filepath.Join(dir, s.CreatedAt.UTC().Format(fileTimeFormat)+".json")
+	if err := s.Save(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Load returns the counts of the scans recorded since a time, oldest first.
+// Only the findings in namespaces keep accepts are counted, and scans of
+// other namespaces are left out.
+func Load(dir string, since time.Time, keep func(namespace string) bool) ([]Point, error) {
+	paths, err := snapshotFiles(dir, since)
+	if err != nil {
+		return nil, err
+	}
+	var points []Point
+	for _, path := range paths {
+		s, err := snapshot.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		if s.Namespace != "" && !keep(s.Namespace) {
+			continue
+		}
+		p := Point{
+			Time:        s.CreatedAt,
+			Scope:       s.Namespace,
+			Path:        path,
+			BySeverity:  make(map[trivy.Severity]int),
+			ByNamespace: make(map[string]int),
+		}
+		for _, e := range s.Entries {
+			if !keep(e.Namespace()) {
+				continue
+			}
+			p.Total++
+			p.BySeverity[e.Severity]++
+			p.ByNamespace[e.Namespace()]++
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// Prune deletes the snapshots recorded before a time and returns how many it
+// deleted
+func Prune(dir string, before time.Time) (int, error) {
+	paths, err := snapshotFiles(dir, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, path := range paths {
+		if !recordedAt(path).Before(before) {
+			break
+		}
+		if err := os.Remove(path); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// snapshotFiles returns the snapshots in a directory recorded since a time,
+// oldest first. A missing directory has none.
+func snapshotFiles(dir string, since time.Time) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var result []string
+	for _, path := range paths {
+		recorded := recordedAt(path)
+		if recorded.IsZero() || recorded.Before(since) {
+			continue // Not a snapshot trix recorded, or too old
+		}
+		result = append(result, path)
+	}
+	return result, nil
+}
+
+// recordedAt returns the time in a snapshot's file name, or zero if the name
+// isn't one Record chose
+func recordedAt(path string) time.Time {
+	t, err := time.Parse(fileTimeFormat, strings.TrimSuffix(filepath.Base(path), ".json"))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,217 @@
+// Package ignore reads and writes .trix-ignore.yaml, the accepted risks of a
+// cluster: findings that were reviewed and won't be fixed, each with a
+// justification and an optional expiry date. trix drops the findings its
+// active rules match while reading reports, so they don't show up in any
+// query, summary, report or export.
+package ignore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultPath is the ignore file read when no other is given
+const DefaultPath = ".trix-ignore.yaml"
+
+// DateFormat is the format of the expires and added dates
+const DateFormat = time.DateOnly
+
+// File is the contents of an ignore file
+type File struct {
+	Rules []Rule `json:"ignores"`
+}
+
+// Rule accepts the findings of one CVE, check or secret rule, optionally
+// only in some namespaces, resources or images. Scopes are globs where *
+// matches anything, including "/".
+type Rule struct {
+	ID            string `json:"id"`                  // e.g. CVE-2024-45337, KSV014 or aws-access-key-id
+	Namespace     string `json:"namespace,omitempty"` // e.g. prod or team-*
+	Resource      string `json:"resource,omitempty"`  // Kind/name, e.g. Deployment/api or ReplicaSet/api-*
+	Image         string `json:"image,omitempty"`     // e.g. *nginx:1.25*
+	Justification string `json:"justification"`
+	Expires       string `json:"expires,omitempty"` // YYYY-MM-DD, the last day the rule applies
+	Added         string `json:"added,omitempty"`   // YYYY-MM-DD
+}
+
+// Load reads an ignore file. A missing file yields an empty one.
+func Load(path string) (*File, error) {
+	f := &File{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse ignore file %s: %w", path, err)
+	}
+	for i, r := range f.Rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("ignore file %s, rule %d: %w", path, i+1, err)
+		}
+	}
+	return f, nil
+}
+
+// Save writes the ignore file
+func (f *File) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	header := "# Accepted risks, managed with 'trix ignore'. Findings matching an active\n# rule are left out of every trix command.\n"
+	if err := os.WriteFile(path, append([]byte(header), data...), 0o644); err != nil {
+		return fmt.Errorf("failed to write ignore file: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that a rule has an ID, a justification and valid dates
+func (r Rule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if strings.TrimSpace(r.Justification) == "" {
+		return fmt.Errorf("%s: missing justification", r.ID)
+	}
+	for _, date := range []string{r.Expires, r.Added} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(DateFormat, date); err != nil {
+			return fmt.Errorf("%s: invalid date %q (expected YYYY-MM-DD)", r.ID, date)
+		}
+	}
+	return nil
+}
+
+// Expired reports whether the rule's last day is before now
+func (r Rule) Expired(now time.Time) bool {
+	if r.Expires == "" {
+		return false
+	}
+	expires, err := time.ParseInLocation(DateFormat, r.Expires, now.Location())
+	if err != nil {
+		return false
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// Scope describes where a rule applies, e.g. "namespace prod, Deployment/api"
+func (r Rule) Scope() string {
+	var parts []string
+	if r.Namespace != "" {
+		parts = append(parts, "namespace "+r.Namespace)
+	}
+	if r.Resource != "" {
+		parts = append(parts, r.Resource)
+	}
+	if r.Image != "" {
+		parts = append(parts, "image "+r.Image)
+	}
+	if len(parts) == 0 {
+		return "everywhere"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Matches reports whether the rule covers a finding, regardless of expiry
+func (r Rule) Matches(id string, target trivy.IgnoreTarget) bool {
+	if !strings.EqualFold(r.ID, id) && trivy.NormalizeCheckID(r.ID) != trivy.NormalizeCheckID(id) {
+		return false
+	}
+	if r.Namespace != "" && !glob(r.Namespace, target.Namespace) {
+		return false
+	}
+	if r.Image != "" && !glob(r.Image, target.Image) {
+		return false
+	}
+	if r.Resource != "" && !matchResource(r.Resource, target) {
+		return false
+	}
+	return true
+}
+
+// Ignores reports whether an active rule covers a finding
+func (f *File) Ignores(id string, target trivy.IgnoreTarget) bool {
+	now := time.Now()
+	for _, r := range f.Rules {
+		if !r.Expired(now) && r.Matches(id, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the rules that haven't expired
+func (f *File) Active(now time.Time) []Rule {
+	var active []Rule
+	for _, r := range f.Rules {
+		if !r.Expired(now) {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
+// Prune removes the expired rules and returns them
+func (f *File) Prune(now time.Time) []Rule {
+	var expired, kept []Rule
+	for _, r := range f.Rules {
+		if r.Expired(now) {
+			expired = append(expired, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	f.Rules = kept
+	return expired
+}
+
+// matchResource matches a Kind/name pattern against the scanned resource.
+// Trivy Operator scans the ReplicaSets of Deployments and the Jobs of
+// CronJobs, so Deployment/api also matches ReplicaSet/api-<hash> and
+// CronJob/backup matches Job/backup-<id>.
+func matchResource(pattern string, target trivy.IgnoreTarget) bool {
+	kind, name, ok := strings.Cut(pattern, "/")
+	if !ok {
+		kind, name = "*", pattern
+	}
+	if strings.EqualFold(kind, target.Kind) || kind == "*" {
+		return glob(name, target.Name)
+	}
+	owned := map[string]string{"deployment": "replicaset", "cronjob": "job"}
+	if owned[strings.ToLower(kind)] != strings.ToLower(target.Kind) {
+		return false
+	}
+	i := strings.LastIndex(target.Name, "-")
+	return i > 0 && glob(name, target.Name[:i])
+}
+
+// glob matches s against a pattern where * matches any run of characters
+func glob(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
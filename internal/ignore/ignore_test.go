@@ -0,0 +1,120 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+func TestRuleMatches(t *testing.T) {
+	pod := trivy.IgnoreTarget{Namespace: "prod", Kind: "ReplicaSet", Name: "api-7d9f8c", Image: "docker.io/library/nginx:1.25"}
+	job := trivy.IgnoreTarget{Namespace: "ops", Kind: "Job", Name: "backup-28400"}
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		id     string
+		target trivy.IgnoreTarget
+		want   bool
+	}{
+		{"id only", Rule{ID: "CVE-2024-45337"}, "CVE-2024-45337", pod, true},
+		{"id is case-insensitive", Rule{ID: "cve-2024-45337"}, "CVE-2024-45337", pod, true},
+		{"other id", Rule{ID: "CVE-2024-45337"}, "CVE-2024-0001", pod, false},
+		{"check id forms", Rule{ID: "KSV014"}, "AVD-KSV-0014", pod, true},
+		{"namespace", Rule{ID: "CVE-1", Namespace: "prod"}, "CVE-1", pod, true},
+		{"namespace glob", Rule{ID: "CVE-1", Namespace: "pr*"}, "CVE-1", pod, true},
+		{"other namespace", Rule{ID: "CVE-1", Namespace: "staging"}, "CVE-1", pod, false},
+		{"image glob", Rule{ID: "CVE-1", Image: "*nginx:1.25*"}, "CVE-1", pod, true},
+		{"other image", Rule{ID: "CVE-1", Image: "*nginx:1.26*"}, "CVE-1", pod, false},
+		{"scanned resource", Rule{ID: "CVE-1", Resource: "ReplicaSet/api-*"}, "CVE-1", pod, true},
+		{"deployment of the replicaset", Rule{ID: "CVE-1", Resource: "Deployment/api"}, "CVE-1", pod, true},
+		{"other deployment", Rule{ID: "CVE-1", Resource: "Deployment/web"}, "CVE-1", pod, false},
+		{"cronjob of the job", Rule{ID: "CVE-1", Resource: "cronjob/backup"}, "CVE-1", job, true},
+		{"name without kind", Rule{ID: "CVE-1", Resource: "api-*"}, "CVE-1", pod, true},
+		{"all scopes", Rule{ID: "CVE-1", Namespace: "prod", Resource: "Deployment/api", Image: "*nginx*"}, "CVE-1", pod, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.id, tt.target); got != tt.want {
+				t.Errorf("Matches(%q, %+v) = %v, want %v", tt.id, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"prod", "prod", true},
+		{"prod", "production", false},
+		{"*", "", true},
+		{"team-*", "team-a", true},
+		{"*-system", "kube-system", true},
+		{"*nginx*", "docker.io/library/nginx:1.25", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "ac", false},
+		{"ab*ba", "aba", false},
+	}
+	for _, tt := range tests {
+		if got := glob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("glob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRuleExpired(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expires string
+		want    bool
+	}{
+		{"", false},
+		{"2026-03-11", false},
+		{"2026-03-10", false}, // The last day the rule applies
+		{"2026-03-09", true},
+	}
+	for _, tt := range tests {
+		if got := (Rule{ID: "CVE-1", Expires: tt.expires}).Expired(now); got != tt.want {
+			t.Errorf("Expired() with expires %q = %v, want %v", tt.expires, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{"rules", "ignores:\n  - id: CVE-1\n    justification: not reachable\n  - id: KSV014\n    namespace: dev\n    justification: dev only\n", 2, false},
+		{"missing justification", "ignores:\n  - id: CVE-1\n", 0, true},
+		{"invalid date", "ignores:\n  - id: CVE-1\n    justification: x\n    expires: 31/12/2026\n", 0, true},
+		{"unknown field", "ignores:\n  - id: CVE-1\n    justification: x\n    reason: y\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultPath)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(f.Rules) != tt.want {
+				t.Errorf("Load() read %d rules, want %d", len(f.Rules), tt.want)
+			}
+		})
+	}
+
+	f, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(f.Rules) != 0 {
+		t.Errorf("Load() of a missing file = %v, %v, want an empty file", f, err)
+	}
+}
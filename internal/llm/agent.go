@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultMaxIterations is used by RunAgent when MaxIterations is not set.
+const DefaultMaxIterations = 10
+
+// ToolExecutor runs the tools an agent calls. tools.Registry implements it.
+type ToolExecutor interface {
+	Execute(ctx context.Context, name string, params map[string]interface{}) (string, error)
+}
+
+// AgentOptions configures RunAgent.
+type AgentOptions struct {
+	MaxIterations int             // Chat rounds before giving up (default 10)
+	TokenBudget   int             // Input plus output tokens across the loop; 0 for no limit
+	MaxToolOutput int             // Bytes of each tool result kept; 0 for no limit
+	Context       *ContextManager // Keeps the conversation within the context window; optional
+	ChatOptions   []Option        // Passed to every Chat call
+
+	OnResponse func(resp *Response) // Called after every Chat call, e.g. to show usage
+	OnToolCall func(call ToolCall)  // Called before each tool is executed, e.g. to show progress
+	OnDelta    func(text string)    // Streams response text as it is generated, if the client can stream
+}
+
+// AgentResult is the outcome of RunAgent.
+type AgentResult struct {
+	Content    string    // The final answer
+	Messages   []Message // The conversation including tool calls, results and the answer
+	Usage      Totals    // Usage across all iterations
+	Iterations int       // Number of Chat calls made
+	LastUsage  Usage     // Usage of the final Chat call
+}
+
+// RunAgent runs the chat → tool call → tool result loop until the model
+// answers without calling tools. On the last allowed iteration, or once the
+// token budget is spent, tools are disabled so the model summarizes what it
+// has found instead of the loop failing. Tool errors are passed back to the
+// model as results rather than aborting the loop.
+func RunAgent(ctx context.Context, client Client, messages []Message, tools []Tool, executor ToolExecutor, opts AgentOptions) (*AgentResult, error) {
+	maxIterations := opts.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+
+	result := &AgentResult{Messages: append([]Message(nil), messages...)}
+
+	for i := 0; i < maxIterations; i++ {
+		if opts.Context != nil {
+			fitted, err := opts.Context.Fit(ctx, result.Messages, tools)
+			if err != nil {
+				return result, fmt.Errorf("LLM error: %w", err)
+			}
+			result.Messages = fitted
+		}
+
+		chatOpts := opts.ChatOptions
+		final := i == maxIterations-1 ||
+			(opts.TokenBudget > 0 && result.Usage.InputTokens+result.Usage.OutputTokens >= opts.TokenBudget)
+		if final {
+			chatOpts = append(chatOpts[:len(chatOpts):len(chatOpts)], WithToolChoice(ToolChoiceNone))
+		}
+
+		var response *Response
+		var err error
+		if streaming, ok := client.(StreamingClient); ok && opts.OnDelta != nil {
+			response, err = streaming.ChatStream(ctx, result.Messages, tools, opts.OnDelta, chatOpts...)
+		} else {
+			response, err = client.Chat(ctx, result.Messages, tools, chatOpts...)
+		}
+		if err != nil {
+			return result, fmt.Errorf("LLM error: %w", err)
+		}
+		result.Iterations++
+		result.Usage.Add(response.Usage)
+		result.LastUsage = response.Usage
+		if opts.OnResponse != nil {
+			opts.OnResponse(response)
+		}
+
+		if len(response.ToolCalls) == 0 {
+			result.Content = response.Content
+			result.Messages = append(result.Messages, Message{
+				Role:    RoleAssistant,
+				Content: response.Content,
+			})
+			return result, nil
+		}
+		if final {
+			break // The model ignored the request to stop calling tools
+		}
+
+		result.Messages = append(result.Messages, Message{
+			Role:      RoleAssistant,
+			Content:   response.Content,
+			ToolCalls: response.ToolCalls,
+			Thinking:  response.Thinking,
+		})
+
+		for _, tc := range response.ToolCalls {
+			if opts.OnToolCall != nil {
+				opts.OnToolCall(tc)
+			}
+
+			var output string
+			if tc.ArgumentsError != "" {
+				output = invalidArgumentsResult(tc)
+			} else if output, err = executor.Execute(ctx, tc.Name, tc.Parameters); err != nil {
+				output = fmt.Sprintf("Error: %v", err)
+			}
+			if opts.MaxToolOutput > 0 && len(output) > opts.MaxToolOutput {
+				output = output[:opts.MaxToolOutput] + "\n... (truncated)"
+			}
+
+			result.Messages = append(result.Messages, Message{
+				Role:       RoleTool,
+				Content:    output,
+				ToolCallID: tc.ID,
+			})
+		}
+	}
+
+	return result, fmt.Errorf("agent loop exceeded maximum iterations")
+}
@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeExecutor records the tool calls of the agent loop and answers them
+type fakeExecutor struct {
+	calls []string
+	err   error
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, name string, params map[string]interface{}) (string, error) {
+	e.calls = append(e.calls, name)
+	if e.err != nil {
+		return "", e.err
+	}
+	return "CVE-2024-45337 CRITICAL Deployment/api-gateway", nil
+}
+
+func TestRunAgent(t *testing.T) {
+	tests := []struct {
+		name           string
+		fixture        string
+		opts           AgentOptions
+		executorErr    error
+		wantContent    string
+		wantErr        bool
+		wantCalls      int
+		wantIterations int
+		wantToolResult string // Part of the first tool result sent back to the model
+	}{
+		{
+			name:           "answers after a tool call",
+			fixture:        "agent-tool-call.json",
+			wantContent:    "CVE-2024-45337 affects Deployment/api-gateway in prod.",
+			wantCalls:      1,
+			wantIterations: 2,
+			wantToolResult: "CVE-2024-45337",
+		},
+		{
+			name:           "passes tool errors back to the model",
+			fixture:        "agent-tool-call.json",
+			executorErr:    errors.New("forbidden"),
+			wantContent:    "CVE-2024-45337 affects Deployment/api-gateway in prod.",
+			wantCalls:      1,
+			wantIterations: 2,
+			wantToolResult: "Error: forbidden",
+		},
+		{
+			name:           "asks again after invalid arguments",
+			fixture:        "agent-invalid-arguments.json",
+			wantContent:    "No critical vulnerabilities in prod.",
+			wantCalls:      1,
+			wantIterations: 3,
+			wantToolResult: "were not valid JSON",
+		},
+		{
+			name:           "truncates tool output",
+			fixture:        "agent-tool-call.json",
+			opts:           AgentOptions{MaxToolOutput: 5},
+			wantContent:    "CVE-2024-45337 affects Deployment/api-gateway in prod.",
+			wantCalls:      1,
+			wantIterations: 2,
+			wantToolResult: "CVE-2\n... (truncated)",
+		},
+		{
+			name:           "stops at the iteration limit",
+			fixture:        "agent-endless-tools.json",
+			opts:           AgentOptions{MaxIterations: 2},
+			wantErr:        true,
+			wantCalls:      1,
+			wantIterations: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewMockClient(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			executor := &fakeExecutor{err: tt.executorErr}
+			messages := []Message{
+				{Role: RoleSystem, Content: "You are a Kubernetes security assistant."},
+				{Role: RoleUser, Content: "Which critical CVEs are in prod?"},
+			}
+			tools := []Tool{{Name: "query_vulns", Description: "List vulnerabilities"}}
+
+			result, err := RunAgent(context.Background(), client, messages, tools, executor, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunAgent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", result.Content, tt.wantContent)
+			}
+			if len(executor.calls) != tt.wantCalls {
+				t.Errorf("executed %d tool calls, want %d", len(executor.calls), tt.wantCalls)
+			}
+			if result.Iterations != tt.wantIterations {
+				t.Errorf("Iterations = %d, want %d", result.Iterations, tt.wantIterations)
+			}
+			if result.Usage.InputTokens == 0 {
+				t.Error("Usage wasn't summed")
+			}
+			if tt.wantToolResult != "" {
+				var toolResult string
+				for _, msg := range result.Messages {
+					if msg.Role == RoleTool {
+						toolResult = msg.Content
+						break
+					}
+				}
+				if !strings.Contains(toolResult, tt.wantToolResult) {
+					t.Errorf("first tool result = %q, want it to contain %q", toolResult, tt.wantToolResult)
+				}
+			}
+		})
+	}
+}
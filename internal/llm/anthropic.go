@@ -6,37 +6,121 @@ import (
 	"fmt"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 )
 
 // AnthropicClient implements the client interface for Claude
 type AnthropicClient struct {
 	model  string
+	opts   ClientOptions
 	client anthropic.Client
 }
 
 // NewAnthropicClient creates a new Claude client.
 // It reads the API key from the ANTHROPIC_API_KEY environment variable.
-func NewAnthropicClient(model string) (*AnthropicClient, error) {
+func NewAnthropicClient(model string, opts ...Option) (*AnthropicClient, error) {
 	if model == "" {
 		model = "claude-sonnet-4-20250514"
 	}
+	o := clientOptions(opts)
+	reqOpts := []option.RequestOption{
+		option.WithHTTPClient(newHTTPClient(0)),
+		option.WithMaxRetries(0), // Retries are handled by our transport
+	}
+	if apiKey := resolveAPIKey(o, "ANTHROPIC_API_KEY"); apiKey != "" {
+		reqOpts = append(reqOpts, option.WithAPIKey(apiKey))
+	}
 	return &AnthropicClient{
 		model:  model,
-		client: anthropic.NewClient(),
+		opts:   o,
+		client: anthropic.NewClient(reqOpts...),
 	}, nil
 }
 
 // Chat sends messages to Claude and returns the response.
-func (c *AnthropicClient) Chat(ctx context.Context, messages []Message, tools []Tool) (*Response, error) {
+func (c *AnthropicClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := c.newParams(messages, tools, o)
+
+	resp, err := c.client.Messages.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+
+	response := c.parseResponse(resp)
+	if s := o.ResponseSchema; s != nil {
+		structuredOutput(response, s.Name)
+	}
+	return response, nil
+}
+
+// ChatStream streams the response from Claude, calling onDelta with each text
+// fragment. Thinking and tool calls are only returned once complete.
+func (c *AnthropicClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := c.newParams(messages, tools, o)
+
+	stream := c.client.Messages.NewStreaming(ctx, params)
+	defer func() { _ = stream.Close() }()
+
+	var message anthropic.Message
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		if delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent); ok && onDelta != nil {
+			if text, ok := delta.Delta.AsAny().(anthropic.TextDelta); ok {
+				onDelta(text.Text)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+
+	response := c.parseResponse(&message)
+	if s := o.ResponseSchema; s != nil {
+		structuredOutput(response, s.Name)
+	}
+	return response, nil
+}
+
+// newParams builds the request for messages, tools and options
+func (c *AnthropicClient) newParams(messages []Message, tools []Tool, o ClientOptions) anthropic.MessageNewParams {
 	anthropicMessages := c.convertMessages(messages)
 	anthropicTools := c.convertTools(tools)
 
 	params := anthropic.MessageNewParams{
-		Model:     anthropic.Model(c.model),
-		MaxTokens: 4096,
+		Model:     anthropic.Model(o.model(c.model)),
+		MaxTokens: int64(o.maxTokens(4096)),
 		Messages:  anthropicMessages,
 	}
 
+	// Extended thinking can't be combined with sampling parameters or a
+	// forced tool, so it is skipped for structured output and forced calls
+	params.StopSequences = o.Stop
+
+	forced := o.ResponseSchema != nil || (o.ToolChoice != "" && o.ToolChoice != ToolChoiceAuto && o.ToolChoice != ToolChoiceNone)
+	if o.ThinkingBudget > 0 && !forced {
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(int64(o.ThinkingBudget))
+		// The thinking budget counts towards max_tokens
+		if params.MaxTokens <= int64(o.ThinkingBudget) {
+			params.MaxTokens = int64(o.ThinkingBudget + o.maxTokens(4096))
+		}
+	} else {
+		if o.Temperature != nil {
+			params.Temperature = anthropic.Float(*o.Temperature)
+		}
+		if o.TopP != nil {
+			params.TopP = anthropic.Float(*o.TopP)
+		}
+	}
+
 	// Extract system message if present
 	for _, msg := range messages {
 		if msg.Role == RoleSystem {
@@ -44,22 +128,70 @@ func (c *AnthropicClient) Chat(ctx context.Context, messages []Message, tools []
 				{
 					Type: "text",
 					Text: msg.Content,
+					// Tools and the system prompt form a stable prefix that
+					// is reused by every turn of the conversation
+					CacheControl: anthropic.NewCacheControlEphemeralParam(),
 				},
 			}
 			break
 		}
 	}
 
+	// Claude has no JSON mode; structured output is a forced call to a tool
+	// whose input schema is the response schema
+	if s := o.ResponseSchema; s != nil {
+		anthropicTools = append(anthropicTools, c.convertTools([]Tool{{
+			Name:        s.Name,
+			Description: s.Description,
+			Parameters:  s.Schema,
+		}})...)
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(s.Name)
+	}
+
 	if len(anthropicTools) > 0 {
 		params.Tools = anthropicTools
+		if o.ResponseSchema == nil && o.ToolChoice != "" {
+			params.ToolChoice = anthropicToolChoice(o.ToolChoice)
+		}
 	}
 
-	resp, err := c.client.Messages.New(ctx, params)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+	// Also cache up to the latest message, so the next agent turn only pays
+	// full price for the tool results added since
+	if n := len(params.Messages); n > 0 {
+		if blocks := params.Messages[n-1].Content; len(blocks) > 0 {
+			if cc := blocks[len(blocks)-1].GetCacheControl(); cc != nil {
+				*cc = anthropic.NewCacheControlEphemeralParam()
+			}
+		}
 	}
+	return params
+}
 
-	return c.parseResponse(resp), nil
+// anthropicToolChoice converts a tool choice mode or tool name to Anthropic's format.
+func anthropicToolChoice(choice string) anthropic.ToolChoiceUnionParam {
+	switch choice {
+	case ToolChoiceAuto:
+		return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	case ToolChoiceRequired:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	case ToolChoiceNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+	}
+	return anthropic.ToolChoiceParamOfTool(choice)
+}
+
+// structuredOutput moves the input of the forced schema tool call into Content.
+func structuredOutput(response *Response, name string) {
+	for i, tc := range response.ToolCalls {
+		if tc.Name != name {
+			continue
+		}
+		if data, err := json.Marshal(tc.Parameters); err == nil {
+			response.Content = string(data)
+		}
+		response.ToolCalls = append(response.ToolCalls[:i], response.ToolCalls[i+1:]...)
+		return
+	}
 }
 
 // convertMessages converts generic Messages to Anthropic's message format.
@@ -73,13 +205,28 @@ func (c *AnthropicClient) convertMessages(messages []Message) []anthropic.Messag
 			continue
 
 		case RoleUser:
-			result = append(result, anthropic.NewUserMessage(
-				anthropic.NewTextBlock(msg.Content),
-			))
+			var blocks []anthropic.ContentBlockParamUnion
+			for _, img := range msg.Images {
+				if img.URL != "" {
+					blocks = append(blocks, anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: img.URL}))
+				} else {
+					blocks = append(blocks, anthropic.NewImageBlockBase64(img.MediaType, img.Data))
+				}
+			}
+			blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
+			result = append(result, anthropic.NewUserMessage(blocks...))
 
 		case RoleAssistant:
 			if len(msg.ToolCalls) > 0 {
 				var blocks []anthropic.ContentBlockParamUnion
+				// Thinking must precede the tool use it led to
+				for _, t := range msg.Thinking {
+					if t.Redacted != "" {
+						blocks = append(blocks, anthropic.NewRedactedThinkingBlock(t.Redacted))
+					} else {
+						blocks = append(blocks, anthropic.NewThinkingBlock(t.Signature, t.Text))
+					}
+				}
 				if msg.Content != "" {
 					blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
 				}
@@ -151,15 +298,23 @@ func (c *AnthropicClient) convertTools(tools []Tool) []anthropic.ToolUnionParam
 func (c *AnthropicClient) parseResponse(resp *anthropic.Message) *Response {
 	response := &Response{
 		Usage: Usage{
-			InputTokens:  int(resp.Usage.InputTokens),
-			OutputTokens: int(resp.Usage.OutputTokens),
+			// Anthropic reports cached tokens separately from input_tokens
+			InputTokens:      int(resp.Usage.InputTokens + resp.Usage.CacheCreationInputTokens + resp.Usage.CacheReadInputTokens),
+			OutputTokens:     int(resp.Usage.OutputTokens),
+			CacheReadTokens:  int(resp.Usage.CacheReadInputTokens),
+			CacheWriteTokens: int(resp.Usage.CacheCreationInputTokens),
 		},
 	}
+	response.Usage.price(string(resp.Model))
 
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
 			response.Content = block.Text
+		case "thinking":
+			response.Thinking = append(response.Thinking, Thinking{Text: block.Thinking, Signature: block.Signature})
+		case "redacted_thinking":
+			response.Thinking = append(response.Thinking, Thinking{Redacted: block.Data})
 		case "tool_use":
 			var params map[string]interface{}
 			if err := json.Unmarshal(block.Input, &params); err != nil {
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is used by Batch when concurrency is not positive.
+const DefaultBatchConcurrency = 8
+
+// BatchRequest is one completion in a batch.
+type BatchRequest struct {
+	Messages []Message
+	Tools    []Tool
+	Options  []Option
+}
+
+// BatchResult is the outcome of one BatchRequest. Exactly one of Response
+// and Err is set.
+type BatchResult struct {
+	Response *Response
+	Err      error
+}
+
+// Batch runs many independent completions with at most concurrency requests
+// in flight, for work like one-line summaries of hundreds of CVEs. Results
+// are returned in request order. Rate limits and transient errors are retried
+// per request by the client's transport, and a request that still fails only
+// fails its own result. The returned totals cover every successful request.
+func Batch(ctx context.Context, client Client, requests []BatchRequest, concurrency int) ([]BatchResult, Totals) {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]BatchResult, len(requests))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, req := range requests {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// Don't start new requests once the batch is cancelled
+			for j := i; j < len(requests); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results, batchTotals(results)
+		}
+
+		wg.Add(1)
+		go func(i int, req BatchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := client.Chat(ctx, req.Messages, req.Tools, req.Options...)
+			results[i] = BatchResult{Response: resp, Err: err}
+		}(i, req)
+	}
+
+	wg.Wait()
+	return results, batchTotals(results)
+}
+
+// batchTotals sums the usage of successful results.
+func batchTotals(results []BatchResult) Totals {
+	var totals Totals
+	for _, r := range results {
+		if r.Err == nil && r.Response != nil {
+			totals.Add(r.Response.Usage)
+		}
+	}
+	return totals
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned by BudgetClient once the budget is spent.
+var ErrBudgetExceeded = errors.New("LLM budget exceeded")
+
+// Budget limits the usage of all requests made through a BudgetClient.
+// Zero fields are not limited.
+type Budget struct {
+	MaxTokens int     // Input plus output tokens
+	MaxCost   float64 // USD; only enforced for models with known prices
+}
+
+// BudgetClient wraps a Client and refuses further requests once the budget is
+// spent, so automation can't run up an unbounded bill. The request that
+// crosses the limit still completes; every request after it fails with
+// ErrBudgetExceeded.
+type BudgetClient struct {
+	client Client
+	budget Budget
+
+	mu    sync.Mutex
+	spent Totals
+}
+
+// NewBudgetClient creates a budget-enforcing wrapper around client.
+func NewBudgetClient(client Client, budget Budget) *BudgetClient {
+	return &BudgetClient{client: client, budget: budget}
+}
+
+// Chat forwards the request to the wrapped client if budget remains.
+func (c *BudgetClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Chat(ctx, messages, tools, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.spent.Add(resp.Usage)
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// Spent returns the usage so far.
+func (c *BudgetClient) Spent() Totals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spent
+}
+
+// check returns an error describing the exceeded limit, if any.
+func (c *BudgetClient) check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tokens := c.spent.InputTokens + c.spent.OutputTokens
+	if c.budget.MaxTokens > 0 && tokens >= c.budget.MaxTokens {
+		return fmt.Errorf("%w: used %s of %s tokens", ErrBudgetExceeded,
+			formatThousands(tokens), formatThousands(c.budget.MaxTokens))
+	}
+	if c.budget.MaxCost > 0 && c.spent.Cost >= c.budget.MaxCost {
+		return fmt.Errorf("%w: spent $%.2f of $%.2f", ErrBudgetExceeded, c.spent.Cost, c.budget.MaxCost)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached responses are reused.
+const DefaultCacheTTL = 24 * time.Hour
+
+// CachingClient wraps a Client with a content-addressed disk cache, so asking
+// the same question about the same cluster state doesn't pay for the same
+// completion twice. Entries are keyed by a hash of the provider, model,
+// messages, tools and generation options.
+type CachingClient struct {
+	client    Client
+	namespace string        // Provider and model, so different models never share entries
+	defaults  ClientOptions // The wrapped client's options, which per-request options override
+	dir       string
+	ttl       time.Duration
+}
+
+// cacheEntry is the on-disk format of a cached response.
+type cacheEntry struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Response  fixtureResponse `json:"response"`
+}
+
+// DefaultCacheDir returns the cache directory (~/.cache/trix/llm on Linux).
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "trix", "llm"), nil
+}
+
+// NewCachingClient creates a caching wrapper around client.
+// namespace should identify the provider and model (e.g. "anthropic/claude-sonnet-4"),
+// and opts should be the options client was created with, so clients with
+// different generation settings never share entries.
+func NewCachingClient(client Client, namespace, dir string, ttl time.Duration, opts ...Option) *CachingClient {
+	defaults := applyOptions(ClientOptions{}, opts)
+	defaults.APIKey = "" // Keep secrets out of the key; rotating a key shouldn't empty the cache
+	return &CachingClient{
+		client:    client,
+		namespace: namespace,
+		defaults:  defaults,
+		dir:       dir,
+		ttl:       ttl,
+	}
+}
+
+// Chat returns a cached response if one exists and hasn't expired,
+// otherwise it calls the wrapped client and caches the result.
+func (c *CachingClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	key, err := c.key(messages, tools, opts)
+	if err != nil {
+		return c.client.Chat(ctx, messages, tools, opts...)
+	}
+	path := filepath.Join(c.dir, key[:2], key+".json")
+	if resp, ok := c.load(path); ok {
+		return resp, nil
+	}
+
+	resp, err := c.client.Chat(ctx, messages, tools, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.store(path, resp)
+	return resp, nil
+}
+
+// ChatStream is Chat for streaming clients. A cached response is passed to
+// onDelta in one piece; a wrapped client that can't stream is asked with Chat.
+func (c *CachingClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	key, err := c.key(messages, tools, opts)
+	path := ""
+	if err == nil {
+		path = filepath.Join(c.dir, key[:2], key+".json")
+		if resp, ok := c.load(path); ok {
+			if onDelta != nil && resp.Content != "" {
+				onDelta(resp.Content)
+			}
+			return resp, nil
+		}
+	}
+
+	var resp *Response
+	if streaming, ok := c.client.(StreamingClient); ok {
+		resp, err = streaming.ChatStream(ctx, messages, tools, onDelta, opts...)
+	} else {
+		resp, err = c.client.Chat(ctx, messages, tools, opts...)
+		if err == nil && onDelta != nil && resp.Content != "" {
+			onDelta(resp.Content)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		c.store(path, resp)
+	}
+	return resp, nil
+}
+
+// load returns the cached response at path if it exists and hasn't expired
+func (c *CachingClient) load(path string) (*Response, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.CreatedAt) >= c.ttl {
+		return nil, false
+	}
+	resp := entry.Response.response()
+	// Nothing was billed for this request
+	resp.Usage = Usage{}
+	return resp, true
+}
+
+// store caches a response at path. Caching is best effort; a failed write
+// must not fail the request.
+func (c *CachingClient) store(path string, resp *Response) {
+	entry := cacheEntry{CreatedAt: time.Now(), Response: newFixtureResponse(resp)}
+	if data, err := json.Marshal(entry); err == nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			_ = os.WriteFile(path, data, 0600)
+		}
+	}
+}
+
+// key hashes everything that determines the response.
+func (c *CachingClient) key(messages []Message, tools []Tool, opts []Option) (string, error) {
+	data, err := json.Marshal(struct {
+		Namespace string
+		Messages  []Message
+		Tools     []Tool
+		Options   ClientOptions
+	}{c.namespace, messages, tools, applyOptions(c.defaults, opts)})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// PruneCache removes expired entries from the cache directory.
+func PruneCache(dir string, ttl time.Duration) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && time.Since(info.ModTime()) > ttl {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
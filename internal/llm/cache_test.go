@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachingClientKey(t *testing.T) {
+	messages := []Message{{Role: RoleUser, Content: "Which pods run as root?"}}
+	key := func(namespace string, clientOpts []Option, opts ...Option) string {
+		t.Helper()
+		c := NewCachingClient(nil, namespace, t.TempDir(), time.Hour, clientOpts...)
+		k, err := c.key(messages, nil, opts)
+		if err != nil {
+			t.Fatalf("key() error = %v", err)
+		}
+		return k
+	}
+	base := key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.2)})
+
+	tests := []struct {
+		name string
+		got  string
+		same bool
+	}{
+		{"same options", key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.2)}), true},
+		{"different API key", key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.2), WithAPIKey("sk-other")}), true},
+		{"per-request option equal to the client's", key("anthropic/claude-sonnet-4", nil, WithTemperature(0.2)), true},
+		{"different client temperature", key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.9)}), false},
+		{"different client max tokens", key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.2), WithMaxTokens(100)}), false},
+		{"different per-request temperature", key("anthropic/claude-sonnet-4", []Option{WithTemperature(0.2)}, WithTemperature(0.9)), false},
+		{"different model", key("anthropic/claude-opus-4", []Option{WithTemperature(0.2)}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := tt.got == base; same != tt.same {
+				t.Errorf("key equal to the base key = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
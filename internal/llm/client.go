@@ -16,8 +16,18 @@ const (
 type Message struct {
 	Role       Role
 	Content    string
+	Images     []Image    // For user messages; supported by Anthropic, OpenAI and Gemini
 	ToolCallID string     // For tool results
 	ToolCalls  []ToolCall // For assistant messages with tool calls
+	Thinking   []Thinking // For assistant messages; must be sent back unchanged
+}
+
+// Thinking is a block of a model's reasoning (Anthropic extended thinking).
+// The signature lets the provider verify the block when it is sent back.
+type Thinking struct {
+	Text      string
+	Signature string
+	Redacted  string // Encrypted reasoning flagged by the provider's safety systems
 }
 
 // Tool describes a tool the LLM can call
@@ -32,22 +42,40 @@ type ToolCall struct {
 	ID         string
 	Name       string
 	Parameters map[string]interface{}
+
+	// ArgumentsError is set when the model's arguments weren't valid JSON,
+	// even after repair. Parameters is empty then.
+	ArgumentsError string
 }
 
 // Usage tracks token consumption
 type Usage struct {
 	InputTokens  int
 	OutputTokens int
+	Cost         float64 // USD, when reported by the provider (e.g. OpenRouter)
+
+	CacheReadTokens  int // Input tokens served from the provider's prompt cache
+	CacheWriteTokens int // Input tokens written to the provider's prompt cache
 }
 
 // Response from the LLM
 type Response struct {
 	Content   string     // Text response (if no tool call)
 	ToolCalls []ToolCall // Tools the LLM wants to call
+	Thinking  []Thinking // Reasoning, if extended thinking was enabled
 	Usage     Usage      // Token usage for this request
 }
 
-// Client is the interface all LLM providers implement
+// Client is the interface all LLM providers implement.
+// Options passed to Chat override the client's defaults for that request.
 type Client interface {
-	Chat(ctx context.Context, messages []Message, tools []Tool) (*Response, error)
+	Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error)
+}
+
+// StreamingClient is implemented by providers that can stream text as it is
+// generated. onDelta is called with each text fragment; the returned Response
+// holds the complete content, tool calls and usage once the stream ends.
+type StreamingClient interface {
+	Client
+	ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error)
 }
@@ -0,0 +1,282 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const cohereAPIURL = "https://api.cohere.com/v2/chat"
+
+// CohereClient implements the Client interface for Cohere's Command models.
+type CohereClient struct {
+	apiKey string
+	model  string
+	opts   ClientOptions
+	client *http.Client
+}
+
+// NewCohereClient creates a new Cohere client.
+// Reads API key from COHERE_API_KEY (or CO_API_KEY) environment variable.
+func NewCohereClient(model string, opts ...Option) (*CohereClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "COHERE_API_KEY", "CO_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("COHERE_API_KEY environment variable not set")
+	}
+
+	if model == "" {
+		model = "command-r-plus"
+	}
+
+	return &CohereClient{
+		apiKey: apiKey,
+		model:  model,
+		opts:   clientOptions(opts),
+		client: newHTTPClient(0),
+	}, nil
+}
+
+// Cohere API request/response types
+type cohereRequest struct {
+	Model       string          `json:"model"`
+	Messages    []cohereMessage `json:"messages"`
+	Tools       []cohereTool    `json:"tools,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	P           *float64        `json:"p,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	ToolChoice  string          `json:"tool_choice,omitempty"` // REQUIRED or NONE
+	Stop        []string        `json:"stop_sequences,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+
+	ResponseFormat *cohereResponseFormat `json:"response_format,omitempty"`
+}
+
+type cohereResponseFormat struct {
+	Type       string                 `json:"type"`
+	JSONSchema map[string]interface{} `json:"json_schema,omitempty"`
+}
+
+type cohereMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolPlan   string           `json:"tool_plan,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	ToolCalls  []cohereToolCall `json:"tool_calls,omitempty"`
+}
+
+type cohereToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function cohereFunctionCall `json:"function"`
+}
+
+type cohereFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type cohereTool struct {
+	Type     string         `json:"type"`
+	Function cohereFunction `json:"function"`
+}
+
+type cohereFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type cohereResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		ToolPlan  string           `json:"tool_plan"`
+		ToolCalls []cohereToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Usage struct {
+		BilledUnits struct {
+			InputTokens  float64 `json:"input_tokens"`
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"billed_units"`
+		Tokens struct {
+			InputTokens  float64 `json:"input_tokens"`
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"tokens"`
+	} `json:"usage"`
+}
+
+type cohereError struct {
+	Message string `json:"message"`
+}
+
+// Chat sends messages to Cohere and returns the response.
+func (c *CohereClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	req := cohereRequest{
+		Model:       o.model(c.model),
+		Messages:    c.convertMessages(messages),
+		Temperature: o.temperature(0.7),
+		P:           o.topP(0.99),
+		MaxTokens:   o.maxTokens(4096),
+		Stop:        o.Stop,
+		Seed:        o.Seed,
+
+		FrequencyPenalty: o.FrequencyPenalty,
+		PresencePenalty:  o.PresencePenalty,
+	}
+
+	if len(tools) > 0 {
+		req.Tools = c.convertTools(tools)
+		switch o.ToolChoice {
+		case "", ToolChoiceAuto:
+		case ToolChoiceNone:
+			req.ToolChoice = "NONE"
+		case ToolChoiceRequired:
+			req.ToolChoice = "REQUIRED"
+		default:
+			// Cohere can't force a particular tool; offer only that one
+			req.Tools = c.convertTools(o.offeredTools(tools))
+			req.ToolChoice = "REQUIRED"
+		}
+	}
+	if o.ResponseSchema != nil {
+		req.ResponseFormat = &cohereResponseFormat{Type: "json_object", JSONSchema: o.ResponseSchema.Schema}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", cohereAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr cohereError
+		_ = json.Unmarshal(respBody, &apiErr)
+		return nil, newAPIError(resp.StatusCode, apiErr.Message, respBody)
+	}
+
+	var cohereResp cohereResponse
+	if err := json.Unmarshal(respBody, &cohereResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	response := c.parseResponse(&cohereResp)
+	response.Usage.price(req.Model)
+	return response, nil
+}
+
+// convertMessages converts generic Messages to Cohere's format.
+func (c *CohereClient) convertMessages(messages []Message) []cohereMessage {
+	var result []cohereMessage
+
+	for _, msg := range messages {
+		cohereMsg := cohereMessage{
+			Content: msg.Content,
+		}
+
+		switch msg.Role {
+		case RoleSystem:
+			cohereMsg.Role = "system"
+		case RoleUser:
+			cohereMsg.Role = "user"
+		case RoleAssistant:
+			cohereMsg.Role = "assistant"
+			if len(msg.ToolCalls) > 0 {
+				// Cohere expects reasoning that precedes tool calls as a tool plan
+				cohereMsg.Content = ""
+				cohereMsg.ToolPlan = msg.Content
+				for _, tc := range msg.ToolCalls {
+					argsJSON, _ := json.Marshal(tc.Parameters)
+					cohereMsg.ToolCalls = append(cohereMsg.ToolCalls, cohereToolCall{
+						ID:   tc.ID,
+						Type: "function",
+						Function: cohereFunctionCall{
+							Name:      tc.Name,
+							Arguments: string(argsJSON),
+						},
+					})
+				}
+			}
+		case RoleTool:
+			cohereMsg.Role = "tool"
+			cohereMsg.ToolCallID = msg.ToolCallID
+		}
+
+		result = append(result, cohereMsg)
+	}
+
+	return result
+}
+
+// convertTools converts generic Tools to Cohere's format.
+func (c *CohereClient) convertTools(tools []Tool) []cohereTool {
+	var result []cohereTool
+
+	for _, tool := range tools {
+		result = append(result, cohereTool{
+			Type:     "function",
+			Function: cohereFunction(tool),
+		})
+	}
+
+	return result
+}
+
+// parseResponse converts Cohere's response to the generic Response type.
+func (c *CohereClient) parseResponse(resp *cohereResponse) *Response {
+	var text strings.Builder
+	for _, block := range resp.Message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	response := &Response{
+		Content: text.String(),
+		Usage: Usage{
+			InputTokens:  int(resp.Usage.Tokens.InputTokens),
+			OutputTokens: int(resp.Usage.Tokens.OutputTokens),
+		},
+	}
+	if response.Content == "" {
+		response.Content = resp.Message.ToolPlan
+	}
+
+	for _, tc := range resp.Message.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, parseToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
+	}
+
+	return response
+}
@@ -0,0 +1,221 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// CompatibleClient implements the Client interface for any server exposing an
+// OpenAI-compatible chat completions API (vLLM, LM Studio, LiteLLM, Groq, ...).
+type CompatibleClient struct {
+	baseURL string
+	model   string
+	opts    ClientOptions
+	client  openai.Client
+}
+
+// NewCompatibleClient creates a client for an OpenAI-compatible endpoint.
+// If baseURL or apiKey are empty they are read from OPENAI_COMPATIBLE_BASE_URL
+// and OPENAI_COMPATIBLE_API_KEY. The API key is optional for local servers.
+func NewCompatibleClient(baseURL, apiKey, model string, opts ...Option) (*CompatibleClient, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv("OPENAI_COMPATIBLE_BASE_URL")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("no base URL set. Use --base-url or OPENAI_COMPATIBLE_BASE_URL")
+	}
+	if apiKey == "" {
+		apiKey = resolveAPIKey(clientOptions(opts), "OPENAI_COMPATIBLE_API_KEY")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("no model set. Use --model to select a model served by %s", baseURL)
+	}
+
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	reqOpts := []option.RequestOption{
+		option.WithHTTPClient(newHTTPClient(0)),
+		option.WithMaxRetries(0),
+		option.WithBaseURL(baseURL),
+	}
+	if apiKey != "" {
+		reqOpts = append(reqOpts, option.WithAPIKey(apiKey))
+	} else {
+		// Never leak OPENAI_API_KEY to a third-party endpoint
+		reqOpts = append(reqOpts, option.WithHeaderDel("authorization"))
+	}
+
+	return &CompatibleClient{
+		baseURL: baseURL,
+		model:   model,
+		opts:    clientOptions(opts),
+		client:  openai.NewClient(reqOpts...),
+	}, nil
+}
+
+// Chat sends messages to the endpoint and returns the response.
+func (c *CompatibleClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := openai.ChatCompletionNewParams{
+		Messages: convertMessages(messages),
+		Model:    o.model(c.model),
+	}
+	if openaiTools := convertTools(tools); len(openaiTools) > 0 {
+		params.Tools = openaiTools
+	}
+	applyOpenAIOptions(&params, o)
+
+	resp, err := c.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+	if len(resp.Choices) == 0 {
+		return &Response{}, nil
+	}
+
+	return parseResponse(resp), nil
+}
+
+// ChatStream streams the response, calling onDelta with each text fragment.
+// Tool call arguments arrive in pieces and are only returned once complete.
+func (c *CompatibleClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := openai.ChatCompletionNewParams{
+		Messages: convertMessages(messages),
+		Model:    o.model(c.model),
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	}
+	if openaiTools := convertTools(tools); len(openaiTools) > 0 {
+		params.Tools = openaiTools
+	}
+	applyOpenAIOptions(&params, o)
+	return streamChatCompletion(ctx, &c.client, params, onDelta)
+}
+
+// streamChatCompletion streams a chat completion from an OpenAI-style API,
+// calling onDelta with each text fragment
+func streamChatCompletion(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams, onDelta func(string)) (*Response, error) {
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer func() { _ = stream.Close() }()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" && onDelta != nil {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+	if len(acc.Choices) == 0 {
+		return &Response{}, nil
+	}
+
+	return parseResponse(&acc.ChatCompletion), nil
+}
+
+// Hosted OpenAI-compatible providers
+
+const groqAPIURL = "https://api.groq.com/openai/v1/"
+
+// NewGroqClient creates a client for Groq's OpenAI-compatible API.
+// Reads API key from GROQ_API_KEY environment variable.
+func NewGroqClient(model string, opts ...Option) (*CompatibleClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "GROQ_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GROQ_API_KEY environment variable not set")
+	}
+	if model == "" {
+		model = "llama-3.3-70b-versatile"
+	}
+	return NewCompatibleClient(groqAPIURL, apiKey, model, opts...)
+}
+
+const deepSeekAPIURL = "https://api.deepseek.com/v1/"
+
+// NewDeepSeekClient creates a client for DeepSeek's OpenAI-compatible API.
+// Reads API key from DEEPSEEK_API_KEY environment variable.
+func NewDeepSeekClient(model string, opts ...Option) (*CompatibleClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "DEEPSEEK_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("DEEPSEEK_API_KEY environment variable not set")
+	}
+	if model == "" {
+		model = "deepseek-chat"
+	}
+	return NewCompatibleClient(deepSeekAPIURL, apiKey, model, opts...)
+}
+
+const xaiAPIURL = "https://api.x.ai/v1/"
+
+// NewGrokClient creates a client for xAI's OpenAI-compatible API.
+// Reads API key from XAI_API_KEY and the default model from XAI_MODEL.
+func NewGrokClient(model string, opts ...Option) (*CompatibleClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "XAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("XAI_API_KEY environment variable not set")
+	}
+	if model == "" {
+		model = os.Getenv("XAI_MODEL")
+	}
+	if model == "" {
+		model = "grok-3"
+	}
+	return NewCompatibleClient(xaiAPIURL, apiKey, model, opts...)
+}
+
+// NewHuggingFaceClient creates a client for a Hugging Face Inference Endpoint.
+// Endpoints running TGI serve the OpenAI Messages API under /v1. If endpointURL
+// is empty it is read from HF_ENDPOINT_URL; the token is read from HF_TOKEN.
+func NewHuggingFaceClient(endpointURL, model string, opts ...Option) (*CompatibleClient, error) {
+	if endpointURL == "" {
+		endpointURL = os.Getenv("HF_ENDPOINT_URL")
+	}
+	if endpointURL == "" {
+		return nil, fmt.Errorf("no endpoint set. Use --base-url or HF_ENDPOINT_URL")
+	}
+	token := resolveAPIKey(clientOptions(opts), "HF_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("HF_TOKEN environment variable not set")
+	}
+
+	endpointURL = strings.TrimSuffix(endpointURL, "/")
+	if !strings.HasSuffix(endpointURL, "/v1") {
+		endpointURL += "/v1"
+	}
+	// TGI serves a single model and ignores the model field
+	if model == "" {
+		model = "tgi"
+	}
+	return NewCompatibleClient(endpointURL, token, model, opts...)
+}
+
+const togetherAPIURL = "https://api.together.xyz/v1/"
+
+// NewTogetherClient creates a client for Together AI's OpenAI-compatible API.
+// Reads API key from TOGETHER_API_KEY environment variable.
+func NewTogetherClient(model string, opts ...Option) (*CompatibleClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "TOGETHER_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("TOGETHER_API_KEY environment variable not set")
+	}
+	if model == "" {
+		model = "meta-llama/Meta-Llama-3.1-405B-Instruct-Turbo"
+	}
+	return NewCompatibleClient(togetherAPIURL, apiKey, model, opts...)
+}
@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxShrunkToolOutput is the size tool results are cut to when even the
+// latest turn does not fit in the context window.
+const maxShrunkToolOutput = 2000
+
+// removedToolOutput replaces tool results that don't fit even when truncated.
+const removedToolOutput = "[tool output removed to fit the context window]"
+
+const summaryPrompt = `Summarize the following investigation of a Kubernetes cluster so it can be continued later.
+Keep every concrete fact: resource names, namespaces, CVE IDs, severities, counts and conclusions.
+Be concise and use bullet points.`
+
+// ContextManager keeps a conversation within a model's context window.
+// When the estimated prompt would not fit, the oldest turns are dropped
+// (and, if a Summarizer is set, replaced by a summary) so the provider
+// never rejects the request for being too long.
+type ContextManager struct {
+	Window     int    // Context window in tokens
+	Reserve    int    // Tokens kept free for the response
+	Summarizer Client // Optional; summarizes dropped turns instead of discarding them
+}
+
+// NewContextManager creates a manager for the given model's context window.
+func NewContextManager(model string) *ContextManager {
+	return &ContextManager{
+		Window:  ContextWindow(model),
+		Reserve: 4096,
+	}
+}
+
+// Fit returns messages trimmed to fit the context window together with tools.
+// Leading system messages and the latest turn are always kept. A turn starts
+// at a user message, so tool calls are never separated from their results.
+// If the latest turn is still too large, its tool results are truncated,
+// largest first, and then replaced by a note, oldest first. When even that
+// doesn't fit, Fit returns an error matching ErrContextTooLong.
+func (m *ContextManager) Fit(ctx context.Context, messages []Message, tools []Tool) ([]Message, error) {
+	budget := m.Window - m.Reserve
+	if EstimateMessages(messages, tools) <= budget {
+		return messages, nil
+	}
+
+	// Split off the system prompt
+	start := 0
+	for start < len(messages) && messages[start].Role == RoleSystem {
+		start++
+	}
+	system := append([]Message(nil), messages[:start]...)
+	rest := messages[start:]
+
+	// Turn boundaries, oldest first
+	var turns []int
+	for i, msg := range rest {
+		if msg.Role == RoleUser {
+			turns = append(turns, i)
+		}
+	}
+
+	// Drop whole turns until the prompt fits, keeping the latest one
+	cut := 0
+	for _, t := range turns {
+		candidate := append(append([]Message(nil), system...), rest[t:]...)
+		if EstimateMessages(candidate, tools) <= budget {
+			cut = t
+			break
+		}
+		cut = t
+	}
+	dropped, kept := rest[:cut], append([]Message(nil), rest[cut:]...)
+
+	if len(dropped) > 0 {
+		note := fmt.Sprintf("[%d earlier messages were removed to fit the context window]", len(dropped))
+		if m.Summarizer != nil {
+			if summary, err := m.summarize(ctx, dropped); err == nil && summary != "" {
+				note = "Summary of the earlier conversation:\n" + summary
+			}
+		}
+		if len(system) > 0 {
+			system[len(system)-1].Content += "\n\n" + note
+		} else {
+			system = []Message{{Role: RoleSystem, Content: note}}
+		}
+	}
+
+	result := append(system, kept...)
+
+	// Still too large: truncate the biggest tool results, each at most once
+	truncated := make(map[int]bool)
+	for EstimateMessages(result, tools) > budget {
+		largest := -1
+		for i, msg := range result {
+			if msg.Role == RoleTool && !truncated[i] && len(msg.Content) > maxShrunkToolOutput &&
+				(largest < 0 || len(msg.Content) > len(result[largest].Content)) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			break
+		}
+		truncated[largest] = true
+		result[largest].Content = result[largest].Content[:maxShrunkToolOutput] + "\n... (truncated to fit context window)"
+	}
+
+	// Then drop the tool results, oldest first, keeping the calls they answer
+	for i := range result {
+		if EstimateMessages(result, tools) <= budget {
+			return result, nil
+		}
+		if result[i].Role == RoleTool && result[i].Content != removedToolOutput {
+			result[i].Content = removedToolOutput
+		}
+	}
+
+	if needed := EstimateMessages(result, tools); needed > budget {
+		return nil, fmt.Errorf("%w: the conversation needs about %d tokens, but %d fit in the context window of %d after reserving %d for the response",
+			ErrContextTooLong, needed, max(budget, 0), m.Window, m.Reserve)
+	}
+	return result, nil
+}
+
+// summarize asks the summarizer to condense dropped messages.
+func (m *ContextManager) summarize(ctx context.Context, dropped []Message) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range dropped {
+		switch msg.Role {
+		case RoleUser:
+			fmt.Fprintf(&transcript, "User: %s\n\n", msg.Content)
+		case RoleAssistant:
+			if msg.Content != "" {
+				fmt.Fprintf(&transcript, "Assistant: %s\n\n", msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(&transcript, "Assistant called %s\n\n", tc.Name)
+			}
+		case RoleTool:
+			content := msg.Content
+			if len(content) > maxShrunkToolOutput {
+				content = content[:maxShrunkToolOutput] + "\n... (truncated)"
+			}
+			fmt.Fprintf(&transcript, "Tool result:\n%s\n\n", content)
+		}
+	}
+
+	resp, err := m.Summarizer.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: summaryPrompt},
+		{Role: RoleUser, Content: transcript.String()},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
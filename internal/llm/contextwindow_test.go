@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContextManagerFit(t *testing.T) {
+	system := Message{Role: RoleSystem, Content: "You are a Kubernetes security assistant."}
+	bigOutput := strings.Repeat("CVE-2024-1234 HIGH openssl ", 2000)
+
+	tests := []struct {
+		name     string
+		window   int
+		messages []Message
+		wantLen  int
+		wantErr  bool
+		check    func(t *testing.T, got []Message)
+	}{
+		{
+			name:   "fits unchanged",
+			window: 10000,
+			messages: []Message{
+				system,
+				{Role: RoleUser, Content: "Which pods run as root?"},
+			},
+			wantLen: 2,
+		},
+		{
+			name:   "drops older turns",
+			window: 3000,
+			messages: []Message{
+				system,
+				{Role: RoleUser, Content: "First question"},
+				{Role: RoleAssistant, Content: strings.Repeat("an earlier answer ", 1000)},
+				{Role: RoleUser, Content: "Second question"},
+			},
+			wantLen: 2,
+			check: func(t *testing.T, got []Message) {
+				if !strings.Contains(got[0].Content, "earlier messages were removed") {
+					t.Errorf("system message = %q, want a note about the removed messages", got[0].Content)
+				}
+				if got[1].Content != "Second question" {
+					t.Errorf("kept %q, want the latest turn", got[1].Content)
+				}
+			},
+		},
+		{
+			name:   "truncates a large tool result once",
+			window: 2000,
+			messages: []Message{
+				system,
+				{Role: RoleUser, Content: "List the vulnerabilities"},
+				{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Name: "query_vulns"}}},
+				{Role: RoleTool, ToolCallID: "1", Content: bigOutput},
+			},
+			wantLen: 4,
+			check: func(t *testing.T, got []Message) {
+				if !strings.HasSuffix(got[3].Content, "(truncated to fit context window)") {
+					t.Errorf("tool result wasn't truncated: %d bytes", len(got[3].Content))
+				}
+			},
+		},
+		{
+			name:   "removes tool results that still don't fit",
+			window: 1100,
+			messages: []Message{
+				system,
+				{Role: RoleUser, Content: "List the vulnerabilities"},
+				{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Name: "query_vulns"}}},
+				{Role: RoleTool, ToolCallID: "1", Content: bigOutput},
+			},
+			wantLen: 4,
+			check: func(t *testing.T, got []Message) {
+				if got[3].Content != removedToolOutput {
+					t.Errorf("tool result = %d bytes, want it removed", len(got[3].Content))
+				}
+			},
+		},
+		{
+			name:   "fails when nothing left can shrink",
+			window: 1100,
+			messages: []Message{
+				system,
+				{Role: RoleUser, Content: bigOutput},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &ContextManager{Window: tt.window, Reserve: 1000}
+			got, err := m.Fit(context.Background(), tt.messages, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrContextTooLong) {
+					t.Fatalf("Fit() error = %v, want ErrContextTooLong", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fit() error = %v", err)
+			}
+			if len(got) != tt.wantLen {
+				t.Fatalf("Fit() kept %d messages, want %d", len(got), tt.wantLen)
+			}
+			if n := EstimateMessages(got, nil); n > tt.window-1000 {
+				t.Errorf("Fit() result needs %d tokens, more than the budget of %d", n, tt.window-1000)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/openai/openai-go"
+)
+
+// Default embedding models. Pass WithModel to Embed to use another.
+const (
+	defaultOpenAIEmbeddingModel  = "text-embedding-3-small"
+	defaultMistralEmbeddingModel = "mistral-embed"
+	defaultOllamaEmbeddingModel  = "nomic-embed-text"
+)
+
+const mistralEmbeddingsURL = "https://api.mistral.ai/v1/embeddings"
+
+// Embedder is implemented by providers that can embed text, for semantic
+// search and deduplication of findings and runbooks. Embed returns one
+// vector per input text, in order. Vectors from different models are not
+// comparable.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string, opts ...Option) ([][]float64, error)
+}
+
+// Embed computes embeddings with OpenAI's embeddings API.
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string, opts ...Option) ([][]float64, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	resp, err := c.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Model: o.model(defaultOpenAIEmbeddingModel),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+
+	result := make([][]float64, len(texts))
+	for _, d := range resp.Data {
+		if int(d.Index) < len(result) {
+			result[d.Index] = d.Embedding
+		}
+	}
+	return result, nil
+}
+
+type mistralEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed computes embeddings with Mistral's embeddings API.
+func (c *MistralClient) Embed(ctx context.Context, texts []string, opts ...Option) ([][]float64, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(mistralEmbeddingRequest{
+		Model: o.model(defaultMistralEmbeddingModel),
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", mistralEmbeddingsURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	respBody, err := doRequest(c.client, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp embeddingResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	result := make([][]float64, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < len(result) {
+			result[d.Index] = d.Embedding
+		}
+	}
+	return result, nil
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed computes embeddings with a local Ollama embedding model.
+func (c *OllamaClient) Embed(ctx context.Context, texts []string, opts ...Option) ([][]float64, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultLocalTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(ollamaEmbedRequest{
+		Model: o.model(defaultOllamaEmbeddingModel),
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	respBody, err := doRequest(c.client, httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ollamaEmbedResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+	return resp.Embeddings, nil
+}
+
+// doRequest sends a request and returns the body of a successful response.
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp.StatusCode, "", body)
+	}
+	return body, nil
+}
+
+// CosineSimilarity returns the cosine similarity of two embeddings, from -1
+// to 1. Near-duplicate findings typically score above 0.9.
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+// Errors that callers can test for with errors.Is, whichever provider failed.
+var (
+	ErrRateLimited    = errors.New("rate limited")
+	ErrAuth           = errors.New("authentication failed")
+	ErrOverloaded     = errors.New("provider overloaded")
+	ErrContextTooLong = errors.New("context too long")
+)
+
+// APIError is an error response from an LLM provider. errors.Is reports
+// whether it matches ErrRateLimited, ErrAuth, ErrOverloaded or ErrContextTooLong.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Err        error // The SDK error, for providers that use one
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("(HTTP Error %d) %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the SDK error, if any.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the sentinel error for the kind of failure.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrOverloaded:
+		return e.StatusCode == 529 || e.StatusCode == http.StatusServiceUnavailable
+	case ErrContextTooLong:
+		return isContextTooLong(e.StatusCode, e.Message)
+	}
+	return false
+}
+
+// contextTooLongMessages are fragments of the errors providers return when a
+// request exceeds the model's context window.
+var contextTooLongMessages = []string{
+	"context length",                     // OpenAI, Mistral, vLLM
+	"context_length_exceeded",            // OpenAI error code
+	"prompt is too long",                 // Anthropic
+	"exceeds the maximum number",         // Gemini
+	"too many tokens",                    // Cohere
+	"exceeds the available context size", // llama.cpp
+}
+
+// isContextTooLong reports whether an error response means the request was
+// larger than the model's context window.
+func isContextTooLong(status int, message string) bool {
+	if status == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	if status != http.StatusBadRequest {
+		return false
+	}
+	message = strings.ToLower(message)
+	for _, m := range contextTooLongMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIError creates an APIError from an error response. message is the
+// provider's parsed error message; the raw body is used if it is empty.
+func newAPIError(status int, message string, body []byte) *APIError {
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	return &APIError{StatusCode: status, Message: message}
+}
+
+// sdkError converts an error from the Anthropic or OpenAI SDK to an APIError,
+// so it matches the sentinel errors. Other errors are returned unchanged.
+func sdkError(err error) error {
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return &APIError{StatusCode: anthropicErr.StatusCode, Message: anthropicErr.Error(), Err: err}
+	}
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return &APIError{StatusCode: openaiErr.StatusCode, Message: openaiErr.Error(), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker settings for FallbackClient
+const (
+	breakerThreshold = 3               // Consecutive failures before a provider is skipped
+	breakerCooldown  = 2 * time.Minute // How long a tripped provider is skipped
+)
+
+// FallbackProvider is one entry in a FallbackClient chain.
+type FallbackProvider struct {
+	Name   string
+	Client Client
+}
+
+// FallbackClient implements the Client interface over an ordered list of
+// providers (e.g. Anthropic → Mistral → Ollama). A request goes to the first
+// healthy provider and fails over to the next one on error. Providers that
+// keep failing are skipped for a cooldown period so every request doesn't
+// pay for the timeout of a provider that is down.
+type FallbackClient struct {
+	providers []FallbackProvider
+
+	// OnFailover, if set, is called when a provider fails and the next one is tried.
+	OnFailover func(from, to string, err error)
+
+	mu       sync.Mutex
+	breakers []breaker
+}
+
+// breaker tracks the health of a single provider.
+type breaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewFallbackClient creates a client that tries providers in order.
+func NewFallbackClient(providers ...FallbackProvider) *FallbackClient {
+	return &FallbackClient{
+		providers: providers,
+		breakers:  make([]breaker, len(providers)),
+	}
+}
+
+// Chat sends messages to the first available provider, failing over on errors.
+func (c *FallbackClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	order := c.order()
+	var errs []error
+
+	for i, idx := range order {
+		p := c.providers[idx]
+
+		resp, err := p.Client.Chat(ctx, messages, tools, opts...)
+		if err == nil {
+			c.recordSuccess(idx)
+			return resp, nil
+		}
+
+		// A cancelled request says nothing about the provider's health
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		c.recordFailure(idx)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+
+		if i+1 < len(order) && c.OnFailover != nil {
+			c.OnFailover(p.Name, c.providers[order[i+1]].Name, err)
+		}
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// order returns the provider indexes to try: healthy providers first in their
+// configured order, then tripped ones as a last resort.
+func (c *FallbackClient) order() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var healthy, tripped []int
+	for i, b := range c.breakers {
+		if now.Before(b.openUntil) {
+			tripped = append(tripped, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	return append(healthy, tripped...)
+}
+
+// recordSuccess closes the breaker of a provider.
+func (c *FallbackClient) recordSuccess(idx int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.breakers[idx] = breaker{}
+}
+
+// recordFailure counts a failure and trips the breaker at the threshold.
+func (c *FallbackClient) recordFailure(idx int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := &c.breakers[idx]
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+	}
+}
@@ -0,0 +1,361 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+const geminiAPIURL = "https://generativelanguage.googleapis.com/v1beta/models"
+
+// GeminiClient implements the Client interface for Google Gemini.
+// The same wire format is served by the Gemini API (API key auth) and
+// Vertex AI (OAuth2 token auth), so both share this client.
+type GeminiClient struct {
+	apiKey      string
+	tokenSource oauth2.TokenSource // Set for Vertex AI instead of apiKey
+	endpoint    string             // URL prefix of the models collection
+	model       string
+	opts        ClientOptions
+	client      *http.Client
+}
+
+// NewGeminiClient creates a new Gemini client.
+// Reads API key from GEMINI_API_KEY environment variable.
+func NewGeminiClient(model string, opts ...Option) (*GeminiClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+	}
+
+	if model == "" {
+		model = "gemini-1.5-pro"
+	}
+
+	return &GeminiClient{
+		apiKey:   apiKey,
+		endpoint: geminiAPIURL,
+		model:    model,
+		opts:     clientOptions(opts),
+		client:   newHTTPClient(0),
+	}, nil
+}
+
+// Gemini API request/response types
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig      `json:"toolConfig,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"` // AUTO, ANY or NONE
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
+
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+
+	ResponseMimeType   string                 `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema map[string]interface{} `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type geminiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// Chat sends messages to Gemini and returns the response.
+func (c *GeminiClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	req := geminiRequest{
+		Contents: c.convertMessages(messages),
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     o.temperature(0.7),
+			TopP:            o.topP(1.0),
+			MaxOutputTokens: o.maxTokens(4096),
+			StopSequences:   o.Stop,
+			Seed:            o.Seed,
+
+			FrequencyPenalty: o.FrequencyPenalty,
+			PresencePenalty:  o.PresencePenalty,
+		},
+	}
+	if o.ResponseSchema != nil {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = o.ResponseSchema.Schema
+	}
+
+	// Gemini takes the system prompt as a separate instruction
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			req.SystemInstruction = &geminiContent{
+				Parts: []geminiPart{{Text: msg.Content}},
+			}
+			break
+		}
+	}
+
+	if len(tools) > 0 {
+		req.Tools = c.convertTools(tools)
+		if o.ToolChoice != "" {
+			req.ToolConfig = geminiToolChoice(o.ToolChoice)
+		}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/"+o.model(c.model)+":generateContent", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+		token.SetAuthHeader(httpReq)
+	} else {
+		httpReq.Header.Set("x-goog-api-key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr geminiError
+		_ = json.Unmarshal(respBody, &apiErr)
+		return nil, newAPIError(resp.StatusCode, apiErr.Error.Message, respBody)
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	response := c.parseResponse(&geminiResp)
+	response.Usage.price(o.model(c.model))
+	return response, nil
+}
+
+// geminiToolChoice converts a tool choice mode or tool name to Gemini's tool config.
+func geminiToolChoice(choice string) *geminiToolConfig {
+	cfg := &geminiToolConfig{}
+	switch choice {
+	case ToolChoiceAuto:
+		cfg.FunctionCallingConfig.Mode = "AUTO"
+	case ToolChoiceRequired:
+		cfg.FunctionCallingConfig.Mode = "ANY"
+	case ToolChoiceNone:
+		cfg.FunctionCallingConfig.Mode = "NONE"
+	default:
+		cfg.FunctionCallingConfig.Mode = "ANY"
+		cfg.FunctionCallingConfig.AllowedFunctionNames = []string{choice}
+	}
+	return cfg
+}
+
+// convertMessages converts generic Messages to Gemini's content format.
+// Gemini identifies function responses by name, so tool call IDs are
+// mapped back to the function name of the originating call.
+func (c *GeminiClient) convertMessages(messages []Message) []geminiContent {
+	var result []geminiContent
+	toolNames := make(map[string]string)
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			// System messages are handled separately in req.SystemInstruction
+			continue
+
+		case RoleUser:
+			parts := []geminiPart{{Text: msg.Content}}
+			for _, img := range msg.Images {
+				if img.URL != "" {
+					// Gemini only fetches files it hosts; pass other URLs as text
+					parts = append(parts, geminiPart{Text: "Image: " + img.URL})
+					continue
+				}
+				parts = append(parts, geminiPart{InlineData: &geminiInlineData{MimeType: img.MediaType, Data: img.Data}})
+			}
+			result = append(result, geminiContent{
+				Role:  "user",
+				Parts: parts,
+			})
+
+		case RoleAssistant:
+			content := geminiContent{Role: "model"}
+			if msg.Content != "" {
+				content.Parts = append(content.Parts, geminiPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Name
+				content.Parts = append(content.Parts, geminiPart{
+					FunctionCall: &geminiFunctionCall{
+						Name: tc.Name,
+						Args: tc.Parameters,
+					},
+				})
+			}
+			result = append(result, content)
+
+		case RoleTool:
+			part := geminiPart{
+				FunctionResponse: &geminiFunctionResponse{
+					Name:     toolNames[msg.ToolCallID],
+					Response: map[string]interface{}{"content": msg.Content},
+				},
+			}
+			// Consecutive tool results belong in a single user turn
+			if n := len(result); n > 0 && result[n-1].Role == "user" && result[n-1].Parts[0].FunctionResponse != nil {
+				result[n-1].Parts = append(result[n-1].Parts, part)
+			} else {
+				result = append(result, geminiContent{Role: "user", Parts: []geminiPart{part}})
+			}
+		}
+	}
+
+	return result
+}
+
+// convertTools converts generic Tools to Gemini's function declarations.
+func (c *GeminiClient) convertTools(tools []Tool) []geminiTool {
+	var declarations []geminiFunctionDeclaration
+
+	for _, tool := range tools {
+		decl := geminiFunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+		}
+		// Gemini rejects object schemas without properties
+		if props, ok := tool.Parameters["properties"].(map[string]interface{}); ok && len(props) > 0 {
+			decl.Parameters = tool.Parameters
+		}
+		declarations = append(declarations, decl)
+	}
+
+	return []geminiTool{{FunctionDeclarations: declarations}}
+}
+
+// parseResponse converts Gemini's response to the generic Response type.
+func (c *GeminiClient) parseResponse(resp *geminiResponse) *Response {
+	response := &Response{
+		Usage: Usage{
+			InputTokens:  resp.UsageMetadata.PromptTokenCount,
+			OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
+		},
+	}
+
+	if len(resp.Candidates) == 0 {
+		return response
+	}
+
+	for i, part := range resp.Candidates[0].Content.Parts {
+		if part.Text != "" {
+			response.Content += part.Text
+		}
+		if part.FunctionCall != nil {
+			// Older Gemini models don't return call IDs, so synthesize one
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("%s-%d", part.FunctionCall.Name, i)
+			}
+			params := part.FunctionCall.Args
+			if params == nil {
+				params = make(map[string]interface{})
+			}
+			response.ToolCalls = append(response.ToolCalls, ToolCall{
+				ID:         id,
+				Name:       part.FunctionCall.Name,
+				Parameters: params,
+			})
+		}
+	}
+
+	return response
+}
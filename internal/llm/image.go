@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// imageTokens is a rough per-image token estimate for context management.
+// Providers bill images by resolution; ~1600 tokens covers a typical screenshot.
+const imageTokens = 1600
+
+// maxImageBytes is the largest image accepted; providers reject larger uploads.
+const maxImageBytes = 5 << 20
+
+// Image is an image attached to a user message, given either inline as
+// base64 data or by URL.
+type Image struct {
+	MediaType string // e.g. "image/png"; required for inline data
+	Data      string // Base64-encoded image
+	URL       string // Publicly reachable image URL, instead of Data
+}
+
+// LoadImage reads an image file (PNG, JPEG, GIF or WebP) for attaching to a message.
+func LoadImage(path string) (Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return Image{}, fmt.Errorf("image %s is larger than 5MB", path)
+	}
+
+	mediaType := http.DetectContentType(data)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return Image{}, fmt.Errorf("unsupported image type %s for %s (use PNG, JPEG, GIF or WebP)", mediaType, path)
+	}
+
+	return Image{MediaType: mediaType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+// ImageFromURL references an image by URL, or by a data: URL.
+func ImageFromURL(url string) Image {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if mediaType, data, ok := strings.Cut(rest, ";base64,"); ok {
+			return Image{MediaType: mediaType, Data: data}
+		}
+	}
+	return Image{URL: url}
+}
+
+// dataURL returns the image as a URL, inlining data as a data: URL.
+func (i Image) dataURL() string {
+	if i.URL != "" {
+		return i.URL
+	}
+	return "data:" + i.MediaType + ";base64," + i.Data
+}
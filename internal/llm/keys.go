@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// providerKeyEnv lists the API key environment variables of the built-in
+// providers, so New can find key lists to rotate through.
+var providerKeyEnv = map[string][]string{
+	"anthropic":   {"ANTHROPIC_API_KEY"},
+	"openai":      {"OPENAI_API_KEY"},
+	"azure":       {"AZURE_OPENAI_API_KEY"},
+	"mistral":     {"MISTRAL_API_KEY"},
+	"gemini":      {"GEMINI_API_KEY"},
+	"groq":        {"GROQ_API_KEY"},
+	"openrouter":  {"OPENROUTER_API_KEY"},
+	"cohere":      {"COHERE_API_KEY", "CO_API_KEY"},
+	"deepseek":    {"DEEPSEEK_API_KEY"},
+	"grok":        {"XAI_API_KEY"},
+	"huggingface": {"HF_TOKEN"},
+	"together":    {"TOGETHER_API_KEY"},
+	"compatible":  {"OPENAI_COMPATIBLE_API_KEY"},
+}
+
+// resolveAPIKey returns the API key for a provider. If several keys are
+// configured (see lookupAPIKey) it returns the first; New rotates through all of them.
+func resolveAPIKey(o ClientOptions, envVars ...string) string {
+	if keys := splitKeys(lookupAPIKey(o, envVars...)); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// lookupAPIKey returns the configured API key or key list: the WithAPIKey
+// option, then the first of envVars that is set, then the contents of the
+// file named by <VAR>_FILE (e.g. ANTHROPIC_API_KEY_FILE, for Docker and
+// Kubernetes secrets mounted as files).
+func lookupAPIKey(o ClientOptions, envVars ...string) string {
+	if o.APIKey != "" {
+		return o.APIKey
+	}
+	for _, name := range envVars {
+		if key := os.Getenv(name); key != "" {
+			return key
+		}
+	}
+	for _, name := range envVars {
+		if path := os.Getenv(name + "_FILE"); path != "" {
+			if data, err := os.ReadFile(path); err == nil {
+				return strings.TrimSpace(string(data))
+			}
+		}
+	}
+	return ""
+}
+
+// splitKeys splits a comma- or newline-separated list of API keys.
+func splitKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// keyRotationClient spreads requests across clients of the same provider that
+// use different API keys, round-robin. A request that is rate limited moves on
+// to the next key straight away instead of backing off.
+type keyRotationClient struct {
+	clients []Client
+
+	mu   sync.Mutex
+	next int
+}
+
+// rateLimitKey is the context key of a *rateLimitState.
+type rateLimitKey struct{}
+
+// rateLimitState lets retryTransport tell keyRotationClient that a request
+// was rate limited, whichever provider and error format produced it.
+type rateLimitState struct {
+	skipRetry bool        // Return 429 responses instead of retrying them
+	limited   atomic.Bool // Set when a 429 response was returned
+}
+
+// rateLimitFrom returns the rate limit state of ctx, if any.
+func rateLimitFrom(ctx context.Context) *rateLimitState {
+	state, _ := ctx.Value(rateLimitKey{}).(*rateLimitState)
+	return state
+}
+
+// Chat sends the request with the next key, trying the others in turn while
+// the keys are rate limited. The last key tried retries with backoff as usual.
+func (c *keyRotationClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	return c.rotate(ctx, func(ctx context.Context, client Client) (*Response, error) {
+		return client.Chat(ctx, messages, tools, opts...)
+	})
+}
+
+// ChatStream is Chat for streaming clients. Rate limits are reported before
+// anything is streamed, so a request moves on to the next key without
+// repeating deltas. A client that can't stream is asked with Chat.
+func (c *keyRotationClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	return c.rotate(ctx, func(ctx context.Context, client Client) (*Response, error) {
+		if streaming, ok := client.(StreamingClient); ok {
+			return streaming.ChatStream(ctx, messages, tools, onDelta, opts...)
+		}
+		resp, err := client.Chat(ctx, messages, tools, opts...)
+		if err == nil && onDelta != nil && resp.Content != "" {
+			onDelta(resp.Content)
+		}
+		return resp, err
+	})
+}
+
+// rotate calls send with the next client, then with the others in turn while
+// their keys are rate limited
+func (c *keyRotationClient) rotate(ctx context.Context, send func(context.Context, Client) (*Response, error)) (*Response, error) {
+	c.mu.Lock()
+	start := c.next
+	c.next = (c.next + 1) % len(c.clients)
+	c.mu.Unlock()
+
+	var resp *Response
+	var err error
+	for i := range c.clients {
+		state := &rateLimitState{skipRetry: i < len(c.clients)-1}
+		client := c.clients[(start+i)%len(c.clients)]
+		resp, err = send(context.WithValue(ctx, rateLimitKey{}, state), client)
+		if err == nil || !state.limited.Load() || ctx.Err() != nil {
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// ListModels lists models with the first key.
+func (c *keyRotationClient) ListModels(ctx context.Context) ([]string, error) {
+	return ListModels(ctx, c.clients[0])
+}
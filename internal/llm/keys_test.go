@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// limitedClient streams its key as the response, or fails as rate limited
+// the way retryTransport reports it
+type limitedClient struct {
+	key     string
+	limited bool
+}
+
+func (c limitedClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	return c.ChatStream(ctx, messages, tools, nil, opts...)
+}
+
+func (c limitedClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	if c.limited {
+		if state := rateLimitFrom(ctx); state != nil {
+			state.limited.Store(true)
+		}
+		return nil, errors.New("429 rate limited")
+	}
+	if onDelta != nil {
+		onDelta(c.key)
+	}
+	return &Response{Content: c.key}, nil
+}
+
+func TestKeyRotationChatStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		clients []Client
+		want    []string // Responses of consecutive requests; "error" for a failure
+	}{
+		{"round-robin", []Client{limitedClient{key: "a"}, limitedClient{key: "b"}}, []string{"a", "b", "a"}},
+		{"skips rate limited keys", []Client{limitedClient{key: "a", limited: true}, limitedClient{key: "b"}}, []string{"b", "b"}},
+		{"all keys rate limited", []Client{limitedClient{key: "a", limited: true}, limitedClient{key: "b", limited: true}}, []string{"error"}},
+		{"client without streaming", []Client{&fakeClient{}}, []string{"hello world"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &keyRotationClient{clients: tt.clients}
+			for i, want := range tt.want {
+				var deltas []string
+				resp, err := client.ChatStream(context.Background(), nil, nil, func(delta string) { deltas = append(deltas, delta) })
+				if want == "error" {
+					if err == nil {
+						t.Errorf("request %d succeeded, want an error", i)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+				if resp.Content != want || strings.Join(deltas, "") != want {
+					t.Errorf("request %d = %q with deltas %q, want %q", i, resp.Content, deltas, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// LlamaCppClient implements the Client interface for the llama.cpp HTTP server.
+//
+// By default tool calls are produced with grammar-constrained JSON: the server's
+// json_schema extension forces the model to answer with either a valid tool call
+// or a final answer, which is far more reliable than native tool calling for
+// small local models. Set LLAMACPP_TOOL_MODE=native to use the server's
+// OpenAI-style tool calling instead (requires llama-server --jinja).
+type LlamaCppClient struct {
+	baseURL string
+	model   string
+	native  bool
+	opts    ClientOptions
+	client  *http.Client
+}
+
+// NewLlamaCppClient creates a new llama.cpp client.
+// It reads the base URL from LLAMACPP_HOST environment variable or uses localhost:8080.
+func NewLlamaCppClient(baseURL, model string, opts ...Option) (*LlamaCppClient, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv("LLAMACPP_HOST")
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+
+	var native bool
+	switch mode := os.Getenv("LLAMACPP_TOOL_MODE"); mode {
+	case "", "grammar":
+	case "native":
+		native = true
+	default:
+		return nil, fmt.Errorf("invalid LLAMACPP_TOOL_MODE %q (use 'grammar' or 'native')", mode)
+	}
+
+	// llama-server serves whichever model it was started with
+	if model == "" {
+		model = "default"
+	}
+
+	return &LlamaCppClient{
+		baseURL: baseURL,
+		model:   model,
+		native:  native,
+		opts:    clientOptions(opts),
+		client:  newHTTPClient(0), // Requests are bounded by DefaultLocalTimeout
+	}, nil
+}
+
+// llama.cpp API types (OpenAI format plus llama.cpp extensions)
+
+type llamaCppRequest struct {
+	Model       string                 `json:"model"`
+	Messages    []llamaCppMessage      `json:"messages"`
+	Tools       []llamaCppTool         `json:"tools,omitempty"`
+	ToolChoice  string                 `json:"tool_choice,omitempty"`
+	JSONSchema  map[string]interface{} `json:"json_schema,omitempty"` // llama.cpp extension
+	Temperature *float64               `json:"temperature,omitempty"`
+	TopP        *float64               `json:"top_p,omitempty"`
+	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	Stop        []string               `json:"stop,omitempty"`
+	Seed        *int                   `json:"seed,omitempty"`
+
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+}
+
+type llamaCppMessage struct {
+	Role       string             `json:"role"`
+	Content    string             `json:"content"`
+	ToolCalls  []llamaCppToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string             `json:"tool_call_id,omitempty"`
+}
+
+type llamaCppToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type llamaCppTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type llamaCppResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string             `json:"content"`
+			ToolCalls []llamaCppToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type llamaCppError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// llamaCppAction is the grammar-constrained output in grammar tool mode.
+type llamaCppAction struct {
+	Tool      string                 `json:"tool,omitempty"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Answer    string                 `json:"answer,omitempty"`
+}
+
+// Chat sends messages to llama.cpp and returns the response.
+func (c *LlamaCppClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultLocalTimeout)
+	defer cancel()
+	req := llamaCppRequest{
+		Model:       o.model(c.model),
+		Temperature: o.temperature(0.2),
+		TopP:        o.TopP,
+		MaxTokens:   o.maxTokens(4096),
+		Stop:        o.Stop,
+		Seed:        o.Seed,
+
+		FrequencyPenalty: o.FrequencyPenalty,
+		PresencePenalty:  o.PresencePenalty,
+	}
+
+	grammar := !c.native && len(tools) > 0
+	if grammar {
+		req.Messages = c.convertMessagesGrammar(messages, tools)
+		// ToolChoiceNone leaves only the answer; forcing a tool removes it
+		forced := o.ToolChoice != "" && o.ToolChoice != ToolChoiceAuto && o.ToolChoice != ToolChoiceNone
+		req.JSONSchema = c.actionSchema(o.offeredTools(tools), !forced)
+	} else {
+		req.Messages = c.convertMessages(messages)
+		if len(tools) > 0 {
+			switch o.ToolChoice {
+			case "", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+				req.Tools = c.convertTools(tools)
+				req.ToolChoice = o.ToolChoice
+			default:
+				// The server can't force a particular tool; offer only that one
+				req.Tools = c.convertTools(toolsNamed(tools, o.ToolChoice))
+				req.ToolChoice = ToolChoiceRequired
+			}
+		}
+		if o.ResponseSchema != nil {
+			req.JSONSchema = o.ResponseSchema.Schema
+		}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr llamaCppError
+		_ = json.Unmarshal(respBody, &apiErr)
+		return nil, newAPIError(resp.StatusCode, apiErr.Error.Message, respBody)
+	}
+
+	var llamaResp llamaCppResponse
+	if err := json.Unmarshal(respBody, &llamaResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	response := &Response{
+		Usage: Usage{
+			InputTokens:  llamaResp.Usage.PromptTokens,
+			OutputTokens: llamaResp.Usage.CompletionTokens,
+		},
+	}
+	if len(llamaResp.Choices) == 0 {
+		return response, nil
+	}
+	msg := llamaResp.Choices[0].Message
+
+	if grammar {
+		var action llamaCppAction
+		if err := json.Unmarshal([]byte(msg.Content), &action); err != nil {
+			// Should not happen with a grammar, but don't lose the output
+			response.Content = msg.Content
+			return response, nil
+		}
+		if action.Tool != "" {
+			params := action.Arguments
+			if params == nil {
+				params = make(map[string]interface{})
+			}
+			response.ToolCalls = []ToolCall{{
+				ID:         fmt.Sprintf("call-%d", len(messages)),
+				Name:       action.Tool,
+				Parameters: params,
+			}}
+		} else {
+			response.Content = action.Answer
+		}
+		return response, nil
+	}
+
+	response.Content = msg.Content
+	for _, tc := range msg.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, parseToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
+	}
+
+	return response, nil
+}
+
+// convertMessages converts generic Messages to the OpenAI-style format used for native tool calls.
+func (c *LlamaCppClient) convertMessages(messages []Message) []llamaCppMessage {
+	var result []llamaCppMessage
+
+	for _, msg := range messages {
+		llamaMsg := llamaCppMessage{
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, tc := range msg.ToolCalls {
+			argsJSON, _ := json.Marshal(tc.Parameters)
+			call := llamaCppToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = string(argsJSON)
+			llamaMsg.ToolCalls = append(llamaMsg.ToolCalls, call)
+		}
+		result = append(result, llamaMsg)
+	}
+
+	return result
+}
+
+// convertTools converts generic Tools to the OpenAI-style tool format.
+func (c *LlamaCppClient) convertTools(tools []Tool) []llamaCppTool {
+	var result []llamaCppTool
+
+	for _, tool := range tools {
+		t := llamaCppTool{Type: "function"}
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = tool.Parameters
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// convertMessagesGrammar converts generic Messages for grammar tool mode.
+// The tools are described in the system prompt, earlier tool calls are replayed
+// as the JSON the model produced, and tool results are passed back as user turns.
+func (c *LlamaCppClient) convertMessagesGrammar(messages []Message, tools []Tool) []llamaCppMessage {
+	var result []llamaCppMessage
+	toolNames := make(map[string]string)
+
+	var instructions strings.Builder
+	instructions.WriteString("You can call the following tools:\n")
+	for _, tool := range tools {
+		paramsJSON, _ := json.Marshal(tool.Parameters)
+		fmt.Fprintf(&instructions, "- %s: %s\n  parameters: %s\n", tool.Name, tool.Description, paramsJSON)
+	}
+	instructions.WriteString("\nRespond with a single JSON object. To call a tool use {\"tool\": \"<name>\", \"arguments\": {...}}. " +
+		"When you have enough information, respond with {\"answer\": \"<your final answer in markdown>\"}.")
+
+	hasSystem := false
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			hasSystem = true
+			result = append(result, llamaCppMessage{
+				Role:    "system",
+				Content: msg.Content + "\n\n" + instructions.String(),
+			})
+
+		case RoleUser:
+			result = append(result, llamaCppMessage{Role: "user", Content: msg.Content})
+
+		case RoleAssistant:
+			if len(msg.ToolCalls) == 0 {
+				answerJSON, _ := json.Marshal(llamaCppAction{Answer: msg.Content})
+				result = append(result, llamaCppMessage{Role: "assistant", Content: string(answerJSON)})
+				continue
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Name
+				actionJSON, _ := json.Marshal(llamaCppAction{Tool: tc.Name, Arguments: tc.Parameters})
+				result = append(result, llamaCppMessage{Role: "assistant", Content: string(actionJSON)})
+			}
+
+		case RoleTool:
+			result = append(result, llamaCppMessage{
+				Role:    "user",
+				Content: fmt.Sprintf("Result of %s:\n%s", toolNames[msg.ToolCallID], msg.Content),
+			})
+		}
+	}
+
+	if !hasSystem {
+		result = append([]llamaCppMessage{{Role: "system", Content: instructions.String()}}, result...)
+	}
+
+	return result
+}
+
+// actionSchema builds the JSON schema the server compiles into a grammar:
+// exactly one tool call with valid arguments, or a final answer.
+func (c *LlamaCppClient) actionSchema(tools []Tool, allowAnswer bool) map[string]interface{} {
+	var options []interface{}
+
+	for _, tool := range tools {
+		args := tool.Parameters
+		if args == nil {
+			args = map[string]interface{}{"type": "object"}
+		}
+		options = append(options, map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tool":      map[string]interface{}{"const": tool.Name},
+				"arguments": args,
+			},
+			"required":             []string{"tool", "arguments"},
+			"additionalProperties": false,
+		})
+	}
+
+	if allowAnswer {
+		options = append(options, map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"answer": map[string]interface{}{"type": "string"},
+			},
+			"required":             []string{"answer"},
+			"additionalProperties": false,
+		})
+	}
+
+	return map[string]interface{}{"oneOf": options}
+}
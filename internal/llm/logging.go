@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoggingClient wraps a Client and appends every request and response to a
+// JSONL file, for debugging prompt quality. Credentials and secrets are
+// redacted before anything is written.
+type LoggingClient struct {
+	client Client
+	mu     sync.Mutex
+	file   *os.File
+}
+
+type logEntry struct {
+	Time       time.Time     `json:"time"`
+	DurationMs int64         `json:"duration_ms"`
+	Model      string        `json:"model,omitempty"` // Per-request override, if any
+	Messages   []logMessage  `json:"messages"`
+	Tools      []string      `json:"tools,omitempty"`
+	Response   *logMessage   `json:"response,omitempty"`
+	Usage      *fixtureUsage `json:"usage,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type logMessage struct {
+	Role       Role          `json:"role"`
+	Content    string        `json:"content,omitempty"`
+	Images     int           `json:"images,omitempty"` // Image data is not logged
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+	ToolCalls  []logToolCall `json:"tool_calls,omitempty"`
+	Thinking   []string      `json:"thinking,omitempty"`
+}
+
+type logToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// NewLoggingClient creates a logging wrapper around client that appends to path.
+func NewLoggingClient(client Client, path string) (*LoggingClient, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LLM log: %w", err)
+	}
+	return &LoggingClient{client: client, file: file}, nil
+}
+
+// Chat calls the wrapped client and logs the exchange.
+func (c *LoggingClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	start := time.Now()
+	resp, err := c.client.Chat(ctx, messages, tools, opts...)
+
+	entry := logEntry{
+		Time:       start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Model:      applyOptions(ClientOptions{}, opts).Model,
+	}
+	for _, msg := range messages {
+		entry.Messages = append(entry.Messages, newLogMessage(msg))
+	}
+	for _, tool := range tools {
+		entry.Tools = append(entry.Tools, tool.Name)
+	}
+	if err != nil {
+		entry.Error = Redact(err.Error())
+	} else {
+		msg := newLogMessage(Message{Role: RoleAssistant, Content: resp.Content, ToolCalls: resp.ToolCalls, Thinking: resp.Thinking})
+		entry.Response = &msg
+		entry.Usage = &fixtureUsage{
+			InputTokens:  resp.Usage.InputTokens,
+			OutputTokens: resp.Usage.OutputTokens,
+			Cost:         resp.Usage.Cost,
+
+			CacheReadTokens:  resp.Usage.CacheReadTokens,
+			CacheWriteTokens: resp.Usage.CacheWriteTokens,
+		}
+	}
+
+	// Logging is best effort; a failed write must not fail the request
+	if data, merr := json.Marshal(entry); merr == nil {
+		c.mu.Lock()
+		_, _ = c.file.Write(append(data, '\n'))
+		c.mu.Unlock()
+	}
+
+	return resp, err
+}
+
+// Close closes the log file.
+func (c *LoggingClient) Close() error {
+	return c.file.Close()
+}
+
+// newLogMessage converts a message to its redacted log form.
+func newLogMessage(msg Message) logMessage {
+	result := logMessage{
+		Role:       msg.Role,
+		Content:    Redact(msg.Content),
+		Images:     len(msg.Images),
+		ToolCallID: msg.ToolCallID,
+	}
+	for _, tc := range msg.ToolCalls {
+		args, _ := json.Marshal(tc.Parameters)
+		result.ToolCalls = append(result.ToolCalls, logToolCall{
+			ID:        tc.ID,
+			Name:      tc.Name,
+			Arguments: Redact(string(args)),
+		})
+	}
+	for _, t := range msg.Thinking {
+		if t.Text != "" {
+			result.Thinking = append(result.Thinking, Redact(t.Text))
+		}
+	}
+	return result
+}
+
+// redacted replaces secrets in logs.
+const redacted = "[REDACTED]"
+
+// secretPatterns match credentials by their well-known formats.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),                                   // OpenAI, Anthropic, DeepSeek, OpenRouter
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),                                   // Google
+	regexp.MustCompile(`\b(gsk|xai|hf)_?-?[A-Za-z0-9]{30,}`),                        // Groq, xAI, Hugging Face
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),                              // GitHub
+	regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),                               // AWS access key IDs
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`), // JWTs (e.g. service account tokens)
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{8,}`),
+}
+
+// secretAssignment matches "password: value", "api_key=value" and similar,
+// keeping the name so the log still shows what was there.
+var secretAssignment = regexp.MustCompile(`(?i)\b([A-Za-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)["']?\s*[:=]\s*["']?)([^\s"',;}]{4,})`)
+
+// Redact removes API keys, bearer tokens and other secrets from s. Values of
+// environment variables that look like credentials are removed verbatim.
+func Redact(s string) string {
+	for _, value := range secretEnvValues() {
+		s = strings.ReplaceAll(s, value, redacted)
+	}
+	for _, p := range secretPatterns {
+		s = p.ReplaceAllString(s, redacted)
+	}
+	return secretAssignment.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// secretEnvValues returns the values of environment variables whose names
+// suggest they hold a credential, such as ANTHROPIC_API_KEY or HF_TOKEN.
+func secretEnvValues() []string {
+	var values []string
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if len(value) < 8 {
+			continue
+		}
+		for _, marker := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD"} {
+			if strings.Contains(strings.ToUpper(name), marker) {
+				values = append(values, value)
+				break
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,79 @@
+package llm
+
+import "context"
+
+// ClientMiddleware wraps a Client with extra behaviour, such as logging,
+// metrics, rate limiting or content filtering, without changing the provider.
+type ClientMiddleware func(next Client) Client
+
+// ClientFunc adapts an ordinary function to the Client interface, which
+// keeps middleware short:
+//
+//	func Logged(next llm.Client) llm.Client {
+//		return llm.ClientFunc(func(ctx context.Context, messages []llm.Message, tools []llm.Tool, opts ...llm.Option) (*llm.Response, error) {
+//			start := time.Now()
+//			resp, err := next.Chat(ctx, messages, tools, opts...)
+//			log.Printf("chat took %s", time.Since(start))
+//			return resp, err
+//		})
+//	}
+//
+// Under Chain, a ClientFunc streams when its next client does: see Chain.
+type ClientFunc func(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error)
+
+// Chat calls f.
+func (f ClientFunc) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	return f(ctx, messages, tools, opts...)
+}
+
+// Chain wraps client with middleware. The first middleware is the outermost,
+// so it sees each request first and each response last. If client streams,
+// so does the chain: ChatStream runs the middleware's Chat as usual, and the
+// next.Chat call it makes streams from the client.
+func Chain(client Client, middleware ...ClientMiddleware) Client {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next, streaming := client.(StreamingClient)
+		if !streaming {
+			client = middleware[i](client)
+			continue
+		}
+		client = middleware[i](streamingNext{next})
+		if _, ok := client.(StreamingClient); !ok {
+			client = streamingMiddleware{client}
+		}
+	}
+	return client
+}
+
+// deltaKey is the context key of the onDelta callback of a ChatStream call
+// made through middleware.
+type deltaKey struct{}
+
+// streamingMiddleware makes a middleware's client a StreamingClient. ChatStream
+// passes onDelta through the context to the streamingNext the middleware
+// calls.
+type streamingMiddleware struct {
+	Client
+}
+
+// ChatStream calls Chat with onDelta in the context.
+func (m streamingMiddleware) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	return m.Chat(context.WithValue(ctx, deltaKey{}, onDelta), messages, tools, opts...)
+}
+
+// streamingNext is the next client as middleware sees it: Chat streams when
+// the chain was called with ChatStream.
+type streamingNext struct {
+	StreamingClient
+}
+
+// Chat calls ChatStream with the context's onDelta, if any.
+func (n streamingNext) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	onDelta, _ := ctx.Value(deltaKey{}).(func(string))
+	if onDelta == nil {
+		return n.StreamingClient.Chat(ctx, messages, tools, opts...)
+	}
+	// Calls further down the chain start without a stream of their own
+	ctx = context.WithValue(ctx, deltaKey{}, (func(string))(nil))
+	return n.ChatStream(ctx, messages, tools, onDelta, opts...)
+}
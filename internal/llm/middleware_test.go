@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// fakeClient answers every request with "hello world" and counts them.
+// fakeStreamingClient makes it a StreamingClient.
+type fakeClient struct {
+	chats   int
+	streams int
+}
+
+func (c *fakeClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	c.chats++
+	return &Response{Content: "hello world"}, nil
+}
+
+type fakeStreamingClient struct {
+	*fakeClient
+}
+
+func (c fakeStreamingClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	c.streams++
+	for _, delta := range []string{"hello", " world"} {
+		onDelta(delta)
+	}
+	return &Response{Content: "hello world"}, nil
+}
+
+// counting is middleware that counts the requests it sees
+func counting(calls *int) ClientMiddleware {
+	return func(next Client) Client {
+		return ClientFunc(func(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+			*calls++
+			return next.Chat(ctx, messages, tools, opts...)
+		})
+	}
+}
+
+func TestChainStreaming(t *testing.T) {
+	inner := &fakeClient{}
+	var outer, middle int
+	client := Chain(fakeStreamingClient{inner}, counting(&outer), counting(&middle))
+
+	streaming, ok := client.(StreamingClient)
+	if !ok {
+		t.Fatal("the chain of a streaming client doesn't stream")
+	}
+	var deltas []string
+	resp, err := streaming.ChatStream(context.Background(), nil, nil, func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "hello world" || strings.Join(deltas, "|") != "hello| world" {
+		t.Errorf("ChatStream() = %q with deltas %q, want the streamed response", resp.Content, deltas)
+	}
+	if outer != 1 || middle != 1 || inner.streams != 1 || inner.chats != 0 {
+		t.Errorf("ChatStream() ran the middleware %d and %d times, streamed %d and chatted %d times, want 1, 1, 1, 0", outer, middle, inner.streams, inner.chats)
+	}
+
+	// Chat doesn't stream
+	if _, err := client.Chat(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if outer != 2 || middle != 2 || inner.streams != 1 || inner.chats != 1 {
+		t.Errorf("Chat() ran the middleware %d and %d times, streamed %d and chatted %d times, want 2, 2, 1, 1", outer, middle, inner.streams, inner.chats)
+	}
+}
+
+func TestChainWithoutStreaming(t *testing.T) {
+	var calls int
+	client := Chain(&fakeClient{}, counting(&calls))
+	if _, ok := client.(StreamingClient); ok {
+		t.Error("the chain of a client that can't stream implements StreamingClient")
+	}
+	if _, err := client.Chat(context.Background(), nil, nil); err != nil || calls != 1 {
+		t.Errorf("Chat() = %v after %d middleware calls, want 1", err, calls)
+	}
+}
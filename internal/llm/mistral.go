@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 )
 
 const mistralAPIURL = "https://api.mistral.ai/v1/chat/completions"
@@ -16,13 +15,14 @@ const mistralAPIURL = "https://api.mistral.ai/v1/chat/completions"
 type MistralClient struct {
 	apiKey string
 	model  string
+	opts   ClientOptions
 	client *http.Client
 }
 
 // NewMistralClient creates a new Mistral client.
 // Reads API key from MISTRAL_API_KEY environment variable.
-func NewMistralClient(model string) (*MistralClient, error) {
-	apiKey := os.Getenv("MISTRAL_API_KEY")
+func NewMistralClient(model string, opts ...Option) (*MistralClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "MISTRAL_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("MISTRAL_API_KEY environment variable not set")
 	}
@@ -34,7 +34,8 @@ func NewMistralClient(model string) (*MistralClient, error) {
 	return &MistralClient{
 		apiKey: apiKey,
 		model:  model,
-		client: &http.Client{},
+		opts:   clientOptions(opts),
+		client: newHTTPClient(0),
 	}, nil
 }
 
@@ -43,10 +44,21 @@ type mistralRequest struct {
 	Model       string           `json:"model"`
 	Messages    []mistralMessage `json:"messages"`
 	Tools       []mistralTool    `json:"tools,omitempty"`
-	ToolChoice  string           `json:"tool_choice,omitempty"`
-	Temperature float64          `json:"temperature,omitempty"`
-	TopP        float64          `json:"top_p,omitempty"`
+	ToolChoice  interface{}      `json:"tool_choice,omitempty"` // A mode, or a specific function
+	Temperature *float64         `json:"temperature,omitempty"`
+	TopP        *float64         `json:"top_p,omitempty"`
 	MaxTokens   int              `json:"max_tokens,omitempty"`
+	Stop        []string         `json:"stop,omitempty"`
+	RandomSeed  *int             `json:"random_seed,omitempty"`
+
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+
+	ResponseFormat *mistralResponseFormat `json:"response_format,omitempty"`
+}
+
+type mistralResponseFormat struct {
+	Type string `json:"type"`
 }
 
 type mistralMessage struct {
@@ -106,19 +118,45 @@ type mistralError struct {
 	Code    string `json:"code"`
 }
 
+// mistralToolChoice converts a tool choice mode or tool name to Mistral's format.
+func mistralToolChoice(choice string) interface{} {
+	switch choice {
+	case "":
+		return ToolChoiceAuto
+	case ToolChoiceAuto, ToolChoiceRequired, ToolChoiceNone:
+		return choice
+	}
+	return map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": choice},
+	}
+}
+
 // Chat sends messages to Mistral and returns the response.
-func (c *MistralClient) Chat(ctx context.Context, messages []Message, tools []Tool) (*Response, error) {
+func (c *MistralClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
 	req := mistralRequest{
-		Model:       c.model,
+		Model:       o.model(c.model),
 		Messages:    c.convertMessages(messages),
-		Temperature: 0.7,
-		TopP:        1.0,
-		MaxTokens:   4096,
+		Temperature: o.temperature(0.7),
+		TopP:        o.topP(1.0),
+		MaxTokens:   o.maxTokens(4096),
+		Stop:        o.Stop,
+		RandomSeed:  o.Seed,
+
+		FrequencyPenalty: o.FrequencyPenalty,
+		PresencePenalty:  o.PresencePenalty,
 	}
 
 	if len(tools) > 0 {
 		req.Tools = c.convertTools(tools)
-		req.ToolChoice = "auto"
+		req.ToolChoice = mistralToolChoice(o.ToolChoice)
+	}
+	if o.ResponseSchema != nil {
+		// JSON mode; the schema itself is part of the prompt
+		req.ResponseFormat = &mistralResponseFormat{Type: "json_object"}
 	}
 
 	body, err := json.Marshal(req)
@@ -147,10 +185,8 @@ func (c *MistralClient) Chat(ctx context.Context, messages []Message, tools []To
 
 	if resp.StatusCode != http.StatusOK {
 		var apiErr mistralError
-		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Message != "" {
-			return nil, fmt.Errorf("(HTTP Error %d) %s", resp.StatusCode, apiErr.Message)
-		}
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+		_ = json.Unmarshal(respBody, &apiErr)
+		return nil, newAPIError(resp.StatusCode, apiErr.Message, respBody)
 	}
 
 	var mistralResp mistralResponse
@@ -229,18 +265,10 @@ func (c *MistralClient) parseResponse(resp *mistralResponse) *Response {
 			OutputTokens: resp.Usage.CompletionTokens,
 		},
 	}
+	response.Usage.price(resp.Model)
 
 	for _, tc := range choice.Message.ToolCalls {
-		var params map[string]interface{}
-		if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-			params = make(map[string]interface{})
-		}
-
-		response.ToolCalls = append(response.ToolCalls, ToolCall{
-			ID:         tc.ID,
-			Name:       tc.Function.Name,
-			Parameters: params,
-		})
+		response.ToolCalls = append(response.ToolCalls, parseToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
 	}
 
 	return response
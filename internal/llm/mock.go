@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// MockClient implements the Client interface by replaying canned responses
+// from a fixture file. It makes no network calls, which makes it useful for
+// integration tests of LLM-driven commands and for offline demos.
+type MockClient struct {
+	mu        sync.Mutex
+	responses []fixtureResponse
+	next      int
+}
+
+// fixture is the on-disk format shared by MockClient and RecordingClient.
+type fixture struct {
+	Responses []fixtureResponse `json:"responses"`
+}
+
+type fixtureResponse struct {
+	// Question is the last user message that produced this response.
+	// It is informational only; responses are replayed in order.
+	Question  string            `json:"question,omitempty"`
+	Content   string            `json:"content,omitempty"`
+	ToolCalls []fixtureToolCall `json:"tool_calls,omitempty"`
+	Thinking  []fixtureThinking `json:"thinking,omitempty"`
+	Usage     fixtureUsage      `json:"usage"`
+}
+
+type fixtureThinking struct {
+	Text      string `json:"text,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Redacted  string `json:"redacted,omitempty"`
+}
+
+type fixtureToolCall struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters"`
+
+	ArgumentsError string `json:"arguments_error,omitempty"`
+}
+
+type fixtureUsage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost,omitempty"`
+
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// NewMockClient creates a client that replays the responses in the given fixture file.
+// If path is empty it is read from TRIX_LLM_FIXTURE.
+func NewMockClient(path string) (*MockClient, error) {
+	if path == "" {
+		path = os.Getenv("TRIX_LLM_FIXTURE")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no fixture set. Use --fixture or TRIX_LLM_FIXTURE")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	if len(f.Responses) == 0 {
+		return nil, fmt.Errorf("fixture %s contains no responses", path)
+	}
+
+	return &MockClient{responses: f.Responses}, nil
+}
+
+// Chat returns the next canned response from the fixture.
+func (c *MockClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next >= len(c.responses) {
+		return nil, fmt.Errorf("mock fixture exhausted after %d responses", len(c.responses))
+	}
+	r := c.responses[c.next]
+	c.next++
+
+	return r.response(), nil
+}
+
+// newFixtureResponse converts a Response to its serializable form.
+func newFixtureResponse(resp *Response) fixtureResponse {
+	r := fixtureResponse{
+		Content: resp.Content,
+		Usage: fixtureUsage{
+			InputTokens:  resp.Usage.InputTokens,
+			OutputTokens: resp.Usage.OutputTokens,
+			Cost:         resp.Usage.Cost,
+
+			CacheReadTokens:  resp.Usage.CacheReadTokens,
+			CacheWriteTokens: resp.Usage.CacheWriteTokens,
+		},
+	}
+	for _, tc := range resp.ToolCalls {
+		r.ToolCalls = append(r.ToolCalls, fixtureToolCall(tc))
+	}
+	for _, t := range resp.Thinking {
+		r.Thinking = append(r.Thinking, fixtureThinking(t))
+	}
+	return r
+}
+
+// response converts a serialized response back to a Response.
+func (r fixtureResponse) response() *Response {
+	response := &Response{
+		Content: r.Content,
+		Usage: Usage{
+			InputTokens:  r.Usage.InputTokens,
+			OutputTokens: r.Usage.OutputTokens,
+			Cost:         r.Usage.Cost,
+
+			CacheReadTokens:  r.Usage.CacheReadTokens,
+			CacheWriteTokens: r.Usage.CacheWriteTokens,
+		},
+	}
+	for _, tc := range r.ToolCalls {
+		params := tc.Parameters
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		response.ToolCalls = append(response.ToolCalls, ToolCall{
+			ID:         tc.ID,
+			Name:       tc.Name,
+			Parameters: params,
+
+			ArgumentsError: tc.ArgumentsError,
+		})
+	}
+	for _, t := range r.Thinking {
+		response.Thinking = append(response.Thinking, Thinking(t))
+	}
+	return response
+}
+
+// RecordingClient wraps another Client and writes every response it returns
+// to a fixture file that MockClient can replay later.
+type RecordingClient struct {
+	client Client
+	path   string
+
+	mu      sync.Mutex
+	fixture fixture
+}
+
+// NewRecordingClient creates a client that records the responses of client to path.
+func NewRecordingClient(client Client, path string) *RecordingClient {
+	return &RecordingClient{client: client, path: path}
+}
+
+// Chat forwards the request to the wrapped client and records its response.
+// The fixture is rewritten after every call so a partial session is still usable.
+func (c *RecordingClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	resp, err := c.client.Chat(ctx, messages, tools, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newFixtureResponse(resp)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			r.Question = messages[i].Content
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fixture.Responses = append(c.fixture.Responses, r)
+	data, err := json.MarshalIndent(c.fixture, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return resp, nil
+}
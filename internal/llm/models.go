@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+// OpenAIModels lists the OpenAI chat models known to work with trix's tool calling.
+var OpenAIModels = []string{
+	"gpt-5", "gpt-5-mini", "gpt-5-nano",
+	"gpt-4.1", "gpt-4.1-mini", "gpt-4.1-nano",
+	"gpt-4o", "gpt-4o-mini",
+	"gpt-4-turbo", "gpt-4",
+	"gpt-3.5-turbo",
+	"o1", "o3", "o3-mini", "o4-mini",
+}
+
+// ValidateModel returns an error if model is not a known model of provider.
+// Dated snapshots ("gpt-4o-2024-08-06") and fine-tuned models ("ft:...") are
+// accepted. Providers without a fixed model list accept any model.
+func ValidateModel(provider, model string) error {
+	if provider != "openai" || model == "" || strings.HasPrefix(model, "ft:") {
+		return nil
+	}
+	for _, known := range OpenAIModels {
+		if model == known {
+			return nil
+		}
+		if rest, ok := strings.CutPrefix(model, known+"-"); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown OpenAI model %q (known: %s)", model, strings.Join(OpenAIModels, ", "))
+}
+
+// ModelLister is implemented by providers that can list the models available
+// to the configured credentials.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// ListModels returns the models available from client's provider, sorted.
+// It returns errors.ErrUnsupported if the provider can't list models.
+func ListModels(ctx context.Context, client Client) ([]string, error) {
+	lister, ok := client.(ModelLister)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// Ping checks that the provider is reachable and accepts the credentials,
+// e.g. before a long run. It lists models where the provider supports it,
+// which is free, and otherwise sends a one-token chat request.
+func Ping(ctx context.Context, client Client) error {
+	_, err := ListModels(ctx, client)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	_, err = client.Chat(ctx, []Message{{Role: RoleUser, Content: "ping"}}, nil, WithMaxTokens(1))
+	return err
+}
+
+// ListModels lists the models available to the API key.
+func (c *AnthropicClient) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := c.opts.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var models []string
+	pager := c.client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{})
+	for pager.Next() {
+		models = append(models, pager.Current().ID)
+	}
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+	return models, nil
+}
+
+// ListModels lists the models available to the API key.
+// Azure deployments can't be listed with the data plane API.
+func (c *OpenAIClient) ListModels(ctx context.Context) ([]string, error) {
+	if c.azure {
+		return nil, errors.ErrUnsupported
+	}
+	return listOpenAIModels(ctx, c.opts, c.client)
+}
+
+// ListModels lists the models served by the endpoint.
+func (c *CompatibleClient) ListModels(ctx context.Context) ([]string, error) {
+	return listOpenAIModels(ctx, c.opts, c.client)
+}
+
+// ListModels lists the models available through OpenRouter.
+func (c *OpenRouterClient) ListModels(ctx context.Context) ([]string, error) {
+	return listOpenAIModels(ctx, c.opts, c.client)
+}
+
+// listOpenAIModels lists models with the OpenAI models API.
+func listOpenAIModels(ctx context.Context, opts ClientOptions, client openai.Client) ([]string, error) {
+	ctx, cancel := opts.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var models []string
+	pager := client.Models.ListAutoPaging(ctx)
+	for pager.Next() {
+		models = append(models, pager.Current().ID)
+	}
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+	return models, nil
+}
+
+const mistralModelsURL = "https://api.mistral.ai/v1/models"
+
+// ListModels lists the models available to the API key.
+func (c *MistralClient) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := c.opts.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mistralModelsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := getJSON(c.client, req, &resp); err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for _, m := range resp.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// ListModels lists the Gemini models available to the API key.
+// Vertex AI publisher models can't be listed this way.
+func (c *GeminiClient) ListModels(ctx context.Context) ([]string, error) {
+	if c.tokenSource != nil {
+		return nil, errors.ErrUnsupported
+	}
+	ctx, cancel := c.opts.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint+"?pageSize=1000", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	var resp struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(c.client, req, &resp); err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for _, m := range resp.Models {
+		models = append(models, strings.TrimPrefix(m.Name, "models/"))
+	}
+	return models, nil
+}
+
+// ListModels lists the models pulled on the Ollama server.
+func (c *OllamaClient) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := c.opts.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var resp struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(c.client, req, &resp); err != nil {
+		return nil, err
+	}
+
+	var models []string
+	for _, m := range resp.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
+
+// getJSON sends req and decodes the JSON body of a successful response into out.
+func getJSON(client *http.Client, req *http.Request, out interface{}) error {
+	body, err := doRequest(client, req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -8,19 +8,19 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"time"
 )
 
 // OllamaClient implements the Client interface for Ollama.
 type OllamaClient struct {
 	baseURL string
 	model   string
+	opts    ClientOptions
 	client  *http.Client
 }
 
 // NewOllamaClient creates a new Ollama client.
 // It reads the base URL from OLLAMA_HOST environment variable or uses localhost:11434.
-func NewOllamaClient(baseURL, model string) (*OllamaClient, error) {
+func NewOllamaClient(baseURL, model string, opts ...Option) (*OllamaClient, error) {
 	if baseURL == "" {
 		baseURL = os.Getenv("OLLAMA_HOST")
 	}
@@ -33,25 +33,53 @@ func NewOllamaClient(baseURL, model string) (*OllamaClient, error) {
 	return &OllamaClient{
 		baseURL: baseURL,
 		model:   model,
-		client: &http.Client{
-			Timeout: 5 * time.Minute, // LLMs can be slow
-		},
+		opts:    clientOptions(opts),
+		client:  newHTTPClient(0), // Requests are bounded by DefaultLocalTimeout
 	}, nil
 }
 
 // Chat sends messages to Ollama and returns the response.
-func (c *OllamaClient) Chat(ctx context.Context, messages []Message, tools []Tool) (*Response, error) {
+func (c *OllamaClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultLocalTimeout)
+	defer cancel()
 	ollamaMessages := c.convertMessages(messages)
-	ollamaTools := c.convertTools(tools)
+	// Ollama has no tool_choice, so limit the tools offered instead
+	ollamaTools := c.convertTools(o.offeredTools(tools))
 
 	reqBody := ollamaChatRequest{
-		Model:    c.model,
+		Model:    o.model(c.model),
 		Messages: ollamaMessages,
 		Stream:   false,
+		Options:  make(map[string]any),
+	}
+	if o.Temperature != nil {
+		reqBody.Options["temperature"] = *o.Temperature
+	}
+	if o.TopP != nil {
+		reqBody.Options["top_p"] = *o.TopP
+	}
+	if o.MaxTokens > 0 {
+		reqBody.Options["num_predict"] = o.MaxTokens
+	}
+	if len(o.Stop) > 0 {
+		reqBody.Options["stop"] = o.Stop
+	}
+	if o.Seed != nil {
+		reqBody.Options["seed"] = *o.Seed
+	}
+	if o.FrequencyPenalty != nil {
+		reqBody.Options["frequency_penalty"] = *o.FrequencyPenalty
+	}
+	if o.PresencePenalty != nil {
+		reqBody.Options["presence_penalty"] = *o.PresencePenalty
 	}
 	if len(ollamaTools) > 0 {
 		reqBody.Tools = ollamaTools
 	}
+	if o.ResponseSchema != nil {
+		reqBody.Format = o.ResponseSchema.Schema
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -72,7 +100,11 @@ func (c *OllamaClient) Chat(ctx context.Context, messages []Message, tools []Too
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return nil, newAPIError(resp.StatusCode, apiErr.Error, body)
 	}
 
 	var ollamaResp ollamaChatResponse
@@ -90,6 +122,8 @@ type ollamaChatRequest struct {
 	Messages []ollamaMessage `json:"messages"`
 	Stream   bool            `json:"stream"`
 	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Options  map[string]any  `json:"options,omitempty"`
+	Format   map[string]any  `json:"format,omitempty"` // JSON schema of the response
 }
 
 type ollamaMessage struct {
@@ -4,49 +4,185 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
 )
 
+// defaultAzureAPIVersion is used when AZURE_OPENAI_API_VERSION is not set.
+const defaultAzureAPIVersion = "2024-10-21"
+
 // OpenAIClient implements the Client interface for OpenAI's Chat API.
 type OpenAIClient struct {
 	model  string
+	opts   ClientOptions
 	client openai.Client
+	azure  bool // Azure routes by deployment and can't list models
 }
 
 // NewOpenAIClient creates a new OpenAI client.
 // It reads the API key from the OPENAI_API_KEY environment variable.
-func NewOpenAIClient(model string) (*OpenAIClient, error) {
+func NewOpenAIClient(model string, opts ...Option) (*OpenAIClient, error) {
 	if model == "" {
 		model = "gpt-4o"
 	}
+	o := clientOptions(opts)
+	reqOpts := []option.RequestOption{
+		option.WithHTTPClient(newHTTPClient(0)),
+		option.WithMaxRetries(0), // Retries are handled by our transport
+	}
+	if apiKey := resolveAPIKey(o, "OPENAI_API_KEY"); apiKey != "" {
+		reqOpts = append(reqOpts, option.WithAPIKey(apiKey))
+	}
 	return &OpenAIClient{
 		model:  model,
-		client: openai.NewClient(),
+		opts:   o,
+		client: openai.NewClient(reqOpts...),
+	}, nil
+}
+
+// NewAzureOpenAIClient creates an OpenAI client that talks to an Azure OpenAI resource.
+// It reads AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_API_KEY, and optionally
+// AZURE_OPENAI_API_VERSION. The deployment name defaults to AZURE_OPENAI_DEPLOYMENT.
+func NewAzureOpenAIClient(deployment string, opts ...Option) (*OpenAIClient, error) {
+	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
+	if endpoint == "" {
+		return nil, fmt.Errorf("AZURE_OPENAI_ENDPOINT environment variable not set")
+	}
+	apiKey := resolveAPIKey(clientOptions(opts), "AZURE_OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("AZURE_OPENAI_API_KEY environment variable not set")
+	}
+	if deployment == "" {
+		deployment = os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("no Azure deployment set. Use --model or AZURE_OPENAI_DEPLOYMENT")
+	}
+	apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION")
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+
+	// Azure routes by deployment in the URL path rather than the model field
+	baseURL := strings.TrimSuffix(endpoint, "/") + "/openai/deployments/" + deployment + "/"
+
+	return &OpenAIClient{
+		model: deployment,
+		opts:  clientOptions(opts),
+		azure: true,
+		client: openai.NewClient(
+			option.WithHTTPClient(newHTTPClient(0)),
+			option.WithMaxRetries(0),
+			option.WithBaseURL(baseURL),
+			option.WithQueryAdd("api-version", apiVersion),
+			option.WithHeader("api-key", apiKey),
+			// Azure rejects a stray OPENAI_API_KEY bearer token
+			option.WithHeaderDel("authorization"),
+		),
 	}, nil
 }
 
 // Chat sends messages to OpenAI and returns the response.
-func (c *OpenAIClient) Chat(ctx context.Context, messages []Message, tools []Tool) (*Response, error) {
+func (c *OpenAIClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
 	openaiMessages := convertMessages(messages)
 	openaiTools := convertTools(tools)
 
 	params := openai.ChatCompletionNewParams{
 		Messages: openaiMessages,
-		Model:    c.model,
+		Model:    o.model(c.model),
 	}
 	if len(openaiTools) > 0 {
 		params.Tools = openaiTools
 	}
+	applyOpenAIOptions(&params, o)
 
 	resp, err := c.client.Chat.Completions.New(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
 	}
 
 	return parseResponse(resp), nil
 }
 
+// ChatStream streams the response from OpenAI, calling onDelta with each text
+// fragment. Tool call arguments arrive in pieces and are only returned once
+// complete.
+func (c *OpenAIClient) ChatStream(ctx context.Context, messages []Message, tools []Tool, onDelta func(string), opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := openai.ChatCompletionNewParams{
+		Messages: convertMessages(messages),
+		Model:    o.model(c.model),
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	}
+	if openaiTools := convertTools(tools); len(openaiTools) > 0 {
+		params.Tools = openaiTools
+	}
+	applyOpenAIOptions(&params, o)
+	return streamChatCompletion(ctx, &c.client, params, onDelta)
+}
+
+// applyOpenAIOptions sets the generation parameters that were explicitly requested.
+// It must be called after params.Tools is set.
+func applyOpenAIOptions(params *openai.ChatCompletionNewParams, o ClientOptions) {
+	if o.Temperature != nil {
+		params.Temperature = openai.Float(*o.Temperature)
+	}
+	if o.TopP != nil {
+		params.TopP = openai.Float(*o.TopP)
+	}
+	if o.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(o.MaxTokens))
+	}
+	if len(o.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: o.Stop}
+	}
+	if o.Seed != nil {
+		params.Seed = openai.Int(int64(*o.Seed))
+	}
+	if o.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(*o.FrequencyPenalty)
+	}
+	if o.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(*o.PresencePenalty)
+	}
+	if o.ToolChoice != "" && len(params.Tools) > 0 {
+		params.ToolChoice = openAIToolChoice(o.ToolChoice)
+	}
+	if o.ResponseSchema != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:        o.ResponseSchema.Name,
+					Description: openai.String(o.ResponseSchema.Description),
+					Schema:      o.ResponseSchema.Schema,
+				},
+			},
+		}
+	}
+}
+
+// openAIToolChoice converts a tool choice mode or tool name to OpenAI's format.
+func openAIToolChoice(choice string) openai.ChatCompletionToolChoiceOptionUnionParam {
+	switch choice {
+	case ToolChoiceAuto, ToolChoiceRequired, ToolChoiceNone:
+		return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(choice)}
+	}
+	return openai.ChatCompletionToolChoiceOptionParamOfChatCompletionNamedToolChoice(
+		openai.ChatCompletionNamedToolChoiceFunctionParam{Name: choice},
+	)
+}
+
 // convertMessages converts generic Messages to OpenAI's message format.
 func convertMessages(messages []Message) []openai.ChatCompletionMessageParamUnion {
 	var result []openai.ChatCompletionMessageParamUnion
@@ -57,7 +193,17 @@ func convertMessages(messages []Message) []openai.ChatCompletionMessageParamUnio
 			result = append(result, openai.SystemMessage(msg.Content))
 
 		case RoleUser:
-			result = append(result, openai.UserMessage(msg.Content))
+			if len(msg.Images) == 0 {
+				result = append(result, openai.UserMessage(msg.Content))
+				continue
+			}
+			parts := []openai.ChatCompletionContentPartUnionParam{openai.TextContentPart(msg.Content)}
+			for _, img := range msg.Images {
+				parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+					URL: img.dataURL(),
+				}))
+			}
+			result = append(result, openai.UserMessage(parts))
 
 		case RoleAssistant:
 			if len(msg.ToolCalls) > 0 {
@@ -117,18 +263,10 @@ func parseResponse(resp *openai.ChatCompletion) *Response {
 			OutputTokens: int(resp.Usage.CompletionTokens),
 		},
 	}
+	response.Usage.price(resp.Model)
 
 	for _, tc := range resp.Choices[0].Message.ToolCalls {
-		var params map[string]interface{}
-		if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-			params = make(map[string]interface{})
-		}
-
-		response.ToolCalls = append(response.ToolCalls, ToolCall{
-			ID:         tc.ID,
-			Name:       tc.Function.Name,
-			Parameters: params,
-		})
+		response.ToolCalls = append(response.ToolCalls, parseToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments))
 	}
 
 	return response
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const (
+	openRouterAPIURL  = "https://openrouter.ai/api/v1/"
+	openRouterReferer = "https://github.com/davealtena/trix"
+	openRouterTitle   = "trix"
+)
+
+// OpenRouterClient implements the Client interface for OpenRouter.
+// OpenRouter routes a single API key across many upstream models.
+type OpenRouterClient struct {
+	model    string
+	fallback []string // Additional models OpenRouter falls back to, in order
+	opts     ClientOptions
+	client   openai.Client
+}
+
+// NewOpenRouterClient creates a new OpenRouter client.
+// Reads API key from OPENROUTER_API_KEY environment variable.
+//
+// The model may be a comma-separated list (e.g. "anthropic/claude-3.5-sonnet,openai/gpt-4o");
+// OpenRouter tries them in order. OPENROUTER_REFERER and OPENROUTER_TITLE override
+// the attribution headers sent with each request.
+func NewOpenRouterClient(model string, opts ...Option) (*OpenRouterClient, error) {
+	apiKey := resolveAPIKey(clientOptions(opts), "OPENROUTER_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("OPENROUTER_API_KEY environment variable not set")
+	}
+
+	if model == "" {
+		model = "openrouter/auto"
+	}
+
+	var models []string
+	for _, m := range strings.Split(model, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			models = append(models, m)
+		}
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no OpenRouter model in %q", model)
+	}
+
+	referer := os.Getenv("OPENROUTER_REFERER")
+	if referer == "" {
+		referer = openRouterReferer
+	}
+	title := os.Getenv("OPENROUTER_TITLE")
+	if title == "" {
+		title = openRouterTitle
+	}
+
+	return &OpenRouterClient{
+		model:    models[0],
+		fallback: models[1:],
+		opts:     clientOptions(opts),
+		client: openai.NewClient(
+			option.WithHTTPClient(newHTTPClient(0)),
+			option.WithMaxRetries(0),
+			option.WithBaseURL(openRouterAPIURL),
+			option.WithAPIKey(apiKey),
+			option.WithHeader("HTTP-Referer", referer),
+			option.WithHeader("X-Title", title),
+		),
+	}, nil
+}
+
+// Chat sends messages to OpenRouter and returns the response.
+func (c *OpenRouterClient) Chat(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+	o := applyOptions(c.opts, opts)
+	ctx, cancel := o.withTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	params := openai.ChatCompletionNewParams{
+		Messages: convertMessages(messages),
+		Model:    o.model(c.model),
+	}
+	if openaiTools := convertTools(tools); len(openaiTools) > 0 {
+		params.Tools = openaiTools
+	}
+	applyOpenAIOptions(&params, o)
+
+	// Ask OpenRouter to include the billed cost in the usage block
+	reqOpts := []option.RequestOption{
+		option.WithJSONSet("usage", map[string]bool{"include": true}),
+	}
+	// A per-request model replaces the fallback list
+	if len(c.fallback) > 0 && o.Model == "" {
+		reqOpts = append(reqOpts,
+			option.WithJSONSet("models", append([]string{c.model}, c.fallback...)),
+			option.WithJSONSet("route", "fallback"),
+		)
+	}
+
+	resp, err := c.client.Chat.Completions.New(ctx, params, reqOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", sdkError(err))
+	}
+	if len(resp.Choices) == 0 {
+		return &Response{}, nil
+	}
+
+	response := parseResponse(resp)
+	if cost, ok := resp.Usage.JSON.ExtraFields["cost"]; ok {
+		response.Usage.Cost, _ = strconv.ParseFloat(cost.Raw(), 64)
+	}
+
+	return response, nil
+}
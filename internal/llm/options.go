@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// Tool choice modes for WithToolChoice. Any other value names a specific tool.
+const (
+	ToolChoiceAuto     = "auto"     // The model decides (default)
+	ToolChoiceRequired = "required" // The model must call at least one tool
+	ToolChoiceNone     = "none"     // The model must answer without calling tools
+)
+
+// Default request timeouts, covering all retry attempts of a request.
+// Local models get longer because CPU inference of a long prompt is slow.
+const (
+	DefaultTimeout      = 2 * time.Minute
+	DefaultLocalTimeout = 10 * time.Minute
+)
+
+// ClientOptions holds generation parameters shared by all providers.
+// Unset fields fall back to the provider's defaults.
+type ClientOptions struct {
+	Model       string        // Overrides the client's model for a request
+	BaseURL     string        // Endpoint for self-hosted providers (used by New)
+	APIKey      string        // Used instead of the provider's API key environment variable
+	Temperature *float64      // Sampling temperature
+	TopP        *float64      // Nucleus sampling probability mass
+	MaxTokens   int           // Maximum tokens to generate
+	Stop        []string      // Sequences that end generation
+	Seed        *int          // Seed for reproducible sampling, where supported
+	Timeout     time.Duration // Deadline for the whole request, including retries
+
+	FrequencyPenalty *float64 // Penalizes tokens by how often they already appeared
+	PresencePenalty  *float64 // Penalizes tokens that already appeared at all
+
+	ResponseSchema *Schema // Constrains the response to JSON matching this schema
+	ThinkingBudget int     // Tokens the model may spend reasoning (Anthropic extended thinking)
+	ToolChoice     string  // ToolChoiceAuto, ToolChoiceRequired, ToolChoiceNone or a tool name
+}
+
+// Option configures ClientOptions. Options can be passed to a constructor to
+// set client-wide defaults, or to Chat to override them for a single request.
+type Option func(*ClientOptions)
+
+// WithModel selects the model.
+func WithModel(model string) Option {
+	return func(o *ClientOptions) {
+		o.Model = model
+	}
+}
+
+// WithBaseURL sets the endpoint of a self-hosted or OpenAI-compatible provider.
+func WithBaseURL(baseURL string) Option {
+	return func(o *ClientOptions) {
+		o.BaseURL = baseURL
+	}
+}
+
+// WithAPIKey sets the provider's API key, e.g. one read from a file or secret.
+// Only constructors use it.
+func WithAPIKey(apiKey string) Option {
+	return func(o *ClientOptions) {
+		o.APIKey = apiKey
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+func WithTemperature(temperature float64) Option {
+	return func(o *ClientOptions) {
+		o.Temperature = &temperature
+	}
+}
+
+// WithTopP sets nucleus sampling.
+func WithTopP(topP float64) Option {
+	return func(o *ClientOptions) {
+		o.TopP = &topP
+	}
+}
+
+// WithMaxTokens limits the number of tokens generated.
+func WithMaxTokens(maxTokens int) Option {
+	return func(o *ClientOptions) {
+		o.MaxTokens = maxTokens
+	}
+}
+
+// WithStop sets sequences that end generation. Anthropic, OpenAI, Gemini,
+// Mistral, Cohere, Ollama and llama.cpp support them.
+func WithStop(sequences ...string) Option {
+	return func(o *ClientOptions) {
+		o.Stop = sequences
+	}
+}
+
+// WithSeed sets the sampling seed, so repeated requests return the same
+// response where the provider supports it (not Anthropic).
+func WithSeed(seed int) Option {
+	return func(o *ClientOptions) {
+		o.Seed = &seed
+	}
+}
+
+// DeterministicSeed is the seed set by WithDeterministic.
+const DeterministicSeed = 42
+
+// WithDeterministic selects greedy sampling (temperature 0) and a fixed seed,
+// so repeated runs return the same output as far as the provider allows.
+// OpenAI and Mistral honor the seed; other providers are only as repeatable
+// as temperature 0 makes them. Later options override either setting.
+func WithDeterministic() Option {
+	return func(o *ClientOptions) {
+		temperature, seed := 0.0, DeterministicSeed
+		o.Temperature = &temperature
+		o.Seed = &seed
+	}
+}
+
+// WithFrequencyPenalty penalizes tokens in proportion to how often they have
+// appeared, reducing repetition. Anthropic ignores it.
+func WithFrequencyPenalty(penalty float64) Option {
+	return func(o *ClientOptions) {
+		o.FrequencyPenalty = &penalty
+	}
+}
+
+// WithPresencePenalty penalizes tokens that have appeared at all, encouraging
+// new topics. Anthropic ignores it.
+func WithPresencePenalty(penalty float64) Option {
+	return func(o *ClientOptions) {
+		o.PresencePenalty = &penalty
+	}
+}
+
+// WithTimeout limits how long a request may take, including retries.
+// A deadline already set on the request's context still applies.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *ClientOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithThinking enables extended thinking with the given token budget.
+// Providers without extended thinking ignore it.
+func WithThinking(budgetTokens int) Option {
+	return func(o *ClientOptions) {
+		o.ThinkingBudget = budgetTokens
+	}
+}
+
+// WithToolChoice controls whether and which tools the model calls: one of
+// ToolChoiceAuto, ToolChoiceRequired, ToolChoiceNone, or the name of a tool
+// the model must call. Providers that can't force a tool still honor
+// ToolChoiceNone by not offering any tools.
+func WithToolChoice(choice string) Option {
+	return func(o *ClientOptions) {
+		o.ToolChoice = choice
+	}
+}
+
+// WithResponseSchema requests a JSON response matching schema.
+// Most callers should use ChatStructured, which also decodes the response.
+func WithResponseSchema(schema Schema) Option {
+	return func(o *ClientOptions) {
+		o.ResponseSchema = &schema
+	}
+}
+
+// applyOptions returns a copy of base with opts applied on top.
+func applyOptions(base ClientOptions, opts []Option) ClientOptions {
+	for _, opt := range opts {
+		opt(&base)
+	}
+	return base
+}
+
+// clientOptions builds the client-wide defaults passed to a constructor.
+// The constructor's model argument takes precedence, so Model is cleared.
+func clientOptions(opts []Option) ClientOptions {
+	o := applyOptions(ClientOptions{}, opts)
+	o.Model = ""
+	return o
+}
+
+// model returns the requested model, or def if none was set.
+func (o ClientOptions) model(def string) string {
+	if o.Model != "" {
+		return o.Model
+	}
+	return def
+}
+
+// temperature returns the requested temperature, or def if none was set.
+func (o ClientOptions) temperature(def float64) *float64 {
+	if o.Temperature != nil {
+		return o.Temperature
+	}
+	return &def
+}
+
+// topP returns the requested top_p, or def if none was set.
+func (o ClientOptions) topP(def float64) *float64 {
+	if o.TopP != nil {
+		return o.TopP
+	}
+	return &def
+}
+
+// withTimeout returns ctx bounded by the requested timeout, or def if none was set.
+func (o ClientOptions) withTimeout(ctx context.Context, def time.Duration) (context.Context, context.CancelFunc) {
+	timeout := def
+	if o.Timeout > 0 {
+		timeout = o.Timeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// offeredTools approximates the tool choice for providers without native
+// support: no tools for ToolChoiceNone, and only the named tool when a
+// specific tool was requested.
+func (o ClientOptions) offeredTools(tools []Tool) []Tool {
+	switch o.ToolChoice {
+	case "", ToolChoiceAuto, ToolChoiceRequired:
+		return tools
+	case ToolChoiceNone:
+		return nil
+	}
+	return toolsNamed(tools, o.ToolChoice)
+}
+
+// toolsNamed returns the tool with the given name, or all tools if there is none.
+func toolsNamed(tools []Tool, name string) []Tool {
+	for _, t := range tools {
+		if t.Name == name {
+			return []Tool{t}
+		}
+	}
+	return tools
+}
+
+// maxTokens returns the requested token limit, or def if none was set.
+func (o ClientOptions) maxTokens(def int) int {
+	if o.MaxTokens > 0 {
+		return o.MaxTokens
+	}
+	return def
+}
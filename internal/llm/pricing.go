@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is the list price of a model in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Pricing maps model name prefixes to their list price. The longest matching
+// prefix wins, so "gpt-4o-mini" is not billed as "gpt-4o". Local models
+// (Ollama, llama.cpp) are free and not listed.
+var Pricing = map[string]Price{
+	// Anthropic
+	"claude-opus-4":     {Input: 15, Output: 75},
+	"claude-sonnet-4":   {Input: 3, Output: 15},
+	"claude-3-7-sonnet": {Input: 3, Output: 15},
+	"claude-3-5-sonnet": {Input: 3, Output: 15},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	"claude-3-opus":     {Input: 15, Output: 75},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25},
+
+	// OpenAI
+	"gpt-4o":       {Input: 2.5, Output: 10},
+	"gpt-4o-mini":  {Input: 0.15, Output: 0.6},
+	"gpt-4.1":      {Input: 2, Output: 8},
+	"gpt-4.1-mini": {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano": {Input: 0.1, Output: 0.4},
+	"gpt-4-turbo":  {Input: 10, Output: 30},
+	"o1":           {Input: 15, Output: 60},
+	"o3":           {Input: 2, Output: 8},
+	"o3-mini":      {Input: 1.1, Output: 4.4},
+	"o4-mini":      {Input: 1.1, Output: 4.4},
+
+	// Mistral
+	"mistral-large": {Input: 2, Output: 6},
+	"mistral-small": {Input: 0.2, Output: 0.6},
+	"codestral":     {Input: 0.3, Output: 0.9},
+
+	// Google
+	"gemini-1.5-pro":   {Input: 1.25, Output: 5},
+	"gemini-1.5-flash": {Input: 0.075, Output: 0.3},
+	"gemini-2.0-flash": {Input: 0.1, Output: 0.4},
+	"gemini-2.5-pro":   {Input: 1.25, Output: 10},
+	"gemini-2.5-flash": {Input: 0.3, Output: 2.5},
+
+	// Cohere
+	"command-r-plus": {Input: 2.5, Output: 10},
+	"command-r":      {Input: 0.15, Output: 0.6},
+
+	// DeepSeek
+	"deepseek-chat":     {Input: 0.27, Output: 1.1},
+	"deepseek-reasoner": {Input: 0.55, Output: 2.19},
+
+	// xAI
+	"grok-3":      {Input: 3, Output: 15},
+	"grok-3-mini": {Input: 0.3, Output: 0.5},
+
+	// Groq and Together
+	"llama-3.3-70b-versatile":                       {Input: 0.59, Output: 0.79},
+	"meta-llama/Meta-Llama-3.1-405B-Instruct-Turbo": {Input: 3.5, Output: 3.5},
+}
+
+// PriceFor returns the price of a model, if known.
+func PriceFor(model string) (Price, bool) {
+	best := ""
+	for prefix := range Pricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return Pricing[best], true
+}
+
+// Prompt cache prices relative to the input price (Anthropic list prices)
+const (
+	cacheReadMultiplier  = 0.1
+	cacheWriteMultiplier = 1.25
+)
+
+// price fills in Cost from the pricing table unless the provider reported it.
+func (u *Usage) price(model string) {
+	if u.Cost > 0 {
+		return
+	}
+	if p, ok := PriceFor(model); ok {
+		uncached := u.InputTokens - u.CacheReadTokens - u.CacheWriteTokens
+		input := float64(uncached) +
+			float64(u.CacheReadTokens)*cacheReadMultiplier +
+			float64(u.CacheWriteTokens)*cacheWriteMultiplier
+		u.Cost = (input*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+	}
+}
+
+// Totals accumulates usage across the requests of a session.
+type Totals struct {
+	InputTokens     int
+	OutputTokens    int
+	CacheReadTokens int
+	Cost            float64
+}
+
+// Add adds the usage of one request.
+func (t *Totals) Add(u Usage) {
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.CacheReadTokens += u.CacheReadTokens
+	t.Cost += u.Cost
+}
+
+// String formats the totals, e.g. "LLM cost: $0.34 (12,410 in / 3,221 out tokens)".
+func (t Totals) String() string {
+	tokens := fmt.Sprintf("%s in / %s out tokens", formatThousands(t.InputTokens), formatThousands(t.OutputTokens))
+	if t.CacheReadTokens > 0 {
+		tokens = fmt.Sprintf("%s in (%s cached) / %s out tokens",
+			formatThousands(t.InputTokens), formatThousands(t.CacheReadTokens), formatThousands(t.OutputTokens))
+	}
+	if t.Cost == 0 {
+		return fmt.Sprintf("LLM usage: %s", tokens)
+	}
+	return fmt.Sprintf("LLM cost: $%.2f (%s)", t.Cost, tokens)
+}
+
+// formatThousands formats n with comma separators.
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatThousands(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
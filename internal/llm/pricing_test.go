@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   Price
+		wantOK bool
+	}{
+		{"claude-sonnet-4-20250514", Pricing["claude-sonnet-4"], true},
+		{"gpt-4o-2024-08-06", Pricing["gpt-4o"], true},
+		{"gpt-4o-mini", Pricing["gpt-4o-mini"], true}, // Longest prefix wins
+		{"llama3.1:8b", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := PriceFor(tt.model)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("PriceFor(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestUsagePrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		usage Usage
+		want  float64
+	}{
+		{"list price", "claude-sonnet-4", Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}, 3 + 15},
+		{"cached input", "claude-sonnet-4", Usage{InputTokens: 1_000_000, CacheReadTokens: 1_000_000}, 0.3},
+		{"cache writes", "claude-sonnet-4", Usage{InputTokens: 1_000_000, CacheWriteTokens: 1_000_000}, 3.75},
+		{"reported by the provider", "claude-sonnet-4", Usage{InputTokens: 1_000_000, Cost: 0.01}, 0.01},
+		{"unknown model", "llama3.1:8b", Usage{InputTokens: 1_000_000}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.usage
+			u.price(tt.model)
+			if math.Abs(u.Cost-tt.want) > 1e-9 {
+				t.Errorf("Cost = %v, want %v", u.Cost, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalsString(t *testing.T) {
+	tests := []struct {
+		totals Totals
+		want   string
+	}{
+		{Totals{InputTokens: 12410, OutputTokens: 3221}, "LLM usage: 12,410 in / 3,221 out tokens"},
+		{Totals{InputTokens: 12410, OutputTokens: 3221, Cost: 0.344}, "LLM cost: $0.34 (12,410 in / 3,221 out tokens)"},
+		{Totals{InputTokens: 1000, CacheReadTokens: 800, OutputTokens: 5}, "LLM usage: 1,000 in (800 cached) / 5 out tokens"},
+	}
+	for _, tt := range tests {
+		if got := tt.totals.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates a Client. The model and endpoint are passed as options
+// (WithModel, WithBaseURL) alongside any generation parameters.
+type Factory func(opts ...Option) (Client, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+	middleware []ClientMiddleware
+)
+
+// Register makes a provider available to New under the given name.
+// Registering an existing name replaces it, so built-in providers can be overridden.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Use adds middleware that New wraps around every client it creates,
+// in the order given (see Chain).
+func Use(mw ...ClientMiddleware) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	middleware = append(middleware, mw...)
+}
+
+// New creates a client for the named provider.
+func New(provider string, opts ...Option) (Client, error) {
+	registryMu.RLock()
+	factory, ok := registry[provider]
+	mw := middleware
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s (use %s)", provider, strings.Join(Providers(), ", "))
+	}
+	// Several API keys give one client per key, used in rotation
+	keys := splitKeys(lookupAPIKey(applyOptions(ClientOptions{}, opts), providerKeyEnv[provider]...))
+	if len(keys) > 1 {
+		rotation := &keyRotationClient{}
+		for _, key := range keys {
+			client, err := factory(append(opts[:len(opts):len(opts)], WithAPIKey(key))...)
+			if err != nil {
+				return nil, err
+			}
+			rotation.clients = append(rotation.clients, client)
+		}
+		return Chain(rotation, mw...), nil
+	}
+
+	client, err := factory(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return Chain(client, mw...), nil
+}
+
+// Providers returns the names of all registered providers, sorted.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Built-in providers
+func init() {
+	Register("anthropic", func(opts ...Option) (Client, error) {
+		return NewAnthropicClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("openai", func(opts ...Option) (Client, error) {
+		return NewOpenAIClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("azure", func(opts ...Option) (Client, error) {
+		return NewAzureOpenAIClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("mistral", func(opts ...Option) (Client, error) {
+		return NewMistralClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("gemini", func(opts ...Option) (Client, error) {
+		return NewGeminiClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("vertex", func(opts ...Option) (Client, error) {
+		return NewVertexClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("groq", func(opts ...Option) (Client, error) {
+		return NewGroqClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("openrouter", func(opts ...Option) (Client, error) {
+		return NewOpenRouterClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("cohere", func(opts ...Option) (Client, error) {
+		return NewCohereClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("deepseek", func(opts ...Option) (Client, error) {
+		return NewDeepSeekClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("grok", func(opts ...Option) (Client, error) {
+		return NewGrokClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("together", func(opts ...Option) (Client, error) {
+		return NewTogetherClient(applyOptions(ClientOptions{}, opts).Model, opts...)
+	})
+	Register("huggingface", func(opts ...Option) (Client, error) {
+		o := applyOptions(ClientOptions{}, opts)
+		return NewHuggingFaceClient(o.BaseURL, o.Model, opts...)
+	})
+	Register("llamacpp", func(opts ...Option) (Client, error) {
+		o := applyOptions(ClientOptions{}, opts)
+		return NewLlamaCppClient(o.BaseURL, o.Model, opts...)
+	})
+	Register("ollama", func(opts ...Option) (Client, error) {
+		o := applyOptions(ClientOptions{}, opts)
+		return NewOllamaClient(o.BaseURL, o.Model, opts...)
+	})
+	Register("compatible", func(opts ...Option) (Client, error) {
+		o := applyOptions(ClientOptions{}, opts)
+		return NewCompatibleClient(o.BaseURL, "", o.Model, opts...)
+	})
+}
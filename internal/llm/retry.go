@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxAttempts is the number of times a request is sent before giving up on
+// transient failures (429, 529 and 5xx responses, and network errors).
+// Set it to 1 to disable retries.
+var MaxAttempts = 4
+
+const (
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// newHTTPClient returns an HTTP client that retries transient failures.
+// All providers share it so retry behaviour is the same regardless of backend.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &retryTransport{base: transport},
+	}
+}
+
+// retryTransport retries requests with jittered exponential backoff,
+// honouring Retry-After when the server sends one.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	// A body we can't rewind can only be sent once
+	if req.Body != nil && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if state := rateLimitFrom(req.Context()); state != nil && err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			state.limited.Store(true)
+			if state.skipRetry {
+				return resp, nil // Another API key will be tried instead
+			}
+		}
+		if attempt >= attempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		if err == nil {
+			if d, ok := retryAfter(resp.Header); ok {
+				delay = d
+			}
+		}
+		// Waiting past the deadline would only turn this failure into a timeout
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableStatus reports whether an HTTP status indicates a transient failure.
+// 529 is Anthropic's "overloaded" status.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, 529,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered delay before the given retry attempt.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << (attempt - 1)
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	// Random delay in [d/2, d) so concurrent clients don't retry in lockstep
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// retryAfter parses the Retry-After header (seconds or HTTP date),
+// or OpenAI's millisecond-precision retry-after-ms.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	var d time.Duration
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil {
+		d = time.Duration(ms * float64(time.Millisecond))
+	} else if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			d = time.Duration(secs * float64(time.Second))
+		} else if t, err := http.ParseTime(v); err == nil {
+			d = time.Until(t)
+		} else {
+			return 0, false
+		}
+	} else {
+		return 0, false
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > 2*retryMaxDelay {
+		d = 2 * retryMaxDelay
+	}
+	return d, true
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{10, retryMaxDelay / 2, retryMaxDelay},
+		{100, retryMaxDelay / 2, retryMaxDelay}, // Shift overflow
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := backoff(tt.attempt); d < tt.min || d >= tt.max {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v)", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		want    time.Duration
+		wantSet bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"fractional seconds", http.Header{"Retry-After": {"1.5"}}, 1500 * time.Millisecond, true},
+		{"milliseconds win", http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"250"}}, 250 * time.Millisecond, true},
+		{"past date", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0, true},
+		{"capped", http.Header{"Retry-After": {"3600"}}, 2 * retryMaxDelay, true},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.header)
+			if got != tt.want || ok != tt.wantSet {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantSet)
+			}
+		})
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // Responses of the server in order; the last repeats
+		maxAttempts  int
+		wantStatus   int
+		wantRequests int32
+	}{
+		{"success", []int{200}, 4, 200, 1},
+		{"retries overloaded", []int{529, 503, 200}, 4, 200, 3},
+		{"retries rate limits", []int{429, 200}, 4, 200, 2},
+		{"gives up after max attempts", []int{500}, 3, 500, 3},
+		{"doesn't retry client errors", []int{400}, 4, 400, 1},
+		{"retries disabled", []int{503, 200}, 1, 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			defer func(attempts int) { MaxAttempts = attempts }(MaxAttempts)
+			MaxAttempts = tt.maxAttempts
+
+			client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"model":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema describes the JSON document a structured response must match.
+type Schema struct {
+	Name        string                 // Identifier, e.g. "triage_result"
+	Description string                 // What the document represents
+	Schema      map[string]interface{} // JSON Schema of the document
+}
+
+// ChatStructured asks the model for a JSON response matching schema and
+// decodes it into out. Providers constrain the output natively where they
+// can (OpenAI json_schema, Anthropic forced tool use, Gemini/Mistral/Cohere
+// JSON mode, Ollama/llama.cpp grammars); the schema is also included in the
+// prompt so providers without native support still produce usable output.
+func ChatStructured(ctx context.Context, client Client, messages []Message, schema Schema, out interface{}, opts ...Option) (*Response, error) {
+	schemaJSON, err := json.Marshal(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	instruction := fmt.Sprintf("Respond only with a JSON document matching this JSON schema, without any other text:\n%s", schemaJSON)
+	withSchema := make([]Message, 0, len(messages)+1)
+	withSchema = append(withSchema, messages...)
+	if len(withSchema) > 0 && withSchema[0].Role == RoleSystem {
+		withSchema[0].Content += "\n\n" + instruction
+	} else {
+		withSchema = append([]Message{{Role: RoleSystem, Content: instruction}}, withSchema...)
+	}
+
+	resp, err := client.Chat(ctx, withSchema, nil, append(opts, WithResponseSchema(schema))...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), out); err != nil {
+		return resp, fmt.Errorf("failed to parse structured response: %w", err)
+	}
+	return resp, nil
+}
+
+// extractJSON strips Markdown code fences and any text around the JSON document.
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+
+	start := strings.IndexAny(content, "{[")
+	end := strings.LastIndexAny(content, "}]")
+	if start >= 0 && end > start {
+		return content[start : end+1]
+	}
+	return strings.TrimSpace(content)
+}
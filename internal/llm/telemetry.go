@@ -0,0 +1,263 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPExporter sends a trace of an invocation's LLM calls to an OpenTelemetry
+// collector using OTLP/HTTP with JSON encoding. All calls become child spans
+// of one root span, which ends when the exporter is closed.
+//
+// It implements the small part of the OTel SDK trix needs, so the standard
+// OTEL_* environment variables work without pulling in the SDK.
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+
+	traceID string
+	rootID  string
+	root    string
+	start   time.Time
+
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+// NewOTLPExporter creates an exporter from the standard environment variables:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME. It returns nil if no
+// endpoint is set. rootName names the span covering the whole invocation.
+func NewOTLPExporter(rootName string) (*OTLPExporter, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" {
+		return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q (trix supports http/json)", protocol)
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "trix"
+	}
+
+	return &OTLPExporter{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		traceID:  randomID(16),
+		rootID:   randomID(8),
+		root:     rootName,
+		start:    time.Now(),
+	}, nil
+}
+
+// Middleware records a span for every Chat call of the wrapped client.
+// provider and model label the spans; a per-request WithModel overrides model.
+func (e *OTLPExporter) Middleware(provider, model string) ClientMiddleware {
+	return func(next Client) Client {
+		return ClientFunc(func(ctx context.Context, messages []Message, tools []Tool, opts ...Option) (*Response, error) {
+			o := applyOptions(ClientOptions{}, opts)
+			start := time.Now()
+			resp, err := next.Chat(ctx, messages, tools, opts...)
+			e.record(provider, o.model(model), start, resp, err)
+			return resp, err
+		})
+	}
+}
+
+// record adds a span for one Chat call, using the OpenTelemetry GenAI
+// semantic conventions.
+func (e *OTLPExporter) record(provider, model string, start time.Time, resp *Response, err error) {
+	attrs := []otlpAttribute{
+		stringAttr("gen_ai.operation.name", "chat"),
+		stringAttr("gen_ai.system", provider),
+	}
+	if model != "" {
+		attrs = append(attrs, stringAttr("gen_ai.request.model", model))
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			intAttr("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
+			intAttr("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+		)
+		if resp.Usage.Cost > 0 {
+			attrs = append(attrs, doubleAttr("trix.llm.cost_usd", resp.Usage.Cost))
+		}
+	}
+
+	span := otlpSpan{
+		TraceID:           e.traceID,
+		SpanID:            randomID(8),
+		ParentSpanID:      e.rootID,
+		Name:              strings.TrimSpace("chat " + model),
+		Kind:              3, // SPAN_KIND_CLIENT
+		StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	if err != nil {
+		attrs = append(attrs, stringAttr("error.type", errorClass(err)))
+		span.Status = &otlpStatus{Code: 2, Message: err.Error()} // STATUS_CODE_ERROR
+	}
+	span.Attributes = attrs
+
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// errorClass returns the error.type of a failed call.
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrAuth):
+		return "auth"
+	case errors.Is(err, ErrOverloaded):
+		return "overloaded"
+	case errors.Is(err, ErrContextTooLong):
+		return "context_too_long"
+	case errors.Is(err, ErrBudgetExceeded):
+		return "budget_exceeded"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "_OTHER"
+}
+
+// Close ends the root span and sends all spans to the collector.
+func (e *OTLPExporter) Close() error {
+	e.mu.Lock()
+	spans := append(e.spans, otlpSpan{
+		TraceID:           e.traceID,
+		SpanID:            e.rootID,
+		Name:              e.root,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: strconv.FormatInt(e.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+	})
+	e.spans = nil
+	e.mu.Unlock()
+
+	body, err := json.Marshal(otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{stringAttr("service.name", e.service)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/davealtena/trix/internal/llm"},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	if _, err := doRequest(e.client, req); err != nil {
+		return fmt.Errorf("failed to export traces: %w", err)
+	}
+	return nil
+}
+
+// randomID returns n random bytes, hex encoded, for trace and span IDs.
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// OTLP/JSON trace types (opentelemetry-proto, JSON encoding)
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is encoded as a string
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int) otlpAttribute {
+	s := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func doubleAttr(key string, value float64) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{DoubleValue: &value}}
+}
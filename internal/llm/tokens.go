@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Per-message framing overhead (role markers, separators) in BPE tokenizers.
+const messageOverheadTokens = 4
+
+// defaultContextWindow is used for models we don't know about.
+const defaultContextWindow = 128000
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// The longest matching prefix wins.
+var contextWindows = map[string]int{
+	"claude":             200000,
+	"gpt-4o":             128000,
+	"gpt-4.1":            1047576,
+	"gpt-4-turbo":        128000,
+	"gpt-4":              8192,
+	"gpt-3.5":            16385,
+	"o1":                 200000,
+	"o3":                 200000,
+	"o4":                 200000,
+	"mistral-large":      128000,
+	"mistral-small":      32000,
+	"codestral":          256000,
+	"gemini-1.5-pro":     2097152,
+	"gemini-1.5-flash":   1048576,
+	"gemini-2":           1048576,
+	"command-r":          128000,
+	"deepseek":           64000,
+	"grok":               131072,
+	"llama3":             8192,
+	"llama3.1":           128000,
+	"llama3.2":           128000,
+	"llama-3.1":          128000,
+	"llama-3.3":          128000,
+	"meta-llama/Llama-3": 128000,
+	"meta-llama/Meta-":   128000,
+	"qwen2.5":            32768,
+}
+
+// ContextWindow returns the context window of a model in tokens.
+// Unknown models get a conservative default.
+func ContextWindow(model string) int {
+	best, window := "", defaultContextWindow
+	for prefix, w := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, window = prefix, w
+		}
+	}
+	return window
+}
+
+// EstimateTokens approximates the number of tokens in text.
+//
+// It mimics BPE tokenizers closely enough for budgeting: common words are
+// roughly one token, long words are split every ~4 characters, and
+// punctuation and symbols (frequent in YAML and JSON tool output) are
+// mostly tokens of their own. Estimates err on the high side.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+
+	flush := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + 3) / 4
+			wordLen = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if r > unicode.MaxASCII {
+				// Non-Latin scripts tokenize at roughly one token per character
+				flush()
+				tokens++
+				continue
+			}
+			wordLen++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// EstimateMessages approximates the prompt tokens for a request.
+func EstimateMessages(messages []Message, tools []Tool) int {
+	total := 0
+	for _, msg := range messages {
+		total += messageOverheadTokens + EstimateTokens(msg.Content) + len(msg.Images)*imageTokens
+		for _, tc := range msg.ToolCalls {
+			params, _ := json.Marshal(tc.Parameters)
+			total += EstimateTokens(tc.Name) + EstimateTokens(string(params))
+		}
+	}
+	for _, tool := range tools {
+		schema, _ := json.Marshal(tool.Parameters)
+		total += EstimateTokens(tool.Name) + EstimateTokens(tool.Description) + EstimateTokens(string(schema))
+	}
+	return total
+}
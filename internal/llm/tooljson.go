@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// trailingComma matches a comma directly before a closing brace or bracket.
+var trailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+
+// parseToolCall converts a tool call whose arguments arrive as a JSON string.
+// Malformed arguments are repaired where possible. If that fails,
+// ArgumentsError records why, so the agent can ask the model to try again
+// instead of running the tool without its arguments.
+func parseToolCall(id, name, arguments string) ToolCall {
+	call := ToolCall{ID: id, Name: name, Parameters: make(map[string]interface{})}
+
+	if strings.TrimSpace(arguments) == "" {
+		return call // Tools without parameters
+	}
+
+	var params map[string]interface{}
+	err := json.Unmarshal([]byte(arguments), &params)
+	if err != nil {
+		if repairErr := json.Unmarshal([]byte(repairJSON(arguments)), &params); repairErr != nil {
+			call.ArgumentsError = err.Error()
+			return call
+		}
+	}
+	if params != nil {
+		call.Parameters = params
+	}
+	return call
+}
+
+// repairJSON fixes the mistakes models commonly make in tool arguments:
+// Markdown code fences, trailing commas, and objects cut off before their
+// closing braces.
+func repairJSON(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```json")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	s = trailingComma.ReplaceAllString(strings.TrimSpace(s), "$1")
+
+	// Close unterminated strings, arrays and objects
+	var open []byte
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			open = append(open, '}')
+		case c == '[':
+			open = append(open, ']')
+		case (c == '}' || c == ']') && len(open) > 0:
+			open = open[:len(open)-1]
+		}
+	}
+	if inString {
+		s += `"`
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		s += string(open[i])
+	}
+	return s
+}
+
+// invalidArgumentsResult is the tool result sent back for a call whose
+// arguments couldn't be parsed, so the model can correct itself.
+func invalidArgumentsResult(call ToolCall) string {
+	return fmt.Sprintf("Error: the arguments for %s were not valid JSON (%s). Call the tool again with a valid JSON object.",
+		call.Name, call.ArgumentsError)
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Transport defaults, tuned for many concurrent requests to a few API hosts
+// (see Batch) rather than Go's defaults, which keep only 2 idle connections
+// per host and reconnect for every other request.
+const (
+	DefaultConnectTimeout = 10 * time.Second // Dial and TLS handshake, each
+	defaultMaxIdleConns   = 4 * DefaultBatchConcurrency
+	defaultIdleConnTime   = 90 * time.Second
+	defaultKeepAlive      = 30 * time.Second
+)
+
+// HTTPConfig configures the network transport shared by all LLM clients.
+// By default the transport honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+type HTTPConfig struct {
+	ProxyURL           string // Overrides the proxy environment variables
+	CAFile             string // PEM bundle trusted in addition to the system roots
+	InsecureSkipVerify bool   // Disables TLS certificate verification
+
+	ConnectTimeout  time.Duration // Dial and TLS handshake timeout (default 10s)
+	MaxConnsPerHost int           // Limits concurrent connections per host; 0 for no limit
+}
+
+// transport is the base transport of every LLM client. It is replaced by
+// ConfigureHTTP, which must be called before clients are created.
+var transport http.RoundTripper = newTransport(DefaultConnectTimeout)
+
+// newTransport returns a pooled HTTP/2 transport with keep-alives.
+func newTransport(connectTimeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: defaultKeepAlive}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   connectTimeout,
+		MaxIdleConns:          defaultMaxIdleConns,
+		MaxIdleConnsPerHost:   defaultMaxIdleConns,
+		IdleConnTimeout:       defaultIdleConnTime,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// ConfigureHTTP sets up the proxy, TLS and connection settings used by LLM
+// clients created afterwards.
+func ConfigureHTTP(cfg HTTPConfig) error {
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+	t := newTransport(connectTimeout)
+	t.MaxConnsPerHost = cfg.MaxConnsPerHost
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // Explicitly requested by the user
+		}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in CA bundle %s", cfg.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	transport = t
+	return nil
+}
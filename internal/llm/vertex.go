@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	vertexScope      = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	metadataHostname = "metadata.google.internal"
+)
+
+// NewVertexClient creates a Gemini client backed by Google Vertex AI.
+// Authentication uses Application Default Credentials, so it works with
+// gcloud user credentials, service account key files, and GKE workload identity
+// without a static API key.
+//
+// The project is read from GOOGLE_CLOUD_PROJECT (falling back to the project of the
+// default credentials) and the region from GOOGLE_CLOUD_LOCATION (default us-central1).
+func NewVertexClient(model string, opts ...Option) (*GeminiClient, error) {
+	tokenSource, credsProject, err := defaultGoogleCredentials(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google application default credentials: %w", err)
+	}
+
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		project = credsProject
+	}
+	if project == "" {
+		return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT environment variable not set")
+	}
+
+	location := os.Getenv("GOOGLE_CLOUD_LOCATION")
+	if location == "" {
+		location = "us-central1"
+	}
+
+	if model == "" {
+		model = "gemini-1.5-pro"
+	}
+
+	host := location + "-aiplatform.googleapis.com"
+	if location == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+
+	return &GeminiClient{
+		tokenSource: tokenSource,
+		endpoint: fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google/models",
+			host, project, location),
+		model:  model,
+		opts:   clientOptions(opts),
+		client: newHTTPClient(0),
+	}, nil
+}
+
+// googleCredentialsFile is the subset of a Google credentials JSON file we use.
+type googleCredentialsFile struct {
+	Type string `json:"type"`
+
+	// service_account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ProjectID    string `json:"project_id"`
+
+	// authorized_user (gcloud auth application-default login)
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// defaultGoogleCredentials resolves Application Default Credentials in the same
+// order as Google's client libraries:
+//  1. The file named by GOOGLE_APPLICATION_CREDENTIALS
+//  2. The gcloud well-known file (~/.config/gcloud/application_default_credentials.json)
+//  3. The GCE/GKE metadata server (workload identity)
+//
+// It returns the token source and the project ID associated with the credentials, if any.
+func defaultGoogleCredentials(ctx context.Context) (oauth2.TokenSource, string, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			wellKnown := filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+			if _, err := os.Stat(wellKnown); err == nil {
+				path = wellKnown
+			}
+		}
+	}
+
+	if path != "" {
+		// Token requests go through the same proxy and CA settings as the API
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport, Timeout: 30 * time.Second})
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		var f googleCredentialsFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, "", fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+		}
+
+		switch f.Type {
+		case "service_account":
+			tokenURL := f.TokenURI
+			if tokenURL == "" {
+				tokenURL = googleTokenURL
+			}
+			cfg := &jwt.Config{
+				Email:        f.ClientEmail,
+				PrivateKey:   []byte(f.PrivateKey),
+				PrivateKeyID: f.PrivateKeyID,
+				Scopes:       []string{vertexScope},
+				TokenURL:     tokenURL,
+			}
+			return cfg.TokenSource(ctx), f.ProjectID, nil
+
+		case "authorized_user":
+			cfg := &oauth2.Config{
+				ClientID:     f.ClientID,
+				ClientSecret: f.ClientSecret,
+				Endpoint:     oauth2.Endpoint{TokenURL: googleTokenURL},
+				Scopes:       []string{vertexScope},
+			}
+			return cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: f.RefreshToken}), f.QuotaProjectID, nil
+
+		default:
+			return nil, "", fmt.Errorf("unsupported credentials type %q in %s", f.Type, path)
+		}
+	}
+
+	// No credentials file - assume we're running on GCE/GKE
+	ms := &metadataTokenSource{host: metadataHost(), client: &http.Client{Timeout: 5 * time.Second}}
+	project, err := ms.get(ctx, "project/project-id")
+	if err != nil {
+		return nil, "", fmt.Errorf("no credentials file found and metadata server unreachable: %w", err)
+	}
+	return oauth2.ReuseTokenSource(nil, ms), project, nil
+}
+
+// metadataHost returns the GCE metadata server host, honoring GCE_METADATA_HOST.
+func metadataHost() string {
+	if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+		return host
+	}
+	return metadataHostname
+}
+
+// metadataTokenSource fetches access tokens from the GCE/GKE metadata server.
+type metadataTokenSource struct {
+	host   string
+	client *http.Client
+}
+
+// Token implements oauth2.TokenSource.
+func (s *metadataTokenSource) Token() (*oauth2.Token, error) {
+	body, err := s.get(context.Background(), "instance/service-accounts/default/token?scopes="+vertexScope)
+	if err != nil {
+		return nil, err
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := json.Unmarshal([]byte(body), &tok); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata token: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: tok.AccessToken,
+		TokenType:   tok.TokenType,
+		Expiry:      time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// get performs a GET against the metadata server and returns the body.
+func (s *metadataTokenSource) get(ctx context.Context, suffix string) (string, error) {
+	url := fmt.Sprintf("http://%s/computeMetadata/v1/%s", s.host, suffix)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
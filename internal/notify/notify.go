@@ -0,0 +1,122 @@
+// Package notify sends alerts and scan summaries to where people will see
+// them: a chat webhook (Slack, Mattermost, Teams workflows and anything else
+// that accepts a JSON POST) or a local command such as notify-send.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alert is a report that gained CRITICAL findings
+type Alert struct {
+	Kind      string    `json:"kind"` // Report kind, e.g. VulnerabilityReport
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Critical  int       `json:"critical"`          // CRITICAL findings in the report
+	New       int       `json:"new"`               // CRITICAL findings since the last version of the report
+	IDs       []string  `json:"ids,omitempty"`     // Some of the CRITICAL findings, e.g. CVE IDs
+	Context   string    `json:"context,omitempty"` // Kubernetes context
+	Time      time.Time `json:"time"`
+}
+
+// Text returns the alert as one line of plain text
+func (a Alert) Text() string {
+	name := a.Name
+	if a.Namespace != "" {
+		name = a.Namespace + "/" + name
+	}
+	text := fmt.Sprintf("trix: %d new CRITICAL finding(s) in %s %s (%d in total)", a.New, a.Kind, name, a.Critical)
+	if len(a.IDs) > 0 {
+		text += ": " + strings.Join(a.IDs, ", ")
+	}
+	if a.Context != "" {
+		text += " [" + a.Context + "]"
+	}
+	return text
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Webhook posts alerts as JSON. The text field makes the payload a valid
+// Slack and Mattermost message; the other fields are the alert itself.
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+// NewWebhook creates a webhook notifier
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the alert
+func (w *Webhook) Notify(ctx context.Context, alert Alert) error {
+	payload := struct {
+		Text string `json:"text"`
+		Alert
+	}{alert.Text(), alert}
+	return w.post(ctx, payload)
+}
+
+// post sends payload as JSON
+func (w *Webhook) post(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Command runs a shell command for each alert. The alert is passed in
+// TRIX_ALERT_* environment variables, with the text in TRIX_ALERT_TEXT.
+type Command struct {
+	Command string
+}
+
+// NewCommand creates a command notifier
+func NewCommand(command string) *Command {
+	return &Command{Command: command}
+}
+
+// Notify runs the command
+func (c *Command) Notify(ctx context.Context, alert Alert) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Env = append(os.Environ(),
+		"TRIX_ALERT_TEXT="+alert.Text(),
+		"TRIX_ALERT_KIND="+alert.Kind,
+		"TRIX_ALERT_NAMESPACE="+alert.Namespace,
+		"TRIX_ALERT_NAME="+alert.Name,
+		"TRIX_ALERT_CRITICAL="+strconv.Itoa(alert.Critical),
+		"TRIX_ALERT_NEW="+strconv.Itoa(alert.New),
+		"TRIX_ALERT_IDS="+strings.Join(alert.IDs, ","),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
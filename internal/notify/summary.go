@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// severities orders the counts of a summary
+var severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// Summary is the outcome of a scan, sent by 'trix notify'
+type Summary struct {
+	Context     string         `json:"context,omitempty"`
+	Namespace   string         `json:"namespace,omitempty"` // Empty for all namespaces
+	MinSeverity string         `json:"minSeverity,omitempty"`
+	Severities  []string       `json:"severities,omitempty"`
+	Total       int            `json:"total"`
+	BySeverity  map[string]int `json:"bySeverity"`
+	Findings    []Finding      `json:"findings"` // Most severe first
+	Omitted     int            `json:"omitted"`  // Findings left out of Findings
+	Time        time.Time      `json:"time"`
+}
+
+// Finding is one vulnerability or check and the resources it was found in
+type Finding struct {
+	Severity  string   `json:"severity"`
+	ID        string   `json:"id"`
+	Title     string   `json:"title,omitempty"`
+	Resources []string `json:"resources"` // namespace/Kind/name
+}
+
+// Sender delivers scan summaries
+type Sender interface {
+	Send(ctx context.Context, summary Summary) error
+}
+
+// Headline returns the first line of the summary, e.g. "trix: 12 findings
+// (3 CRITICAL, 9 HIGH) in prod [kind-dev]"
+func (s Summary) Headline() string {
+	scope := "all namespaces"
+	if s.Namespace != "" {
+		scope = s.Namespace
+	}
+	var counts []string
+	for _, sev := range severities {
+		if n := s.BySeverity[sev]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+	text := fmt.Sprintf("trix: %d findings in %s", s.Total, scope)
+	if len(counts) > 0 {
+		text = fmt.Sprintf("trix: %d findings (%s) in %s", s.Total, strings.Join(counts, ", "), scope)
+	}
+	if s.Context != "" {
+		text += " [" + s.Context + "]"
+	}
+	return text
+}
+
+// Text returns the summary as plain text: the headline and a line per
+// finding
+func (s Summary) Text() string {
+	lines := []string{s.Headline()}
+	for _, f := range s.Findings {
+		lines = append(lines, "- "+f.line())
+	}
+	if s.Omitted > 0 {
+		lines = append(lines, fmt.Sprintf("... and %d more", s.Omitted))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// line describes a finding in one line, naming its first resource
+func (f Finding) line() string {
+	text := f.Severity + " " + f.ID
+	if f.Title != "" {
+		text += ": " + f.Title
+	}
+	switch len(f.Resources) {
+	case 0:
+	case 1:
+		text += " (" + f.Resources[0] + ")"
+	default:
+		text += fmt.Sprintf(" (%s and %d more)", f.Resources[0], len(f.Resources)-1)
+	}
+	return text
+}
+
+// Send posts the summary as JSON, with the plain text in the text field
+func (w *Webhook) Send(ctx context.Context, summary Summary) error {
+	payload := struct {
+		Text string `json:"text"`
+		Summary
+	}{summary.Text(), summary}
+	return w.post(ctx, payload)
+}
+
+// Slack posts summaries to a Slack incoming webhook as Block Kit messages
+type Slack struct {
+	webhook *Webhook
+}
+
+// NewSlack creates a Slack notifier for an incoming webhook URL
+func NewSlack(url string) *Slack {
+	return &Slack{webhook: NewWebhook(url)}
+}
+
+// Send posts the summary: the headline, the counts and the findings as a list
+func (s *Slack) Send(ctx context.Context, summary Summary) error {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type   string `json:"type"`
+		Text   *text  `json:"text,omitempty"`
+		Fields []text `json:"fields,omitempty"`
+	}
+
+	blocks := []block{{Type: "section", Text: &text{Type: "mrkdwn", Text: "*" + slackEscape(summary.Headline()) + "*"}}}
+	var fields []text
+	for _, sev := range severities {
+		if n := summary.BySeverity[sev]; n > 0 {
+			fields = append(fields, text{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%d", sev, n)})
+		}
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, block{Type: "section", Fields: fields})
+	}
+	if len(summary.Findings) > 0 {
+		var lines []string
+		for _, f := range summary.Findings {
+			lines = append(lines, "• "+slackEscape(f.line()))
+		}
+		if summary.Omitted > 0 {
+			lines = append(lines, fmt.Sprintf("_... and %d more_", summary.Omitted))
+		}
+		// Slack caps a text block at 3000 characters
+		body := strings.Join(lines, "\n")
+		if len(body) > 3000 {
+			if i := strings.LastIndex(body[:2990], "\n"); i > 0 {
+				body = body[:i] + "\n_..._"
+			}
+		}
+		blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: body}})
+	}
+
+	payload := struct {
+		Text   string  `json:"text"` // Shown in notifications
+		Blocks []block `json:"blocks"`
+	}{summary.Headline(), blocks}
+	return s.webhook.post(ctx, payload)
+}
+
+// slackEscape escapes the characters Slack's mrkdwn gives a meaning
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// Send runs the command with the summary as JSON on stdin, and the headline,
+// text and counts in TRIX_NOTIFY_* environment variables
+func (c *Command) Send(ctx context.Context, summary Summary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"TRIX_NOTIFY_HEADLINE="+summary.Headline(),
+		"TRIX_NOTIFY_TEXT="+summary.Text(),
+		"TRIX_NOTIFY_TOTAL="+strconv.Itoa(summary.Total),
+	)
+	for _, sev := range severities {
+		cmd.Env = append(cmd.Env, "TRIX_NOTIFY_"+sev+"="+strconv.Itoa(summary.BySeverity[sev]))
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
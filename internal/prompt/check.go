@@ -0,0 +1,122 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Finding is the data of the finding templates (explain, exploitability, remediation).
+type Finding struct {
+	ID           string
+	Title        string
+	Severity     string
+	Score        float64
+	Namespace    string
+	ResourceKind string
+	ResourceName string
+	Description  string
+	Remediation  string
+	Exposure     string  // How the workload is reachable, e.g. "LoadBalancer service on port 443"
+	Workload     string  // Relevant parts of the pod spec, e.g. the securityContext as YAML
+	EPSS         float64 // Probability of exploitation in the next 30 days, 0 if unknown
+	KEV          bool    // Listed in CISA's Known Exploited Vulnerabilities catalog
+	Criticality  string  // Criticality label of the namespace, e.g. "high"
+}
+
+// Triage is the data of the triage template. Findings are referred to by
+// their index in the list.
+type Triage struct {
+	Findings []Finding
+}
+
+// Impact is the data of the impact template.
+type Impact struct {
+	Subject  string // e.g. "CVE-2024-45337" or "Deployment/payments in namespace prod"
+	Advisory string // Advisory text, when explaining a CVE
+	Findings []Finding
+	Omitted  int    // Findings left out to keep the prompt short
+	Exposure string // How the affected workloads are reachable
+}
+
+// Report is the data of the report template.
+type Report struct {
+	Scope string // What the report covers, e.g. "namespace prod of context kind"
+	Facts string // The report's numbers and tables as Markdown
+}
+
+// sampleFinding is rendered by Check to catch broken templates.
+var sampleFinding = Finding{
+	ID:           "CVE-2024-45337",
+	Title:        "golang.org/x/crypto: misuse of ServerConfig.PublicKeyCallback may cause authorization bypass",
+	Severity:     "CRITICAL",
+	Score:        9.1,
+	Namespace:    "production",
+	ResourceKind: "Deployment",
+	ResourceName: "api-gateway",
+	Description:  "Applications and libraries which misuse the connection.serverAuthenticate callback may be susceptible to an authorization bypass.",
+	Remediation:  "Upgrade golang.org/x/crypto to 0.31.0",
+	Exposure:     "LoadBalancer service on port 443",
+	Workload:     "securityContext:\n  runAsNonRoot: true\n  readOnlyRootFilesystem: true",
+}
+
+// samples holds the data each built-in template is checked with.
+var samples = map[string]interface{}{
+	"investigate":    nil,
+	"explain":        sampleFinding,
+	"exploitability": sampleFinding,
+	"remediation":    sampleFinding,
+	"triage": Triage{Findings: []Finding{
+		{ID: sampleFinding.ID, Title: sampleFinding.Title, Severity: "CRITICAL", Score: 9.1, Namespace: "production", ResourceKind: "Deployment", ResourceName: "api-gateway", Exposure: "external (service/api-gateway)", EPSS: 0.42, KEV: true, Criticality: "high"},
+		{ID: "KSV-0017", Title: "Privileged container", Severity: "HIGH"},
+	}},
+	"impact": Impact{
+		Subject:  "CVE-2024-45337",
+		Advisory: sampleFinding.Description,
+		Findings: []Finding{sampleFinding},
+		Omitted:  3,
+		Exposure: "Deployment/api-gateway: external (service/api-gateway, ingress/api)",
+	},
+	"report": Report{
+		Scope: "all namespaces of context production",
+		Facts: "## Executive Summary\n\n- **42 findings**: 3 critical, 12 high, 27 medium\n\n## Top Risks\n\n| CVE | Severity |\n|---|---|\n| CVE-2024-45337 | CRITICAL |",
+	},
+}
+
+// requiredSections are headings that code or users rely on in the output of
+// the latest version of a template; overrides must keep them too.
+var requiredSections = map[string][]string{
+	"exploitability": {"## Verdict", "## Reasoning", "## Mitigations"},
+	"remediation":    {"## Fix", "## Patch", "## Verify", "## Risk"},
+	"impact":         {"## Impact", "## Remediation", "## Verify"},
+	"report":         {"## Executive Summary", "## Top Risks", "## Remediation Plan"},
+}
+
+// Check renders a template with sample data and verifies that it produces the
+// sections expected of it. It catches syntax errors, references to fields
+// that don't exist, and overrides that drop parts of the expected structure.
+func Check(t *Template) error {
+	data, ok := samples[t.Name]
+	if !ok {
+		return nil // No sample data for templates trix doesn't use
+	}
+
+	out, err := t.Render(data)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return fmt.Errorf("prompt %s (%s) renders empty", t.Name, t.Source)
+	}
+
+	// Older built-in versions predate the current structure
+	versions := builtinVersions()[t.Name]
+	if t.Version != 0 && t.Version != versions[len(versions)-1] {
+		return nil
+	}
+	for _, section := range requiredSections[t.Name] {
+		if !strings.Contains(out, section) {
+			return fmt.Errorf("prompt %s (%s) is missing section %q", t.Name, t.Source, section)
+		}
+	}
+	return nil
+}
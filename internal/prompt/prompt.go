@@ -0,0 +1,160 @@
+// Package prompt provides the named, versioned prompt templates used by trix.
+//
+// Built-in templates live in templates/ as <name>.v<version>.tmpl and are
+// rendered with text/template. A file named <name>.tmpl in the user prompt
+// directory (~/.config/trix/prompts, or TRIX_PROMPTS_DIR) replaces the
+// built-in template, so teams can tune prompts without forking trix.
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// funcs are available to all templates.
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+}
+
+// Template describes a prompt template.
+type Template struct {
+	Name    string
+	Version int    // Built-in version; 0 for user overrides
+	Source  string // "builtin" or the path of the user override
+	Text    string
+}
+
+// UserDir returns the directory that user prompt overrides are read from.
+func UserDir() string {
+	if dir := os.Getenv("TRIX_PROMPTS_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trix", "prompts")
+}
+
+// Get returns a template by name. A user override takes precedence over the
+// built-in template. "name@vN" selects a specific built-in version, which
+// bypasses user overrides.
+func Get(name string) (*Template, error) {
+	name, version, pinned := strings.Cut(name, "@v")
+	if pinned {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt version %q", version)
+		}
+		return getBuiltin(name, v)
+	}
+
+	if dir := UserDir(); dir != "" {
+		path := filepath.Join(dir, name+".tmpl")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return &Template{Name: name, Source: path, Text: string(data)}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read prompt override: %w", err)
+		}
+	}
+
+	return getBuiltin(name, 0)
+}
+
+// Builtin returns the latest built-in version of a template, ignoring user overrides.
+func Builtin(name string) (*Template, error) {
+	return getBuiltin(name, 0)
+}
+
+// getBuiltin returns a built-in template; version 0 selects the latest.
+func getBuiltin(name string, version int) (*Template, error) {
+	versions := builtinVersions()[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+	if version == 0 {
+		version = versions[len(versions)-1]
+	}
+
+	data, err := builtin.ReadFile(fmt.Sprintf("templates/%s.v%d.tmpl", name, version))
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt version: %s@v%d", name, version)
+	}
+	return &Template{Name: name, Version: version, Source: "builtin", Text: string(data)}, nil
+}
+
+// builtinVersions returns the available versions of each built-in template, ascending.
+func builtinVersions() map[string][]int {
+	result := make(map[string][]int)
+	entries, _ := builtin.ReadDir("templates")
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".tmpl")
+		idx := strings.LastIndex(base, ".v")
+		if idx < 0 {
+			continue
+		}
+		v, err := strconv.Atoi(base[idx+2:])
+		if err != nil {
+			continue
+		}
+		result[base[:idx]] = append(result[base[:idx]], v)
+	}
+	for name := range result {
+		sort.Ints(result[name])
+	}
+	return result
+}
+
+// List returns the template that Get would return for every built-in name.
+func List() ([]Template, error) {
+	var names []string
+	for name := range builtinVersions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []Template
+	for _, name := range names {
+		t, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+// Render executes the named template with data.
+func Render(name string, data interface{}) (string, error) {
+	t, err := Get(name)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(data)
+}
+
+// Render executes the template with data.
+func (t *Template) Render(data interface{}) (string, error) {
+	tmpl, err := template.New(t.Name).Funcs(funcs).Option("missingkey=error").Parse(t.Text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt %s (%s): %w", t.Name, t.Source, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s (%s): %w", t.Name, t.Source, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
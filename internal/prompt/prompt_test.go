@@ -0,0 +1,76 @@
+package prompt
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestBuiltinGolden renders every version of every built-in template with
+// its sample data and compares the prompt with testdata/<name>.v<N>.golden.
+// Run 'go test ./internal/prompt -update' after changing a template.
+func TestBuiltinGolden(t *testing.T) {
+	t.Setenv("TRIX_PROMPTS_DIR", t.TempDir()) // No user overrides
+
+	for name, versions := range builtinVersions() {
+		for _, version := range versions {
+			id := fmt.Sprintf("%s.v%d", name, version)
+			t.Run(id, func(t *testing.T) {
+				tmpl, err := getBuiltin(name, version)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := Check(tmpl); err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+				got, err := tmpl.Render(samples[name])
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+
+				golden := filepath.Join("testdata", id+".golden")
+				if *update {
+					if err := os.MkdirAll("testdata", 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(golden, []byte(got+"\n"), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run 'go test ./internal/prompt -update' to create it)", err)
+				}
+				if got+"\n" != string(want) {
+					t.Errorf("%s doesn't match %s; run 'go test ./internal/prompt -update' if the change is intended\ngot:\n%s", id, golden, got)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{"keeps the sections", "Fix {{.ID}}\n## Fix\n## Patch\n## Verify\n## Risk", false},
+		{"missing a section", "Fix {{.ID}}\n## Fix\n## Patch", true},
+		{"unknown field", "Fix {{.CVE}}\n## Fix\n## Patch\n## Verify\n## Risk", true},
+		{"syntax error", "Fix {{.ID}", true},
+		{"empty", "{{/* nothing */}}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(&Template{Name: "remediation", Source: "test", Text: tt.text})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
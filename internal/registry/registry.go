@@ -0,0 +1,192 @@
+// Package registry reads from OCI registries over the distribution API:
+// manifests, blobs and tag lists. It pulls anonymously, so it only reaches
+// public repositories.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxResponseSize bounds what is downloaded from a registry
+const MaxResponseSize = 10 << 20
+
+// maxTagPages bounds how many pages of a tag list are followed
+const maxTagPages = 20
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// challengeParam matches key="value" in a WWW-Authenticate header. Values may
+// contain commas, e.g. scope="repository:app:pull,push".
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// nextLink matches the next page in a Link header: </v2/app/tags/list?last=x&n=100>; rel="next"
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// ParseReference splits registry/repo:tag or registry/repo@digest. Without a
+// registry the reference is on Docker Hub.
+func ParseReference(ref string) (host, repo, reference string) {
+	repo, reference = ref, "latest"
+	if name, digest, ok := strings.Cut(ref, "@"); ok {
+		repo, reference = name, digest
+	} else if i := strings.LastIndex(ref, ":"); i >= 0 && !strings.Contains(ref[i:], "/") {
+		repo, reference = ref[:i], ref[i+1:]
+	}
+
+	host = "registry-1.docker.io"
+	if first, rest, ok := strings.Cut(repo, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, repo = first, rest
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = "registry-1.docker.io"
+	}
+	if host == "registry-1.docker.io" && !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	return host, repo, reference
+}
+
+// Repository is a repository of a registry. It holds the anonymous bearer
+// token of the repository, fetched on the first 401.
+type Repository struct {
+	Host  string
+	Name  string
+	token string
+}
+
+// NewRepository returns the repository of an image reference
+func NewRepository(ref string) *Repository {
+	host, name, _ := ParseReference(ref)
+	return &Repository{Host: host, Name: name}
+}
+
+// URL returns the distribution API URL of a path in the repository, e.g.
+// "manifests/1.0" or "tags/list"
+func (r *Repository) URL(path string) string {
+	return "https://" + r.Host + "/v2/" + r.Name + "/" + path
+}
+
+// Get fetches a URL of the repository
+func (r *Repository) Get(ctx context.Context, url, accept string) ([]byte, error) {
+	body, _, err := r.get(ctx, url, accept)
+	return body, err
+}
+
+// Tags lists the tags of the repository, following pagination
+func (r *Repository) Tags(ctx context.Context) ([]string, error) {
+	var tags []string
+	url := r.URL("tags/list?n=1000")
+	for page := 0; page < maxTagPages && url != ""; page++ {
+		body, header, err := r.get(ctx, url, "application/json")
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("failed to parse tag list: %w", err)
+		}
+		tags = append(tags, list.Tags...)
+
+		url = ""
+		if m := nextLink.FindStringSubmatch(header.Get("Link")); m != nil {
+			url = m[1]
+			if strings.HasPrefix(url, "/") {
+				url = "https://" + r.Host + url
+			}
+		}
+	}
+	return tags, nil
+}
+
+// get fetches a URL, getting a token and retrying once if asked to
+func (r *Repository) get(ctx context.Context, url, accept string) ([]byte, http.Header, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if r.token != "" {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if err := r.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, nil, err
+			}
+		case resp.StatusCode != http.StatusOK:
+			return nil, nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+		default:
+			return body, resp.Header, nil
+		}
+	}
+}
+
+// authenticate gets an anonymous token from the realm of a Bearer challenge
+func (r *Repository) authenticate(ctx context.Context, challenge string) error {
+	params, ok := strings.CutPrefix(challenge, "Bearer ")
+	if !ok {
+		return fmt.Errorf("registry requires authentication, which isn't supported")
+	}
+	values := make(map[string]string)
+	for _, m := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[m[1]] = m[2]
+	}
+	if values["realm"] == "" {
+		return fmt.Errorf("registry sent no token realm")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, values["realm"], nil)
+	if err != nil {
+		return err
+	}
+	q := req.URL.Query()
+	for _, k := range []string{"service", "scope"} {
+		if values[k] != "" {
+			q.Set(k, values[k])
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get registry token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	r.token = token.Token
+	if r.token == "" {
+		r.token = token.AccessToken
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionTag matches tags such as 1.25.3, v2.1 or 3.19.1-alpine: an optional
+// "v", a dotted version and a variant suffix
+var versionTag = regexp.MustCompile(`^(v?)(\d+(?:\.\d+)*)(.*)$`)
+
+// PatchedTag returns the newest tag in the release line of tag: the same
+// variant and the same version up to its last part, e.g. 1.25.5 for 1.25.3,
+// or 3.19.4-alpine for 3.19.1-alpine. A newer patch release usually only
+// carries fixes, so it is the upgrade least likely to break the workload.
+// Tags that aren't versions, such as latest, have no release line.
+func PatchedTag(tag string, tags []string) (string, bool) {
+	current := parseTag(tag)
+	if current == nil || len(current.parts) < 2 {
+		return "", false
+	}
+	best := current
+	bestTag := ""
+	for _, t := range tags {
+		candidate := parseTag(t)
+		if candidate == nil || !candidate.sameLine(current) {
+			continue
+		}
+		if compareParts(candidate.parts, best.parts) > 0 {
+			best, bestTag = candidate, t
+		}
+	}
+	return bestTag, bestTag != ""
+}
+
+type parsedTag struct {
+	prefix, suffix string
+	parts          []int
+}
+
+func parseTag(tag string) *parsedTag {
+	m := versionTag.FindStringSubmatch(tag)
+	if m == nil {
+		return nil
+	}
+	t := &parsedTag{prefix: m[1], suffix: m[3]}
+	for _, p := range strings.Split(m[2], ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil
+		}
+		t.parts = append(t.parts, n)
+	}
+	return t
+}
+
+// sameLine reports whether two tags are of the same variant and differ only
+// in their last version part
+func (t *parsedTag) sameLine(other *parsedTag) bool {
+	if t.prefix != other.prefix || t.suffix != other.suffix || len(t.parts) != len(other.parts) {
+		return false
+	}
+	for i := 0; i < len(t.parts)-1; i++ {
+		if t.parts[i] != other.parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func compareParts(a, b []int) int {
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,127 @@
+package remediate
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// checkFix is the manifest change that passes a configuration check
+type checkFix struct {
+	description string
+	// apply adds the change for a container to its patch and reports whether
+	// the container needed it
+	apply func(c corev1.Container, patch map[string]any) bool
+}
+
+// checkFixes are the fixes of the Trivy checks a patch can pass, by ID
+var checkFixes = map[string]checkFix{
+	"KSV001": securityContextFix("allowPrivilegeEscalation", false, func(sc *corev1.SecurityContext) bool {
+		return sc.AllowPrivilegeEscalation != nil && !*sc.AllowPrivilegeEscalation
+	}),
+	"KSV003": dropAllCapabilities,
+	"KSV004": dropAllCapabilities,
+	"KSV106": dropAllCapabilities,
+	"KSV011": resourceFix("limits", corev1.ResourceCPU, "500m"),
+	"KSV012": securityContextFix("runAsNonRoot", true, func(sc *corev1.SecurityContext) bool {
+		return sc.RunAsNonRoot != nil && *sc.RunAsNonRoot
+	}),
+	"KSV014": securityContextFix("readOnlyRootFilesystem", true, func(sc *corev1.SecurityContext) bool {
+		return sc.ReadOnlyRootFilesystem != nil && *sc.ReadOnlyRootFilesystem
+	}),
+	"KSV015": resourceFix("requests", corev1.ResourceCPU, "100m"),
+	"KSV016": resourceFix("requests", corev1.ResourceMemory, "128Mi"),
+	"KSV017": securityContextFix("privileged", false, func(sc *corev1.SecurityContext) bool {
+		return sc.Privileged == nil || !*sc.Privileged
+	}),
+	"KSV018": resourceFix("limits", corev1.ResourceMemory, "512Mi"),
+	"KSV020": securityContextFix("runAsUser", 10001, func(sc *corev1.SecurityContext) bool {
+		return sc.RunAsUser != nil && *sc.RunAsUser > 10000
+	}),
+	"KSV021": securityContextFix("runAsGroup", 10001, func(sc *corev1.SecurityContext) bool {
+		return sc.RunAsGroup != nil && *sc.RunAsGroup > 10000
+	}),
+	"KSV030": runtimeDefaultSeccomp,
+	"KSV104": runtimeDefaultSeccomp,
+}
+
+// securityContextFix sets a container securityContext field unless ok says
+// the container already passes
+func securityContextFix(field string, value any, ok func(*corev1.SecurityContext) bool) checkFix {
+	return checkFix{
+		description: fmt.Sprintf("%s: %v", field, value),
+		apply: func(c corev1.Container, patch map[string]any) bool {
+			if c.SecurityContext != nil && ok(c.SecurityContext) {
+				return false
+			}
+			securityContext(patch)[field] = value
+			return true
+		},
+	}
+}
+
+var dropAllCapabilities = checkFix{
+	description: "capabilities.drop: [ALL]",
+	apply: func(c corev1.Container, patch map[string]any) bool {
+		if sc := c.SecurityContext; sc != nil && sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				if strings.EqualFold(string(capability), "ALL") {
+					return false
+				}
+			}
+		}
+		securityContext(patch)["capabilities"] = map[string]any{"drop": []string{"ALL"}}
+		return true
+	},
+}
+
+var runtimeDefaultSeccomp = checkFix{
+	description: "seccompProfile: RuntimeDefault",
+	apply: func(c corev1.Container, patch map[string]any) bool {
+		if sc := c.SecurityContext; sc != nil && sc.SeccompProfile != nil && sc.SeccompProfile.Type != corev1.SeccompProfileTypeUnconfined {
+			return false
+		}
+		securityContext(patch)["seccompProfile"] = map[string]any{"type": string(corev1.SeccompProfileTypeRuntimeDefault)}
+		return true
+	},
+}
+
+// resourceFix sets a resource request or limit the container lacks. The
+// value is a conservative starting point, not a measurement.
+func resourceFix(kind string, resource corev1.ResourceName, value string) checkFix {
+	return checkFix{
+		description: fmt.Sprintf("resources.%s.%s: %s", kind, resource, value),
+		apply: func(c corev1.Container, patch map[string]any) bool {
+			existing := c.Resources.Limits
+			if kind == "requests" {
+				existing = c.Resources.Requests
+			}
+			if _, ok := existing[resource]; ok {
+				return false
+			}
+			resources, ok := patch["resources"].(map[string]any)
+			if !ok {
+				resources = make(map[string]any)
+				patch["resources"] = resources
+			}
+			values, ok := resources[kind].(map[string]any)
+			if !ok {
+				values = make(map[string]any)
+				resources[kind] = values
+			}
+			values[string(resource)] = value
+			return true
+		},
+	}
+}
+
+// securityContext returns the securityContext of a container patch
+func securityContext(patch map[string]any) map[string]any {
+	sc, ok := patch["securityContext"].(map[string]any)
+	if !ok {
+		sc = make(map[string]any)
+		patch["securityContext"] = sc
+	}
+	return sc
+}
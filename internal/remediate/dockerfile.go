@@ -0,0 +1,77 @@
+package remediate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+)
+
+// HasDockerfile reports whether the plan suggests Dockerfile changes
+func (p *Plan) HasDockerfile() bool {
+	for _, image := range p.Images {
+		if len(image.Upgrades) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Dockerfile returns the Dockerfile lines that upgrade the vulnerable
+// packages of each image, for the final stage of its build. OS packages are
+// upgraded with the image's package manager; language packages are listed as
+// comments, as they are pinned in the application's own lock files.
+func (p *Plan) Dockerfile() []byte {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# Package upgrades for the images of %s/%s, generated by trix fix.\n", p.Workload.Kind, p.Workload.Name)
+	out.WriteString("# Add them to the final stage of each image's Dockerfile, or rebuild on a\n")
+	out.WriteString("# newer base image that already includes them.\n")
+	for _, image := range p.Images {
+		if len(image.Upgrades) == 0 {
+			continue
+		}
+		fmt.Fprintf(&out, "\n# %s\n", image.Image)
+		var osPkgs, langPkgs []aggregate.PackageUpgrade
+		for _, u := range image.Upgrades {
+			if u.Class == "os-pkgs" {
+				osPkgs = append(osPkgs, u)
+			} else {
+				langPkgs = append(langPkgs, u)
+			}
+		}
+		if len(osPkgs) > 0 {
+			out.WriteString(installCommand(image.OSFamily, osPkgs) + "\n")
+		}
+		for _, u := range langPkgs {
+			fmt.Fprintf(&out, "# %s %s -> %s (%s)\n", u.PkgName, u.InstalledVersion, u.FixedVersion, strings.Join(u.VulnerabilityIDs, ", "))
+		}
+	}
+	return []byte(out.String())
+}
+
+// installCommand returns the RUN instruction that upgrades OS packages with
+// the package manager of an OS family
+func installCommand(family string, upgrades []aggregate.PackageUpgrade) string {
+	pkgs := func(sep string) string {
+		parts := make([]string, 0, len(upgrades))
+		for _, u := range upgrades {
+			parts = append(parts, u.PkgName+sep+u.FixedVersion)
+		}
+		return strings.Join(parts, " \\\n      ")
+	}
+	switch strings.ToLower(family) {
+	case "debian", "ubuntu":
+		return "RUN apt-get update && apt-get install -y --only-upgrade \\\n      " + pkgs("=") + " \\\n    && rm -rf /var/lib/apt/lists/*"
+	case "alpine", "wolfi", "chainguard":
+		return "RUN apk add --no-cache --upgrade \\\n      " + pkgs("=")
+	case "redhat", "centos", "rocky", "alma", "fedora", "oracle", "amazon", "azurelinux", "cbl-mariner":
+		return "RUN dnf upgrade -y \\\n      " + pkgs("-") + " \\\n    && dnf clean all"
+	case "opensuse", "opensuse.leap", "opensuse.tumbleweed", "sles", "suse linux enterprise server":
+		return "RUN zypper --non-interactive update \\\n      " + pkgs("=") + " \\\n    && zypper clean --all"
+	}
+	var lines []string
+	for _, u := range upgrades {
+		lines = append(lines, fmt.Sprintf("# %s %s -> %s (%s)", u.PkgName, u.InstalledVersion, u.FixedVersion, strings.Join(u.VulnerabilityIDs, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,253 @@
+// Package remediate turns findings into changes that fix them: a strategic
+// merge patch for the workload's manifest (securityContext, resource limits,
+// image tags) and Dockerfile lines for vulnerable packages baked into images.
+package remediate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Workload is a workload and the containers of its pod template
+type Workload struct {
+	Kind       string
+	Name       string
+	Namespace  string
+	Containers []corev1.Container
+}
+
+// GetWorkload fetches the pod template of a workload
+func GetWorkload(ctx context.Context, clientset kubernetes.Interface, kind, name, namespace string) (*Workload, error) {
+	var spec corev1.PodSpec
+	var err error
+	switch kind {
+	case "Deployment":
+		d, e := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = d.Spec.Template.Spec
+		}
+	case "StatefulSet":
+		s, e := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = s.Spec.Template.Spec
+		}
+	case "DaemonSet":
+		d, e := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = d.Spec.Template.Spec
+		}
+	case "ReplicaSet":
+		r, e := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = r.Spec.Template.Spec
+		}
+	case "Job":
+		j, e := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = j.Spec.Template.Spec
+		}
+	case "CronJob":
+		c, e := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = c.Spec.JobTemplate.Spec.Template.Spec
+		}
+	case "Pod":
+		p, e := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = e; err == nil {
+			spec = p.Spec
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind: %s", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s: %w", kind, name, err)
+	}
+	return &Workload{Kind: kind, Name: name, Namespace: namespace, Containers: spec.Containers}, nil
+}
+
+// Check is a failed configuration check of the workload
+type Check struct {
+	ID    string
+	Title string
+}
+
+// Plan is the remediation of one workload
+type Plan struct {
+	Workload *Workload
+	Fixed    []string // What the patch fixes, e.g. "KSV014: readOnlyRootFilesystem: true"
+	Manual   []string // Failed checks the patch can't fix
+	Images   []aggregate.ImageUpgrades
+
+	containers map[string]map[string]any // Patch of each container, by name
+}
+
+// NewPlan works out the fixes for a workload's failed checks and vulnerable
+// images. upgrades are the image upgrade plans of the workload.
+func NewPlan(w *Workload, checks []Check, upgrades []aggregate.ImageUpgrades) *Plan {
+	p := &Plan{Workload: w, Images: upgrades, containers: make(map[string]map[string]any)}
+
+	seen := make(map[string]bool)
+	for _, check := range checks {
+		id := trivy.NormalizeCheckID(check.ID)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		fix, ok := checkFixes[id]
+		if !ok {
+			p.Manual = append(p.Manual, fmt.Sprintf("%s: %s", check.ID, check.Title))
+			continue
+		}
+		changed := false
+		for _, c := range w.Containers {
+			patch, ok := p.containers[c.Name]
+			if !ok {
+				patch = map[string]any{"name": c.Name}
+			}
+			if fix.apply(c, patch) {
+				p.containers[c.Name] = patch
+				changed = true
+			}
+		}
+		if changed {
+			p.Fixed = append(p.Fixed, fmt.Sprintf("%s: %s", check.ID, fix.description))
+		}
+	}
+
+	for _, image := range upgrades {
+		for _, c := range w.Containers {
+			if !sameImage(c.Image, image.Image) {
+				continue
+			}
+			if bumped, u, ok := bumpTag(c.Image, image.Upgrades); ok {
+				patch, ok := p.containers[c.Name]
+				if !ok {
+					patch = map[string]any{"name": c.Name}
+					p.containers[c.Name] = patch
+				}
+				patch["image"] = bumped
+				p.Fixed = append(p.Fixed, fmt.Sprintf("%s: image %s (fixes %s %s)", c.Name, bumped, u.PkgName, strings.Join(u.VulnerabilityIDs, ", ")))
+			}
+		}
+	}
+	return p
+}
+
+// HasPatch reports whether the plan changes the manifest
+func (p *Plan) HasPatch() bool {
+	return len(p.containers) > 0
+}
+
+// Patch returns the strategic merge patch of the workload. Containers are
+// merged by name, so only the fields that change are listed.
+func (p *Plan) Patch() ([]byte, error) {
+	names := make([]string, 0, len(p.containers))
+	for name := range p.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	containers := make([]any, 0, len(names))
+	for _, name := range names {
+		containers = append(containers, p.containers[name])
+	}
+
+	podSpec := map[string]any{"containers": containers}
+	var patch map[string]any
+	switch p.Workload.Kind {
+	case "Pod":
+		patch = map[string]any{"spec": podSpec}
+	case "CronJob":
+		patch = map[string]any{"spec": map[string]any{"jobTemplate": map[string]any{"spec": map[string]any{"template": map[string]any{"spec": podSpec}}}}}
+	default:
+		patch = map[string]any{"spec": map[string]any{"template": map[string]any{"spec": podSpec}}}
+	}
+	data, err := yaml.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# Strategic merge patch for %s/%s in namespace %s, generated by trix fix\n", p.Workload.Kind, p.Workload.Name, p.Workload.Namespace)
+	fmt.Fprintf(&out, "# Apply with: %s\n", p.ApplyCommand("patch.yaml"))
+	for _, fixed := range p.Fixed {
+		fmt.Fprintf(&out, "#   %s\n", fixed)
+	}
+	if p.hasDefaultResources() {
+		out.WriteString("# Resource requests and limits are starting points; tune them to the workload's usage.\n")
+	}
+	if _, err := out.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write patch: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// ApplyCommand returns the kubectl command that applies the patch file
+func (p *Plan) ApplyCommand(file string) string {
+	return fmt.Sprintf("kubectl patch %s %s -n %s --patch-file %s", strings.ToLower(p.Workload.Kind), p.Workload.Name, p.Workload.Namespace, file)
+}
+
+// hasDefaultResources reports whether the patch sets resource defaults
+func (p *Plan) hasDefaultResources() bool {
+	for _, c := range p.containers {
+		if _, ok := c["resources"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sameImage reports whether a container image is the scanned image. Reports
+// may add the registry (docker.io/library/...), so images are compared by
+// their last path segment, including the tag.
+func sameImage(containerImage, scanned string) bool {
+	base := func(image string) string {
+		image, _, _ = strings.Cut(image, "@")
+		return image[strings.LastIndex(image, "/")+1:]
+	}
+	return base(containerImage) == base(scanned)
+}
+
+// releaseVersion matches upstream release versions such as 1.25.4 or v2.1,
+// as opposed to distribution package versions like 1.25.4-1~deb12u1
+var releaseVersion = regexp.MustCompile(`^v?\d+(\.\d+)+$`)
+
+// bumpTag returns the image with its tag bumped to the fixed version of the
+// application it ships, e.g. bitnami/redis:7.0.11 to 7.0.15 when the redis
+// binary is fixed in 7.0.15. It only applies when a package is named after
+// the image and its fixed version is a release version; OS packages are
+// fixed by rebuilding instead. Digest-pinned images aren't bumped.
+func bumpTag(image string, upgrades []aggregate.PackageUpgrade) (string, aggregate.PackageUpgrade, bool) {
+	if strings.Contains(image, "@") {
+		return "", aggregate.PackageUpgrade{}, false
+	}
+	repo, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+	name := repo[strings.LastIndex(repo, "/")+1:]
+	for _, u := range upgrades {
+		if u.Class == "os-pkgs" || !strings.EqualFold(u.PkgName, name) || !releaseVersion.MatchString(u.FixedVersion) {
+			continue
+		}
+		fixed := strings.TrimPrefix(u.FixedVersion, "v")
+		if strings.HasPrefix(tag, "v") {
+			fixed = "v" + fixed
+		}
+		if fixed == tag {
+			continue
+		}
+		return repo + ":" + fixed, u, true
+	}
+	return "", aggregate.PackageUpgrade{}, false
+}
@@ -0,0 +1,64 @@
+// Package render writes command results in the format of the global --output
+// flag, so every command serializes its results the same way.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Format is an output format
+type Format string
+
+const (
+	Table Format = "table" // Human-readable tables, shortened to fit
+	Wide  Format = "wide"  // Tables without shortening, with extra columns
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+// Formats are the formats --output accepts
+var Formats = []Format{Table, Wide, JSON, YAML}
+
+// ParseFormat parses an output format; empty means Table
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return Table, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (expected table, wide, json or yaml)", s)
+}
+
+// Structured reports whether the format is for machines: JSON or YAML
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+// Write writes v as JSON or YAML. YAML is converted from the JSON encoding,
+// so both have the same field names.
+func Write(w io.Writer, f Format, v any) error {
+	var data []byte
+	var err error
+	switch f {
+	case JSON:
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	case YAML:
+		data, err = yaml.Marshal(v)
+	default:
+		return fmt.Errorf("%s is not a structured output format", f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", f, err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// htmlStyle keeps the page readable when printed or attached as a file
+const htmlStyle = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+h1, h2 { border-bottom: 1px solid #d1d9e0; padding-bottom: .3rem; }
+table { border-collapse: collapse; margin: 1rem 0; font-size: .9rem; }
+th, td { border: 1px solid #d1d9e0; padding: .3rem .6rem; text-align: left; }
+th { background: #f6f8fa; }
+code { background: #f6f8fa; padding: .1rem .3rem; border-radius: 4px; }
+@media print { body { margin: 0; max-width: none; } }`
+
+// HTML renders the report as a standalone HTML page
+func (r *Report) HTML() (string, error) {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	var body bytes.Buffer
+	if err := md.Convert([]byte(r.Markdown()), &body); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return fmt.Sprintf("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n%s</body>\n</html>\n",
+		html.EscapeString(r.Title), htmlStyle, body.String()), nil
+}
+
+// narrativeSections maps the headings of an LLM's answer to the parts of a
+// narrative
+var narrativeSections = map[string]func(*Narrative) *string{
+	"executive summary": func(n *Narrative) *string { return &n.ExecutiveSummary },
+	"top risks":         func(n *Narrative) *string { return &n.TopRisks },
+	"remediation plan":  func(n *Narrative) *string { return &n.RemediationPlan },
+}
+
+// ParseNarrative splits an answer with "## Executive Summary", "## Top Risks"
+// and "## Remediation Plan" sections into a narrative. Text under other
+// headings is dropped; deeper headings are kept with their section.
+func ParseNarrative(text string) Narrative {
+	var n Narrative
+	var current *string
+	var section strings.Builder
+	flush := func() {
+		if current != nil {
+			*current = strings.TrimSpace(section.String())
+		}
+		section.Reset()
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			flush()
+			current = nil
+			if part, ok := narrativeSections[strings.ToLower(strings.TrimSpace(heading))]; ok {
+				current = part(&n)
+			}
+			continue
+		}
+		section.WriteString(line + "\n")
+	}
+	flush()
+	return n
+}
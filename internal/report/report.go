@@ -0,0 +1,347 @@
+// Package report builds the security report of a cluster or namespace, for
+// attaching to a security review: an executive summary, severity trends
+// since a baseline, the top risks and a remediation plan. Reports render as
+// Markdown or as a standalone HTML page, and can carry prose written by an
+// LLM next to the numbers.
+package report
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/snapshot"
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// severities lists severities from most to least severe
+var severities = []trivy.Severity{trivy.SeverityCritical, trivy.SeverityHigh, trivy.SeverityMedium, trivy.SeverityLow, trivy.SeverityUnknown}
+
+// Options describes the scan a report is of and how much it lists
+type Options struct {
+	Title     string             // Default "Security Report: <context>"
+	Version   string             // trix version
+	Context   string             // Kubernetes context scanned
+	Namespace string             // Empty for all namespaces
+	Time      time.Time          // When the findings were collected
+	Baseline  *snapshot.Snapshot // Earlier scan to show trends against, if any
+	Top       int                // Entries per top-risk table
+	// Lowest severity the remediation plan clears (default HIGH)
+	UpgradeSeverity trivy.Severity
+}
+
+// Check is a misconfiguration, RBAC, secret or infra check and the
+// resources failing it
+type Check struct {
+	ID          string
+	Type        trivy.FindingType
+	Severity    trivy.Severity
+	Title       string
+	Remediation string
+	Resources   []string // [namespace/]Kind/name
+}
+
+// Narrative is the prose of a report, e.g. written by an LLM. Empty parts
+// are left out.
+type Narrative struct {
+	ExecutiveSummary string
+	TopRisks         string
+	RemediationPlan  string
+}
+
+// Report is the content of a security report
+type Report struct {
+	Options
+	Summary         aggregate.Summary
+	Vulnerabilities []aggregate.UniqueVulnerability // Most severe first
+	Checks          []Check                         // Most severe first
+	Upgrades        []aggregate.ImageUpgrades
+	Diff            *snapshot.Diff // Set with a baseline
+	Current         map[trivy.Severity]int
+	Previous        map[trivy.Severity]int // Set with a baseline
+	Narrative       Narrative
+}
+
+// New builds the report of a set of findings
+func New(findings []trivy.Finding, opts Options) *Report {
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	if opts.UpgradeSeverity == "" {
+		opts.UpgradeSeverity = aggregate.DefaultUpgradeSeverity
+	}
+	if opts.Title == "" {
+		opts.Title = "Security Report"
+		if opts.Context != "" {
+			opts.Title += ": " + opts.Context
+		}
+	}
+
+	agg := aggregate.New()
+	agg.Add(findings...)
+	vulns := agg.Vulnerabilities()
+	r := &Report{
+		Options:         opts,
+		Summary:         agg.Summary(aggregate.DefaultOptions),
+		Vulnerabilities: vulns,
+		Checks:          checks(findings),
+		Upgrades:        aggregate.UpgradePlan(vulns, opts.UpgradeSeverity),
+		Current:         make(map[trivy.Severity]int),
+	}
+	for _, f := range findings {
+		r.Current[trivy.Severity(strings.ToUpper(string(f.Severity)))]++
+	}
+	if opts.Baseline != nil {
+		diff := snapshot.Compare(opts.Baseline, snapshot.New(opts.Context, opts.Namespace, findings))
+		r.Diff = &diff
+		r.Previous = make(map[trivy.Severity]int)
+		for _, e := range opts.Baseline.Entries {
+			r.Previous[trivy.Severity(strings.ToUpper(string(e.Severity)))]++
+		}
+	}
+	return r
+}
+
+// checks groups the findings other than vulnerabilities by check ID
+func checks(findings []trivy.Finding) []Check {
+	byID := make(map[string]*Check)
+	for _, f := range findings {
+		if f.Type == trivy.FindingTypeVulnerability {
+			continue
+		}
+		c, ok := byID[f.ID]
+		if !ok {
+			c = &Check{ID: f.ID, Type: f.Type, Severity: f.Severity, Title: f.Title, Remediation: f.Remediation}
+			byID[f.ID] = c
+		}
+		resource := f.Resource()
+		if f.Owner == nil && f.ResourceKind != "" {
+			resource = f.ResourceKind + "/" + f.ResourceName
+		}
+		if f.Namespace != "" {
+			resource = f.Namespace + "/" + resource
+		}
+		if !slices.Contains(c.Resources, resource) {
+			c.Resources = append(c.Resources, resource)
+		}
+	}
+
+	result := make([]Check, 0, len(byID))
+	for _, c := range byID {
+		sort.Strings(c.Resources)
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() > b.Severity.Rank()
+		}
+		if len(a.Resources) != len(b.Resources) {
+			return len(a.Resources) > len(b.Resources)
+		}
+		return a.ID < b.ID
+	})
+	return result
+}
+
+// Scope describes what the report covers, e.g. "namespace prod of context kind"
+func (r *Report) Scope() string {
+	scope := "all namespaces"
+	if r.Namespace != "" {
+		scope = "namespace " + r.Namespace
+	}
+	if r.Context != "" {
+		scope += " of context " + r.Context
+	}
+	return scope
+}
+
+// Facts returns the numbers of the report as Markdown, without narrative:
+// what an LLM writes the narrative from
+func (r *Report) Facts() string {
+	return r.markdown(false)
+}
+
+// Markdown renders the report as Markdown
+func (r *Report) Markdown() string {
+	return r.markdown(true)
+}
+
+func (r *Report) markdown(narrative bool) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n\n", r.Title)
+	generated := r.Time.Local().Format("2006-01-02 15:04")
+	fmt.Fprintf(&out, "_Generated %s by trix %s for %s._\n\n", generated, r.Version, r.Scope())
+
+	out.WriteString("## Executive Summary\n\n")
+	if narrative && r.Narrative.ExecutiveSummary != "" {
+		out.WriteString(r.Narrative.ExecutiveSummary + "\n\n")
+	}
+	r.writeSummary(&out)
+
+	out.WriteString("## Severity Trends\n\n")
+	r.writeTrends(&out)
+
+	out.WriteString("## Top Risks\n\n")
+	if narrative && r.Narrative.TopRisks != "" {
+		out.WriteString(r.Narrative.TopRisks + "\n\n")
+	}
+	r.writeTopRisks(&out)
+
+	out.WriteString("## Remediation Plan\n\n")
+	if narrative && r.Narrative.RemediationPlan != "" {
+		out.WriteString(r.Narrative.RemediationPlan + "\n\n")
+	}
+	r.writeRemediation(&out)
+	return strings.TrimSpace(out.String()) + "\n"
+}
+
+func (r *Report) writeSummary(out *strings.Builder) {
+	s := r.Summary
+	if s.TotalFindings == 0 {
+		out.WriteString("No findings.\n\n")
+		return
+	}
+	var counts []string
+	for _, sev := range severities {
+		if n := r.Current[sev]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, strings.ToLower(string(sev))))
+		}
+	}
+	fmt.Fprintf(out, "- **%d findings**: %s\n", s.TotalFindings, strings.Join(counts, ", "))
+	if vulns := s.ByType[string(trivy.FindingTypeVulnerability)]; vulns > 0 {
+		fmt.Fprintf(out, "- Vulnerabilities: %d unique, %d occurrences across workloads, %d of them fixable\n", s.UniqueVulnerabilities, vulns, s.Fixable)
+	}
+	if len(r.Checks) > 0 {
+		fmt.Fprintf(out, "- Failing configuration, RBAC and secret checks: %d\n", len(r.Checks))
+	}
+	if len(r.Upgrades) > 0 {
+		fmt.Fprintf(out, "- Images with %s or more severe vulnerabilities: %d, upgrades in the remediation plan\n", strings.ToLower(string(r.UpgradeSeverity)), len(r.Upgrades))
+	}
+	if r.Diff != nil {
+		fmt.Fprintf(out, "- Since the baseline: %d new, %d resolved\n", len(r.Diff.New), len(r.Diff.Resolved))
+	}
+	if len(s.TopResources) > 0 {
+		fmt.Fprintf(out, "- Most affected resource: %s (%d)\n", s.TopResources[0].Resource, s.TopResources[0].Count)
+	}
+	out.WriteString("\n")
+}
+
+func (r *Report) writeTrends(out *strings.Builder) {
+	if r.Diff == nil {
+		out.WriteString("| Severity | Findings |\n|---|---:|\n")
+		for _, sev := range severities {
+			fmt.Fprintf(out, "| %s | %d |\n", sev, r.Current[sev])
+		}
+		out.WriteString("\nNo baseline to compare against; save one with `trix snapshot` and pass it with `--baseline`.\n\n")
+		return
+	}
+
+	out.WriteString("| Severity | Baseline | Current | Change |\n|---|---:|---:|---:|\n")
+	for _, sev := range severities {
+		before, now := r.Previous[sev], r.Current[sev]
+		fmt.Fprintf(out, "| %s | %d | %d | %+d |\n", sev, before, now, now-before)
+	}
+	fmt.Fprintf(out, "\nCompared with the baseline of %s: %d new, %d in new workloads, %d severity changes, %d resolved.\n\n",
+		r.Baseline.CreatedAt.Local().Format("2006-01-02"), len(r.Diff.New), len(r.Diff.NewWorkloads), len(r.Diff.SeverityChanged), len(r.Diff.Resolved))
+	if len(r.Diff.New) > 0 {
+		out.WriteString("New since the baseline:\n\n")
+		for i, issue := range r.Diff.New {
+			if i == r.Top {
+				fmt.Fprintf(out, "- ...and %d more\n", len(r.Diff.New)-r.Top)
+				break
+			}
+			fmt.Fprintf(out, "- %s %s in %s\n", issue.Severity, issue.ID, cell(strings.Join(issue.Workloads, ", ")))
+		}
+		out.WriteString("\n")
+	}
+}
+
+func (r *Report) writeTopRisks(out *strings.Builder) {
+	if len(r.Vulnerabilities) > 0 {
+		out.WriteString("### Vulnerabilities\n\n| CVE | Severity | CVSS | Package | Image | Workloads | Fixed In |\n|---|---|---:|---|---|---:|---|\n")
+		for _, v := range top(r.Vulnerabilities, r.Top) {
+			score := "-"
+			if v.Score > 0 {
+				score = fmt.Sprintf("%.1f", v.Score)
+			}
+			fixed := v.FixedVersion
+			if fixed == "" {
+				fixed = "no fix"
+			}
+			fmt.Fprintf(out, "| %s | %s | %s | %s %s | %s | %d | %s |\n", v.VulnerabilityID, v.Severity, score, cell(v.PkgName), cell(v.InstalledVersion), cell(v.Image), len(v.Workloads), cell(fixed))
+		}
+		out.WriteString("\n")
+	}
+	if len(r.Checks) > 0 {
+		out.WriteString("### Misconfigurations\n\n| Check | Severity | Type | Title | Resources |\n|---|---|---|---|---:|\n")
+		for _, c := range top(r.Checks, r.Top) {
+			fmt.Fprintf(out, "| %s | %s | %s | %s | %d |\n", c.ID, c.Severity, c.Type, cell(c.Title), len(c.Resources))
+		}
+		out.WriteString("\n")
+	}
+	if len(r.Summary.TopResources) > 0 {
+		out.WriteString("### Most Affected Resources\n\n| Resource | Findings |\n|---|---:|\n")
+		for _, rc := range r.Summary.TopResources {
+			fmt.Fprintf(out, "| %s | %d |\n", cell(rc.Resource), rc.Count)
+		}
+		out.WriteString("\n")
+	}
+	if len(r.Vulnerabilities) == 0 && len(r.Checks) == 0 {
+		out.WriteString("No findings.\n\n")
+	}
+}
+
+func (r *Report) writeRemediation(out *strings.Builder) {
+	if len(r.Upgrades) > 0 {
+		fmt.Fprintf(out, "### Image Upgrades\n\nPackage upgrades that clear every %s and more severe vulnerability, per image. Rebuild the image with them or move to a base image that has them.\n\n", strings.ToLower(string(r.UpgradeSeverity)))
+		out.WriteString("| Image | Package | Upgrade | Clears |\n|---|---|---|---|\n")
+		for _, image := range top(r.Upgrades, r.Top) {
+			for _, u := range image.Upgrades {
+				fmt.Fprintf(out, "| %s | %s | %s → %s | %s |\n", cell(image.Image), cell(u.PkgName), cell(u.InstalledVersion), cell(u.FixedVersion), strings.Join(u.VulnerabilityIDs, ", "))
+			}
+			for _, u := range image.Unfixable {
+				fmt.Fprintf(out, "| %s | %s | no fix yet | %s |\n", cell(image.Image), cell(u.PkgName), strings.Join(u.VulnerabilityIDs, ", "))
+			}
+		}
+		if len(r.Upgrades) > r.Top {
+			fmt.Fprintf(out, "\n...and %d more images; see `trix upgrades`.\n", len(r.Upgrades)-r.Top)
+		}
+		out.WriteString("\n")
+	}
+	if len(r.Checks) > 0 {
+		out.WriteString("### Configuration Fixes\n\n")
+		for _, c := range top(r.Checks, r.Top) {
+			fmt.Fprintf(out, "- **%s** %s (affects %d)", c.ID, c.Title, len(c.Resources))
+			if c.Remediation != "" {
+				fmt.Fprintf(out, ": %s", oneLine(c.Remediation))
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString("\n`trix fix <kind/name>` writes a patch for the workload checks it knows how to fix.\n\n")
+	}
+	if len(r.Upgrades) == 0 && len(r.Checks) == 0 {
+		out.WriteString("Nothing to remediate.\n\n")
+	}
+}
+
+// top returns the first n entries of a list
+func top[T any](list []T, n int) []T {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
+
+// cell escapes text for a Markdown table cell
+func cell(s string) string {
+	return strings.ReplaceAll(oneLine(s), "|", `\|`)
+}
+
+// oneLine joins the lines of s
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,182 @@
+// Package schedule parses cron expressions, so 'trix schedule' can run scans
+// at set times without an external scheduler.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors are the shorthands accepted in place of five fields
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range and names of one cron field
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, e.g. jan for 1
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Cron is a parsed cron expression: minute, hour, day of month, month and day
+// of week
+type Cron struct {
+	spec    string
+	minutes [60]bool
+	hours   [24]bool
+	days    [32]bool
+	months  [13]bool
+	weekday [7]bool
+	anyDay  bool // Day of month is *, so only the day of week restricts days
+	anyWeek bool // Day of week is *, so only the day of month restricts days
+}
+
+// Parse parses a five-field cron expression ("0 6 * * 1-5") or a
+// shorthand such as @daily. Fields take *, values, names (mon, jan),
+// ranges (1-5), lists (1,15) and steps (*/15).
+func Parse(spec string) (*Cron, error) {
+	expr := strings.TrimSpace(spec)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week) or a shorthand such as @daily", spec)
+	}
+
+	c := &Cron{spec: spec, anyDay: parts[2] == "*", anyWeek: parts[4] == "*"}
+	targets := [][]bool{c.minutes[:], c.hours[:], c.days[:], c.months[:], nil}
+	for i, part := range parts {
+		values, err := fields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		for _, v := range values {
+			if i == 4 {
+				c.weekday[v%7] = true // 7 is Sunday too
+				continue
+			}
+			targets[i][v] = true
+		}
+	}
+	return c, nil
+}
+
+// String returns the expression as it was parsed
+func (c *Cron) String() string {
+	return c.spec
+}
+
+// Next returns the first minute after a time that the expression matches, or
+// the zero time if it matches none in the next five years (e.g. 30 February)
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case !c.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule for days: when both the day of month and
+// the day of week are restricted, a day matching either runs
+func (c *Cron) dayMatches(t time.Time) bool {
+	day, week := c.days[t.Day()], c.weekday[t.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeek:
+		return true
+	case c.anyDay:
+		return week
+	case c.anyWeek:
+		return day
+	}
+	return day || week
+}
+
+// parse returns the values a field's expression selects
+func (f field) parse(expr string) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %s", stepExpr, f.name)
+			}
+			step = n
+		}
+
+		var low, high int
+		switch lowExpr, highExpr, isRange := strings.Cut(rangeExpr, "-"); {
+		case rangeExpr == "*":
+			low, high = f.min, f.max
+		case isRange:
+			var err error
+			if low, err = f.value(lowExpr); err != nil {
+				return nil, err
+			}
+			if high, err = f.value(highExpr); err != nil {
+				return nil, err
+			}
+			if low > high {
+				return nil, fmt.Errorf("invalid range %q in %s", rangeExpr, f.name)
+			}
+		default:
+			var err error
+			if low, err = f.value(rangeExpr); err != nil {
+				return nil, err
+			}
+			high = low
+			if hasStep {
+				high = f.max // 5/15 means from 5 on, every 15
+			}
+		}
+		for v := low; v <= high; v += step {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// value parses a single number or name of a field
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q (expected %d-%d)", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
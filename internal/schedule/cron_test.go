@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@often",
+	}
+	for _, spec := range tests {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	after := time.Date(2026, time.January, 14, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 14, 10, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 1, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2026, 1, 15, 6, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 6 * * 1-5", time.Date(2026, 1, 15, 6, 0, 0, 0, time.UTC)},
+		{"0 6 * * sat,sun", time.Date(2026, 1, 17, 6, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)}, // 7 is Sunday
+		{"0 0 1 jun *", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10 14 1 *", time.Date(2027, 1, 14, 10, 30, 0, 0, time.UTC)}, // Strictly after
+		{"0 0 20 * mon", time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)},   // Either day matches
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}}, // Never
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			c, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := c.Next(after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", after, got, tt.want)
+			}
+		})
+	}
+}
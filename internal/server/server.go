@@ -0,0 +1,247 @@
+// Package server serves findings over a read-only JSON API and a web
+// dashboard, rescanning on an interval, so trix can run in a cluster as a
+// shared security view.
+package server
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/davealtena/trix/internal/aggregate"
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+//go:embed static
+var static embed.FS
+
+// DefaultHistory is how many scans the trends cover by default
+const DefaultHistory = 288
+
+// maxLimit caps the findings returned by one request
+const maxLimit = 1000
+
+// Options configures a server
+type Options struct {
+	Scan      func(ctx context.Context) []trivy.Finding
+	Interval  time.Duration // Between scans
+	History   int           // Trend points kept, DefaultHistory if 0
+	Context   string        // Shown on the dashboard
+	Namespace string        // Empty for all namespaces
+	Version   string
+}
+
+// TrendPoint is the severity counts of one scan
+type TrendPoint struct {
+	Time       time.Time      `json:"time"`
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"bySeverity"`
+}
+
+// SummaryResponse is the body of /api/v1/summary
+type SummaryResponse struct {
+	Context   string            `json:"context,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	ScannedAt time.Time         `json:"scannedAt"`
+	Risk      float64           `json:"risk"` // 0-100, see aggregate.RiskScore
+	Summary   aggregate.Summary `json:"summary"`
+}
+
+// FindingsResponse is the body of /api/v1/findings
+type FindingsResponse struct {
+	Total    int             `json:"total"` // Matching findings, before limit and offset
+	Findings []trivy.Finding `json:"findings"`
+}
+
+// Server holds the findings of the last scan and serves them
+type Server struct {
+	opts Options
+
+	mu        sync.RWMutex
+	findings  []trivy.Finding
+	summary   aggregate.Summary
+	scannedAt time.Time
+	trends    []TrendPoint
+}
+
+// New creates a server. Nothing is scanned until Run is called.
+func New(opts Options) *Server {
+	if opts.History <= 0 {
+		opts.History = DefaultHistory
+	}
+	return &Server{opts: opts}
+}
+
+// Run scans now and then every interval, until ctx is done
+func (s *Server) Run(ctx context.Context) {
+	s.Refresh(ctx)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh scans and replaces the served findings
+func (s *Server) Refresh(ctx context.Context) {
+	findings := s.opts.Scan(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	for i := range findings {
+		// Raw report data is large and may hold matched secret values
+		findings[i].RawData = nil
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if ri, rj := findings[i].Severity.Rank(), findings[j].Severity.Rank(); ri != rj {
+			return ri > rj
+		}
+		return findings[i].Score > findings[j].Score
+	})
+	summary := aggregate.Summarize(findings, aggregate.DefaultOptions)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findings = findings
+	s.summary = summary
+	s.scannedAt = now
+	s.trends = append(s.trends, TrendPoint{Time: now, Total: summary.TotalFindings, BySeverity: summary.BySeverity})
+	if len(s.trends) > s.opts.History {
+		s.trends = s.trends[len(s.trends)-s.opts.History:]
+	}
+}
+
+// Handler returns the API and dashboard routes. Everything is read-only.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", s.ready)
+	mux.HandleFunc("GET /api/v1/summary", s.handleSummary)
+	mux.HandleFunc("GET /api/v1/findings", s.handleFindings)
+	mux.HandleFunc("GET /api/v1/trends", s.handleTrends)
+	dashboard, _ := fs.Sub(static, "static")
+	mux.Handle("GET /", http.FileServerFS(dashboard))
+	return mux
+}
+
+// ready answers 200 once the first scan is done
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	scanned := !s.scannedAt.IsZero()
+	s.mu.RUnlock()
+	if !scanned {
+		http.Error(w, "first scan in progress", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	resp := SummaryResponse{
+		Context:   s.opts.Context,
+		Namespace: s.opts.Namespace,
+		Version:   s.opts.Version,
+		ScannedAt: s.scannedAt,
+		Risk:      aggregate.RiskScore(s.summary.BySeverity),
+		Summary:   s.summary,
+	}
+	s.mu.RUnlock()
+	writeJSON(w, resp)
+}
+
+// handleFindings returns the findings, most severe first, filtered by the
+// query parameters severity (minimum), type, namespace and q (text in the
+// ID, title or resource), and paged by limit and offset
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var minSeverity trivy.Severity
+	if v := query.Get("severity"); v != "" {
+		sev, err := trivy.ParseSeverity(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minSeverity = sev
+	}
+	limit, err := intParam(query.Get("limit"), 100)
+	if err != nil {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	offset, err := intParam(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return
+	}
+	limit = min(limit, maxLimit)
+	typ, namespace := query.Get("type"), query.Get("namespace")
+	text := strings.ToLower(query.Get("q"))
+
+	s.mu.RLock()
+	matched := []trivy.Finding{}
+	for _, f := range s.findings {
+		if minSeverity != "" && f.Severity.Rank() < minSeverity.Rank() {
+			continue
+		}
+		if typ != "" && string(f.Type) != typ {
+			continue
+		}
+		if namespace != "" && f.Namespace != namespace {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(f.ID+" "+f.Title+" "+f.Namespace+"/"+f.Resource()), text) {
+			continue
+		}
+		matched = append(matched, f)
+	}
+	s.mu.RUnlock()
+
+	resp := FindingsResponse{Total: len(matched), Findings: []trivy.Finding{}}
+	if offset < len(matched) {
+		resp.Findings = matched[offset:min(offset+limit, len(matched))]
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleTrends(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	trends := append([]TrendPoint{}, s.trends...)
+	s.mu.RUnlock()
+	writeJSON(w, trends)
+}
+
+// intParam parses a non-negative integer query parameter
+func intParam(v string, def int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,157 @@
+// Package session persists interactive conversations so they can be resumed
+// across invocations of trix.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/llm"
+)
+
+// Session is a saved conversation.
+type Session struct {
+	ID        string        `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Provider  string        `json:"provider,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Messages  []llm.Message `json:"messages"`
+	Usage     llm.Totals    `json:"usage"`
+}
+
+// New creates an empty session with a timestamp-based ID.
+func New(provider, model string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        now.Format("20060102-150405"),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Provider:  provider,
+		Model:     model,
+	}
+}
+
+// Dir returns the directory sessions are stored in
+// ($XDG_DATA_HOME/trix/sessions, or ~/.local/share/trix/sessions).
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "trix", "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "trix", "sessions"), nil
+}
+
+// Title returns the first question asked in the session.
+func (s *Session) Title() string {
+	for _, msg := range s.Messages {
+		if msg.Role == llm.RoleUser {
+			title := strings.Join(strings.Fields(msg.Content), " ")
+			if len(title) > 60 {
+				title = title[:57] + "..."
+			}
+			return title
+		}
+	}
+	return ""
+}
+
+// Save writes the session to the session directory.
+func (s *Session) Save() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	// Write atomically so an interrupted save never corrupts a session
+	path := filepath.Join(dir, s.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a session by ID. The ID "last" loads the most recently updated session.
+func Load(id string) (*Session, error) {
+	if id == "last" {
+		sessions, err := List()
+		if err != nil {
+			return nil, err
+		}
+		if len(sessions) == 0 {
+			return nil, fmt.Errorf("no saved sessions")
+		}
+		return Load(sessions[0].ID)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(id)+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// List returns all saved sessions, most recently updated first.
+func List() ([]Session, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	var sessions []Session
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		s, err := Load(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue // Skip unreadable sessions rather than failing the listing
+		}
+		sessions = append(sessions, *s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Issue is a finding independent of where it occurs: a CVE in one package of
+// one image, or a failed check. Workloads lists where it occurs.
+type Issue struct {
+	Type      trivy.FindingType `json:"type"`
+	ID        string            `json:"id"`
+	Severity  trivy.Severity    `json:"severity"`
+	Title     string            `json:"title,omitempty"`
+	Image     string            `json:"image,omitempty"`
+	Package   string            `json:"package,omitempty"`
+	Workloads []string          `json:"workloads"`
+}
+
+// SeverityChange is an issue whose severity changed, e.g. after a CVE was
+// rescored
+type SeverityChange struct {
+	Issue
+	Previous trivy.Severity `json:"previousSeverity"`
+}
+
+// WorkloadChange is an issue that now affects workloads it didn't before
+type WorkloadChange struct {
+	Issue
+	Added []string `json:"addedWorkloads"`
+}
+
+// Diff is the change between two snapshots
+type Diff struct {
+	New             []Issue          `json:"new"`
+	Resolved        []Issue          `json:"resolved"`
+	SeverityChanged []SeverityChange `json:"severityChanged"`
+	NewWorkloads    []WorkloadChange `json:"newWorkloads"`
+}
+
+// Compare returns what changed from the baseline to the current snapshot
+func Compare(baseline, current *Snapshot) Diff {
+	before := issues(baseline)
+	after := issues(current)
+
+	var d Diff
+	for key, issue := range after {
+		old, ok := before[key]
+		if !ok {
+			d.New = append(d.New, *issue)
+			continue
+		}
+		if old.Severity != issue.Severity {
+			d.SeverityChanged = append(d.SeverityChanged, SeverityChange{Issue: *issue, Previous: old.Severity})
+		}
+		if added := missing(issue.Workloads, old.Workloads); len(added) > 0 {
+			d.NewWorkloads = append(d.NewWorkloads, WorkloadChange{Issue: *issue, Added: added})
+		}
+	}
+	for key, issue := range before {
+		if _, ok := after[key]; !ok {
+			d.Resolved = append(d.Resolved, *issue)
+		}
+	}
+
+	sortIssues(d.New)
+	sortIssues(d.Resolved)
+	sort.Slice(d.SeverityChanged, func(i, j int) bool { return less(d.SeverityChanged[i].Issue, d.SeverityChanged[j].Issue) })
+	sort.Slice(d.NewWorkloads, func(i, j int) bool { return less(d.NewWorkloads[i].Issue, d.NewWorkloads[j].Issue) })
+	return d
+}
+
+// Regressions reports whether the diff makes things worse at or above a
+// severity: new issues, issues in new workloads, or raised severities.
+// Resolved issues and lowered severities are never regressions.
+func (d Diff) Regressions(minSeverity trivy.Severity) bool {
+	for _, issue := range d.Regressed() {
+		if issue.Severity.Rank() >= minSeverity.Rank() {
+			return true
+		}
+	}
+	return false
+}
+
+// Regressed returns the issues that make things worse, each once and most
+// severe first: new issues, issues in new workloads and raised severities
+func (d Diff) Regressed() []Issue {
+	seen := make(map[string]bool)
+	var result []Issue
+	add := func(issue Issue) {
+		if !seen[issue.key()] {
+			seen[issue.key()] = true
+			result = append(result, issue)
+		}
+	}
+	for _, issue := range d.New {
+		add(issue)
+	}
+	for _, change := range d.NewWorkloads {
+		add(change.Issue)
+	}
+	for _, change := range d.SeverityChanged {
+		if change.Severity.Rank() > change.Previous.Rank() {
+			add(change.Issue)
+		}
+	}
+	sortIssues(result)
+	return result
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return len(d.New) == 0 && len(d.Resolved) == 0 && len(d.SeverityChanged) == 0 && len(d.NewWorkloads) == 0
+}
+
+// issues groups the entries of a snapshot by issue
+func issues(s *Snapshot) map[string]*Issue {
+	result := make(map[string]*Issue)
+	for _, e := range s.Entries {
+		issue := &Issue{Type: e.Type, ID: e.ID, Severity: e.Severity, Title: e.Title, Image: e.Image, Package: e.Package}
+		if existing, ok := result[issue.key()]; ok {
+			issue = existing
+		} else {
+			result[issue.key()] = issue
+		}
+		issue.Workloads = append(issue.Workloads, e.Workload)
+	}
+	for _, issue := range result {
+		sort.Strings(issue.Workloads)
+		issue.Workloads = slices.Compact(issue.Workloads)
+	}
+	return result
+}
+
+// key identifies an issue across snapshots
+func (i Issue) key() string {
+	return string(i.Type) + "|" + i.ID + "|" + i.Image + "|" + i.Package
+}
+
+// missing returns the values of a that aren't in b
+func missing(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		seen[v] = true
+	}
+	var result []string
+	for _, v := range a {
+		if !seen[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// less orders issues most severe first, then by ID
+func less(a, b Issue) bool {
+	if a.Severity.Rank() != b.Severity.Rank() {
+		return a.Severity.Rank() > b.Severity.Rank()
+	}
+	if a.ID != b.ID {
+		return a.ID < b.ID
+	}
+	return a.Image+a.Package < b.Image+b.Package
+}
+
+func sortIssues(issues []Issue) {
+	sort.Slice(issues, func(i, j int) bool { return less(issues[i], issues[j]) })
+}
@@ -0,0 +1,115 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// vuln returns a vulnerability finding in a pod of a ReplicaSet owned by a
+// Deployment
+func vuln(id string, severity trivy.Severity, replicaSet, deployment string) trivy.Finding {
+	f := trivy.VulnerabilityToFinding(trivy.Vulnerability{
+		VulnerabilityID: id,
+		Severity:        string(severity),
+		PkgName:         "openssl",
+		Image:           "nginx:1.25",
+	}, "default", replicaSet)
+	if deployment != "" {
+		f.Owner = &trivy.Owner{Kind: "Deployment", Name: deployment}
+	}
+	return f
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name            string
+		baseline        []trivy.Finding
+		current         []trivy.Finding
+		wantNew         int
+		wantResolved    int
+		wantSeverity    int
+		wantWorkloads   int
+		wantRegressions bool
+	}{
+		{
+			name:     "unchanged",
+			baseline: []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+			current:  []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+		},
+		{
+			name:     "rollout to a new ReplicaSet",
+			baseline: []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+			current:  []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-def", "web")},
+		},
+		{
+			name:            "new issue",
+			baseline:        []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+			current:         []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web"), vuln("CVE-2", trivy.SeverityCritical, "web-abc", "web")},
+			wantNew:         1,
+			wantRegressions: true,
+		},
+		{
+			name:            "spread to another workload",
+			baseline:        []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+			current:         []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web"), vuln("CVE-1", trivy.SeverityHigh, "api-abc", "api")},
+			wantWorkloads:   1,
+			wantRegressions: true,
+		},
+		{
+			name:            "raised severity",
+			baseline:        []trivy.Finding{vuln("CVE-1", trivy.SeverityMedium, "web-abc", "web")},
+			current:         []trivy.Finding{vuln("CVE-1", trivy.SeverityCritical, "web-abc", "web")},
+			wantSeverity:    1,
+			wantRegressions: true,
+		},
+		{
+			name:         "lowered severity",
+			baseline:     []trivy.Finding{vuln("CVE-1", trivy.SeverityCritical, "web-abc", "web")},
+			current:      []trivy.Finding{vuln("CVE-1", trivy.SeverityMedium, "web-abc", "web")},
+			wantSeverity: 1,
+		},
+		{
+			name:         "resolved",
+			baseline:     []trivy.Finding{vuln("CVE-1", trivy.SeverityHigh, "web-abc", "web")},
+			wantResolved: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Compare(New("test", "", tt.baseline), New("test", "", tt.current))
+			if len(d.New) != tt.wantNew {
+				t.Errorf("New = %d, want %d", len(d.New), tt.wantNew)
+			}
+			if len(d.Resolved) != tt.wantResolved {
+				t.Errorf("Resolved = %d, want %d", len(d.Resolved), tt.wantResolved)
+			}
+			if len(d.SeverityChanged) != tt.wantSeverity {
+				t.Errorf("SeverityChanged = %d, want %d", len(d.SeverityChanged), tt.wantSeverity)
+			}
+			if len(d.NewWorkloads) != tt.wantWorkloads {
+				t.Errorf("NewWorkloads = %d, want %d", len(d.NewWorkloads), tt.wantWorkloads)
+			}
+			if got := d.Regressions(trivy.SeverityHigh); got != tt.wantRegressions {
+				t.Errorf("Regressions(HIGH) = %v, want %v", got, tt.wantRegressions)
+			}
+		})
+	}
+}
+
+func TestEntryNamespace(t *testing.T) {
+	tests := []struct {
+		workload string
+		want     string
+	}{
+		{"default/Deployment/web", "default"},
+		{"default/web-abc", "default"},
+		{"node-1", ""},
+	}
+	for _, tt := range tests {
+		if got := (Entry{Workload: tt.workload}).Namespace(); got != tt.want {
+			t.Errorf("Entry{Workload: %q}.Namespace() = %q, want %q", tt.workload, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package snapshot saves the findings of a scan to a file and compares two
+// scans, so changes since a baseline (new, resolved and changed findings)
+// can be reported and gated on.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Version is the current snapshot file format
+const Version = 1
+
+// Snapshot is the finding set of one scan
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Context   string    `json:"context,omitempty"`   // Kubernetes context scanned
+	Namespace string    `json:"namespace,omitempty"` // Empty for all namespaces
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry is one finding, reduced to what identifies and compares it
+type Entry struct {
+	Type     trivy.FindingType `json:"type"`
+	ID       string            `json:"id"`
+	Severity trivy.Severity    `json:"severity"`
+	Title    string            `json:"title,omitempty"`
+	Image    string            `json:"image,omitempty"`   // Vulnerabilities only
+	Package  string            `json:"package,omitempty"` // Vulnerabilities only
+	Workload string            `json:"workload"`          // [namespace/]resource, Kind/name for resolved owners
+}
+
+// New creates a snapshot of findings
+func New(context, namespace string, findings []trivy.Finding) *Snapshot {
+	s := &Snapshot{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Context:   context,
+		Namespace: namespace,
+		Entries:   make([]Entry, 0, len(findings)),
+	}
+	for _, f := range findings {
+		s.Entries = append(s.Entries, entry(f))
+	}
+	return s
+}
+
+// entry reduces a finding to a snapshot entry
+func entry(f trivy.Finding) Entry {
+	e := Entry{
+		Type:     f.Type,
+		ID:       f.ID,
+		Severity: f.Severity,
+		Title:    f.Title,
+		Workload: f.Resource(),
+	}
+	// The owner rather than the scanned ReplicaSet or Job, so a rollout
+	// doesn't make every finding look like it spread to a new workload
+	if f.Namespace != "" {
+		e.Workload = f.Namespace + "/" + f.Resource()
+	}
+	if v, ok := f.RawData.(trivy.Vulnerability); ok {
+		e.Image = v.Image
+		e.Package = v.PkgName
+	}
+	return e
+}
+
+// Namespace returns the namespace of the entry's workload, or "" for
+// cluster-scoped resources
+func (e Entry) Namespace() string {
+	namespace, _, ok := strings.Cut(e.Workload, "/")
+	if !ok {
+		return ""
+	}
+	return namespace
+}
+
+// Save writes the snapshot to a file as JSON
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot written by Save
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s", s.Version, path)
+	}
+	return &s, nil
+}
@@ -0,0 +1,55 @@
+package exposure
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewClusterAnalyzer creates an analyzer with all checkers
+func NewClusterAnalyzer(clientset kubernetes.Interface, dynamicClient dynamic.Interface) *Analyzer {
+	return NewAnalyzer(
+		NewServiceChecker(clientset),
+		NewIngressChecker(clientset),
+		NewGatewayChecker(clientset, dynamicClient),
+	)
+}
+
+// WorkloadLabels fetches the pod template labels for a workload
+func WorkloadLabels(ctx context.Context, clientset kubernetes.Interface, kind, name, namespace string) (map[string]string, error) {
+	switch kind {
+	case "Deployment":
+		deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return deploy.Spec.Selector.MatchLabels, nil
+
+	case "DaemonSet":
+		ds, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return ds.Spec.Selector.MatchLabels, nil
+
+	case "StatefulSet":
+		sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return sts.Spec.Selector.MatchLabels, nil
+
+	case "Pod":
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return pod.Labels, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported workload kind: %s (use Deployment, DaemonSet, StatefulSet, or Pod)", kind)
+	}
+}
@@ -0,0 +1,277 @@
+package kubebench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultImage is the kube-bench image the jobs run
+const DefaultImage = "docker.io/aquasec/kube-bench:v0.10.0"
+
+// JobSelector selects the jobs trix creates, so their results can be read
+// again later
+const JobSelector = "app.kubernetes.io/name=kube-bench,app.kubernetes.io/managed-by=trix"
+
+// hostPaths are the node directories kube-bench reads, as in kube-bench's own
+// job.yaml. The node's binaries are mounted apart, so kube-bench can run
+// them to find their versions.
+var hostPaths = map[string]string{
+	"/var/lib/etcd":                    "/var/lib/etcd",
+	"/var/lib/kubelet":                 "/var/lib/kubelet",
+	"/var/lib/kube-scheduler":          "/var/lib/kube-scheduler",
+	"/var/lib/kube-controller-manager": "/var/lib/kube-controller-manager",
+	"/etc/systemd":                     "/etc/systemd",
+	"/lib/systemd":                     "/lib/systemd",
+	"/srv/kubernetes":                  "/srv/kubernetes",
+	"/etc/kubernetes":                  "/etc/kubernetes",
+	"/usr/bin":                         "/usr/local/mount-from-host/bin",
+	"/etc/cni/net.d":                   "/etc/cni/net.d",
+	"/opt/cni/bin":                     "/opt/cni/bin",
+}
+
+// Runner runs kube-bench as one Job per node and reads the results from the
+// logs of their pods
+type Runner struct {
+	clientset kubernetes.Interface
+	namespace string
+	image     string
+	ttl       time.Duration
+}
+
+// NewRunner creates a runner whose jobs run image in namespace and are
+// removed ttl after finishing
+func NewRunner(clientset kubernetes.Interface, namespace, image string, ttl time.Duration) *Runner {
+	if image == "" {
+		image = DefaultImage
+	}
+	return &Runner{clientset: clientset, namespace: namespace, image: image, ttl: ttl}
+}
+
+// ListNodes returns the names of the nodes matching a label selector
+func (r *Runner) ListNodes(ctx context.Context, selector string) ([]string, error) {
+	list, err := r.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, node := range list.Items {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Run starts a kube-bench job on every node, replacing the earlier jobs of
+// those nodes, and waits for them to finish. The results of the nodes that
+// finished are returned with an error per node that didn't.
+func (r *Runner) Run(ctx context.Context, nodes []string, timeout time.Duration) ([]NodeResult, []error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var errs []error
+	if err := r.deleteJobs(ctx, nodes); err != nil {
+		errs = append(errs, err)
+	}
+	pending := make(map[string]string) // Job name to node
+	for _, node := range nodes {
+		job, err := r.clientset.BatchV1().Jobs(r.namespace).Create(ctx, r.job(node), metav1.CreateOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: failed to create job: %w", node, err))
+			continue
+		}
+		pending[job.Name] = node
+	}
+
+	var results []NodeResult
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			for name, node := range pending {
+				errs = append(errs, fmt.Errorf("node %s: job %s/%s didn't finish in %s", node, r.namespace, name, timeout))
+			}
+			return results, errs
+		case <-ticker.C:
+		}
+		for name, node := range pending {
+			job, err := r.clientset.BatchV1().Jobs(r.namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				continue // Try again on the next tick
+			}
+			switch {
+			case job.Status.Succeeded > 0:
+				result, err := r.result(ctx, job)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+				} else {
+					results = append(results, *result)
+				}
+				delete(pending, name)
+			case job.Status.Failed > 0:
+				errs = append(errs, fmt.Errorf("node %s: job %s/%s failed; see 'kubectl -n %s logs job/%s'", node, r.namespace, name, r.namespace, name))
+				delete(pending, name)
+			}
+		}
+	}
+	sortResults(results)
+	return results, errs
+}
+
+// Existing returns the results of the newest finished job of each node, in
+// a namespace or all namespaces
+func (r *Runner) Existing(ctx context.Context, namespace string) ([]NodeResult, []error) {
+	list, err := r.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{LabelSelector: JobSelector})
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to list kube-bench jobs: %w", err)}
+	}
+	newest := make(map[string]*batchv1.Job)
+	for i := range list.Items {
+		job := &list.Items[i]
+		node := job.Spec.Template.Spec.NodeName
+		if job.Status.Succeeded == 0 || node == "" {
+			continue
+		}
+		if prev, ok := newest[node]; !ok || job.CreationTimestamp.After(prev.CreationTimestamp.Time) {
+			newest[node] = job
+		}
+	}
+
+	var results []NodeResult
+	var errs []error
+	for node, job := range newest {
+		result, err := r.result(ctx, job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+			continue
+		}
+		results = append(results, *result)
+	}
+	sortResults(results)
+	return results, errs
+}
+
+// result reads the kube-bench report from the log of a job's pod
+func (r *Runner) result(ctx context.Context, job *batchv1.Job) (*NodeResult, error) {
+	pods, err := r.clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + job.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the pod of job %s/%s: %w", job.Namespace, job.Name, err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		logs, err := r.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the log of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		report, err := Parse(logs)
+		if err != nil {
+			return nil, err
+		}
+		finished := job.CreationTimestamp.Time
+		if job.Status.CompletionTime != nil {
+			finished = job.Status.CompletionTime.Time
+		}
+		return &NodeResult{Node: job.Spec.Template.Spec.NodeName, Time: finished, Report: *report}, nil
+	}
+	return nil, errors.New("the pod of job " + job.Namespace + "/" + job.Name + " and its log are gone")
+}
+
+// deleteJobs removes the earlier jobs of the nodes, so only the newest
+// results are kept
+func (r *Runner) deleteJobs(ctx context.Context, nodes []string) error {
+	list, err := r.clientset.BatchV1().Jobs(r.namespace).List(ctx, metav1.ListOptions{LabelSelector: JobSelector})
+	if err != nil {
+		return fmt.Errorf("failed to list earlier kube-bench jobs: %w", err)
+	}
+	replace := make(map[string]bool)
+	for _, node := range nodes {
+		replace[node] = true
+	}
+	background := metav1.DeletePropagationBackground
+	for _, job := range list.Items {
+		if !replace[job.Spec.Template.Spec.NodeName] {
+			continue
+		}
+		err := r.clientset.BatchV1().Jobs(r.namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil {
+			return fmt.Errorf("failed to delete job %s/%s: %w", r.namespace, job.Name, err)
+		}
+	}
+	return nil
+}
+
+// job returns the Job that runs kube-bench on a node. It runs in the host's
+// PID namespace to see the kubelet and control plane processes, tolerates
+// every taint so control plane nodes are checked too, and mounts the
+// node's configuration read-only.
+func (r *Runner) job(node string) *batchv1.Job {
+	labels := make(map[string]string)
+	for _, label := range strings.Split(JobSelector, ",") {
+		key, value, _ := strings.Cut(label, "=")
+		labels[key] = value
+	}
+	backoffLimit := int32(0)
+	ttl := int32(r.ttl.Seconds())
+
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	paths := make([]string, 0, len(hostPaths))
+	for path := range hostPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for i, path := range paths {
+		name := fmt.Sprintf("host-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: hostPaths[path], ReadOnly: true})
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "trix-kube-bench-",
+			Namespace:    r.namespace,
+			Labels:       labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					NodeName:      node,
+					HostPID:       true,
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{{
+						Name:         "kube-bench",
+						Image:        r.image,
+						Command:      []string{"kube-bench", "run", "--json"},
+						VolumeMounts: mounts,
+					}},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// sortResults orders results by node name
+func sortResults(results []NodeResult) {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Node < results[j].Node
+	})
+}
@@ -0,0 +1,179 @@
+// Package kubebench runs kube-bench on the cluster's nodes and reads its CIS
+// benchmark results. Trivy Operator's compliance reports only check what the
+// API server shows; kube-bench checks the nodes themselves: config file
+// permissions, kubelet flags and the control plane's processes.
+package kubebench
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Statuses of a kube-bench check
+const (
+	StatusPass = "PASS"
+	StatusFail = "FAIL"
+	StatusWarn = "WARN" // Manual check, or the audit couldn't tell
+	StatusInfo = "INFO"
+)
+
+// Report is the JSON output of kube-bench run --json
+type Report struct {
+	Controls []Controls `json:"Controls"`
+}
+
+// Controls is one benchmark part kube-bench ran, such as the worker node
+// checks (section 4) of CIS 1.8
+type Controls struct {
+	ID              string  `json:"id"`
+	Version         string  `json:"version"` // Benchmark, e.g. cis-1.8
+	DetectedVersion string  `json:"detected_version"`
+	Text            string  `json:"text"`
+	NodeType        string  `json:"node_type"` // master, node, etcd, controlplane or policies
+	Groups          []Group `json:"tests"`
+}
+
+// Group is a section of checks, such as 4.1 Worker Node Configuration Files
+type Group struct {
+	Section string  `json:"section"`
+	Desc    string  `json:"desc"`
+	Checks  []Check `json:"results"`
+}
+
+// Check is the result of one benchmark recommendation
+type Check struct {
+	ID             string `json:"test_number"`
+	Desc           string `json:"test_desc"`
+	Remediation    string `json:"remediation"`
+	Status         string `json:"status"`
+	Scored         bool   `json:"scored"`
+	ActualValue    string `json:"actual_value"`
+	ExpectedResult string `json:"expected_result"`
+	Reason         string `json:"reason"`
+}
+
+// NodeResult is the kube-bench report of one node
+type NodeResult struct {
+	Node   string    `json:"node"`
+	Time   time.Time `json:"time"`
+	Report Report    `json:"report"`
+}
+
+// Parse reads kube-bench JSON output. Releases before 0.6 printed the list
+// of controls without the surrounding object.
+func Parse(data []byte) (*Report, error) {
+	data = []byte(strings.TrimSpace(string(data)))
+	var report Report
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &report.Controls); err != nil {
+			return nil, fmt.Errorf("failed to parse kube-bench output: %w", err)
+		}
+		return &report, nil
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse kube-bench output: %w", err)
+	}
+	if len(report.Controls) == 0 {
+		return nil, fmt.Errorf("kube-bench output has no results; was it run with --json?")
+	}
+	return &report, nil
+}
+
+// Benchmark returns the benchmark the results are of, e.g. cis-1.8
+func Benchmark(results []NodeResult) string {
+	for _, r := range results {
+		for _, c := range r.Report.Controls {
+			if c.Version != "" {
+				return c.Version
+			}
+		}
+	}
+	return "cis"
+}
+
+// Compliance merges the results of every node into benchmark controls, as
+// Trivy Operator's compliance reports have them: a control fails when it
+// fails on any node, counting the nodes it fails on, and WARN and INFO
+// checks are manual. The failing nodes are returned keyed by control ID.
+func Compliance(results []NodeResult) ([]trivy.BenchmarkControl, map[string][]trivy.ControlResource) {
+	var controls []trivy.BenchmarkControl
+	index := make(map[string]int)
+	failures := make(map[string][]trivy.ControlResource)
+	for _, r := range results {
+		for _, c := range r.Report.Controls {
+			for _, group := range c.Groups {
+				for _, check := range group.Checks {
+					i, ok := index[check.ID]
+					if !ok {
+						i = len(controls)
+						index[check.ID] = i
+						controls = append(controls, trivy.BenchmarkControl{ID: check.ID, Name: check.Desc, Status: trivy.ControlPass})
+					}
+					control := &controls[i]
+					switch check.Status {
+					case StatusFail:
+						control.Status = trivy.ControlFail
+						control.TotalFail++
+						failures[check.ID] = append(failures[check.ID], trivy.ControlResource{
+							Kind:     "Node",
+							Name:     r.Node,
+							CheckID:  check.ID,
+							Title:    check.Desc,
+							Messages: checkMessages(check),
+						})
+					case StatusWarn, StatusInfo:
+						if control.Status != trivy.ControlFail {
+							control.Status = trivy.ControlManual
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(controls, func(i, j int) bool {
+		return compareIDs(controls[i].ID, controls[j].ID) < 0
+	})
+	return controls, failures
+}
+
+// checkMessages explains a failed check: what was found and how to fix it
+func checkMessages(check Check) []string {
+	var messages []string
+	if check.ActualValue != "" {
+		messages = append(messages, "Found: "+check.ActualValue)
+	}
+	if check.ExpectedResult != "" {
+		messages = append(messages, "Expected: "+check.ExpectedResult)
+	}
+	if check.Remediation != "" {
+		messages = append(messages, check.Remediation)
+	}
+	return messages
+}
+
+// compareIDs orders dotted control IDs numerically, so 1.2.10 follows 1.2.9
+func compareIDs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		var x, y int
+		_, errX := fmt.Sscanf(pa[i], "%d", &x)
+		_, errY := fmt.Sscanf(pb[i], "%d", &y)
+		switch {
+		case errX != nil || errY != nil:
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+		case x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
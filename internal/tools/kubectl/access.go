@@ -0,0 +1,30 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CanList asks the API server whether the current user may list a resource,
+// in a namespace or cluster-wide when namespace is empty. The reason is the
+// API server's explanation, if it gives one.
+func (c *Client) CanList(ctx context.Context, group, resource, namespace string) (bool, string, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	result, err := c.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check access to %s: %w", resource, err)
+	}
+	return result.Status.Allowed, result.Status.Reason, nil
+}
@@ -2,6 +2,8 @@ package kubectl
 
 import (
 	"fmt"
+	"os"
+	"sort"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -10,18 +12,25 @@ import (
 
 // Client wraps Kubernetes client
 type Client struct {
-	clientset     *kubernetes.Clientset
+	clientset     kubernetes.Interface
 	dynamicClient dynamic.Interface
+	context       string // Set for clients not backed by the kubeconfig
+}
+
+// KubeContext is a context in the kubeconfig
+type KubeContext struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+	Current   bool   `json:"current"`
 }
 
 // NewClient creates a K8s client using default kubeconfig loading rules
-// Respects KUBECONFIG env var and ~/.kube/config
+// Respects KUBECONFIG env var and ~/.kube/config, and the context and
+// cluster selected with TRIX_CONTEXT and TRIX_CLUSTER
 func NewClient() (*Client, error) {
-	// Use default loading rules (same as kubectl)
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
-
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+	kubeConfig := clientConfig()
 
 	config, err := kubeConfig.ClientConfig()
 	if err != nil {
@@ -46,15 +55,26 @@ func NewClient() (*Client, error) {
 	}, nil
 }
 
+// NewOfflineClient creates a client backed by something other than a live
+// cluster, such as the reports of an exported bundle. contextName is
+// reported as the current context.
+func NewOfflineClient(clientset kubernetes.Interface, dynamicClient dynamic.Interface, contextName string) *Client {
+	return &Client{
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+		context:       contextName,
+	}
+}
+
 // GetCurrentContext returns the current kubectl context name
 func (c *Client) GetCurrentContext() (string, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules,
-		&clientcmd.ConfigOverrides{},
-	)
-
-	rawConfig, err := config.RawConfig()
+	if c.context != "" {
+		return c.context, nil
+	}
+	if name := os.Getenv("TRIX_CONTEXT"); name != "" {
+		return name, nil
+	}
+	rawConfig, err := clientConfig().RawConfig()
 	if err != nil {
 		return "", err
 	}
@@ -62,12 +82,50 @@ func (c *Client) GetCurrentContext() (string, error) {
 	return rawConfig.CurrentContext, nil
 }
 
+// ListContexts returns the contexts in the kubeconfig sorted by name, marking
+// the one trix uses as current
+func ListContexts() ([]KubeContext, error) {
+	rawConfig, err := clientConfig().RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	current := rawConfig.CurrentContext
+	if name := os.Getenv("TRIX_CONTEXT"); name != "" {
+		current = name
+	}
+
+	contexts := make([]KubeContext, 0, len(rawConfig.Contexts))
+	for name, kubeContext := range rawConfig.Contexts {
+		contexts = append(contexts, KubeContext{
+			Name:      name,
+			Cluster:   kubeContext.Cluster,
+			User:      kubeContext.AuthInfo,
+			Namespace: kubeContext.Namespace,
+			Current:   name == current,
+		})
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Name < contexts[j].Name
+	})
+	return contexts, nil
+}
+
+// clientConfig loads the kubeconfig the same way kubectl does, with the
+// context and cluster of TRIX_CONTEXT and TRIX_CLUSTER instead of the
+// kubeconfig's own when they're set
+func clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: os.Getenv("TRIX_CONTEXT")}
+	configOverrides.Context.Cluster = os.Getenv("TRIX_CLUSTER")
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+}
+
 // DynamicClient returns the dynamic client for CRD queries
 func (c *Client) DynamicClient() dynamic.Interface {
 	return c.dynamicClient
 }
 
 // Clientset returns the kubernetes clientset
-func (c *Client) Clientset() *kubernetes.Clientset {
+func (c *Client) Clientset() kubernetes.Interface {
 	return c.clientset
 }
@@ -0,0 +1,58 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AdminBinding is a RoleBinding or ClusterRoleBinding granting cluster-admin
+type AdminBinding struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"` // Empty for ClusterRoleBindings
+	Subjects  []string `json:"subjects"`            // Kind/name, with the namespace of ServiceAccounts
+}
+
+// ListClusterAdminBindings lists the bindings to the cluster-admin ClusterRole.
+// ClusterRoleBindings are included when listing all namespaces.
+func (c *Client) ListClusterAdminBindings(ctx context.Context, namespace string) ([]AdminBinding, error) {
+	var bindings []AdminBinding
+	if namespace == "" {
+		crbs, err := c.clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+		}
+		for _, crb := range crbs.Items {
+			if crb.RoleRef.Kind == "ClusterRole" && crb.RoleRef.Name == "cluster-admin" {
+				bindings = append(bindings, AdminBinding{Kind: "ClusterRoleBinding", Name: crb.Name, Subjects: subjectNames(crb.Subjects)})
+			}
+		}
+	}
+
+	rbs, err := c.clientset.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	for _, rb := range rbs.Items {
+		if rb.RoleRef.Kind == "ClusterRole" && rb.RoleRef.Name == "cluster-admin" {
+			bindings = append(bindings, AdminBinding{Kind: "RoleBinding", Name: rb.Name, Namespace: rb.Namespace, Subjects: subjectNames(rb.Subjects)})
+		}
+	}
+	return bindings, nil
+}
+
+// subjectNames formats the subjects of a binding as Kind/name
+func subjectNames(subjects []rbacv1.Subject) []string {
+	var names []string
+	for _, s := range subjects {
+		name := s.Kind + "/" + s.Name
+		if s.Namespace != "" {
+			name = s.Kind + "/" + s.Namespace + "/" + s.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
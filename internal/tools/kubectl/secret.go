@@ -0,0 +1,36 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetSecretValue returns one key of a Secret. An empty namespace selects the
+// current namespace: the kubeconfig context's, or the pod's when in-cluster.
+func (c *Client) GetSecretValue(ctx context.Context, namespace, name, key string) (string, error) {
+	if namespace == "" {
+		ns, _, err := clientConfig().Namespace()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine namespace: %w", err)
+		}
+		namespace = ns
+	}
+
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s/%s: %w", namespace, name, err)
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		var keys []string
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+		return "", fmt.Errorf("secret %s/%s has no key %q (keys: %s)", namespace, name, key, strings.Join(keys, ", "))
+	}
+	return strings.TrimSpace(string(value)), nil
+}
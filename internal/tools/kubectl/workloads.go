@@ -0,0 +1,76 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListNamespaces returns the names of the namespaces in the cluster
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	list, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListWorkloads returns the names of the workloads of a kind (Deployment,
+// StatefulSet, DaemonSet, CronJob or Job) in a namespace
+func (c *Client) ListWorkloads(ctx context.Context, namespace, kind string) ([]string, error) {
+	var names []string
+	opts := metav1.ListOptions{}
+	switch kind {
+	case "Deployment":
+		list, err := c.clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case "StatefulSet":
+		list, err := c.clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case "DaemonSet":
+		list, err := c.clientset.AppsV1().DaemonSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case "CronJob":
+		list, err := c.clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case "Job":
+		list, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+	sort.Strings(names)
+	return names, nil
+}
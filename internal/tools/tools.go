@@ -11,7 +11,6 @@ import (
 	"github.com/davealtena/trix/internal/llm"
 	"github.com/davealtena/trix/internal/tools/exposure"
 	"github.com/davealtena/trix/internal/tools/kubectl"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Executor runs a tools and returns the result
@@ -108,6 +107,7 @@ func (r *Registry) RegisterDefaults() {
 				"namespace": map[string]string{"type": "string", "description": "Namespace to query (optional, omit for all)"},
 				"type":      map[string]string{"type": "string", "description": "Finding type: vulnerability, compliance, rbac, secret, infra (optional)"},
 				"severity":  map[string]string{"type": "string", "description": "Filter by severity: CRITICAL, HIGH, MEDIUM, LOW (optional, recommended)"},
+				"selector":  map[string]string{"type": "string", "description": "Label selector on the reports, e.g. app=payments or trivy-operator.resource.kind=Deployment (optional)"},
 				"limit":     map[string]string{"type": "integer", "description": "Max findings to return (default 20)"},
 			},
 		},
@@ -129,7 +129,7 @@ func (r *Registry) RegisterDefaults() {
 	// trix_summary - get aggregated summary
 	r.register(llm.Tool{
 		Name:        "trix_summary",
-		Description: "Get aggregated security summary with counts by severity and type. Use this FIRST to understand the overall security posture before drilling into specific findings.",
+		Description: "Get aggregated security summary with counts by severity and type, fixable vs unfixable vulnerabilities, and the most affected namespaces, images and resources. Use this FIRST to understand the overall security posture before drilling into specific findings.",
 		Parameters: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -138,6 +138,44 @@ func (r *Registry) RegisterDefaults() {
 		},
 	}, r.trixSummary)
 
+	// trix_cve_details - advisory data from OSV/NVD plus occurrences
+	r.register(llm.Tool{
+		Name:        "trix_cve_details",
+		Description: "Look up a vulnerability (CVE or GHSA ID) in OSV.dev and the NVD: description, CVSS score and vector, CWEs, fixed versions and references, plus the images and workloads in the cluster it affects. Use this instead of relying on memory when explaining a CVE.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id": map[string]string{"type": "string", "description": "Vulnerability ID, e.g. CVE-2024-45337"},
+			},
+			"required": []string{"id"},
+		},
+	}, r.trixCVEDetails)
+
+	// trix_upgrade_plan - package upgrades per image
+	r.register(llm.Tool{
+		Name:        "trix_upgrade_plan",
+		Description: "Per image, the minimal set of package upgrades (installed version to fixed version) that clears its CRITICAL and HIGH vulnerabilities, plus the packages with no fix yet. Use this when asked how to remediate vulnerabilities.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"namespace": map[string]string{"type": "string", "description": "Namespace to query (optional, omit for all)"},
+			},
+		},
+	}, r.trixUpgradePlan)
+
+	// trix_rbac_roles - most over-privileged roles per namespace
+	r.register(llm.Tool{
+		Name:        "trix_rbac_roles",
+		Description: "List the most over-privileged Roles and ClusterRoles per namespace, ranked by the severity of their failed RBAC checks, with the failed checks of each. Use this to answer questions about RBAC and excessive permissions.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"namespace": map[string]string{"type": "string", "description": "Namespace to query (optional, omit for all including ClusterRoles)"},
+				"top":       map[string]string{"type": "integer", "description": "Roles to show per namespace (default: 3)"},
+			},
+		},
+	}, r.trixRbacRoles)
+
 	// trix_sbom_summary - SBOM overview (token-efficient)
 	r.register(llm.Tool{
 		Name:        "trix_sbom_summary",
@@ -263,6 +301,7 @@ func (r *Registry) trixFindings(ctx context.Context, params map[string]interface
 	namespace, _ := params["namespace"].(string)
 	findingType, _ := params["type"].(string)
 	severity, _ := params["severity"].(string)
+	selector, _ := params["selector"].(string)
 	limit := 20
 	if l, ok := params["limit"].(float64); ok {
 		limit = int(l)
@@ -280,6 +319,9 @@ func (r *Registry) trixFindings(ctx context.Context, params map[string]interface
 	} else {
 		args = append(args, "-A")
 	}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	}
 
 	output, err := r.runCommand(ctx, exe, args...)
 	if err != nil {
@@ -343,6 +385,60 @@ func (r *Registry) trixSummary(ctx context.Context, params map[string]interface{
 	return r.runCommand(ctx, exe, args...)
 }
 
+func (r *Registry) trixCVEDetails(ctx context.Context, params map[string]interface{}) (string, error) {
+	id, _ := params["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("id parameter is required")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	return r.runCommand(ctx, exe, "query", "cve", id, "-A", "-o", "json")
+}
+
+func (r *Registry) trixUpgradePlan(ctx context.Context, params map[string]interface{}) (string, error) {
+	namespace, _ := params["namespace"].(string)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	args := []string{"query", "upgrades", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	} else {
+		args = append(args, "-A")
+	}
+
+	return r.runCommand(ctx, exe, args...)
+}
+
+func (r *Registry) trixRbacRoles(ctx context.Context, params map[string]interface{}) (string, error) {
+	namespace, _ := params["namespace"].(string)
+	top := 3
+	if t, ok := params["top"].(float64); ok {
+		top = int(t)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	args := []string{"query", "rbac", "-d", "--top", fmt.Sprintf("%d", top)}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	} else {
+		args = append(args, "-A")
+	}
+
+	return r.runCommand(ctx, exe, args...)
+}
+
 func (r *Registry) formatFindingsCompact(jsonOutput, findingType, severity string, limit int) (string, error) {
 	var findings []map[string]interface{}
 	if err := json.Unmarshal([]byte(jsonOutput), &findings); err != nil {
@@ -374,6 +470,11 @@ func (r *Registry) formatFindingsCompact(jsonOutput, findingType, severity strin
 		typ, _ := f["type"].(string)
 		ns, _ := f["namespace"].(string)
 		name, _ := f["resourceName"].(string)
+		if owner, ok := f["owner"].(map[string]interface{}); ok {
+			kind, _ := owner["kind"].(string)
+			ownerName, _ := owner["name"].(string)
+			name = kind + "/" + ownerName
+		}
 		title, _ := f["title"].(string)
 
 		// Truncate long titles
@@ -618,6 +719,11 @@ func (r *Registry) checkExposure(ctx context.Context, params map[string]interfac
 		kind = "Deployment"
 	}
 
+	// Bundles hold no Services or Ingresses to analyze
+	if os.Getenv("TRIX_BUNDLE") != "" {
+		return "", fmt.Errorf("exposure analysis needs a live cluster and can't run from a bundle")
+	}
+
 	// Create k8s client
 	client, err := kubectl.NewClient()
 	if err != nil {
@@ -625,7 +731,7 @@ func (r *Registry) checkExposure(ctx context.Context, params map[string]interfac
 	}
 
 	// Get workload labels based on kind
-	labels, err := r.getWorkloadLabels(ctx, client, kind, name, namespace)
+	labels, err := exposure.WorkloadLabels(ctx, client.Clientset(), kind, name, namespace)
 	if err != nil {
 		return "", fmt.Errorf("failed to get workload: %w", err)
 	}
@@ -639,11 +745,7 @@ func (r *Registry) checkExposure(ctx context.Context, params map[string]interfac
 	}
 
 	// Create analyzer with all checkers
-	analyzer := exposure.NewAnalyzer(
-		exposure.NewServiceChecker(client.Clientset()),
-		exposure.NewIngressChecker(client.Clientset()),
-		exposure.NewGatewayChecker(client.Clientset(), client.DynamicClient()),
-	)
+	analyzer := exposure.NewClusterAnalyzer(client.Clientset(), client.DynamicClient())
 
 	// Run analysis
 	result, err := analyzer.Analyze(ctx, workload)
@@ -654,41 +756,3 @@ func (r *Registry) checkExposure(ctx context.Context, params map[string]interfac
 	// Return compact output for token efficiency
 	return result.CompactString(), nil
 }
-
-// getWorkloadLabels fetches the pod template labels for a workload
-func (r *Registry) getWorkloadLabels(ctx context.Context, client *kubectl.Client, kind, name, namespace string) (map[string]string, error) {
-	clientset := client.Clientset()
-
-	switch kind {
-	case "Deployment":
-		deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return nil, err
-		}
-		return deploy.Spec.Selector.MatchLabels, nil
-
-	case "DaemonSet":
-		ds, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return nil, err
-		}
-		return ds.Spec.Selector.MatchLabels, nil
-
-	case "StatefulSet":
-		sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return nil, err
-		}
-		return sts.Spec.Selector.MatchLabels, nil
-
-	case "Pod":
-		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			return nil, err
-		}
-		return pod.Labels, nil
-
-	default:
-		return nil, fmt.Errorf("unsupported workload kind: %s (use Deployment, DaemonSet, StatefulSet, or Pod)", kind)
-	}
-}
@@ -3,83 +3,207 @@ package trivy
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
-// ListClusterComplianceReports queries ClusterComplianceReport CRDs (CIS/NSA benchmarks)
-func (c *Client) ListBenchmarkReports(ctx context.Context) ([]map[string]interface{}, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clustercompliancereports",
-	}
+// ListBenchmarkReports queries ClusterComplianceReport CRDs (CIS/NSA benchmarks)
+func (c *Client) ListBenchmarkReports(ctx context.Context) ([]v1alpha1.ClusterComplianceReport, error) {
+	return c.ListClusterComplianceReports(ctx)
+}
 
-	list, err := c.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list cluster compliance reports: %w", err)
+// ConvertBenchmarkControls extracts the status of each control from a benchmark report
+func ConvertBenchmarkControls(report *v1alpha1.ClusterComplianceReport) []BenchmarkControl {
+	if report.Status.SummaryReport == nil {
+		return nil
 	}
 
-	var reports []map[string]interface{}
-	for _, item := range list.Items {
-		reports = append(reports, item.Object)
+	// The spec maps each control to the checks that implement it
+	specChecks := make(map[string][]string)
+	for _, control := range report.Spec.Compliance.Controls {
+		for _, check := range control.Checks {
+			specChecks[control.ID] = append(specChecks[control.ID], check.ID)
+		}
 	}
-	return reports, nil
-}
 
-// ParseBenchmarkControls extracts failed controls from a benchmark report
-func (c *Client) ParseBenchmarkControls(report map[string]interface{}) (string, []BenchmarkControl, error) {
 	var controls []BenchmarkControl
-
-	// Get benchmark name from metadata
-	metadata, ok := report["metadata"].(map[string]interface{})
-	if !ok {
-		return "", nil, fmt.Errorf("no metadata found")
+	for _, result := range report.Status.SummaryReport.ControlChecks {
+		control := BenchmarkControl{
+			ID:        result.ID,
+			Name:      result.Name,
+			Severity:  result.Severity,
+			TotalFail: result.TotalFail,
+			Checks:    specChecks[result.ID],
+		}
+		switch {
+		case control.TotalFail > 0:
+			control.Status = ControlFail
+		case len(control.Checks) == 0:
+			control.Status = ControlManual
+		default:
+			control.Status = ControlPass
+		}
+		controls = append(controls, control)
 	}
-	benchmarkName, _ := metadata["name"].(string)
 
-	// Get status
-	status, ok := report["status"].(map[string]interface{})
-	if !ok {
-		return benchmarkName, controls, nil
+	return controls
+}
+
+// BenchmarkFramework reports whether a benchmark report belongs to a
+// framework such as cis, nsa or pss-restricted, going by its name or the ID
+// of its spec (e.g. k8s-cis-1.23)
+func BenchmarkFramework(report *v1alpha1.ClusterComplianceReport, framework string) bool {
+	framework = "-" + strings.ToLower(framework) + "-"
+	for _, id := range []string{report.Name, report.Spec.Compliance.ID} {
+		if strings.Contains("-"+strings.ToLower(id)+"-", framework) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Get summary report
-	summaryReport, ok := status["summaryReport"].(map[string]interface{})
-	if !ok {
-		return benchmarkName, controls, nil
+// BenchmarkSections groups controls into sections by their ID without its
+// last part (control 1.2.3 is in section 1.2, NSA control 1.0 in section 1)
+// and scores each. Manual controls don't count toward the score.
+func BenchmarkSections(controls []BenchmarkControl) []BenchmarkSection {
+	var sections []BenchmarkSection
+	index := make(map[string]int)
+	for _, c := range controls {
+		id := c.ID
+		if i := strings.LastIndex(id, "."); i > 0 {
+			id = id[:i]
+		}
+		i, ok := index[id]
+		if !ok {
+			i = len(sections)
+			index[id] = i
+			sections = append(sections, BenchmarkSection{ID: id})
+		}
+		switch c.Status {
+		case ControlPass:
+			sections[i].Pass++
+		case ControlFail:
+			sections[i].Fail++
+		default:
+			sections[i].Manual++
+		}
+	}
+	for i := range sections {
+		sections[i].Score = BenchmarkScore(sections[i].Pass, sections[i].Fail)
 	}
+	return sections
+}
 
-	// Get control checks
-	controlChecks, ok := summaryReport["controlCheck"].([]interface{})
-	if !ok {
-		return benchmarkName, controls, nil
+// BenchmarkScore is the percentage of automated controls passing, or 100
+// when there are none
+func BenchmarkScore(pass, fail int) float64 {
+	if pass+fail == 0 {
+		return 100
 	}
+	return float64(pass) * 100 / float64(pass+fail)
+}
 
-	for _, item := range controlChecks {
-		checkMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
+// ControlFailures finds the resources behind failed benchmark controls, by
+// matching each control's checks against the config audit, RBAC and infra
+// assessment reports of the whole cluster. The result is keyed by control ID.
+func (c *Client) ControlFailures(ctx context.Context, controls []BenchmarkControl) (map[string][]ControlResource, error) {
+	// Index the controls by the checks they consist of
+	controlsByCheck := make(map[string][]string)
+	for _, control := range controls {
+		for _, checkID := range control.Checks {
+			key := NormalizeCheckID(checkID)
+			controlsByCheck[key] = append(controlsByCheck[key], control.ID)
 		}
+	}
 
-		totalFail := 0
-		// Try both int64 (from K8s API) and float64 (from JSON)
-		if tf, ok := checkMap["totalFail"].(int64); ok {
-			totalFail = int(tf)
-		} else if tf, ok := checkMap["totalFail"].(float64); ok {
-			totalFail = int(tf)
+	failures := make(map[string][]ControlResource)
+	add := func(meta metav1.ObjectMeta, checks []v1alpha1.Check) {
+		namespace, kind, name := v1alpha1.ScannedResource(meta)
+		for _, check := range checks {
+			if check.Success {
+				continue
+			}
+			for _, controlID := range controlsByCheck[NormalizeCheckID(check.CheckID)] {
+				failures[controlID] = append(failures[controlID], ControlResource{
+					Namespace: namespace,
+					Kind:      kind,
+					Name:      name,
+					CheckID:   check.CheckID,
+					Title:     check.Title,
+					Messages:  check.Messages,
+				})
+			}
 		}
+	}
 
-		control := BenchmarkControl{
-			ID:        getString(checkMap, "id"),
-			Name:      getString(checkMap, "name"),
-			Severity:  getString(checkMap, "severity"),
-			TotalFail: totalFail,
+	// Not every report kind is enabled in every cluster, so only fail if none could be listed
+	var errs []error
+	if reports, err := c.ListConfigAuditReports(ctx, ""); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
 		}
-
-		controls = append(controls, control)
+	} else {
+		errs = append(errs, err)
+	}
+	if reports, err := c.ListClusterConfigAuditReports(ctx); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if reports, err := c.ListRbacAssessmentReports(ctx, ""); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if reports, err := c.ListClusterRbacAssessmentReports(ctx); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if reports, err := c.ListInfraAssessmentReports(ctx, ""); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if reports, err := c.ListClusterInfraAssessmentReports(ctx); err == nil {
+		for _, r := range reports {
+			add(r.ObjectMeta, r.Report.Checks)
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if len(errs) == 6 {
+		return nil, errs[0]
 	}
 
-	return benchmarkName, controls, nil
+	return failures, nil
+}
+
+// NormalizeCheckID converts check IDs to one form, since compliance specs
+// use AVD IDs (AVD-KSV-0012) while reports use short IDs (KSV012)
+func NormalizeCheckID(id string) string {
+	id = strings.TrimPrefix(strings.ToUpper(id), "AVD-")
+	id = strings.ReplaceAll(id, "-", "")
+	i := strings.IndexFunc(id, unicode.IsDigit)
+	if i <= 0 {
+		return id
+	}
+	n, err := strconv.Atoi(id[i:])
+	if err != nil {
+		return id
+	}
+	return fmt.Sprintf("%s%03d", id[:i], n)
 }
@@ -30,10 +30,7 @@ func (s *BenchmarkScanner) Scan(ctx context.Context, _ string) ([]Finding, error
 	var findings []Finding
 
 	for _, report := range reports {
-		benchmarkName, controls, err := s.client.ParseBenchmarkControls(report)
-		if err != nil {
-			continue
-		}
+		benchmarkName, controls := report.Name, ConvertBenchmarkControls(&report)
 
 		for _, c := range controls {
 			if c.TotalFail == 0 {
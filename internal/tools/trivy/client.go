@@ -1,25 +1,55 @@
 package trivy
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/davealtena/trix/internal/tools/kubectl"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // Client wraps kubectl.Client for Trivy-specific operations
 type Client struct {
 	k8sClient     *kubectl.Client
 	dynamicClient dynamic.Interface
-	clientset     *kubernetes.Clientset
+	clientset     kubernetes.Interface
+
+	// Selectors applied to every list, watch and delete of reports
+	labelSelector string
+	fieldSelector string
+	filter        FilterOptions
+
+	pageSize    int64 // Reports fetched per list request; 0 lists all at once
+	concurrency int   // Namespaces read in parallel
+
+	owners *ownerResolver
 }
 
+// DefaultPageSize is the number of reports fetched per list request, as
+// kubectl's --chunk-size
+const DefaultPageSize = 500
+
 // NewClient creates a Trivy client from a kubectl client
 func NewClient(k8sClient *kubectl.Client) *Client {
 	return &Client{
 		k8sClient:     k8sClient,
 		dynamicClient: k8sClient.DynamicClient(),
 		clientset:     k8sClient.Clientset(),
+		pageSize:      DefaultPageSize,
+		concurrency:   1,
+		owners:        newOwnerResolver(k8sClient.Clientset()),
 	}
 }
 
@@ -27,3 +57,110 @@ func NewClient(k8sClient *kubectl.Client) *Client {
 func (c *Client) K8sClient() *kubectl.Client {
 	return c.k8sClient
 }
+
+// SetSelector scopes all report operations to the reports matching a label
+// selector (e.g. app=payments or trivy-operator.resource.kind=Deployment) and a
+// field selector (e.g. metadata.name=replicaset-web-6f7d). Empty selects all.
+func (c *Client) SetSelector(labelSelector, fieldSelector string) error {
+	if _, err := labels.Parse(labelSelector); err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	if _, err := fields.ParseSelector(fieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector: %w", err)
+	}
+	c.labelSelector = labelSelector
+	c.fieldSelector = fieldSelector
+	return nil
+}
+
+// listOptions returns the list options for the client's selectors
+func (c *Client) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: c.labelSelector, FieldSelector: c.fieldSelector}
+}
+
+// SetPageSize sets how many reports are fetched per list request. Large
+// clusters are listed in pages so neither the API server nor trix holds every
+// report in one response. 0 disables paging.
+func (c *Client) SetPageSize(n int64) {
+	c.pageSize = n
+	c.owners.pageSize = n
+}
+
+// listPages lists the reports of one kind page by page, following the continue
+// token, and calls fn with each page as it arrives
+func (c *Client) listPages(ctx context.Context, gvr schema.GroupVersionResource, namespace string, fn func(*unstructured.UnstructuredList) error) error {
+	opts := c.listOptions()
+	opts.Limit = c.pageSize
+	for {
+		list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, opts)
+		if apierrors.IsNotFound(err) {
+			// The resource itself is unknown, so the CRD is missing
+			return fmt.Errorf("no %s CRD: %w", gvr.Resource, ErrOperatorNotInstalled)
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(list); err != nil {
+			return err
+		}
+		if list.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = list.GetContinue()
+	}
+}
+
+// eachReport calls fn with every report of one kind, in all namespaces if
+// namespace is empty, converted to a typed struct with the client's filter
+// applied. Reports are fetched a page at a time, so callers that don't keep
+// them never hold more than one page. Namespaces are read in parallel but fn
+// is never called concurrently. If some namespaces fail, fn still sees the
+// reports of the others and a *PartialError is returned. what describes the
+// reports in errors.
+func eachReport[T v1alpha1.Report](ctx context.Context, c *Client, gvr schema.GroupVersionResource, namespace, what string, fn func(*T)) error {
+	namespaces := []string{namespace}
+	if !v1alpha1.ClusterScoped(gvr) {
+		namespaces = c.namespacesFor(ctx, namespace)
+	}
+
+	var mu sync.Mutex
+	return c.forEachNamespace(ctx, namespaces, func(ns string) error {
+		err := c.listPages(ctx, gvr, ns, func(list *unstructured.UnstructuredList) error {
+			if len(c.filter.Exclude) > 0 && !v1alpha1.ClusterScoped(gvr) {
+				list.Items = slices.DeleteFunc(list.Items, func(item unstructured.Unstructured) bool {
+					return c.filter.excludes(item.GetNamespace())
+				})
+			}
+			reports, err := v1alpha1.FromUnstructuredList[T](list)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipped malformed %s: %v\n", what, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for i := range reports {
+				c.filter.apply(&reports[i])
+				fn(&reports[i])
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", what, err)
+		}
+		return nil
+	})
+}
+
+// listReports lists the reports of one kind, in all namespaces if namespace is
+// empty, and converts them to typed structs with the client's filter applied.
+// On a *PartialError the reports of the readable namespaces are returned too.
+// what describes them in errors.
+func listReports[T v1alpha1.Report](ctx context.Context, c *Client, gvr schema.GroupVersionResource, namespace, what string) ([]T, error) {
+	var reports []T
+	err := eachReport(ctx, c, gvr, namespace, what, func(report *T) {
+		reports = append(reports, *report)
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+	return reports, err
+}
@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // ClusterVulnScanner scans cluster-scoped vulnerability reports
@@ -19,31 +20,21 @@ func (s *ClusterVulnScanner) Name() string {
 }
 
 func (s *ClusterVulnScanner) Scan(ctx context.Context, _ string) ([]Finding, error) {
-	reports, err := s.client.ListClusterVulnerabilityReports(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list cluster vulnerability reports: %w", err)
-	}
-
 	var findings []Finding
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-
-		vulns, err := s.client.ParseVulnerabilities(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.ClusterVulnerabilityReports, "", "cluster vulnerability reports", func(report *v1alpha1.VulnerabilityReport) {
+		name := report.Name
+		vulns := ConvertVulnerabilities(report)
 
 		for _, v := range vulns {
 			finding := VulnerabilityToFinding(v, "", name)
 			finding.ResourceKind = "Cluster"
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-	return findings, nil
+	return findings, err
 }
 
 // ClusterComplianceScanner scans cluster-scoped config audit reports
@@ -60,23 +51,10 @@ func (s *ClusterComplianceScanner) Name() string {
 }
 
 func (s *ClusterComplianceScanner) Scan(ctx context.Context, _ string) ([]Finding, error) {
-	reports, err := s.client.ListClusterConfigAuditReports(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list cluster config audit reports: %w", err)
-	}
-
 	var findings []Finding
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-
-		checks, err := s.client.ParseComplianceChecks(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.ClusterConfigAuditReports, "", "cluster config audit reports", func(report *v1alpha1.ConfigAuditReport) {
+		name := report.Name
+		checks := ConvertChecks(report.Report.Checks)
 
 		for _, c := range checks {
 			if c.Success {
@@ -86,8 +64,11 @@ func (s *ClusterComplianceScanner) Scan(ctx context.Context, _ string) ([]Findin
 			finding.ResourceKind = "Cluster"
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-	return findings, nil
+	return findings, err
 }
 
 // ClusterRbacScanner scans cluster-scoped RBAC assessment reports
@@ -104,23 +85,10 @@ func (s *ClusterRbacScanner) Name() string {
 }
 
 func (s *ClusterRbacScanner) Scan(ctx context.Context, _ string) ([]Finding, error) {
-	reports, err := s.client.ListClusterRbacAssessmentReports(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list cluster rbac assessment reports: %w", err)
-	}
-
 	var findings []Finding
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-
-		checks, err := s.client.ParseRbacChecks(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.ClusterRbacAssessmentReports, "", "cluster rbac assessment reports", func(report *v1alpha1.RbacAssessmentReport) {
+		name := report.Name
+		checks := ConvertChecks(report.Report.Checks)
 
 		for _, c := range checks {
 			if c.Success {
@@ -130,8 +98,11 @@ func (s *ClusterRbacScanner) Scan(ctx context.Context, _ string) ([]Finding, err
 			finding.ResourceKind = "ClusterRole"
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-	return findings, nil
+	return findings, err
 }
 
 // ClusterInfraScanner scans cluster-scoped infra assessment reports
@@ -148,32 +119,27 @@ func (s *ClusterInfraScanner) Name() string {
 }
 
 func (s *ClusterInfraScanner) Scan(ctx context.Context, _ string) ([]Finding, error) {
-	reports, err := s.client.ListClusterInfraAssessmentReports(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list cluster infra assessment reports: %w", err)
-	}
-
 	var findings []Finding
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
+	err := eachReport(ctx, s.client, v1alpha1.ClusterInfraAssessmentReports, "", "cluster infra assessment reports", func(report *v1alpha1.InfraAssessmentReport) {
+		// Name the assessed node or component, not the report
+		_, kind, name := v1alpha1.ScannedResource(report.ObjectMeta)
+		if kind == "" {
+			kind = "Cluster"
 		}
-		name, _ := metadata["name"].(string)
 
-		checks, err := s.client.ParseInfraChecks(report)
-		if err != nil {
-			continue
-		}
+		checks := ConvertChecks(report.Report.Checks)
 
 		for _, c := range checks {
 			if c.Success {
 				continue
 			}
 			finding := InfraCheckToFinding(c, "", name)
-			finding.ResourceKind = "Cluster"
+			finding.ResourceKind = kind
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-	return findings, nil
+	return findings, err
 }
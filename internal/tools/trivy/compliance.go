@@ -2,82 +2,87 @@ package trivy
 
 import (
 	"context"
-	"fmt"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // ListConfigAuditReports queries Trivy ConfigAuditReport CRDs
-func (c *Client) ListConfigAuditReports(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
-	// Define the GVR (GroupVersionResource) for ConfigAuditReports
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "configauditreports",
-	}
-
-	// Query the resources
-	list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list config audit reports: %w", err)
-	}
+func (c *Client) ListConfigAuditReports(ctx context.Context, namespace string) ([]v1alpha1.ConfigAuditReport, error) {
+	return listReports[v1alpha1.ConfigAuditReport](ctx, c, v1alpha1.ConfigAuditReports, namespace, "config audit reports")
+}
 
-	// Convert to slice of maps
-	var reports []map[string]interface{}
-	for _, item := range list.Items {
-		reports = append(reports, item.Object)
+// ConvertChecks converts the checks of a config audit, RBAC or infra
+// assessment report to compliance checks
+func ConvertChecks(checks []v1alpha1.Check) []ComplianceCheck {
+	var result []ComplianceCheck
+	for _, check := range checks {
+		result = append(result, ComplianceCheck{
+			CheckID:     check.CheckID,
+			Title:       check.Title,
+			Description: check.Description,
+			Severity:    check.Severity,
+			Category:    check.Category,
+			Success:     check.Success,
+			Messages:    check.Messages,
+			Remediation: check.Remediation,
+		})
 	}
-
-	return reports, nil
+	return result
 }
 
-// ParseComplianceChecks extracts compliance check details from a report
-func (c *Client) ParseComplianceChecks(report map[string]interface{}) ([]ComplianceCheck, error) {
-	var checks []ComplianceCheck
-
-	// Navigate to report.checks array
-	reportData, ok := report["report"].(map[string]interface{})
-	if !ok {
-		return checks, fmt.Errorf("no report data found")
-	}
+// Categories trix groups config audit checks in. Trivy files every check
+// under "Kubernetes Security Check", so they are assigned by check ID.
+const (
+	CheckCategorySecurityContext = "security-context"
+	CheckCategoryNetwork         = "network"
+	CheckCategoryResources       = "resources"
+	CheckCategoryOther           = "other"
+)
 
-	checksArray, ok := reportData["checks"].([]interface{})
-	if !ok {
-		return checks, nil // No checks = empty slice, not error
-	}
+// CheckCategories lists the categories in display order
+var CheckCategories = []string{CheckCategorySecurityContext, CheckCategoryNetwork, CheckCategoryResources, CheckCategoryOther}
 
-	// Parse each check
-	for _, item := range checksArray {
-		checkMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		check := ComplianceCheck{
-			CheckID:     getString(checkMap, "checkID"),
-			Title:       getString(checkMap, "title"),
-			Description: getString(checkMap, "description"),
-			Severity:    getString(checkMap, "severity"),
-			Category:    getString(checkMap, "category"),
-			Remediation: getString(checkMap, "remediation"),
-		}
+var checkCategories = map[string]string{
+	"KSV001": CheckCategorySecurityContext, // allowPrivilegeEscalation
+	"KSV002": CheckCategorySecurityContext, // AppArmor
+	"KSV003": CheckCategorySecurityContext, // Drop all capabilities
+	"KSV004": CheckCategorySecurityContext, // Drop unused capabilities
+	"KSV005": CheckCategorySecurityContext, // SYS_ADMIN
+	"KSV010": CheckCategorySecurityContext, // hostPID
+	"KSV012": CheckCategorySecurityContext, // runAsNonRoot
+	"KSV014": CheckCategorySecurityContext, // readOnlyRootFilesystem
+	"KSV017": CheckCategorySecurityContext, // privileged
+	"KSV020": CheckCategorySecurityContext, // runAsUser
+	"KSV021": CheckCategorySecurityContext, // runAsGroup
+	"KSV022": CheckCategorySecurityContext, // Non-default capabilities
+	"KSV023": CheckCategorySecurityContext, // hostPath volumes
+	"KSV025": CheckCategorySecurityContext, // SELinux
+	"KSV026": CheckCategorySecurityContext, // Unsafe sysctls
+	"KSV027": CheckCategorySecurityContext, // procMount
+	"KSV028": CheckCategorySecurityContext, // Volume types
+	"KSV029": CheckCategorySecurityContext, // Root group
+	"KSV030": CheckCategorySecurityContext, // seccomp
+	"KSV104": CheckCategorySecurityContext, // seccomp profile
+	"KSV105": CheckCategorySecurityContext, // Root user ID
+	"KSV106": CheckCategorySecurityContext, // Capabilities other than NET_BIND_SERVICE
+	"KSV118": CheckCategorySecurityContext, // Default security context
 
-		// Success is a boolean
-		if success, ok := checkMap["success"].(bool); ok {
-			check.Success = success
-		}
+	"KSV008": CheckCategoryNetwork, // hostIPC
+	"KSV009": CheckCategoryNetwork, // hostNetwork
+	"KSV024": CheckCategoryNetwork, // hostPort
+	"KSV038": CheckCategoryNetwork, // NetworkPolicy selectors
+	"KSV117": CheckCategoryNetwork, // Privileged ports
 
-		// Messages is an array of strings
-		if msgArray, ok := checkMap["messages"].([]interface{}); ok {
-			for _, msg := range msgArray {
-				if msgStr, ok := msg.(string); ok {
-					check.Messages = append(check.Messages, msgStr)
-				}
-			}
-		}
+	"KSV011": CheckCategoryResources, // CPU limits
+	"KSV015": CheckCategoryResources, // CPU requests
+	"KSV016": CheckCategoryResources, // Memory requests
+	"KSV018": CheckCategoryResources, // Memory limits
+}
 
-		checks = append(checks, check)
+// CheckCategory returns the category of a config audit check
+func CheckCategory(checkID string) string {
+	if category, ok := checkCategories[NormalizeCheckID(checkID)]; ok {
+		return category
 	}
-
-	return checks, nil
+	return CheckCategoryOther
 }
@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // TrivyComplianceScanner scans for compliance issues using Trivy Operator CRDs
@@ -22,27 +23,11 @@ func (s *TrivyComplianceScanner) Name() string {
 
 // Scan queries ConfigAuditReports and returns findings
 func (s *TrivyComplianceScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
-	reports, err := s.client.ListConfigAuditReports(ctx, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list config audit reports: %w", err)
-	}
-
 	var findings []Finding
-
-	for _, report := range reports {
-		// Extract metadata
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-		ns, _ := metadata["namespace"].(string)
-
-		// Parse compliance checks
-		checks, err := s.client.ParseComplianceChecks(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.ConfigAuditReports, namespace, "config audit reports", func(report *v1alpha1.ConfigAuditReport) {
+		name, ns := report.Name, report.Namespace
+		checks := ConvertChecks(report.Report.Checks)
+		owner := s.client.ownerOf(ctx, report.ObjectMeta)
 
 		// Convert each failed check to a Finding
 		for _, c := range checks {
@@ -50,9 +35,12 @@ func (s *TrivyComplianceScanner) Scan(ctx context.Context, namespace string) ([]
 				continue // Only report failures
 			}
 			finding := ComplianceCheckToFinding(c, ns, name)
+			finding.Owner = owner
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	return findings, nil
+	return findings, err
 }
@@ -5,137 +5,116 @@ import (
 	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // DeleteVulnerabilityReports deletes VulnerabilityReports to trigger rescan
 // Returns the number of reports deleted
 func (c *Client) DeleteVulnerabilityReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "vulnerabilityreports",
+	return c.deleteReports(ctx, v1alpha1.VulnerabilityReports, namespace)
+}
+
+// DeleteVulnerabilityReport deletes one VulnerabilityReport to trigger a
+// rescan of its workload
+func (c *Client) DeleteVulnerabilityReport(ctx context.Context, namespace, name string) error {
+	err := c.dynamicClient.Resource(v1alpha1.VulnerabilityReports).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete vulnerability report %s/%s: %w", namespace, name, err)
 	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return nil
 }
 
 // DeleteConfigAuditReports deletes ConfigAuditReports to trigger rescan
 func (c *Client) DeleteConfigAuditReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "configauditreports",
-	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return c.deleteReports(ctx, v1alpha1.ConfigAuditReports, namespace)
 }
 
 // DeleteExposedSecretReports deletes ExposedSecretReports to trigger rescan
 func (c *Client) DeleteExposedSecretReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "exposedsecretreports",
-	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return c.deleteReports(ctx, v1alpha1.ExposedSecretReports, namespace)
 }
 
 // DeleteRbacAssessmentReports deletes RbacAssessmentReports to trigger rescan
 func (c *Client) DeleteRbacAssessmentReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "rbacassessmentreports",
-	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return c.deleteReports(ctx, v1alpha1.RbacAssessmentReports, namespace)
 }
 
 // DeleteInfraAssessmentReports deletes InfraAssessmentReports to trigger rescan
 func (c *Client) DeleteInfraAssessmentReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "infraassessmentreports",
-	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return c.deleteReports(ctx, v1alpha1.InfraAssessmentReports, namespace)
 }
 
 // DeleteSbomReports deletes SbomReports to trigger rescan
 func (c *Client) DeleteSbomReports(ctx context.Context, namespace string) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "sbomreports",
-	}
-	return c.deleteReports(ctx, gvr, namespace)
+	return c.deleteReports(ctx, v1alpha1.SbomReports, namespace)
 }
 
 // DeleteClusterVulnerabilityReports deletes cluster-scoped VulnerabilityReports
 func (c *Client) DeleteClusterVulnerabilityReports(ctx context.Context) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clustervulnerabilityreports",
-	}
-	return c.deleteClusterReports(ctx, gvr)
+	return c.deleteClusterReports(ctx, v1alpha1.ClusterVulnerabilityReports)
 }
 
 // DeleteClusterConfigAuditReports deletes cluster-scoped ConfigAuditReports
 func (c *Client) DeleteClusterConfigAuditReports(ctx context.Context) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clusterconfigauditreports",
-	}
-	return c.deleteClusterReports(ctx, gvr)
+	return c.deleteClusterReports(ctx, v1alpha1.ClusterConfigAuditReports)
 }
 
 // DeleteClusterRbacAssessmentReports deletes cluster-scoped RbacAssessmentReports
 func (c *Client) DeleteClusterRbacAssessmentReports(ctx context.Context) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clusterrbacassessmentreports",
-	}
-	return c.deleteClusterReports(ctx, gvr)
+	return c.deleteClusterReports(ctx, v1alpha1.ClusterRbacAssessmentReports)
 }
 
 // DeleteClusterInfraAssessmentReports deletes cluster-scoped InfraAssessmentReports
 func (c *Client) DeleteClusterInfraAssessmentReports(ctx context.Context) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clusterinfraassessmentreports",
-	}
-	return c.deleteClusterReports(ctx, gvr)
+	return c.deleteClusterReports(ctx, v1alpha1.ClusterInfraAssessmentReports)
 }
 
 // DeleteClusterComplianceReports deletes ClusterComplianceReports (benchmarks)
 func (c *Client) DeleteClusterComplianceReports(ctx context.Context) (int, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "clustercompliancereports",
-	}
-	return c.deleteClusterReports(ctx, gvr)
+	return c.deleteClusterReports(ctx, v1alpha1.ClusterComplianceReports)
 }
 
-// deleteReports is a helper that deletes namespaced reports
+// deleteReports is a helper that deletes namespaced reports. Across all
+// namespaces, only the namespaces the filter keeps are deleted from.
 func (c *Client) deleteReports(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (int, error) {
 	// List first to get count
-	list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	namespaces := make(map[string]int)
+	err := c.listPages(ctx, gvr, namespace, func(list *unstructured.UnstructuredList) error {
+		for _, item := range list.Items {
+			if c.filter.keepNamespace(item.GetNamespace()) {
+				namespaces[item.GetNamespace()]++
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
 	}
-
-	count := len(list.Items)
-	if count == 0 {
+	if len(namespaces) == 0 {
 		return 0, nil
 	}
 
-	// Delete all
-	err = c.dynamicClient.Resource(gvr).Namespace(namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})
-	if err != nil {
-		return 0, fmt.Errorf("failed to delete %s: %w", gvr.Resource, err)
+	// Delete all, in one request unless the filter narrows the namespaces
+	scoped := namespace == "" && (len(c.filter.Namespaces) > 0 || len(c.filter.Exclude) > 0)
+	targets := []string{namespace}
+	if scoped {
+		targets = nil
+	}
+	count := 0
+	for ns, n := range namespaces {
+		count += n
+		if scoped {
+			targets = append(targets, ns)
+		}
+	}
+	for _, ns := range targets {
+		err = c.dynamicClient.Resource(gvr).Namespace(ns).DeleteCollection(ctx, metav1.DeleteOptions{}, c.listOptions())
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete %s: %w", gvr.Resource, err)
+		}
 	}
 
 	return count, nil
@@ -144,18 +123,16 @@ func (c *Client) deleteReports(ctx context.Context, gvr schema.GroupVersionResou
 // deleteClusterReports is a helper that deletes cluster-scoped reports
 func (c *Client) deleteClusterReports(ctx context.Context, gvr schema.GroupVersionResource) (int, error) {
 	// List first to get count
-	list, err := c.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+	count, err := c.countReports(ctx, gvr, "")
 	if err != nil {
-		return 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		return 0, err
 	}
-
-	count := len(list.Items)
 	if count == 0 {
 		return 0, nil
 	}
 
 	// Delete all
-	err = c.dynamicClient.Resource(gvr).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})
+	err = c.dynamicClient.Resource(gvr).DeleteCollection(ctx, metav1.DeleteOptions{}, c.listOptions())
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete %s: %w", gvr.Resource, err)
 	}
@@ -163,6 +140,28 @@ func (c *Client) deleteClusterReports(ctx context.Context, gvr schema.GroupVersi
 	return count, nil
 }
 
+// countReports counts the reports of one kind a page at a time, leaving out
+// the namespaces the filter skips
+func (c *Client) countReports(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (int, error) {
+	count := 0
+	err := c.listPages(ctx, gvr, namespace, func(list *unstructured.UnstructuredList) error {
+		if v1alpha1.ClusterScoped(gvr) {
+			count += len(list.Items)
+			return nil
+		}
+		for _, item := range list.Items {
+			if c.filter.keepNamespace(item.GetNamespace()) {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	return count, nil
+}
+
 // CountReports returns the number of reports for each type
 type ReportCounts struct {
 	VulnerabilityReports          int
@@ -178,45 +177,45 @@ type ReportCounts struct {
 	ClusterComplianceReports      int
 }
 
-// CountAllReports counts all report types
+// CountAllReports counts all report types, without parsing them
 func (c *Client) CountAllReports(ctx context.Context, namespace string) (*ReportCounts, error) {
 	counts := &ReportCounts{}
 
 	// Namespaced reports
-	if reports, err := c.ListVulnerabilityReports(ctx, namespace); err == nil {
-		counts.VulnerabilityReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.VulnerabilityReports, namespace); err == nil {
+		counts.VulnerabilityReports = n
 	}
-	if reports, err := c.ListConfigAuditReports(ctx, namespace); err == nil {
-		counts.ConfigAuditReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ConfigAuditReports, namespace); err == nil {
+		counts.ConfigAuditReports = n
 	}
-	if reports, err := c.ListExposedSecretReports(ctx, namespace); err == nil {
-		counts.ExposedSecretReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ExposedSecretReports, namespace); err == nil {
+		counts.ExposedSecretReports = n
 	}
-	if reports, err := c.ListRbacAssessmentReports(ctx, namespace); err == nil {
-		counts.RbacAssessmentReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.RbacAssessmentReports, namespace); err == nil {
+		counts.RbacAssessmentReports = n
 	}
-	if reports, err := c.ListInfraAssessmentReports(ctx, namespace); err == nil {
-		counts.InfraAssessmentReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.InfraAssessmentReports, namespace); err == nil {
+		counts.InfraAssessmentReports = n
 	}
-	if reports, err := c.ListSbomReports(ctx, namespace); err == nil {
-		counts.SbomReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.SbomReports, namespace); err == nil {
+		counts.SbomReports = n
 	}
 
 	// Cluster-scoped reports
-	if reports, err := c.ListClusterVulnerabilityReports(ctx); err == nil {
-		counts.ClusterVulnerabilityReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ClusterVulnerabilityReports, ""); err == nil {
+		counts.ClusterVulnerabilityReports = n
 	}
-	if reports, err := c.ListClusterConfigAuditReports(ctx); err == nil {
-		counts.ClusterConfigAuditReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ClusterConfigAuditReports, ""); err == nil {
+		counts.ClusterConfigAuditReports = n
 	}
-	if reports, err := c.ListClusterRbacAssessmentReports(ctx); err == nil {
-		counts.ClusterRbacAssessmentReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ClusterRbacAssessmentReports, ""); err == nil {
+		counts.ClusterRbacAssessmentReports = n
 	}
-	if reports, err := c.ListClusterInfraAssessmentReports(ctx); err == nil {
-		counts.ClusterInfraAssessmentReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ClusterInfraAssessmentReports, ""); err == nil {
+		counts.ClusterInfraAssessmentReports = n
 	}
-	if reports, err := c.ListBenchmarkReports(ctx); err == nil {
-		counts.ClusterComplianceReports = len(reports)
+	if n, err := c.countReports(ctx, v1alpha1.ClusterComplianceReports, ""); err == nil {
+		counts.ClusterComplianceReports = n
 	}
 
 	return counts, nil
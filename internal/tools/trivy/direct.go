@@ -0,0 +1,366 @@
+package trivy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+)
+
+// ErrTrivyNotFound is returned when images must be scanned directly but there
+// is no trivy binary
+var ErrTrivyNotFound = errors.New("trivy binary not found; install it from https://trivy.dev or set TRIX_TRIVY to its path")
+
+// TrivyBinary returns the path of the trivy binary: $TRIX_TRIVY if set,
+// otherwise trivy from PATH
+func TrivyBinary() (string, error) {
+	if path := os.Getenv("TRIX_TRIVY"); path != "" {
+		return path, nil
+	}
+	path, err := exec.LookPath("trivy")
+	if err != nil {
+		return "", ErrTrivyNotFound
+	}
+	return path, nil
+}
+
+// ImageScanner scans the images of running pods with the trivy binary, for
+// clusters without Trivy Operator. It produces the same vulnerability
+// findings as TrivyVulnScanner, named like the operator's reports.
+type ImageScanner struct {
+	client *Client
+	binary string
+	server string
+	token  string
+}
+
+// NewImageScanner creates a scanner that runs the given trivy binary
+func NewImageScanner(client *Client, binary string) *ImageScanner {
+	return &ImageScanner{client: client, binary: binary}
+}
+
+// SetServer makes the scanner use a Trivy server (trivy server --listen) in
+// client/server mode, so the vulnerability database is downloaded once by the
+// server rather than on every machine running trix. token is sent if the
+// server requires one.
+func (s *ImageScanner) SetServer(server, token string) {
+	s.server = server
+	s.token = token
+}
+
+// Name returns the scanner identifier
+func (s *ImageScanner) Name() string {
+	return "trivy-image"
+}
+
+// podImage is one image and the containers that run it
+type podImage struct {
+	image      string
+	digest     string
+	containers []imageContainer
+}
+
+// imageContainer is a container running an image, named like the
+// VulnerabilityReport Trivy Operator would create for it
+type imageContainer struct {
+	namespace string
+	report    string
+	kind      string
+	name      string
+	container string
+}
+
+// labels returns the labels Trivy Operator would set on the container's report
+func (c imageContainer) labels() map[string]string {
+	return map[string]string{
+		v1alpha1.LabelResourceKind:      c.kind,
+		v1alpha1.LabelResourceName:      c.name,
+		v1alpha1.LabelResourceNamespace: c.namespace,
+		v1alpha1.LabelContainerName:     c.container,
+	}
+}
+
+// Scan lists the pods, scans every distinct image once and returns a finding
+// per vulnerability and container. Images that fail to scan are returned as
+// a *PartialError alongside the findings of the others.
+func (s *ImageScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
+	reports, err := s.Reports(ctx, namespace)
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+	var findings []Finding
+	for i := range reports {
+		report := &reports[i]
+		owner := s.client.ownerOf(ctx, report.ObjectMeta)
+		for _, v := range ConvertVulnerabilities(report) {
+			finding := VulnerabilityToFinding(v, report.Namespace, report.Name)
+			finding.Owner = owner
+			findings = append(findings, finding)
+		}
+	}
+	return findings, err
+}
+
+// Reports scans every distinct pod image once and returns a filtered
+// VulnerabilityReport per container, like the ones Trivy Operator creates.
+// Images that fail to scan are returned as a *PartialError.
+func (s *ImageScanner) Reports(ctx context.Context, namespace string) ([]v1alpha1.VulnerabilityReport, error) {
+	images, err := s.podImages(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []v1alpha1.VulnerabilityReport
+	var mu sync.Mutex
+	err = forEachImage(ctx, s.client.concurrency, images, func(image *podImage) error {
+		data, err := s.scanImage(ctx, image)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range image.containers {
+			report := v1alpha1.VulnerabilityReport{
+				ObjectMeta: metav1.ObjectMeta{Name: c.report, Namespace: c.namespace, Labels: c.labels()},
+				Report:     *data,
+			}
+			// The filter edits the slice, which containers share
+			report.Report.Vulnerabilities = slices.Clone(data.Vulnerabilities)
+			s.client.filter.apply(&report)
+			reports = append(reports, report)
+		}
+		return nil
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+	return reports, err
+}
+
+// podImages returns the distinct images of the pods in a namespace, or in
+// all namespaces if it's empty. The client's selectors apply to the pods.
+func (s *ImageScanner) podImages(ctx context.Context, namespace string) ([]*podImage, error) {
+	byImage := make(map[string]*podImage)
+	for _, ns := range s.client.filter.namespaces(namespace) {
+		opts := s.client.listOptions()
+		opts.Limit = s.client.pageSize
+		for {
+			pods, err := s.client.clientset.CoreV1().Pods(ns).List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			for _, pod := range pods.Items {
+				if !s.client.filter.excludes(pod.Namespace) {
+					addPodImages(byImage, &pod)
+				}
+			}
+			if pods.Continue == "" {
+				break
+			}
+			opts.Continue = pods.Continue
+		}
+	}
+
+	images := make([]*podImage, 0, len(byImage))
+	for _, image := range byImage {
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].image < images[j].image })
+	return images, nil
+}
+
+// addPodImages adds the images of a pod's containers, keyed by digest when
+// the pod status has one so tags of the same image are scanned once
+func addPodImages(byImage map[string]*podImage, pod *corev1.Pod) {
+	digests := make(map[string]string)
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		// imageID looks like docker-pullable://nginx@sha256:...
+		if _, digest, ok := strings.Cut(status.ImageID, "@"); ok {
+			digests[status.Name] = digest
+		}
+	}
+
+	kind, name := "Pod", pod.Name
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		kind, name = owner.Kind, owner.Name
+	}
+
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		key := container.Image
+		if digest := digests[container.Name]; digest != "" {
+			key = digest
+		}
+		image, ok := byImage[key]
+		if !ok {
+			image = &podImage{image: container.Image, digest: digests[container.Name]}
+			byImage[key] = image
+		}
+		c := imageContainer{
+			namespace: pod.Namespace,
+			report:    strings.ToLower(kind) + "-" + name + "-" + container.Name,
+			kind:      kind,
+			name:      name,
+			container: container.Name,
+		}
+		// Pods of one ReplicaSet share a report
+		if !slices.Contains(image.containers, c) {
+			image.containers = append(image.containers, c)
+		}
+	}
+}
+
+// imageScanResult is the part of trivy's JSON output used here
+type imageScanResult struct {
+	Metadata struct {
+		OS *v1alpha1.OS `json:"OS"`
+	} `json:"Metadata"`
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+			PrimaryURL       string `json:"PrimaryURL"`
+			PublishedDate    string `json:"PublishedDate"`
+			CVSS             map[string]struct {
+				V3Score float64 `json:"V3Score"`
+			} `json:"CVSS"`
+		} `json:"Vulnerabilities"`
+		Target string `json:"Target"`
+		Class  string `json:"Class"`
+	} `json:"Results"`
+}
+
+// scanImage runs trivy on an image and converts its output to report data
+func (s *ImageScanner) scanImage(ctx context.Context, image *podImage) (*v1alpha1.VulnerabilityReportData, error) {
+	ref := image.image
+	if image.digest != "" {
+		// Scan what's running, not what the tag points to now
+		repo, _, _ := cutTag(image.image)
+		ref = repo + "@" + image.digest
+	}
+
+	args := []string{"image", "--quiet", "--format", "json", "--scanners", "vuln"}
+	if s.server != "" {
+		args = append(args, "--server", s.server)
+	}
+	cmd := exec.CommandContext(ctx, s.binary, append(args, ref)...)
+	if s.token != "" {
+		// Through the environment so it doesn't show in the process list
+		cmd.Env = append(os.Environ(), "TRIVY_TOKEN="+s.token)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("failed to scan %s: %s", image.image, msg)
+	}
+
+	var result imageScanResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy output for %s: %w", image.image, err)
+	}
+
+	repo, tag, _ := cutTag(image.image)
+	data := &v1alpha1.VulnerabilityReportData{
+		Artifact: v1alpha1.Artifact{Repository: repo, Tag: tag, Digest: image.digest},
+	}
+	if result.Metadata.OS != nil {
+		data.OS = *result.Metadata.OS
+	}
+	for _, r := range result.Results {
+		for _, v := range r.Vulnerabilities {
+			vuln := v1alpha1.Vulnerability{
+				VulnerabilityID:  v.VulnerabilityID,
+				Resource:         v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         v.Severity,
+				Title:            v.Title,
+				PrimaryLink:      v.PrimaryURL,
+				Target:           r.Target,
+				Class:            r.Class,
+				PublishedDate:    v.PublishedDate,
+			}
+			// Prefer the NVD score, as Trivy Operator does
+			if cvss, ok := v.CVSS["nvd"]; ok && cvss.V3Score > 0 {
+				score := cvss.V3Score
+				vuln.Score = &score
+			}
+			data.Vulnerabilities = append(data.Vulnerabilities, vuln)
+			countSeverity(&data.Summary, v.Severity)
+		}
+	}
+	return data, nil
+}
+
+// cutTag splits an image reference into its repository and tag. ok is false
+// if it has no tag.
+func cutTag(image string) (repo, tag string, ok bool) {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		// The colon belongs to a registry port
+		return image, "", false
+	}
+	return image[:i], image[i+1:], true
+}
+
+// forEachImage runs fn for every image, at most concurrency at a time. Images that
+// fail are returned as a *PartialError, unless all of them failed.
+func forEachImage(ctx context.Context, concurrency int, images []*podImage, fn func(*podImage) error) error {
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	for _, image := range images {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(image *podImage) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(image); err != nil {
+				mu.Lock()
+				failed[image.image] = err
+				mu.Unlock()
+			}
+		}(image)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	partial := &PartialError{Failed: failed, Total: len(images), What: "images"}
+	if len(failed) == len(images) {
+		return errors.New(partial.Error())
+	}
+	return partial
+}
@@ -0,0 +1,256 @@
+package trivy
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FilterOptions narrows what is read from reports. It is applied while the
+// reports are parsed, so commands never see the findings it excludes.
+type FilterOptions struct {
+	MinSeverity Severity   // Drop vulnerabilities, checks and secrets below this
+	Severities  []Severity // Only keep vulnerabilities, checks and secrets of these
+	CVEIDs      []string   // Only keep these vulnerabilities
+	FixedOnly   bool       // Only keep vulnerabilities with a fixed version
+	Namespaces  []string   // Only read namespaced reports from these namespaces
+	Exclude     []string   // Skip namespaces matching these glob patterns, e.g. kube-*
+	Suppress    Suppressor
+	Ignore      Ignorer
+}
+
+// Suppressor decides whether a vulnerability is known not to affect an image,
+// e.g. from the statements of a VEX document. Suppressed vulnerabilities are
+// dropped like filtered ones.
+type Suppressor interface {
+	Suppresses(v Vulnerability) bool
+}
+
+// Ignorer decides whether a finding is an accepted risk, e.g. from the rules
+// of an ignore file. Ignored findings are dropped like filtered ones.
+type Ignorer interface {
+	Ignores(id string, target IgnoreTarget) bool
+}
+
+// IgnoreTarget is the resource a finding was reported against
+type IgnoreTarget struct {
+	Namespace string // Empty for cluster-scoped resources
+	Kind      string
+	Name      string
+	Image     string // Empty for checks
+}
+
+// ignored reports whether the Ignorer drops a finding
+func (f FilterOptions) ignored(id string, target IgnoreTarget) bool {
+	return f.Ignore != nil && f.Ignore.Ignores(id, target)
+}
+
+// reportTarget returns the resource a report covers, with its image if any
+func reportTarget(meta metav1.ObjectMeta, image string) IgnoreTarget {
+	namespace, kind, name := v1alpha1.ScannedResource(meta)
+	return IgnoreTarget{Namespace: namespace, Kind: kind, Name: name, Image: image}
+}
+
+// severityRank orders severities from least to most severe
+var severityRank = map[Severity]int{
+	SeverityUnknown:  0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Rank orders severities from UNKNOWN (0) to CRITICAL (4)
+func (s Severity) Rank() int {
+	return severityRank[Severity(strings.ToUpper(string(s)))]
+}
+
+// ParseSeverity parses a severity name such as "high"
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToUpper(s))
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("unknown severity %q (use CRITICAL, HIGH, MEDIUM, LOW or UNKNOWN)", s)
+	}
+	return sev, nil
+}
+
+// SetFilter sets the filter applied to all reports the client reads
+func (c *Client) SetFilter(filter FilterOptions) error {
+	if filter.MinSeverity != "" {
+		sev, err := ParseSeverity(string(filter.MinSeverity))
+		if err != nil {
+			return err
+		}
+		filter.MinSeverity = sev
+	}
+	severities := make([]Severity, 0, len(filter.Severities))
+	for _, s := range filter.Severities {
+		sev, err := ParseSeverity(string(s))
+		if err != nil {
+			return err
+		}
+		severities = append(severities, sev)
+	}
+	filter.Severities = severities
+	c.filter = filter
+	return nil
+}
+
+// namespaces returns the namespaces to list namespaced reports from. A nil
+// result means the requested namespace is excluded by the filter.
+func (f FilterOptions) namespaces(namespace string) []string {
+	if namespace != "" && f.excludes(namespace) {
+		return nil
+	}
+	if len(f.Namespaces) == 0 {
+		return []string{namespace}
+	}
+	if namespace == "" {
+		return slices.DeleteFunc(slices.Clone(f.Namespaces), f.excludes)
+	}
+	if slices.Contains(f.Namespaces, namespace) {
+		return []string{namespace}
+	}
+	return nil
+}
+
+// keepNamespace reports whether namespaced reports of a namespace are read
+func (f FilterOptions) keepNamespace(namespace string) bool {
+	if len(f.Namespaces) > 0 && !slices.Contains(f.Namespaces, namespace) {
+		return false
+	}
+	return !f.excludes(namespace)
+}
+
+// excludes reports whether a namespace matches one of the Exclude patterns
+func (f FilterOptions) excludes(namespace string) bool {
+	for _, pattern := range f.Exclude {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filtersSeverity reports whether MinSeverity or Severities drop findings
+func (f FilterOptions) filtersSeverity() bool {
+	return f.MinSeverity != "" || len(f.Severities) > 0
+}
+
+// keepSeverity reports whether a finding of this severity passes
+// MinSeverity and Severities
+func (f FilterOptions) keepSeverity(severity string) bool {
+	sev := Severity(strings.ToUpper(severity))
+	if len(f.Severities) > 0 && !slices.Contains(f.Severities, sev) {
+		return false
+	}
+	if f.MinSeverity == "" {
+		return true
+	}
+	return severityRank[sev] >= severityRank[f.MinSeverity]
+}
+
+// keepVulnerability reports whether a vulnerability passes the filter
+func (f FilterOptions) keepVulnerability(v v1alpha1.Vulnerability) bool {
+	if !f.keepSeverity(v.Severity) {
+		return false
+	}
+	if f.FixedOnly && v.FixedVersion == "" {
+		return false
+	}
+	if len(f.CVEIDs) > 0 && !slices.ContainsFunc(f.CVEIDs, func(id string) bool { return strings.EqualFold(id, v.VulnerabilityID) }) {
+		return false
+	}
+	return true
+}
+
+// suppressed reports whether the Suppressor drops a vulnerability of an image
+func (f FilterOptions) suppressed(v v1alpha1.Vulnerability, image, digest string) bool {
+	if f.Suppress == nil {
+		return false
+	}
+	return f.Suppress.Suppresses(Vulnerability{
+		VulnerabilityID:  v.VulnerabilityID,
+		PkgName:          v.Resource,
+		InstalledVersion: v.InstalledVersion,
+		FixedVersion:     v.FixedVersion,
+		Severity:         v.Severity,
+		Title:            v.Title,
+		Image:            image,
+		Digest:           digest,
+	})
+}
+
+// apply removes the filtered findings from a report and recounts its summary
+func (f FilterOptions) apply(report any) {
+	switch r := report.(type) {
+	case *v1alpha1.VulnerabilityReport:
+		if !f.filtersSeverity() && !f.FixedOnly && len(f.CVEIDs) == 0 && f.Suppress == nil && f.Ignore == nil {
+			return
+		}
+		image := r.Report.Artifact.Image(r.Report.Registry)
+		target := reportTarget(r.ObjectMeta, image)
+		r.Report.Vulnerabilities = slices.DeleteFunc(r.Report.Vulnerabilities, func(v v1alpha1.Vulnerability) bool {
+			return !f.keepVulnerability(v) || f.suppressed(v, image, r.Report.Artifact.Digest) || f.ignored(v.VulnerabilityID, target)
+		})
+		r.Report.Summary = v1alpha1.SeveritySummary{}
+		for _, v := range r.Report.Vulnerabilities {
+			countSeverity(&r.Report.Summary, v.Severity)
+		}
+	case *v1alpha1.ConfigAuditReport:
+		f.applyChecks(&r.Report, reportTarget(r.ObjectMeta, ""))
+	case *v1alpha1.RbacAssessmentReport:
+		f.applyChecks(&r.Report, reportTarget(r.ObjectMeta, ""))
+	case *v1alpha1.InfraAssessmentReport:
+		f.applyChecks(&r.Report, reportTarget(r.ObjectMeta, ""))
+	case *v1alpha1.ExposedSecretReport:
+		if !f.filtersSeverity() && f.Ignore == nil {
+			return
+		}
+		target := reportTarget(r.ObjectMeta, r.Report.Artifact.Image(r.Report.Registry))
+		r.Report.Secrets = slices.DeleteFunc(r.Report.Secrets, func(s v1alpha1.ExposedSecret) bool {
+			return !f.keepSeverity(s.Severity) || f.ignored(s.RuleID, target)
+		})
+		r.Report.Summary = v1alpha1.SeveritySummary{}
+		for _, s := range r.Report.Secrets {
+			countSeverity(&r.Report.Summary, s.Severity)
+		}
+	}
+}
+
+// applyChecks filters the checks of a config audit, RBAC or infra report.
+// The summary counts failed checks, as Trivy Operator's does.
+func (f FilterOptions) applyChecks(report *v1alpha1.CheckReportData, target IgnoreTarget) {
+	if !f.filtersSeverity() && f.Ignore == nil {
+		return
+	}
+	report.Checks = slices.DeleteFunc(report.Checks, func(c v1alpha1.Check) bool {
+		return !f.keepSeverity(c.Severity) || f.ignored(c.CheckID, target)
+	})
+	report.Summary = v1alpha1.SeveritySummary{}
+	for _, c := range report.Checks {
+		if !c.Success {
+			countSeverity(&report.Summary, c.Severity)
+		}
+	}
+}
+
+// countSeverity adds one finding of the given severity to a summary
+func countSeverity(summary *v1alpha1.SeveritySummary, severity string) {
+	switch Severity(strings.ToUpper(severity)) {
+	case SeverityCritical:
+		summary.CriticalCount++
+	case SeverityHigh:
+		summary.HighCount++
+	case SeverityMedium:
+		summary.MediumCount++
+	case SeverityLow:
+		summary.LowCount++
+	default:
+		summary.UnknownCount++
+	}
+}
@@ -37,6 +37,7 @@ type Finding struct {
 	Namespace    string `json:"namespace,omitempty"`
 	ResourceKind string `json:"resourceKind,omitempty"`
 	ResourceName string `json:"resourceName,omitempty"`
+	Owner        *Owner `json:"owner,omitempty"` // Controller humans manage, when resolved
 
 	// Description
 	Title       string `json:"title"`
@@ -51,8 +52,21 @@ type Finding struct {
 	RawData interface{} `json:"rawData,omitempty"`
 }
 
+// Resource returns what a finding is shown against: the owning controller as
+// Kind/name when resolved, the report's resource otherwise
+func (f Finding) Resource() string {
+	if f.Owner != nil {
+		return f.Owner.String()
+	}
+	return f.ResourceName
+}
+
 // VulnerabilityToFinding converts a Trivy vulnerability to a Finding
 func VulnerabilityToFinding(v Vulnerability, namespace, resourceName string) Finding {
+	description := fmt.Sprintf("%s %s (installed: %s, fixed: %s)", v.PkgName, v.VulnerabilityID, v.InstalledVersion, v.FixedVersion)
+	if v.Image != "" {
+		description += " in " + v.Image
+	}
 	return Finding{
 		ID:           v.VulnerabilityID,
 		Type:         FindingTypeVulnerability,
@@ -62,7 +76,7 @@ func VulnerabilityToFinding(v Vulnerability, namespace, resourceName string) Fin
 		ResourceKind: "Pod",
 		ResourceName: resourceName,
 		Title:        v.Title,
-		Description:  fmt.Sprintf("%s %s (installed: %s, fixed: %s)", v.PkgName, v.VulnerabilityID, v.InstalledVersion, v.FixedVersion),
+		Description:  description,
 		Remediation:  fmt.Sprintf("Update %s to version %s", v.PkgName, v.FixedVersion),
 		Source:       "trivy",
 		RawData:      v,
@@ -0,0 +1,194 @@
+package trivy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+)
+
+// MinTrivyOperatorVersion is the oldest supported Trivy Operator
+const MinTrivyOperatorVersion = "0.20.0"
+
+// StaleReportAge is how old the newest report may get before scanning is
+// considered stalled. Trivy Operator rescans daily by default.
+const StaleReportAge = 48 * time.Hour
+
+// installHint tells users how to install the operator
+const installHint = "install it with 'trix operator install', or: helm install trivy-operator aqua/trivy-operator --repo https://aquasecurity.github.io/helm-charts/ -n trivy-system --create-namespace"
+
+// ErrOperatorNotInstalled is returned when a report CRD doesn't exist
+var ErrOperatorNotInstalled = errors.New("Trivy Operator is not installed; " + installHint)
+
+// OperatorHealth describes whether Trivy Operator is installed, running and
+// producing reports
+type OperatorHealth struct {
+	Installed   bool     // The report CRDs exist
+	MissingCRDs []string // Report resources the cluster doesn't serve
+
+	Deployment string // Name of the operator deployment; empty if not found
+	Namespace  string
+	Version    string
+	Ready      int32 // Ready replicas of the operator deployment
+	Desired    int32
+
+	Reports      int       // VulnerabilityReports in the cluster
+	NewestReport time.Time // Zero if there are none
+
+	Problems []string // Actionable descriptions of what's wrong
+}
+
+// Healthy reports whether no problems were found
+func (h *OperatorHealth) Healthy() bool {
+	return len(h.Problems) == 0
+}
+
+// OperatorInstalled reports whether the report CRDs exist. It only returns
+// false when the API server says so, not when it can't be asked.
+func (c *Client) OperatorInstalled() bool {
+	_, err := c.servedReports()
+	return !apierrors.IsNotFound(err)
+}
+
+// CheckOperatorHealth checks that the report CRDs exist, the operator
+// deployment is running, and reports are being written
+func (c *Client) CheckOperatorHealth(ctx context.Context) *OperatorHealth {
+	h := &OperatorHealth{}
+
+	served, err := c.servedReports()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			h.Problems = append(h.Problems, ErrOperatorNotInstalled.Error())
+		} else {
+			h.Problems = append(h.Problems, fmt.Sprintf("can't check for Trivy Operator CRDs: %v", err))
+		}
+		return h
+	}
+	h.Installed = true
+	for _, gvr := range v1alpha1.AllReports {
+		if !served[gvr.Resource] {
+			h.MissingCRDs = append(h.MissingCRDs, gvr.Resource)
+		}
+	}
+
+	deploy, err := c.findOperatorDeployment(ctx)
+	switch {
+	case err != nil:
+		h.Problems = append(h.Problems, fmt.Sprintf("can't find the Trivy Operator deployment: %v", err))
+	case deploy == nil:
+		h.Problems = append(h.Problems, "the report CRDs exist but no Trivy Operator deployment was found, so reports won't be updated; "+installHint)
+	default:
+		h.Deployment = deploy.Name
+		h.Namespace = deploy.Namespace
+		h.Version = deploymentVersion(deploy)
+		h.Ready = deploy.Status.ReadyReplicas
+		h.Desired = 1
+		if deploy.Spec.Replicas != nil {
+			h.Desired = *deploy.Spec.Replicas
+		}
+		if h.Ready == 0 {
+			h.Problems = append(h.Problems, fmt.Sprintf("the Trivy Operator deployment has no ready replicas; check 'kubectl -n %s get pods' and its logs", h.Namespace))
+		}
+		if h.Version != "" && compareVersions(h.Version, MinTrivyOperatorVersion) < 0 {
+			h.Problems = append(h.Problems, fmt.Sprintf("Trivy Operator %s is older than the minimum supported %s; upgrade it", h.Version, MinTrivyOperatorVersion))
+		}
+	}
+
+	if served[v1alpha1.VulnerabilityReports.Resource] {
+		err := c.listPages(ctx, v1alpha1.VulnerabilityReports, "", func(list *unstructured.UnstructuredList) error {
+			h.Reports += len(list.Items)
+			for _, item := range list.Items {
+				if created := item.GetCreationTimestamp().Time; created.After(h.NewestReport) {
+					h.NewestReport = created
+				}
+			}
+			return nil
+		})
+		switch {
+		case err != nil:
+			h.Problems = append(h.Problems, fmt.Sprintf("can't list vulnerability reports: %v", err))
+		case h.Reports == 0:
+			h.Problems = append(h.Problems, "no vulnerability reports yet; the first scans can take a few minutes after installing")
+		case time.Since(h.NewestReport) > StaleReportAge:
+			h.Problems = append(h.Problems, fmt.Sprintf("the newest vulnerability report is %s old, so scanning may have stalled; check the operator logs", time.Since(h.NewestReport).Round(time.Hour)))
+		}
+	}
+	return h
+}
+
+// findOperatorDeployment returns the Trivy Operator deployment, or nil if
+// there is none. It's found by its Helm labels in any namespace, falling back
+// to the default trivy-system/trivy-operator.
+func (c *Client) findOperatorDeployment(ctx context.Context) (*appsv1.Deployment, error) {
+	list, err := c.clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=trivy-operator"})
+	if err == nil && len(list.Items) > 0 {
+		return &list.Items[0], nil
+	}
+
+	deploy, getErr := c.clientset.AppsV1().Deployments("trivy-system").Get(ctx, "trivy-operator", metav1.GetOptions{})
+	if getErr == nil {
+		return deploy, nil
+	}
+	if apierrors.IsNotFound(getErr) {
+		if err != nil && !apierrors.IsForbidden(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return nil, getErr
+}
+
+// deploymentVersion returns the operator version from its labels or image tag
+func deploymentVersion(deploy *appsv1.Deployment) string {
+	if version := deploy.Labels["app.kubernetes.io/version"]; version != "" {
+		return version
+	}
+	for _, container := range deploy.Spec.Template.Spec.Containers {
+		// Image format aquasec/trivy-operator:0.29.0
+		if i := strings.LastIndex(container.Image, ":"); i >= 0 && !strings.Contains(container.Image[i:], "/") {
+			return container.Image[i+1:]
+		}
+	}
+	return ""
+}
+
+// compareVersions compares two dotted versions numerically, ignoring a
+// leading "v" and any pre-release suffix. Unparsable parts count as 0.
+func compareVersions(a, b string) int {
+	parse := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		v, _, _ = strings.Cut(v, "-")
+		var parts []int
+		for _, p := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(p)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	pa, pb := parse(a), parse(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // TrivyInfraScanner scans for infrastructure issues using Trivy Operator CRDs
@@ -22,34 +23,26 @@ func (s *TrivyInfraScanner) Name() string {
 
 // Scan queries InfraAssessmentReports and returns findings
 func (s *TrivyInfraScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
-	reports, err := s.client.ListInfraAssessmentReports(ctx, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list infra assessment reports: %w", err)
-	}
-
 	var findings []Finding
+	err := eachReport(ctx, s.client, v1alpha1.InfraAssessmentReports, namespace, "infra assessment reports", func(report *v1alpha1.InfraAssessmentReport) {
+		// Name the assessed component (e.g. the kube-apiserver pod), not the report
+		ns, kind, name := v1alpha1.ScannedResource(report.ObjectMeta)
 
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-		ns, _ := metadata["namespace"].(string)
-
-		checks, err := s.client.ParseInfraChecks(report)
-		if err != nil {
-			continue
-		}
+		checks := ConvertChecks(report.Report.Checks)
 
 		for _, c := range checks {
 			if c.Success {
 				continue
 			}
 			finding := InfraCheckToFinding(c, ns, name)
+			if kind != "" {
+				finding.ResourceKind = kind
+			}
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	return findings, nil
+	return findings, err
 }
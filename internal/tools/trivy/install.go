@@ -0,0 +1,121 @@
+package trivy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Where Trivy Operator is installed from
+const (
+	OperatorChart     = "trivy-operator"
+	OperatorChartRepo = "https://aquasecurity.github.io/helm-charts/"
+	OperatorRelease   = "trivy-operator"
+	OperatorNamespace = "trivy-system"
+
+	// OperatorManifestVersion is the release whose static manifests are
+	// applied when no version is given, as they're published per tag
+	OperatorManifestVersion = "0.29.0"
+)
+
+// ErrHelmNotFound is returned when the operator is installed with Helm but
+// there is no helm binary
+var ErrHelmNotFound = errors.New("helm binary not found; install it from https://helm.sh or use --manifests to apply the static manifests with kubectl")
+
+// ErrKubectlNotFound is returned when the operator is installed from its
+// manifests but there is no kubectl binary
+var ErrKubectlNotFound = errors.New("kubectl binary not found; install it from https://kubernetes.io/docs/tasks/tools/")
+
+// InstallOptions configures installing or upgrading Trivy Operator
+type InstallOptions struct {
+	Context   string   // Kubeconfig context; default the current one
+	Cluster   string   // Kubeconfig cluster instead of the context's
+	Namespace string   // Default OperatorNamespace
+	Version   string   // Chart or operator version; default the latest chart
+	Values    []string // Helm --set values, e.g. operator.scanJobsConcurrentLimit=5
+	Manifests bool     // Apply the static manifests with kubectl instead of Helm
+	Upgrade   bool     // Keep the values of the installed release
+	DryRun    bool     // Show what would change without changing it
+}
+
+// InstallCommand returns the helm or kubectl command that installs or
+// upgrades the operator. Helm installs are idempotent (helm upgrade
+// --install), create the namespace and wait for the operator to be ready.
+func InstallCommand(opts InstallOptions) ([]string, error) {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = OperatorNamespace
+	}
+
+	if opts.Manifests {
+		if namespace != OperatorNamespace {
+			return nil, fmt.Errorf("the static manifests install into %s; use Helm for another namespace", OperatorNamespace)
+		}
+		if len(opts.Values) > 0 {
+			return nil, errors.New("values can only be set when installing with Helm")
+		}
+		kubectl, err := exec.LookPath("kubectl")
+		if err != nil {
+			return nil, ErrKubectlNotFound
+		}
+		version := strings.TrimPrefix(opts.Version, "v")
+		if version == "" {
+			version = OperatorManifestVersion
+		}
+		args := []string{kubectl, "apply", "--server-side", "--force-conflicts",
+			"-f", "https://raw.githubusercontent.com/aquasecurity/trivy-operator/v" + version + "/deploy/static/trivy-operator.yaml"}
+		if opts.Context != "" {
+			args = append(args, "--context", opts.Context)
+		}
+		if opts.Cluster != "" {
+			args = append(args, "--cluster", opts.Cluster)
+		}
+		if opts.DryRun {
+			args = append(args, "--dry-run=server")
+		}
+		return args, nil
+	}
+
+	if opts.Cluster != "" {
+		return nil, errors.New("helm can't select a cluster other than the context's; use --context, or --manifests")
+	}
+	helm, err := HelmBinary()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{helm, "upgrade", "--install", OperatorRelease, OperatorChart,
+		"--repo", OperatorChartRepo,
+		"--namespace", namespace, "--create-namespace",
+		"--wait", "--timeout", "5m"}
+	if opts.Context != "" {
+		args = append(args, "--kube-context", opts.Context)
+	}
+	if opts.Version != "" {
+		args = append(args, "--version", strings.TrimPrefix(opts.Version, "v"))
+	}
+	if opts.Upgrade {
+		args = append(args, "--reset-then-reuse-values")
+	}
+	for _, value := range opts.Values {
+		args = append(args, "--set", value)
+	}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	return args, nil
+}
+
+// HelmBinary returns the path of the helm binary: $TRIX_HELM if set,
+// otherwise helm from PATH
+func HelmBinary() (string, error) {
+	if path := os.Getenv("TRIX_HELM"); path != "" {
+		return path, nil
+	}
+	path, err := exec.LookPath("helm")
+	if err != nil {
+		return "", ErrHelmNotFound
+	}
+	return path, nil
+}
@@ -0,0 +1,117 @@
+package trivy
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// OperatorScope is which namespaces Trivy Operator scans, from the
+// OPERATOR_TARGET_NAMESPACES and OPERATOR_EXCLUDE_NAMESPACES environment of
+// its deployment
+type OperatorScope struct {
+	Target  []string // Empty for all namespaces
+	Exclude []string // Namespaces or glob patterns, e.g. kube-*
+}
+
+// OperatorScope returns the namespaces the operator deployment scans, or nil
+// when there is no operator deployment
+func (c *Client) OperatorScope(ctx context.Context) (*OperatorScope, error) {
+	deploy, err := c.findOperatorDeployment(ctx)
+	if err != nil || deploy == nil {
+		return nil, err
+	}
+	scope := &OperatorScope{}
+	for _, container := range deploy.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			switch env.Name {
+			case "OPERATOR_TARGET_NAMESPACES":
+				scope.Target = splitNamespaces(env.Value)
+			case "OPERATOR_EXCLUDE_NAMESPACES":
+				scope.Exclude = splitNamespaces(env.Value)
+			}
+		}
+	}
+	return scope, nil
+}
+
+// splitNamespaces splits a comma-separated namespace list
+func splitNamespaces(s string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(s, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// Covers reports whether the operator scans a namespace, and if not, why
+func (s *OperatorScope) Covers(namespace string) (bool, string) {
+	if len(s.Target) > 0 && !slices.Contains(s.Target, namespace) {
+		return false, "not a target namespace"
+	}
+	for _, pattern := range s.Exclude {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return false, "excluded"
+		}
+	}
+	return true, ""
+}
+
+// NamespaceScans are the reports of one namespace
+type NamespaceScans struct {
+	Reports  int
+	LastScan time.Time // When the newest report was written
+}
+
+// ScansByNamespace counts the namespaced reports of every kind per namespace
+// and finds when each namespace was last scanned. Report kinds the cluster
+// doesn't serve and namespaces the filter skips are left out.
+func (c *Client) ScansByNamespace(ctx context.Context) (map[string]*NamespaceScans, error) {
+	scans := make(map[string]*NamespaceScans)
+	listed := false
+	var lastErr error
+	for _, gvr := range v1alpha1.AllReports {
+		if v1alpha1.ClusterScoped(gvr) {
+			continue
+		}
+		err := c.listPages(ctx, gvr, "", func(list *unstructured.UnstructuredList) error {
+			for _, item := range list.Items {
+				if !c.filter.keepNamespace(item.GetNamespace()) {
+					continue
+				}
+				s, ok := scans[item.GetNamespace()]
+				if !ok {
+					s = &NamespaceScans{}
+					scans[item.GetNamespace()] = s
+				}
+				s.Reports++
+				scanned := item.GetCreationTimestamp().Time
+				if updated, ok, _ := unstructured.NestedString(item.Object, "report", "updateTimestamp"); ok {
+					if t, err := time.Parse(time.RFC3339, updated); err == nil {
+						scanned = t
+					}
+				}
+				if scanned.After(s.LastScan) {
+					s.LastScan = scanned
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		listed = true
+	}
+	if !listed && lastErr != nil {
+		return nil, lastErr
+	}
+	return scans, nil
+}
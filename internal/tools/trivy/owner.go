@@ -0,0 +1,148 @@
+package trivy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxOwnerDepth bounds how far owner references are followed
+const maxOwnerDepth = 5
+
+// Owner is the resource humans manage that a finding belongs to, such as the
+// Deployment of the ReplicaSet Trivy Operator scanned
+type Owner struct {
+	Kind  string   `json:"kind"`
+	Name  string   `json:"name"`
+	Chain []string `json:"chain"` // Kind/name from the scanned resource up to the owner
+}
+
+// String returns the owner as Kind/name
+func (o *Owner) String() string {
+	return o.Kind + "/" + o.Name
+}
+
+// ownerRef is a controller reference, or none when kind is empty
+type ownerRef struct {
+	kind, name string
+}
+
+// ownerResolver follows controller owner references. The owners of a kind
+// are listed once per namespace, so a namespace with hundreds of reports
+// costs a few list requests rather than a get per report.
+type ownerResolver struct {
+	clientset kubernetes.Interface
+	pageSize  int64
+	mu        sync.Mutex
+	owners    map[string]ownerRef // namespace/kind/name -> controller
+	loaded    map[string]bool     // namespace/kind
+}
+
+func newOwnerResolver(clientset kubernetes.Interface) *ownerResolver {
+	return &ownerResolver{
+		clientset: clientset,
+		pageSize:  DefaultPageSize,
+		owners:    make(map[string]ownerRef),
+		loaded:    make(map[string]bool),
+	}
+}
+
+// ownerOf resolves the resource a report scanned up to its top controller,
+// e.g. ReplicaSet to Deployment or Job to CronJob. It returns nil for reports
+// without the resource labels. Owners that can't be read, for lack of RBAC
+// permissions or because they're gone, end the chain.
+func (c *Client) ownerOf(ctx context.Context, meta metav1.ObjectMeta) *Owner {
+	namespace, kind, name := v1alpha1.ScannedResource(meta)
+	if kind == "" {
+		return nil
+	}
+	owner := &Owner{Kind: kind, Name: name, Chain: []string{kind + "/" + name}}
+	for range maxOwnerDepth {
+		ref := c.owners.controllerOf(ctx, namespace, owner.Kind, owner.Name)
+		if ref.kind == "" {
+			break
+		}
+		owner.Kind, owner.Name = ref.kind, ref.name
+		owner.Chain = append(owner.Chain, ref.kind+"/"+ref.name)
+	}
+	return owner
+}
+
+// controllerOf returns the controller of a resource, or none for kinds that
+// are the top of a chain: Deployments, StatefulSets, DaemonSets and CronJobs
+func (r *ownerResolver) controllerOf(ctx context.Context, namespace, kind, name string) ownerRef {
+	switch kind {
+	case "ReplicaSet", "Job", "Pod":
+	default:
+		return ownerRef{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded[namespace+"/"+kind] {
+		r.load(ctx, namespace, kind)
+	}
+	return r.owners[namespace+"/"+kind+"/"+name]
+}
+
+// load lists the resources of a kind in a namespace and records their
+// controllers. Kinds the user may not list are remembered as having none;
+// other errors leave the namespace to be listed again on the next lookup.
+func (r *ownerResolver) load(ctx context.Context, namespace, kind string) {
+	opts := metav1.ListOptions{Limit: r.pageSize}
+	for {
+		var items []metav1.ObjectMeta
+		var next string
+		var err error
+		switch kind {
+		case "ReplicaSet":
+			var list *appsv1.ReplicaSetList
+			if list, err = r.clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts); err == nil {
+				for _, item := range list.Items {
+					items = append(items, item.ObjectMeta)
+				}
+				next = list.Continue
+			}
+		case "Job":
+			var list *batchv1.JobList
+			if list, err = r.clientset.BatchV1().Jobs(namespace).List(ctx, opts); err == nil {
+				for _, item := range list.Items {
+					items = append(items, item.ObjectMeta)
+				}
+				next = list.Continue
+			}
+		case "Pod":
+			var list *corev1.PodList
+			if list, err = r.clientset.CoreV1().Pods(namespace).List(ctx, opts); err == nil {
+				for _, item := range list.Items {
+					items = append(items, item.ObjectMeta)
+				}
+				next = list.Continue
+			}
+		}
+		if err != nil {
+			if apierrors.IsForbidden(err) {
+				r.loaded[namespace+"/"+kind] = true
+			}
+			return
+		}
+
+		for _, meta := range items {
+			if controller := metav1.GetControllerOf(&meta); controller != nil {
+				r.owners[namespace+"/"+kind+"/"+meta.Name] = ownerRef{kind: controller.Kind, name: controller.Name}
+			}
+		}
+		if next == "" {
+			break
+		}
+		opts.Continue = next
+	}
+	r.loaded[namespace+"/"+kind] = true
+}
@@ -0,0 +1,128 @@
+package trivy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PartialError reports the namespaces whose reports couldn't be read. The
+// reports of the other namespaces are still returned alongside it.
+type PartialError struct {
+	Failed map[string]error // Error per namespace
+	Total  int              // Namespaces read
+	What   string           // What failed, "namespaces" if empty
+}
+
+func (e *PartialError) Error() string {
+	what := e.What
+	if what == "" {
+		what = "namespaces"
+	}
+
+	namespaces := make([]string, 0, len(e.Failed))
+	for ns := range e.Failed {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var failures []string
+	for _, ns := range namespaces {
+		failures = append(failures, fmt.Sprintf("%s: %v", ns, e.Failed[ns]))
+	}
+	return fmt.Sprintf("failed to read %d of %d %s: %s", len(e.Failed), e.Total, what, strings.Join(failures, "; "))
+}
+
+// IsPartial reports whether err is a *PartialError, i.e. whether the results
+// returned with it are still usable
+func IsPartial(err error) bool {
+	var partial *PartialError
+	return errors.As(err, &partial)
+}
+
+// SetConcurrency sets how many namespaces are read in parallel. Above 1,
+// all-namespace queries list each namespace separately instead of making one
+// cluster-wide request, so a namespace that fails doesn't fail the query.
+func (c *Client) SetConcurrency(n int) {
+	c.concurrency = n
+}
+
+// namespacesFor returns the namespaces to read namespaced reports from
+func (c *Client) namespacesFor(ctx context.Context, namespace string) []string {
+	if len(c.filter.Namespaces) > 0 || namespace != "" || c.concurrency <= 1 {
+		return c.filter.namespaces(namespace)
+	}
+
+	list, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// Not allowed to list namespaces; a cluster-wide request may still work
+		return []string{""}
+	}
+	namespaces := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		if !c.filter.excludes(ns.Name) {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces
+}
+
+// forEachNamespace runs fn for every namespace, at most c.concurrency at a
+// time. It stops starting new namespaces once ctx is done. Namespaces that
+// fail are returned as a *PartialError, unless all of them failed.
+func (c *Client) forEachNamespace(ctx context.Context, namespaces []string, fn func(namespace string) error) error {
+	if len(namespaces) == 1 {
+		return fn(namespaces[0])
+	}
+
+	concurrency := max(c.concurrency, 1)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	for _, ns := range namespaces {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ns); err != nil {
+				mu.Lock()
+				failed[ns] = err
+				mu.Unlock()
+			}
+		}(ns)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	partial := &PartialError{Failed: failed, Total: len(namespaces)}
+	if len(failed) == len(namespaces) {
+		// Nothing to return, so it's not a partial result. A missing
+		// operator fails every namespace the same way; say so once.
+		for _, err := range failed {
+			if errors.Is(err, ErrOperatorNotInstalled) {
+				return err
+			}
+		}
+		return errors.New(partial.Error())
+	}
+	return partial
+}
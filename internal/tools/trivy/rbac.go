@@ -2,76 +2,115 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+	"sort"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
-// ListRbacAssessmentReports queries Trivy RbacAssessmentReport CRDs
-func (c *Client) ListRbacAssessmentReports(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "rbacassessmentreports",
-	}
+// RBAC risks 'trix rbac' puts first
+const (
+	RBACRiskClusterAdmin = "cluster-admin"
+	RBACRiskWildcard     = "wildcard"
+	RBACRiskSecrets      = "secrets"
+)
 
-	list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list rbac assessment reports: %w", err)
-	}
+// rbacRisks maps the checks that detect each risk to it
+var rbacRisks = map[string]string{
+	"KSV041": RBACRiskSecrets,      // Manage secrets
+	"KSV044": RBACRiskWildcard,     // Wildcard verbs on wildcard resources
+	"KSV045": RBACRiskWildcard,     // Wildcard verbs
+	"KSV046": RBACRiskWildcard,     // Manage all resources
+	"KSV113": RBACRiskSecrets,      // Manage namespace secrets
+	"KSV111": RBACRiskClusterAdmin, // Bound to cluster-admin or admin
+}
 
-	var reports []map[string]interface{}
-	for _, item := range list.Items {
-		reports = append(reports, item.Object)
-	}
+// RBACRisk returns the risk an RBAC check detects, or "" for other checks
+func RBACRisk(checkID string) string {
+	return rbacRisks[NormalizeCheckID(checkID)]
+}
 
-	return reports, nil
+// ListRbacAssessmentReports queries Trivy RbacAssessmentReport CRDs
+func (c *Client) ListRbacAssessmentReports(ctx context.Context, namespace string) ([]v1alpha1.RbacAssessmentReport, error) {
+	return listReports[v1alpha1.RbacAssessmentReport](ctx, c, v1alpha1.RbacAssessmentReports, namespace, "rbac assessment reports")
 }
 
-// ParseRbacChecks extracts RBAC check details from a report
-// Reuses the same structure as compliance checks
-func (c *Client) ParseRbacChecks(report map[string]interface{}) ([]ComplianceCheck, error) {
-	var checks []ComplianceCheck
+// ConvertRoleAssessment summarizes the failed checks of an RbacAssessmentReport
+// or ClusterRbacAssessmentReport for the role it covers
+func ConvertRoleAssessment(report *v1alpha1.RbacAssessmentReport) *RoleAssessment {
+	role := &RoleAssessment{}
+	role.Namespace, role.Kind, role.Name = v1alpha1.ScannedResource(report.ObjectMeta)
 
-	reportData, ok := report["report"].(map[string]interface{})
-	if !ok {
-		return checks, fmt.Errorf("no report data found")
+	for _, check := range ConvertChecks(report.Report.Checks) {
+		if check.Success {
+			continue
+		}
+		switch Severity(check.Severity) {
+		case SeverityCritical:
+			role.Critical++
+		case SeverityHigh:
+			role.High++
+		case SeverityMedium:
+			role.Medium++
+		case SeverityLow:
+			role.Low++
+		}
+		role.Checks = append(role.Checks, check)
 	}
 
-	checksArray, ok := reportData["checks"].([]interface{})
-	if !ok {
-		return checks, nil
+	return role
+}
+
+// AssessRoles returns the roles with failed RBAC checks, most over-privileged
+// first. ClusterRoles are included when querying all namespaces. On a
+// *PartialError the roles of the namespaces that could be read are returned.
+func (c *Client) AssessRoles(ctx context.Context, namespace string) ([]RoleAssessment, error) {
+	reports, err := c.ListRbacAssessmentReports(ctx, namespace)
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+	if namespace == "" {
+		// Cluster-scoped reports are optional, like in the cluster scanners
+		if clusterReports, err := c.ListClusterRbacAssessmentReports(ctx); err == nil {
+			reports = append(reports, clusterReports...)
+		}
 	}
 
-	for _, item := range checksArray {
-		checkMap, ok := item.(map[string]interface{})
-		if !ok {
+	var roles []RoleAssessment
+	for _, report := range reports {
+		role := ConvertRoleAssessment(&report)
+		if len(role.Checks) == 0 {
 			continue
 		}
-		check := ComplianceCheck{
-			CheckID:     getString(checkMap, "checkID"),
-			Title:       getString(checkMap, "title"),
-			Description: getString(checkMap, "description"),
-			Severity:    getString(checkMap, "severity"),
-			Category:    getString(checkMap, "category"),
-			Remediation: getString(checkMap, "remediation"),
-		}
+		roles = append(roles, *role)
+	}
 
-		if success, ok := checkMap["success"].(bool); ok {
-			check.Success = success
+	sort.SliceStable(roles, func(i, j int) bool {
+		a, b := roles[i], roles[j]
+		if a.Critical != b.Critical {
+			return a.Critical > b.Critical
 		}
-
-		if msgArray, ok := checkMap["messages"].([]interface{}); ok {
-			for _, msg := range msgArray {
-				if msgStr, ok := msg.(string); ok {
-					check.Messages = append(check.Messages, msgStr)
-				}
-			}
+		if a.High != b.High {
+			return a.High > b.High
 		}
+		if a.Medium != b.Medium {
+			return a.Medium > b.Medium
+		}
+		if a.Low != b.Low {
+			return a.Low > b.Low
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	return roles, err
+}
 
-		checks = append(checks, check)
+// WorstRolesByNamespace groups roles, as sorted by AssessRoles, by namespace
+// and keeps the n worst of each. Cluster-scoped roles are grouped under "".
+func WorstRolesByNamespace(roles []RoleAssessment, n int) map[string][]RoleAssessment {
+	worst := make(map[string][]RoleAssessment)
+	for _, role := range roles {
+		if len(worst[role.Namespace]) < n {
+			worst[role.Namespace] = append(worst[role.Namespace], role)
+		}
 	}
-
-	return checks, nil
+	return worst
 }
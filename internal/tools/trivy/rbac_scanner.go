@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // TrivyRbacScanner scans for RBAC issues using Trivy Operator CRDs
@@ -22,25 +23,10 @@ func (s *TrivyRbacScanner) Name() string {
 
 // Scan queries RbacAssessmentReports and returns findings
 func (s *TrivyRbacScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
-	reports, err := s.client.ListRbacAssessmentReports(ctx, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list rbac assessment reports: %w", err)
-	}
-
 	var findings []Finding
-
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-		ns, _ := metadata["namespace"].(string)
-
-		checks, err := s.client.ParseRbacChecks(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.RbacAssessmentReports, namespace, "rbac assessment reports", func(report *v1alpha1.RbacAssessmentReport) {
+		name, ns := report.Name, report.Namespace
+		checks := ConvertChecks(report.Report.Checks)
 
 		for _, c := range checks {
 			if c.Success {
@@ -49,7 +35,9 @@ func (s *TrivyRbacScanner) Scan(ctx context.Context, namespace string) ([]Findin
 			finding := RbacCheckToFinding(c, ns, name)
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	return findings, nil
+	return findings, err
 }
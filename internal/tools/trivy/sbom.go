@@ -2,88 +2,121 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+	"strings"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // ListSbomReports queries Trivy SbomReport CRDs
-func (c *Client) ListSbomReports(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "sbomreports",
-	}
+func (c *Client) ListSbomReports(ctx context.Context, namespace string) ([]v1alpha1.SbomReport, error) {
+	return listReports[v1alpha1.SbomReport](ctx, c, v1alpha1.SbomReports, namespace, "sbom reports")
+}
 
-	list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list sbom reports: %w", err)
+// ConvertSBOMReport extracts SBOM data from a report
+func ConvertSBOMReport(report *v1alpha1.SbomReport) *SBOMReport {
+	sbom := &SBOMReport{
+		Name:      report.Name,
+		Namespace: report.Namespace,
+		Container: report.Labels[v1alpha1.LabelContainerName],
+		Image:     report.Report.Artifact.Image(report.Report.Registry),
 	}
+	_, sbom.Kind, sbom.Workload = v1alpha1.ScannedResource(report.ObjectMeta)
 
-	var reports []map[string]interface{}
-	for _, item := range list.Items {
-		reports = append(reports, item.Object)
+	for _, comp := range report.Report.Components.Components {
+		// Skip empty components
+		if comp.Name == "" {
+			continue
+		}
+		sbom.Components = append(sbom.Components, SBOMComponent{
+			Name:    comp.Name,
+			Version: comp.Version,
+			Type:    comp.Type,
+			PURL:    comp.PURL,
+		})
 	}
 
-	return reports, nil
+	return sbom
 }
 
-// ParseSBOMReport extracts SBOM data from a report
-func (c *Client) ParseSBOMReport(report map[string]interface{}) (*SBOMReport, error) {
-	sbom := &SBOMReport{}
-
-	// Extract Metadata
-	metadata, ok := report["metadata"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("no metadata found")
-	}
-
-	sbom.Name, _ = metadata["name"].(string)
-	sbom.Namespace, _ = metadata["namespace"].(string)
-
-	// Extract report data
-	reportData, ok := report["report"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("no report data found")
-	}
+// PackageInventory indexes the components of all SbomReports in the cluster,
+// to answer questions like "which workloads contain log4j-core?"
+type PackageInventory struct {
+	Reports []SBOMReport
+}
 
-	// Extract image info from artifacts
-	if artifact, ok := reportData["artifact"].(map[string]interface{}); ok {
-		repo, _ := artifact["repository"].(string)
-		tag, _ := artifact["tag"].(string)
-		sbom.Image = repo + ":" + tag
+// BuildPackageInventory fetches and parses the namespaced and cluster-scoped
+// SbomReports of the whole cluster. On a *PartialError the inventory covers
+// the namespaces that could be read.
+func (c *Client) BuildPackageInventory(ctx context.Context) (*PackageInventory, error) {
+	reports, err := c.ListSbomReports(ctx, "")
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	// Extract components
-	components, ok := reportData["components"].(map[string]interface{})
-	if !ok {
-		return sbom, nil
+	if clusterReports, err := c.ListClusterSbomReports(ctx); err == nil {
+		reports = append(reports, clusterReports...)
 	}
 
-	componentsList, ok := components["components"].([]interface{})
-	if !ok {
-		return sbom, nil
+	inventory := &PackageInventory{}
+	for _, report := range reports {
+		inventory.Reports = append(inventory.Reports, *ConvertSBOMReport(&report))
 	}
+	return inventory, err
+}
 
-	for _, item := range componentsList {
-		compMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
+// FindPackage returns the workloads containing a package, matched by name
+// case-insensitively. Names qualified with a group, such as Maven's
+// org.apache.logging.log4j:log4j-core, also match on their last part.
+func (inv *PackageInventory) FindPackage(name string) []PackageUsage {
+	return inv.find(func(comp SBOMComponent) bool {
+		if strings.EqualFold(comp.Name, name) {
+			return true
 		}
+		short := comp.Name[strings.LastIndexAny(comp.Name, ":/")+1:]
+		return strings.EqualFold(short, name)
+	})
+}
 
-		comp := SBOMComponent{
-			Name:    getString(compMap, "name"),
-			Version: getString(compMap, "version"),
-			Type:    getString(compMap, "type"),
-			PURL:    getString(compMap, "purl"),
+// FindPURL returns the workloads containing the package identified by a
+// package URL. Without a version (pkg:maven/org.apache.logging.log4j/log4j-core)
+// every version matches; qualifiers and subpaths are ignored.
+func (inv *PackageInventory) FindPURL(purl string) []PackageUsage {
+	want := trimPURL(purl)
+	return inv.find(func(comp SBOMComponent) bool {
+		have := trimPURL(comp.PURL)
+		if have == "" {
+			return false
 		}
+		if !strings.Contains(want, "@") {
+			have, _, _ = strings.Cut(have, "@")
+		}
+		return strings.EqualFold(have, want)
+	})
+}
 
-		// Skip empty components
-		if comp.Name != "" {
-			sbom.Components = append(sbom.Components, comp)
+// find returns a PackageUsage for every component that matches
+func (inv *PackageInventory) find(match func(SBOMComponent) bool) []PackageUsage {
+	var usages []PackageUsage
+	for _, sbom := range inv.Reports {
+		for _, comp := range sbom.Components {
+			if !match(comp) {
+				continue
+			}
+			usages = append(usages, PackageUsage{
+				Namespace: sbom.Namespace,
+				Kind:      sbom.Kind,
+				Workload:  sbom.Workload,
+				Container: sbom.Container,
+				Image:     sbom.Image,
+				Package:   comp,
+			})
 		}
 	}
+	return usages
+}
 
-	return sbom, nil
+// trimPURL strips the qualifiers and subpath of a package URL
+func trimPURL(purl string) string {
+	purl, _, _ = strings.Cut(purl, "#")
+	purl, _, _ = strings.Cut(purl, "?")
+	return purl
 }
@@ -7,6 +7,7 @@ type Scanner interface {
 	// Name returns the scanner identifier (e.g., "trivy-vulns", "rbac")
 	Name() string
 
-	// Scan runs the scanner and returns findings
+	// Scan runs the scanner and returns findings. On a *PartialError the
+	// findings of the namespaces that could be read are returned with it.
 	Scan(ctx context.Context, namespace string) ([]Finding, error)
 }
@@ -2,64 +2,83 @@ package trivy
 
 import (
 	"context"
-	"fmt"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
-// ListExposedSecretsReports queries Trivy ExposedSecretReports CRDs
-func (c *Client) ListExposedSecretReports(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
-	gvr := schema.GroupVersionResource{
-		Group:    "aquasecurity.github.io",
-		Version:  "v1alpha1",
-		Resource: "exposedsecretreports",
-	}
-
-	list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list exposed secrets reports: %w", err)
-	}
-
-	var reports []map[string]interface{}
-	for _, item := range list.Items {
-		reports = append(reports, item.Object)
-	}
-
-	return reports, nil
+// ListExposedSecretReports queries Trivy ExposedSecretReport CRDs
+func (c *Client) ListExposedSecretReports(ctx context.Context, namespace string) ([]v1alpha1.ExposedSecretReport, error) {
+	return listReports[v1alpha1.ExposedSecretReport](ctx, c, v1alpha1.ExposedSecretReports, namespace, "exposed secrets reports")
 }
 
-// ParseExposedSecrets extracts secret details from a report
-func (c *Client) ParseExposedSecrets(report map[string]interface{}) ([]ExposedSecret, error) {
+// ConvertExposedSecrets extracts secret details from a report
+func ConvertExposedSecrets(report *v1alpha1.ExposedSecretReport) []ExposedSecret {
 	var secrets []ExposedSecret
-
-	reportData, ok := report["report"].(map[string]interface{})
-	if !ok {
-		return secrets, fmt.Errorf("no report data found")
+	for _, s := range report.Report.Secrets {
+		secrets = append(secrets, ExposedSecret{
+			Target:   s.Target,
+			RuleID:   s.RuleID,
+			Title:    s.Title,
+			Category: s.Category,
+			Severity: s.Severity,
+			Match:    s.Match,
+		})
 	}
+	return secrets
+}
+
+// SecretLocation is where an exposed secret was found. It has no field for
+// the matched value, so the value can't end up in output.
+type SecretLocation struct {
+	Type      string `json:"type"` // e.g. AWS, GitHub, Private key
+	RuleID    string `json:"ruleID"`
+	Title     string `json:"title"`
+	Severity  string `json:"severity"`
+	Namespace string `json:"namespace"`
+	Workload  string `json:"workload"` // Kind/name
+	Container string `json:"container,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Target    string `json:"target"` // File in the image
+}
 
-	secretsArray, ok := reportData["secrets"].([]interface{})
-	if !ok {
-		return secrets, nil // No secrets = empty slice
+// SecretType returns the kind of credential a secret is, from the category
+// of the rule that found it
+func SecretType(s v1alpha1.ExposedSecret) string {
+	switch s.Category {
+	case "":
+		return s.RuleID
+	case "AsymmetricPrivateKey":
+		return "Private key"
 	}
+	return s.Category
+}
 
-	for _, item := range secretsArray {
-		secretMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
+// ListSecretLocations returns where ExposedSecretReports found secrets,
+// without their values
+func (c *Client) ListSecretLocations(ctx context.Context, namespace string) ([]SecretLocation, error) {
+	var locations []SecretLocation
+	err := eachReport(ctx, c, v1alpha1.ExposedSecretReports, namespace, "exposed secrets reports", func(report *v1alpha1.ExposedSecretReport) {
+		_, kind, name := v1alpha1.ScannedResource(report.ObjectMeta)
+		workload := kind + "/" + name
+		if owner := c.ownerOf(ctx, report.ObjectMeta); owner != nil {
+			workload = owner.String()
 		}
-
-		secret := ExposedSecret{
-			Target:   getString(secretMap, "target"),
-			RuleID:   getString(secretMap, "ruleID"),
-			Title:    getString(secretMap, "title"),
-			Category: getString(secretMap, "category"),
-			Severity: getString(secretMap, "severity"),
-			Match:    getString(secretMap, "match"),
+		for _, s := range report.Report.Secrets {
+			locations = append(locations, SecretLocation{
+				Type:      SecretType(s),
+				RuleID:    s.RuleID,
+				Title:     s.Title,
+				Severity:  s.Severity,
+				Namespace: report.Namespace,
+				Workload:  workload,
+				Container: report.Labels[v1alpha1.LabelContainerName],
+				Image:     report.Report.Artifact.Image(report.Report.Registry),
+				Target:    s.Target,
+			})
 		}
-
-		secrets = append(secrets, secret)
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	return secrets, nil
+	return locations, err
 }
@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // TrivySecretScanner scans for exposed secrets using Trivy Operator CRDs
@@ -22,31 +23,20 @@ func (s *TrivySecretScanner) Name() string {
 
 // Scan queries ExposedSecretReports and returns findings
 func (s *TrivySecretScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
-	reports, err := s.client.ListExposedSecretReports(ctx, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list exposed secret reports: %w", err)
-	}
-
 	var findings []Finding
-
-	for _, report := range reports {
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-		ns, _ := metadata["namespace"].(string)
-
-		secrets, err := s.client.ParseExposedSecrets(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.ExposedSecretReports, namespace, "exposed secrets reports", func(report *v1alpha1.ExposedSecretReport) {
+		name, ns := report.Name, report.Namespace
+		secrets := ConvertExposedSecrets(report)
+		owner := s.client.ownerOf(ctx, report.ObjectMeta)
 
 		for _, secret := range secrets {
 			finding := ExposedSecretToFinding(secret, ns, name)
+			finding.Owner = owner
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-
-	return findings, nil
+	return findings, err
 }
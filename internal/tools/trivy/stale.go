@@ -0,0 +1,152 @@
+package trivy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultMaxReportAge is how old a vulnerability report may be before it is
+// flagged as stale. New CVEs are published daily, so a week-old scan misses
+// some even if nothing in the cluster changed.
+const DefaultMaxReportAge = 7 * 24 * time.Hour
+
+// StaleReport is a vulnerability report that may no longer describe what is
+// running
+type StaleReport struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Resource  string    `json:"resource"` // Kind/name of the scanned resource
+	Container string    `json:"container,omitempty"`
+	Image     string    `json:"image"`
+	ScannedAt time.Time `json:"scannedAt"`
+	Reasons   []string  `json:"reasons"`
+}
+
+// runningContainer is what the pods of one resource run in one container
+type runningContainer struct {
+	digests []string  // Image digests the pods report
+	newest  time.Time // Creation time of the newest pod
+}
+
+// StaleReports returns the vulnerability reports in a namespace, or in all
+// namespaces if it's empty, that are older than maxAge or were scanned before
+// the pods of their workload started: the pods may run another image than the
+// one scanned, such as a new push of a mutable tag.
+func (c *Client) StaleReports(ctx context.Context, namespace string, maxAge time.Duration) ([]StaleReport, error) {
+	running, err := c.runningContainers(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []StaleReport
+	err = eachReport(ctx, c, v1alpha1.VulnerabilityReports, namespace, "vulnerability reports", func(report *v1alpha1.VulnerabilityReport) {
+		ns, kind, name := v1alpha1.ScannedResource(report.ObjectMeta)
+		container := report.Labels[v1alpha1.LabelContainerName]
+		scanned := report.Report.UpdateTimestamp.Time
+		if scanned.IsZero() {
+			scanned = report.CreationTimestamp.Time
+		}
+		digest := report.Report.Artifact.Digest
+
+		var reasons []string
+		if age := time.Since(scanned); age > maxAge {
+			reasons = append(reasons, fmt.Sprintf("scanned %s ago", FormatAge(age)))
+		}
+		if pods, ok := running[ns+"/"+kind+"/"+name+"/"+container]; ok {
+			switch {
+			case digest != "" && len(pods.digests) > 0 && !slices.Contains(pods.digests, digest):
+				reasons = append(reasons, fmt.Sprintf("pods run %s, not the scanned %s", shortDigest(pods.digests[0]), shortDigest(digest)))
+			case digest == "" && pods.newest.After(scanned):
+				reasons = append(reasons, "pods started after the scan and may have pulled a newer image")
+			}
+		}
+		if len(reasons) == 0 {
+			return
+		}
+		stale = append(stale, StaleReport{
+			Namespace: report.Namespace,
+			Name:      report.Name,
+			Resource:  kind + "/" + name,
+			Container: container,
+			Image:     report.Report.Artifact.Image(report.Report.Registry),
+			ScannedAt: scanned,
+			Reasons:   reasons,
+		})
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i].ScannedAt.Before(stale[j].ScannedAt) })
+	return stale, err
+}
+
+// runningContainers maps namespace/kind/name/container, named like the report
+// labels Trivy Operator sets, to what the pods of that resource run. The
+// report selectors don't apply to pods, so all pods are listed.
+func (c *Client) runningContainers(ctx context.Context, namespace string) (map[string]*runningContainer, error) {
+	running := make(map[string]*runningContainer)
+	for _, ns := range c.filter.namespaces(namespace) {
+		opts := metav1.ListOptions{Limit: c.pageSize}
+		for {
+			pods, err := c.clientset.CoreV1().Pods(ns).List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			for _, pod := range pods.Items {
+				if c.filter.excludes(pod.Namespace) {
+					continue
+				}
+				kind, name := "Pod", pod.Name
+				if owner := metav1.GetControllerOf(&pod); owner != nil {
+					kind, name = owner.Kind, owner.Name
+				}
+				for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+					key := pod.Namespace + "/" + kind + "/" + name + "/" + status.Name
+					r, ok := running[key]
+					if !ok {
+						r = &runningContainer{}
+						running[key] = r
+					}
+					if _, digest, ok := strings.Cut(status.ImageID, "@"); ok && !slices.Contains(r.digests, digest) {
+						r.digests = append(r.digests, digest)
+					}
+					if created := pod.CreationTimestamp.Time; created.After(r.newest) {
+						r.newest = created
+					}
+				}
+			}
+			if pods.Continue == "" {
+				break
+			}
+			opts.Continue = pods.Continue
+		}
+	}
+	return running, nil
+}
+
+// FormatAge formats a duration in days, or hours or minutes below a day
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(max(d, 0).Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// shortDigest shortens sha256:0123456789abcdef... to sha256:0123456789ab
+func shortDigest(digest string) string {
+	if algo, hex, ok := strings.Cut(digest, ":"); ok && len(hex) > 12 {
+		return algo + ":" + hex[:12]
+	}
+	return digest
+}
@@ -2,7 +2,8 @@ package trivy
 
 import (
 	"context"
-	"fmt"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
 )
 
 // TrivyVulnScanner scans for vulnerabilities using Trivy Operator CRDs
@@ -22,32 +23,21 @@ func (s *TrivyVulnScanner) Name() string {
 
 // Scan queries VulnerabilityReports and returns findings
 func (s *TrivyVulnScanner) Scan(ctx context.Context, namespace string) ([]Finding, error) {
-	reports, err := s.client.ListVulnerabilityReports(ctx, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list vulnerability reports: %w", err)
-	}
-
 	var findings []Finding
-	for _, report := range reports {
-		// Extract Metadata
-		metadata, ok := report["metadata"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		name, _ := metadata["name"].(string)
-		ns, _ := metadata["namespace"].(string)
-
-		// Parse vulnerabilities
-		vulns, err := s.client.ParseVulnerabilities(report)
-		if err != nil {
-			continue
-		}
+	err := eachReport(ctx, s.client, v1alpha1.VulnerabilityReports, namespace, "vulnerability reports", func(report *v1alpha1.VulnerabilityReport) {
+		name, ns := report.Name, report.Namespace
+		vulns := ConvertVulnerabilities(report)
+		owner := s.client.ownerOf(ctx, report.ObjectMeta)
 
 		// Convert each vulnerability to a Finding
 		for _, v := range vulns {
 			finding := VulnerabilityToFinding(v, ns, name)
+			finding.Owner = owner
 			findings = append(findings, finding)
 		}
+	})
+	if err != nil && !IsPartial(err) {
+		return nil, err
 	}
-	return findings, nil
+	return findings, err
 }
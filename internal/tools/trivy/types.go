@@ -9,6 +9,19 @@ type Vulnerability struct {
 	Severity         string  `json:"severity"`
 	Score            float64 `json:"score"`
 	Title            string  `json:"title"`
+	Image            string  `json:"image,omitempty"`  // Scanned image, from the report's artifact
+	Digest           string  `json:"digest,omitempty"` // Image digest, the same across tags
+	PublishedDate    string  `json:"publishedDate,omitempty"`
+	Class            string  `json:"class,omitempty"`    // os-pkgs or lang-pkgs
+	OSFamily         string  `json:"osFamily,omitempty"` // OS of the image, e.g. debian or alpine
+}
+
+// VulnerabilityReport represents a VulnerabilityReport and its parsed findings
+type VulnerabilityReport struct {
+	Name            string          `json:"name"`
+	Namespace       string          `json:"namespace"`
+	Image           string          `json:"image"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
 }
 
 type ComplianceCheck struct {
@@ -22,6 +35,18 @@ type ComplianceCheck struct {
 	Remediation string   `json:"remediation,omitempty"`
 }
 
+// RoleAssessment summarizes the failed RBAC checks of one Role or ClusterRole
+type RoleAssessment struct {
+	Namespace string            `json:"namespace,omitempty"` // Empty for cluster-scoped roles
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Critical  int               `json:"critical"`
+	High      int               `json:"high"`
+	Medium    int               `json:"medium"`
+	Low       int               `json:"low"`
+	Checks    []ComplianceCheck `json:"checks,omitempty"` // Failed checks only
+}
+
 type ExposedSecret struct {
 	Target   string `json:"target"`
 	RuleID   string `json:"ruleID"`
@@ -43,14 +68,56 @@ type SBOMComponent struct {
 type SBOMReport struct {
 	Name       string          `json:"name"`
 	Namespace  string          `json:"namespace"`
+	Kind       string          `json:"kind,omitempty"`      // Kind of the workload running the image
+	Workload   string          `json:"workload,omitempty"`  // Name of the workload running the image
+	Container  string          `json:"container,omitempty"` // Container running the image
 	Image      string          `json:"image"`
 	Components []SBOMComponent `json:"components"`
 }
 
+// PackageUsage is a workload container whose image contains a package
+type PackageUsage struct {
+	Namespace string        `json:"namespace,omitempty"`
+	Kind      string        `json:"kind"`
+	Workload  string        `json:"workload"`
+	Container string        `json:"container,omitempty"`
+	Image     string        `json:"image"`
+	Package   SBOMComponent `json:"package"`
+}
+
+// Benchmark control statuses
+const (
+	ControlPass   = "PASS"
+	ControlFail   = "FAIL"
+	ControlManual = "MANUAL" // No automated checks; must be verified by hand
+)
+
 // BenchmarkControl represents a CIS/NSA benchmark control check result
 type BenchmarkControl struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Severity  string `json:"severity"`
-	TotalFail int    `json:"totalFail"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Severity  string   `json:"severity"`
+	TotalFail int      `json:"totalFail"`
+	Status    string   `json:"status"`
+	Checks    []string `json:"checks,omitempty"` // IDs of the checks behind the control, e.g. AVD-KSV-0012
+}
+
+// BenchmarkSection is the score of a group of benchmark controls, such as
+// CIS section 1.2 (API Server)
+type BenchmarkSection struct {
+	ID     string  `json:"id"`
+	Pass   int     `json:"pass"`
+	Fail   int     `json:"fail"`
+	Manual int     `json:"manual"`
+	Score  float64 `json:"score"` // Percentage of automated controls passing
+}
+
+// ControlResource is a resource that fails one of a benchmark control's checks
+type ControlResource struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	CheckID   string   `json:"checkID"`
+	Title     string   `json:"title"`
+	Messages  []string `json:"messages,omitempty"`
 }
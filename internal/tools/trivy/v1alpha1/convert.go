@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Report is any of the report types in this package
+type Report interface {
+	VulnerabilityReport | ConfigAuditReport | RbacAssessmentReport | InfraAssessmentReport |
+		ExposedSecretReport | SbomReport | ClusterComplianceReport
+}
+
+// FromUnstructured converts an object returned by the dynamic client to a report
+func FromUnstructured[T Report](obj map[string]interface{}) (*T, error) {
+	var report T
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &report); err != nil {
+		return nil, fmt.Errorf("failed to convert report: %w", err)
+	}
+	return &report, nil
+}
+
+// FromUnstructuredList converts a list returned by the dynamic client to
+// reports. Items that don't convert are skipped, so one malformed report
+// doesn't hide the others; they're returned joined in the error.
+func FromUnstructuredList[T Report](list *unstructured.UnstructuredList) ([]T, error) {
+	reports := make([]T, 0, len(list.Items))
+	var errs []error
+	for _, item := range list.Items {
+		report, err := FromUnstructured[T](item.Object)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", item.GetNamespace(), item.GetName(), err))
+			continue
+		}
+		reports = append(reports, *report)
+	}
+	return reports, errors.Join(errs...)
+}
+
+// ScannedResource returns the namespace, kind and name of the resource a
+// report covers, from the labels Trivy Operator sets. The name falls back to
+// the report's own name.
+func ScannedResource(meta metav1.ObjectMeta) (namespace, kind, name string) {
+	name = meta.Name
+	if resourceName := meta.Labels[LabelResourceName]; resourceName != "" {
+		name = resourceName
+	}
+	return meta.Namespace, meta.Labels[LabelResourceKind], name
+}
+
+// Image returns the scanned image as registry/repository:tag, or
+// repository@digest when the image has no tag
+func (a Artifact) Image(registry Registry) string {
+	if a.Repository == "" {
+		return ""
+	}
+	image := a.Repository
+	if registry.Server != "" {
+		image = registry.Server + "/" + image
+	}
+	if a.Tag != "" {
+		return image + ":" + a.Tag
+	}
+	if a.Digest != "" {
+		return image + "@" + a.Digest
+	}
+	return image
+}
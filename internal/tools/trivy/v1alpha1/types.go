@@ -0,0 +1,274 @@
+// Package v1alpha1 contains typed structs for the aquasecurity.github.io/v1alpha1
+// report CRDs written by Trivy Operator. They mirror the upstream API types but
+// only include the fields trix reads, so the operator's module isn't a dependency.
+package v1alpha1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Group and version of the Trivy Operator CRDs
+const (
+	Group   = "aquasecurity.github.io"
+	Version = "v1alpha1"
+)
+
+// Resources of the report CRDs, for the dynamic client
+var (
+	VulnerabilityReports          = resource("vulnerabilityreports")
+	ClusterVulnerabilityReports   = resource("clustervulnerabilityreports")
+	ConfigAuditReports            = resource("configauditreports")
+	ClusterConfigAuditReports     = resource("clusterconfigauditreports")
+	ExposedSecretReports          = resource("exposedsecretreports")
+	RbacAssessmentReports         = resource("rbacassessmentreports")
+	ClusterRbacAssessmentReports  = resource("clusterrbacassessmentreports")
+	InfraAssessmentReports        = resource("infraassessmentreports")
+	ClusterInfraAssessmentReports = resource("clusterinfraassessmentreports")
+	SbomReports                   = resource("sbomreports")
+	ClusterSbomReports            = resource("clustersbomreports")
+	ClusterComplianceReports      = resource("clustercompliancereports")
+)
+
+// AllReports lists the resources of every report CRD
+var AllReports = []schema.GroupVersionResource{
+	VulnerabilityReports, ClusterVulnerabilityReports,
+	ConfigAuditReports, ClusterConfigAuditReports,
+	ExposedSecretReports,
+	RbacAssessmentReports, ClusterRbacAssessmentReports,
+	InfraAssessmentReports, ClusterInfraAssessmentReports,
+	SbomReports, ClusterSbomReports,
+	ClusterComplianceReports,
+}
+
+func resource(name string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: Group, Version: Version, Resource: name}
+}
+
+// ClusterScoped reports whether a report resource is cluster-scoped
+func ClusterScoped(gvr schema.GroupVersionResource) bool {
+	return strings.HasPrefix(gvr.Resource, "cluster")
+}
+
+// Labels Trivy Operator sets on reports to identify the scanned resource
+const (
+	LabelResourceKind      = "trivy-operator.resource.kind"
+	LabelResourceName      = "trivy-operator.resource.name"
+	LabelResourceNamespace = "trivy-operator.resource.namespace"
+	LabelContainerName     = "trivy-operator.container.name"
+)
+
+// Registry is the registry an image was pulled from
+type Registry struct {
+	Server string `json:"server,omitempty"`
+}
+
+// Artifact is the scanned image
+type Artifact struct {
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// OS is the operating system of a scanned image
+type OS struct {
+	Family string `json:"family,omitempty"` // e.g. debian, alpine
+	Name   string `json:"name,omitempty"`   // Version, e.g. 12.5
+	EOSL   bool   `json:"eosl,omitempty"`   // No longer gets security updates
+}
+
+// SeveritySummary counts the findings of a report by severity
+type SeveritySummary struct {
+	CriticalCount int `json:"criticalCount"`
+	HighCount     int `json:"highCount"`
+	MediumCount   int `json:"mediumCount"`
+	LowCount      int `json:"lowCount"`
+	UnknownCount  int `json:"unknownCount,omitempty"`
+}
+
+// VulnerabilityReport is a VulnerabilityReport or ClusterVulnerabilityReport
+type VulnerabilityReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report VulnerabilityReportData `json:"report"`
+}
+
+// VulnerabilityReportData is the scan result of one container image
+type VulnerabilityReportData struct {
+	UpdateTimestamp metav1.Time     `json:"updateTimestamp,omitempty"` // When the image was last scanned
+	Registry        Registry        `json:"registry"`
+	Artifact        Artifact        `json:"artifact"`
+	OS              OS              `json:"os"`
+	Summary         SeveritySummary `json:"summary"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Vulnerability is a CVE found in a package of the image
+type Vulnerability struct {
+	VulnerabilityID  string   `json:"vulnerabilityID"`
+	Resource         string   `json:"resource"` // Package name
+	InstalledVersion string   `json:"installedVersion"`
+	FixedVersion     string   `json:"fixedVersion"`
+	Severity         string   `json:"severity"`
+	Title            string   `json:"title"`
+	PrimaryLink      string   `json:"primaryLink,omitempty"`
+	Score            *float64 `json:"score,omitempty"`
+	Target           string   `json:"target,omitempty"`
+	Class            string   `json:"class,omitempty"` // os-pkgs or lang-pkgs
+	PublishedDate    string   `json:"publishedDate,omitempty"`
+}
+
+// ConfigAuditReport is a ConfigAuditReport or ClusterConfigAuditReport
+type ConfigAuditReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report CheckReportData `json:"report"`
+}
+
+// RbacAssessmentReport is an RbacAssessmentReport or ClusterRbacAssessmentReport
+type RbacAssessmentReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report CheckReportData `json:"report"`
+}
+
+// InfraAssessmentReport is an InfraAssessmentReport or ClusterInfraAssessmentReport
+type InfraAssessmentReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report CheckReportData `json:"report"`
+}
+
+// CheckReportData holds the misconfiguration checks run against one resource,
+// shared by config audit, RBAC and infra assessment reports
+type CheckReportData struct {
+	Summary SeveritySummary `json:"summary"`
+	Checks  []Check         `json:"checks"`
+}
+
+// Check is the result of one misconfiguration check
+type Check struct {
+	CheckID     string   `json:"checkID"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Severity    string   `json:"severity"`
+	Category    string   `json:"category,omitempty"`
+	Messages    []string `json:"messages,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	Success     bool     `json:"success"`
+}
+
+// ExposedSecretReport is an ExposedSecretReport
+type ExposedSecretReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report ExposedSecretReportData `json:"report"`
+}
+
+// ExposedSecretReportData is the secret scan result of one container image
+type ExposedSecretReportData struct {
+	Registry Registry        `json:"registry"`
+	Artifact Artifact        `json:"artifact"`
+	Summary  SeveritySummary `json:"summary"`
+	Secrets  []ExposedSecret `json:"secrets"`
+}
+
+// ExposedSecret is a secret found in a file of the image
+type ExposedSecret struct {
+	Target   string `json:"target"`
+	RuleID   string `json:"ruleID"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Match    string `json:"match"`
+}
+
+// SbomReport is an SbomReport or ClusterSbomReport
+type SbomReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Report SbomReportData `json:"report"`
+}
+
+// SbomReportData is the CycloneDX SBOM of one container image
+type SbomReportData struct {
+	Registry   Registry `json:"registry"`
+	Artifact   Artifact `json:"artifact"`
+	Components BOM      `json:"components"`
+}
+
+// BOM is a CycloneDX bill of materials
+type BOM struct {
+	Components []Component `json:"components,omitempty"`
+}
+
+// Component is a package in a BOM
+type Component struct {
+	Type    string `json:"type"`
+	Group   string `json:"group,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// ClusterComplianceReport is a compliance benchmark such as CIS or NSA
+type ClusterComplianceReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ComplianceSpec   `json:"spec"`
+	Status ComplianceStatus `json:"status"`
+}
+
+// ComplianceSpec defines the benchmark
+type ComplianceSpec struct {
+	Compliance Compliance `json:"compliance"`
+}
+
+// Compliance lists the controls of a benchmark
+type Compliance struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Controls []Control `json:"controls"`
+}
+
+// Control is a benchmark control and the checks that implement it
+type Control struct {
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	Severity string      `json:"severity"`
+	Checks   []SpecCheck `json:"checks,omitempty"`
+}
+
+// SpecCheck references a check by its AVD ID, e.g. AVD-KSV-0012
+type SpecCheck struct {
+	ID string `json:"id"`
+}
+
+// ComplianceStatus holds the benchmark results
+type ComplianceStatus struct {
+	SummaryReport *SummaryReport `json:"summaryReport,omitempty"`
+}
+
+// SummaryReport counts the failures of each control
+type SummaryReport struct {
+	ID            string               `json:"id"`
+	Title         string               `json:"title"`
+	ControlChecks []ControlCheckResult `json:"controlCheck"`
+}
+
+// ControlCheckResult is the result of one control
+type ControlCheckResult struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Severity  string `json:"severity"`
+	TotalFail int    `json:"totalFail,omitempty"`
+}
@@ -0,0 +1,243 @@
+package trivy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+)
+
+// EventType is the kind of change to a report
+type EventType string
+
+const (
+	EventAdded   EventType = "ADDED"
+	EventUpdated EventType = "UPDATED"
+	EventDeleted EventType = "DELETED"
+)
+
+// ReportEvent is a change to a Trivy report seen by a Watcher
+type ReportEvent struct {
+	Type     EventType
+	Resource schema.GroupVersionResource
+	Report   *unstructured.Unstructured // Convert with v1alpha1.FromUnstructured
+
+	// Initial is set for the ADDED events of reports that existed when the
+	// watcher started
+	Initial bool
+}
+
+// Summary returns the severity counts of the report, if it has any
+func (e ReportEvent) Summary() (v1alpha1.SeveritySummary, bool) {
+	var report struct {
+		Report struct {
+			Summary *v1alpha1.SeveritySummary `json:"summary"`
+		} `json:"report"`
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(e.Report.Object, &report); err != nil || report.Report.Summary == nil {
+		return v1alpha1.SeveritySummary{}, false
+	}
+	return *report.Report.Summary, true
+}
+
+// Watcher keeps an in-memory cache of Trivy reports using informers and emits
+// an event for every change, so callers don't have to poll the API server.
+type Watcher struct {
+	client     *Client
+	resources  []schema.GroupVersionResource
+	namespaced dynamicinformer.DynamicSharedInformerFactory
+	cluster    dynamicinformer.DynamicSharedInformerFactory
+	informers  map[schema.GroupVersionResource]cache.SharedIndexInformer
+	events     chan ReportEvent
+}
+
+// NewWatcher creates a watcher for the given report resources, or for all of
+// them if none are given. namespace limits the namespaced reports; empty
+// watches all namespaces. Cluster-scoped reports are always watched
+// cluster-wide. The client's selectors apply to both. resync periodically
+// re-delivers the cache; 0 disables it.
+func (c *Client) NewWatcher(namespace string, resync time.Duration, resources ...schema.GroupVersionResource) *Watcher {
+	if len(resources) == 0 {
+		resources = v1alpha1.AllReports
+	}
+	return &Watcher{
+		client:     c,
+		resources:  resources,
+		namespaced: dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient, resync, namespace, c.tweakListOptions),
+		cluster:    dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient, resync, metav1.NamespaceAll, c.tweakListOptions),
+		informers:  make(map[schema.GroupVersionResource]cache.SharedIndexInformer),
+		events:     make(chan ReportEvent, 1024),
+	}
+}
+
+// Events returns the channel of report changes. It is closed when the
+// context passed to Start is done.
+func (w *Watcher) Events() <-chan ReportEvent {
+	return w.events
+}
+
+// Start starts the informers and waits until their caches are filled.
+// Report kinds the cluster doesn't serve (e.g. disabled scanners) are skipped.
+// The watcher stops when ctx is done.
+func (w *Watcher) Start(ctx context.Context) error {
+	served, err := w.client.servedReports()
+	if err != nil {
+		return err
+	}
+	for _, gvr := range w.resources {
+		if !served[gvr.Resource] {
+			continue
+		}
+		factory := w.namespaced
+		if v1alpha1.ClusterScoped(gvr) {
+			factory = w.cluster
+		}
+		informer := factory.ForResource(gvr).Informer()
+		if _, err := informer.AddEventHandler(w.handler(ctx, gvr)); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", gvr.Resource, err)
+		}
+		w.informers[gvr] = informer
+	}
+	if len(w.informers) == 0 {
+		return fmt.Errorf("no Trivy report resources to watch (is Trivy Operator installed?)")
+	}
+
+	w.namespaced.Start(ctx.Done())
+	w.cluster.Start(ctx.Done())
+	go func() {
+		<-ctx.Done()
+		// Shutdown waits for the event handlers, so nothing sends after the close
+		w.namespaced.Shutdown()
+		w.cluster.Shutdown()
+		close(w.events)
+	}()
+
+	for gvr, informer := range w.informers {
+		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to sync %s", gvr.Resource)
+		}
+	}
+	return nil
+}
+
+// List returns the cached reports of one resource with the client's filter
+// applied, without the namespaces it skips
+func (w *Watcher) List(gvr schema.GroupVersionResource) []*unstructured.Unstructured {
+	informer, ok := w.informers[gvr]
+	if !ok {
+		return nil
+	}
+	var reports []*unstructured.Unstructured
+	for _, obj := range informer.GetStore().List() {
+		report, ok := obj.(*unstructured.Unstructured)
+		if ok && (v1alpha1.ClusterScoped(gvr) || w.client.filter.keepNamespace(report.GetNamespace())) {
+			reports = append(reports, w.client.filterUnstructured(gvr, report))
+		}
+	}
+	return reports
+}
+
+// handler turns informer notifications into events, dropping those of the
+// namespaces the client's filter skips and filtering the findings of the rest
+func (w *Watcher) handler(ctx context.Context, gvr schema.GroupVersionResource) cache.ResourceEventHandler {
+	send := func(event ReportEvent) {
+		if !v1alpha1.ClusterScoped(gvr) && !w.client.filter.keepNamespace(event.Report.GetNamespace()) {
+			return
+		}
+		event.Report = w.client.filterUnstructured(gvr, event.Report)
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+		}
+	}
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if report, ok := obj.(*unstructured.Unstructured); ok {
+				send(ReportEvent{Type: EventAdded, Resource: gvr, Report: report, Initial: isInInitialList})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, _ := oldObj.(*unstructured.Unstructured)
+			report, ok := newObj.(*unstructured.Unstructured)
+			// Resyncs re-deliver unchanged reports
+			if !ok || (old != nil && old.GetResourceVersion() == report.GetResourceVersion()) {
+				return
+			}
+			send(ReportEvent{Type: EventUpdated, Resource: gvr, Report: report})
+		},
+		DeleteFunc: func(obj interface{}) {
+			// The final state may be unknown if the watch missed the deletion
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if report, ok := obj.(*unstructured.Unstructured); ok {
+				send(ReportEvent{Type: EventDeleted, Resource: gvr, Report: report})
+			}
+		},
+	}
+}
+
+// filterUnstructured returns a copy of a report with the client's filter
+// applied, so its findings and summary match those of the listed reports. The
+// informer's cached report is left as is.
+func (c *Client) filterUnstructured(gvr schema.GroupVersionResource, report *unstructured.Unstructured) *unstructured.Unstructured {
+	switch gvr {
+	case v1alpha1.VulnerabilityReports, v1alpha1.ClusterVulnerabilityReports:
+		return filterReport[v1alpha1.VulnerabilityReport](c.filter, report)
+	case v1alpha1.ConfigAuditReports, v1alpha1.ClusterConfigAuditReports:
+		return filterReport[v1alpha1.ConfigAuditReport](c.filter, report)
+	case v1alpha1.RbacAssessmentReports, v1alpha1.ClusterRbacAssessmentReports:
+		return filterReport[v1alpha1.RbacAssessmentReport](c.filter, report)
+	case v1alpha1.InfraAssessmentReports, v1alpha1.ClusterInfraAssessmentReports:
+		return filterReport[v1alpha1.InfraAssessmentReport](c.filter, report)
+	case v1alpha1.ExposedSecretReports:
+		return filterReport[v1alpha1.ExposedSecretReport](c.filter, report)
+	default:
+		return report
+	}
+}
+
+// filterReport converts a report to T, filters it and converts it back. A
+// report that doesn't convert is returned unfiltered.
+func filterReport[T v1alpha1.Report](filter FilterOptions, report *unstructured.Unstructured) *unstructured.Unstructured {
+	typed, err := v1alpha1.FromUnstructured[T](report.Object)
+	if err != nil {
+		return report
+	}
+	filter.apply(typed)
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return report
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+// tweakListOptions applies the client's selectors to the informers' list and watch calls
+func (c *Client) tweakListOptions(opts *metav1.ListOptions) {
+	opts.LabelSelector = c.labelSelector
+	opts.FieldSelector = c.fieldSelector
+}
+
+// servedReports returns the report resources the cluster serves, by name
+func (c *Client) servedReports() (map[string]bool, error) {
+	list, err := c.clientset.Discovery().ServerResourcesForGroupVersion(v1alpha1.Group + "/" + v1alpha1.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover Trivy report resources: %w", err)
+	}
+	served := make(map[string]bool)
+	for _, r := range list.APIResources {
+		served[r.Name] = true
+	}
+	return served, nil
+}
@@ -0,0 +1,83 @@
+package trivy
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/davealtena/trix/internal/tools/trivy/v1alpha1"
+)
+
+// suppressIDs suppresses the vulnerabilities with these IDs
+type suppressIDs []string
+
+func (s suppressIDs) Suppresses(v Vulnerability) bool {
+	for _, id := range s {
+		if id == v.VulnerabilityID {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreIDs ignores the findings with these IDs
+type ignoreIDs []string
+
+func (ig ignoreIDs) Ignores(id string, target IgnoreTarget) bool {
+	return suppressIDs(ig).Suppresses(Vulnerability{VulnerabilityID: id})
+}
+
+func TestFilterUnstructured(t *testing.T) {
+	vuln := func(id, severity string) map[string]interface{} {
+		return map[string]interface{}{"vulnerabilityID": id, "severity": severity, "resource": "openssl", "installedVersion": "3.0.1"}
+	}
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "aquasecurity.github.io/v1alpha1",
+		"kind":       "VulnerabilityReport",
+		"metadata":   map[string]interface{}{"name": "replicaset-api-nginx", "namespace": "prod"},
+		"report": map[string]interface{}{
+			"artifact": map[string]interface{}{"repository": "library/nginx", "tag": "1.25"},
+			"registry": map[string]interface{}{"server": "index.docker.io"},
+			"summary":  map[string]interface{}{"criticalCount": int64(3), "highCount": int64(1)},
+			"vulnerabilities": []interface{}{
+				vuln("CVE-2024-0001", "CRITICAL"),
+				vuln("CVE-2024-0002", "CRITICAL"),
+				vuln("CVE-2024-0003", "CRITICAL"),
+				vuln("CVE-2024-0004", "HIGH"),
+			},
+		},
+	}}
+
+	tests := []struct {
+		name         string
+		filter       FilterOptions
+		wantCritical int
+		wantHigh     int
+	}{
+		{"no filter", FilterOptions{}, 3, 1},
+		{"vex", FilterOptions{Suppress: suppressIDs{"CVE-2024-0001"}}, 2, 1},
+		{"ignore file", FilterOptions{Ignore: ignoreIDs{"CVE-2024-0002", "CVE-2024-0003"}}, 1, 1},
+		{"severity", FilterOptions{MinSeverity: SeverityCritical}, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{filter: tt.filter}
+			event := ReportEvent{Report: c.filterUnstructured(v1alpha1.VulnerabilityReports, report)}
+			summary, ok := event.Summary()
+			if !ok {
+				t.Fatal("filtered report has no summary")
+			}
+			if summary.CriticalCount != tt.wantCritical || summary.HighCount != tt.wantHigh {
+				t.Errorf("summary = %d critical, %d high, want %d, %d", summary.CriticalCount, summary.HighCount, tt.wantCritical, tt.wantHigh)
+			}
+			if event.Report.GetKind() != "VulnerabilityReport" || event.Report.GetNamespace() != "prod" {
+				t.Errorf("filtered report lost its kind or metadata: %v", event.Report.Object)
+			}
+		})
+	}
+
+	// The informer's cached report is left as is
+	if vulns, _, _ := unstructured.NestedSlice(report.Object, "report", "vulnerabilities"); len(vulns) != 4 {
+		t.Errorf("the original report has %d vulnerabilities, want 4", len(vulns))
+	}
+}
@@ -7,6 +7,19 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Wide turns off shortening for -o wide: Truncate keeps text whole and boxes
+// grow to fit their content instead of wrapping it.
+var Wide bool
+
+// Truncate shortens s to at most n characters, ending in "...", unless Wide
+// is set.
+func Truncate(s string, n int) string {
+	if Wide || len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
 // Box creates a rounded box around content.
 //
 // How lipgloss boxes work:
@@ -18,7 +31,13 @@ import (
 //	╭──╮
 //	│  │
 //	╰──╯
+//
+// Plain output has no border: the title, a blank line and the content.
 func Box(title, content string, width int) string {
+	if plain {
+		return title + "\n\n" + strings.TrimRight(content, "\n")
+	}
+
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(ColorBorder).
@@ -28,6 +47,9 @@ func Box(title, content string, width int) string {
 	titleStyle := lipgloss.NewStyle().Bold(true).MarginBottom(1)
 
 	inner := titleStyle.Render(title) + "\n" + content
+	if Wide {
+		boxStyle = boxStyle.Width(max(width, lipgloss.Width(inner)+4)) // Plus the padding
+	}
 
 	return boxStyle.Render(inner)
 }
@@ -35,7 +57,7 @@ func Box(title, content string, width int) string {
 // Section creates a section header with a subtle line underneath.
 // Useful for "By Severity", "By Type" etc.
 func Section(title string) string {
-	return Title.Render(title) + "\n" + Muted.Render(strings.Repeat("─", len(title)+4))
+	return Title.Render(title) + "\n" + Muted.Render(rule(len(title)+4))
 }
 
 // SeverityLine formats a severity row with colored label and count.
@@ -61,9 +83,7 @@ func TypeLine(typeName string, count int) string {
 // ResourceLine formats a resource row for top affected resources.
 func ResourceLine(resource string, count int, maxLen int) string {
 	// Truncate resource name if too long
-	if len(resource) > maxLen {
-		resource = resource[:maxLen-3] + "..."
-	}
+	resource = Truncate(resource, maxLen)
 	label := fmt.Sprintf("%-*s", maxLen, resource)
 	countStr := fmt.Sprintf("%d", count)
 	return "  " + label + "  " + countStr
@@ -129,7 +149,7 @@ func (t *Table) Render() string {
 	// Separator
 	b.WriteString("  ")
 	for i, w := range t.Widths {
-		b.WriteString(Muted.Render(strings.Repeat("─", w)))
+		b.WriteString(Muted.Render(rule(w)))
 		if i < len(t.Widths)-1 {
 			b.WriteString("  ")
 		}
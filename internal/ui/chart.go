@@ -0,0 +1,37 @@
+package ui
+
+import "strings"
+
+// sparkBlocks are the bar heights of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a one-line bar chart, scaled from the smallest
+// to the largest value, so small changes still show. With more values than
+// width, each bar is the last value of its share of them.
+// Example output: "▁▂▂▄▆█▇▅▃"
+func Sparkline(values []int, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		sampled := make([]int, width)
+		for i := range sampled {
+			sampled[i] = values[(i+1)*len(values)/width-1]
+		}
+		values = sampled
+	}
+
+	smallest, largest := values[0], values[0]
+	for _, v := range values {
+		smallest, largest = min(smallest, v), max(largest, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if largest > smallest {
+			i = (v - smallest) * (len(sparkBlocks) - 1) / (largest - smallest)
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// plain is whether output is plain text: no colors or bold, no box borders
+// and ASCII lines, so piped and CI output reads cleanly
+var plain = detectPlain()
+
+func init() {
+	if plain {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// detectPlain reports whether output should be plain text: NO_COLOR is set
+// (https://no-color.org), TERM is dumb or stdout isn't a terminal
+func detectPlain() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	return !term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Plain reports whether output is plain text
+func Plain() bool {
+	return plain
+}
+
+// SetPlain makes output plain text, as for --no-color
+func SetPlain() {
+	plain = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// rule draws a horizontal line n characters long
+func rule(n int) string {
+	if plain {
+		return strings.Repeat("-", n)
+	}
+	return strings.Repeat("─", n)
+}
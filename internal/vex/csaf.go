@@ -0,0 +1,139 @@
+package vex
+
+import (
+	"encoding/json"
+)
+
+// csafDocument is the part of a CSAF 2.0 VEX document used here
+type csafDocument struct {
+	ProductTree struct {
+		Branches         []csafBranch      `json:"branches"`
+		FullProductNames []csafProductName `json:"full_product_names"`
+		Relationships    []struct {
+			ProductReference          string          `json:"product_reference"`
+			RelatesToProductReference string          `json:"relates_to_product_reference"`
+			FullProductName           csafProductName `json:"full_product_name"`
+		} `json:"relationships"`
+	} `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE string `json:"cve"`
+		IDs []struct {
+			Text string `json:"text"`
+		} `json:"ids"`
+		ProductStatus map[string][]string `json:"product_status"`
+		Flags         []struct {
+			Label      string   `json:"label"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"flags"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch     `json:"branches"`
+	Product  *csafProductName `json:"product"`
+}
+
+type csafProductName struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	Helper    struct {
+		PURL string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+// identifier returns the purl of a product, or its name without one
+func (p csafProductName) identifier() string {
+	if p.Helper.PURL != "" {
+		return p.Helper.PURL
+	}
+	return p.Name
+}
+
+// csafStatuses maps CSAF product status groups to VEX statuses, in the order
+// their statements are added. The last match wins, so a product wrongly listed
+// as both affected and not affected stays visible. Other groups such as
+// first_affected or recommended say nothing new.
+var csafStatuses = []struct {
+	group  string
+	status Status
+}{
+	{"known_not_affected", StatusNotAffected},
+	{"fixed", StatusFixed},
+	{"under_investigation", StatusUnderInvestigation},
+	{"known_affected", StatusAffected},
+}
+
+// parseCSAF reads the statements of a CSAF VEX document. Products are
+// resolved through the product tree; a relationship such as "libc6 is a
+// default component of the app image" becomes a product with a subcomponent.
+func parseCSAF(data []byte) ([]Statement, error) {
+	var doc csafDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string) // product_id -> purl or name
+	var walk func([]csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, b := range branches {
+			if b.Product != nil {
+				names[b.Product.ProductID] = b.Product.identifier()
+			}
+			walk(b.Branches)
+		}
+	}
+	walk(doc.ProductTree.Branches)
+	for _, p := range doc.ProductTree.FullProductNames {
+		names[p.ProductID] = p.identifier()
+	}
+
+	products := make(map[string]Product)
+	for id, name := range names {
+		products[id] = Product{ID: name}
+	}
+	for _, r := range doc.ProductTree.Relationships {
+		products[r.FullProductName.ProductID] = Product{
+			ID:            names[r.RelatesToProductReference],
+			Subcomponents: []string{names[r.ProductReference]},
+		}
+	}
+
+	var statements []Statement
+	for _, v := range doc.Vulnerabilities {
+		var aliases []string
+		for _, id := range v.IDs {
+			aliases = append(aliases, id.Text)
+		}
+		vulnerability := v.CVE
+		if vulnerability == "" && len(aliases) > 0 {
+			vulnerability, aliases = aliases[0], aliases[1:]
+		}
+		if vulnerability == "" {
+			continue
+		}
+		justifications := make(map[string]string)
+		for _, flag := range v.Flags {
+			for _, id := range flag.ProductIDs {
+				justifications[id] = flag.Label
+			}
+		}
+
+		for _, s := range csafStatuses {
+			// One statement per product, so each keeps its justification
+			for _, id := range v.ProductStatus[s.group] {
+				product, ok := products[id]
+				if !ok {
+					product = Product{ID: id}
+				}
+				statements = append(statements, Statement{
+					Vulnerability: vulnerability,
+					Aliases:       aliases,
+					Products:      []Product{product},
+					Status:        s.status,
+					Justification: justifications[id],
+				})
+			}
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,60 @@
+package vex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/davealtena/trix/internal/registry"
+)
+
+// ociManifest is the part of an OCI image manifest used here
+type ociManifest struct {
+	Layers []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+	} `json:"layers"`
+}
+
+// fetchOCI downloads a VEX document stored as an OCI artifact, e.g. pushed
+// with 'oras push ghcr.io/org/app-vex:1.0 app.vex.json'. It pulls
+// anonymously, so the repository must be public.
+func fetchOCI(ctx context.Context, ref string) ([]byte, error) {
+	_, _, reference := registry.ParseReference(ref)
+	repo := registry.NewRepository(ref)
+
+	body, err := repo.Get(ctx, repo.URL("manifests/"+reference),
+		"application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json")
+	if err != nil {
+		return nil, err
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("%s has no layers", ref)
+	}
+
+	// Prefer a layer that says it's VEX or JSON; oras defaults to a generic type
+	layer := manifest.Layers[0]
+	for _, l := range manifest.Layers {
+		if strings.Contains(l.MediaType, "vex") || strings.Contains(l.MediaType, "csaf") || strings.HasSuffix(l.MediaType, "json") {
+			layer = l
+			break
+		}
+	}
+
+	data, err := repo.Get(ctx, repo.URL("blobs/"+layer.Digest), "")
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if want := strings.TrimPrefix(layer.Digest, "sha256:"); want != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("digest mismatch for %s", layer.Digest)
+	}
+	return data, nil
+}
@@ -0,0 +1,101 @@
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// openVEXDocument is an OpenVEX document. Fields that changed shape between
+// spec versions are decoded by openVEXRef and openVEXProduct.
+type openVEXDocument struct {
+	Statements []struct {
+		Vulnerability openVEXRef       `json:"vulnerability"`
+		Products      []openVEXProduct `json:"products"`
+		Subcomponents []openVEXRef     `json:"subcomponents"` // v0.0.1 only
+		Status        Status           `json:"status"`
+		Justification string           `json:"justification"`
+	} `json:"statements"`
+}
+
+// openVEXRef is a vulnerability or subcomponent: a plain string in OpenVEX v0.0.1,
+// an object with a name or @id from v0.2.0
+type openVEXRef struct {
+	ID      string
+	Aliases []string
+}
+
+func (r *openVEXRef) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.ID); err == nil {
+		return nil
+	}
+	var obj struct {
+		ID      string   `json:"@id"`
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	r.ID = obj.Name
+	if r.ID == "" {
+		r.ID = obj.ID
+	}
+	r.Aliases = obj.Aliases
+	return nil
+}
+
+// openVEXProduct is a product: a plain string in OpenVEX v0.0.1, an object
+// with an @id and subcomponents from v0.2.0
+type openVEXProduct struct {
+	ID            string
+	Subcomponents []openVEXRef
+}
+
+func (p *openVEXProduct) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.ID); err == nil {
+		return nil
+	}
+	var obj struct {
+		ID            string       `json:"@id"`
+		Subcomponents []openVEXRef `json:"subcomponents"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	p.ID, p.Subcomponents = obj.ID, obj.Subcomponents
+	return nil
+}
+
+// parseOpenVEX reads the statements of an OpenVEX document
+func parseOpenVEX(data []byte) ([]Statement, error) {
+	var doc openVEXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	statements := make([]Statement, 0, len(doc.Statements))
+	for i, s := range doc.Statements {
+		if s.Vulnerability.ID == "" {
+			return nil, fmt.Errorf("statement %d has no vulnerability", i)
+		}
+		statement := Statement{
+			Vulnerability: s.Vulnerability.ID,
+			Aliases:       s.Vulnerability.Aliases,
+			Status:        s.Status,
+			Justification: s.Justification,
+		}
+		for _, p := range s.Products {
+			product := Product{ID: p.ID}
+			subcomponents := p.Subcomponents
+			if len(subcomponents) == 0 {
+				subcomponents = s.Subcomponents
+			}
+			for _, sub := range subcomponents {
+				product.Subcomponents = append(product.Subcomponents, sub.ID)
+			}
+			statement.Products = append(statement.Products, product)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
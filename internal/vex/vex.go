@@ -0,0 +1,246 @@
+// Package vex reads VEX (Vulnerability Exploitability eXchange) documents in
+// the OpenVEX and CSAF formats and suppresses the vulnerabilities they state
+// don't affect a product, so known-irrelevant CVEs drop out of queries,
+// summaries and gates.
+package vex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+// Status is what a statement says about a vulnerability in its products
+type Status string
+
+const (
+	StatusNotAffected        Status = "not_affected"
+	StatusAffected           Status = "affected"
+	StatusFixed              Status = "fixed"
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+// Suppresses reports whether findings with this status should be hidden
+func (s Status) Suppresses() bool {
+	return s == StatusNotAffected || s == StatusFixed
+}
+
+// Statement is one VEX statement, in the same shape for both formats
+type Statement struct {
+	Vulnerability string    // CVE or other ID
+	Aliases       []string  // Other IDs of the same vulnerability
+	Products      []Product // Empty means every product
+	Status        Status
+	Justification string
+}
+
+// Product is what a statement is about: an image or package, identified by a
+// purl (pkg:oci/nginx@sha256:..., pkg:deb/debian/libc6@2.36) or plain name.
+// Subcomponents narrow it to packages inside an image.
+type Product struct {
+	ID            string
+	Subcomponents []string
+}
+
+// Set is the statements of one or more VEX documents. When several statements
+// match a vulnerability, the one loaded last wins.
+type Set struct {
+	Statements []Statement
+}
+
+// Load reads VEX documents from files, or from OCI registries for references
+// starting with oci://, e.g. oci://ghcr.io/org/app-vex:1.0
+func Load(ctx context.Context, refs ...string) (*Set, error) {
+	set := &Set{}
+	for _, ref := range refs {
+		var data []byte
+		var err error
+		if image, ok := strings.CutPrefix(ref, "oci://"); ok {
+			data, err = fetchOCI(ctx, image)
+		} else {
+			data, err = os.ReadFile(ref)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read VEX document %s: %w", ref, err)
+		}
+
+		statements, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse VEX document %s: %w", ref, err)
+		}
+		set.Statements = append(set.Statements, statements...)
+	}
+	return set, nil
+}
+
+// Parse reads the statements of an OpenVEX or CSAF document
+func Parse(data []byte) ([]Statement, error) {
+	var probe struct {
+		Context  string `json:"@context"`
+		Document *struct {
+			CSAFVersion string `json:"csaf_version"`
+		} `json:"document"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.Contains(probe.Context, "openvex"):
+		return parseOpenVEX(data)
+	case probe.Document != nil && probe.Document.CSAFVersion != "":
+		return parseCSAF(data)
+	default:
+		return nil, fmt.Errorf("not an OpenVEX or CSAF document")
+	}
+}
+
+// Suppresses reports whether the last statement matching v says it doesn't
+// affect its image
+func (s *Set) Suppresses(v trivy.Vulnerability) bool {
+	for i := len(s.Statements) - 1; i >= 0; i-- {
+		if s.Statements[i].Matches(v) {
+			return s.Statements[i].Status.Suppresses()
+		}
+	}
+	return false
+}
+
+// Matches reports whether the statement is about v
+func (s Statement) Matches(v trivy.Vulnerability) bool {
+	if !strings.EqualFold(s.Vulnerability, v.VulnerabilityID) && !containsFold(s.Aliases, v.VulnerabilityID) {
+		return false
+	}
+	if len(s.Products) == 0 {
+		return true
+	}
+	for _, p := range s.Products {
+		if !matchProduct(p.ID, v) {
+			continue
+		}
+		if len(p.Subcomponents) == 0 {
+			return true
+		}
+		for _, sub := range p.Subcomponents {
+			if matchPackage(sub, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchProduct reports whether a product ID names v's image, or its package
+// for a non-OCI purl
+func matchProduct(id string, v trivy.Vulnerability) bool {
+	p, ok := parsePURL(id)
+	if !ok {
+		// A plain image reference or digest
+		return id == v.Image || id == v.Digest ||
+			normalize(id) == normalize(repository(v.Image)) ||
+			(v.Digest != "" && strings.HasSuffix(id, "@"+v.Digest))
+	}
+	if p.typ != "oci" {
+		return matchPackage(id, v)
+	}
+	if p.version != "" && p.version != v.Digest {
+		return false
+	}
+	if tag := p.qualifiers.Get("tag"); tag != "" && tag != imageTag(v.Image) {
+		return false
+	}
+	if repo := p.qualifiers.Get("repository_url"); repo != "" {
+		return normalize(repo) == normalize(repository(v.Image))
+	}
+	if p.version != "" {
+		// The digest alone identifies the image
+		return true
+	}
+	repo := repository(v.Image)
+	return repo[strings.LastIndex(repo, "/")+1:] == p.name
+}
+
+// matchPackage reports whether a package purl or name is v's package
+func matchPackage(id string, v trivy.Vulnerability) bool {
+	p, ok := parsePURL(id)
+	if !ok {
+		return id == v.PkgName
+	}
+	if p.name != v.PkgName {
+		return false
+	}
+	return p.version == "" || p.version == v.InstalledVersion
+}
+
+// purl is a parsed package URL
+type purl struct {
+	typ        string
+	name       string
+	version    string
+	qualifiers url.Values
+}
+
+// parsePURL parses pkg:type/namespace/name@version?qualifiers#subpath. ok is
+// false if s isn't a purl.
+func parsePURL(s string) (purl, bool) {
+	rest, ok := strings.CutPrefix(s, "pkg:")
+	if !ok {
+		return purl{}, false
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, query, _ := strings.Cut(rest, "?")
+
+	var p purl
+	p.qualifiers, _ = url.ParseQuery(query)
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		p.version, _ = url.PathUnescape(rest[i+1:])
+		rest = rest[:i]
+	}
+	typ, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return purl{}, false
+	}
+	p.typ = strings.ToLower(typ)
+	p.name, _ = url.PathUnescape(path[strings.LastIndex(path, "/")+1:])
+	return p, true
+}
+
+// repository returns an image reference without its tag or digest
+func repository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// imageTag returns the tag of an image reference, if any
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// normalize drops the Docker Hub registry and library/ prefix, so nginx,
+// docker.io/library/nginx and index.docker.io/library/nginx compare equal
+func normalize(repo string) string {
+	for _, prefix := range []string{"index.docker.io/", "docker.io/", "registry-1.docker.io/"} {
+		repo = strings.TrimPrefix(repo, prefix)
+	}
+	return strings.TrimPrefix(repo, "library/")
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package vex
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/davealtena/trix/internal/tools/trivy"
+)
+
+func TestSuppresses(t *testing.T) {
+	set, err := Load(context.Background(), filepath.Join("testdata", "openvex.json"), filepath.Join("testdata", "csaf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := trivy.Vulnerability{Image: "ghcr.io/example/app:1.0", Digest: "sha256:abc123", PkgName: "openssl", InstalledVersion: "3.0.11-1"}
+	with := func(v trivy.Vulnerability, id string) trivy.Vulnerability {
+		v.VulnerabilityID = id
+		return v
+	}
+
+	tests := []struct {
+		name string
+		vuln trivy.Vulnerability
+		want bool
+	}{
+		{"not affected subcomponent", with(app, "CVE-2024-45337"), true},
+		{"by alias", with(app, "GHSA-v778-237x-gjrc"), true},
+		{"other package version", func() trivy.Vulnerability {
+			v := with(app, "CVE-2024-45337")
+			v.InstalledVersion = "3.0.9-1"
+			return v
+		}(), false},
+		{"other image digest", func() trivy.Vulnerability {
+			v := with(app, "CVE-2024-45337")
+			v.Digest = "sha256:def456"
+			return v
+		}(), false},
+		{"fixed in image tag", trivy.Vulnerability{VulnerabilityID: "CVE-2024-0001", Image: "docker.io/library/nginx:1.25"}, true},
+		{"other image tag", trivy.Vulnerability{VulnerabilityID: "CVE-2024-0001", Image: "nginx:1.26"}, false},
+		{"affected stays", trivy.Vulnerability{VulnerabilityID: "CVE-2024-0002", Image: "nginx:1.25"}, false},
+		{"statement without products", trivy.Vulnerability{VulnerabilityID: "CVE-2024-0003", Image: "redis:7"}, true},
+		{"unknown vulnerability", trivy.Vulnerability{VulnerabilityID: "CVE-2024-9999", Image: "nginx:1.25"}, false},
+		{"csaf relationship", trivy.Vulnerability{VulnerabilityID: "CVE-2025-1111", Image: "ghcr.io/example/app:1.0", PkgName: "libc6", InstalledVersion: "2.36-9"}, true},
+		{"csaf relationship other package", trivy.Vulnerability{VulnerabilityID: "CVE-2025-1111", Image: "ghcr.io/example/app:1.0", PkgName: "openssl"}, false},
+		{"csaf affected wins", trivy.Vulnerability{VulnerabilityID: "CVE-2025-2222", Image: "ghcr.io/example/app:1.0"}, false},
+		{"csaf id without cve", trivy.Vulnerability{VulnerabilityID: "GHSA-xxxx-yyyy-zzzz", Image: "ghcr.io/example/app:2.0"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.Suppresses(tt.vuln); got != tt.want {
+				t.Errorf("Suppresses(%+v) = %v, want %v", tt.vuln, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", "statements:"},
+		{"unknown format", `{"statements": []}`},
+		{"statement without vulnerability", `{"@context": "https://openvex.dev/ns/v0.2.0", "statements": [{"status": "fixed"}]}`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data)); err == nil {
+			t.Errorf("%s: Parse() succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func TestParsePURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    purl
+		wantOK  bool
+		wantTag string
+	}{
+		{"pkg:deb/debian/libc6@2.36-9?arch=amd64", purl{typ: "deb", name: "libc6", version: "2.36-9"}, true, ""},
+		{"pkg:oci/nginx@sha256%3Aabc?tag=1.25", purl{typ: "oci", name: "nginx", version: "sha256:abc"}, true, "1.25"},
+		{"pkg:golang/golang.org/x/crypto@v0.30.0", purl{typ: "golang", name: "crypto", version: "v0.30.0"}, true, ""},
+		{"nginx:1.25", purl{}, false, ""},
+	}
+	for _, tt := range tests {
+		got, ok := parsePURL(tt.in)
+		if ok != tt.wantOK || got.typ != tt.want.typ || got.name != tt.want.name || got.version != tt.want.version {
+			t.Errorf("parsePURL(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+		if ok && got.qualifiers.Get("tag") != tt.wantTag {
+			t.Errorf("parsePURL(%q) tag = %q, want %q", tt.in, got.qualifiers.Get("tag"), tt.wantTag)
+		}
+	}
+}